@@ -118,6 +118,17 @@ class AnalyticsService:
         analysis = await self.get_student_analysis(student_id)
         
         print(f"--- [PYTHON] Анализ пересчитан для студента {student_id} ---")
+
+    async def purge_student(self, data: dict):
+        """Удаляет копии данных студента после его удаления в core-service.
+        Строки в Postgres core-service уже удалил сам, здесь - только кэш."""
+        student_id = data.get('student_id')
+        if not student_id:
+            return
+
+        await self.cache.delete_analytics(student_id)
+
+        print(f"--- [PYTHON] Данные студента {student_id} удалены ({data.get('mode')}) ---")
         
     def _empty_response(self, student_id: int):
         """Возвращает дефолтную структуру, если данных в БД нет"""
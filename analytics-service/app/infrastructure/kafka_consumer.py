@@ -2,15 +2,17 @@ import json
 from aiokafka import AIOKafkaConsumer
 
 class AnalyticsConsumer:
-    def __init__(self, brokers: str, topic: str, service):
+    def __init__(self, brokers: str, topic: str, service, handler=None, group_id: str = "analytics_group_v2"):
         self.consumer = AIOKafkaConsumer(
             topic,
             bootstrap_servers=brokers,
-            group_id="analytics_group_v2", 
+            group_id=group_id, 
             auto_offset_reset="earliest",  # Читать всё с начала, если группа новая
             value_deserializer=lambda m: json.loads(m.decode('utf-8'))
         )
         self.service = service 
+        # handler - обработчик сообщений топика; по умолчанию новые логи
+        self.handler = handler or service.process_new_log
 
     async def start(self):
         print(f"--- Попытка подключения к Kafka... ---")
@@ -23,7 +25,7 @@ class AnalyticsConsumer:
                 s_id = data.get('student_id') or data.get('log_data', {}).get('student_id')
                 print(f"--- [KAFKA] Получены данные для студента {s_id} ---")
                 
-                await self.service.process_new_log(data) 
+                await self.handler(data) 
         except Exception as e:
             print(f"--- [KAFKA ERROR] Ошибка: {e} ---")
         finally:
@@ -25,6 +25,8 @@ async def main():
     server = GRPCServer(port=PORT, handler=handler)
     
     consumer = AnalyticsConsumer(brokers=KAFKA_BROKERS, topic="student-logs", service=service)
+    erasure_consumer = AnalyticsConsumer(brokers=KAFKA_BROKERS, topic="student-erasure", service=service,
+                                         handler=service.purge_student, group_id="analytics_erasure")
 
     print(f"--- Analytics Service запущен на порту {PORT} ---")
     
@@ -32,6 +34,7 @@ async def main():
         await asyncio.gather(
             server.start(),        
             consumer.start(),      
+            erasure_consumer.start(),
         )
     except Exception as e:
         print(f"Ошибка: {e}")
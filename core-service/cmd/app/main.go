@@ -1,25 +1,46 @@
 package main
 
 import (
-	"log"
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"fmt"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	google_grpc "google.golang.org/grpc" // Псевдоним для стандартной библиотеки
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 
 	internal_grpc "github.com/RusselRustCode/teacher_analytics/core-service/internal/api/grpc"
 	internal_http "github.com/RusselRustCode/teacher_analytics/core-service/internal/api/http"
 	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
 	"github.com/RusselRustCode/teacher_analytics/core-service/internal/config"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/infrastructure/certs"
 	"github.com/RusselRustCode/teacher_analytics/core-service/internal/infrastructure/kafka"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/infrastructure/memory"
 	"github.com/RusselRustCode/teacher_analytics/core-service/internal/infrastructure/postgres"
 	"github.com/RusselRustCode/teacher_analytics/core-service/internal/infrastructure/redis"
 	"github.com/RusselRustCode/teacher_analytics/core-service/internal/infrastructure/grpc"
 	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/lifecycle"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/lti"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/notify"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/scheduler"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/tracing"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/webhook"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/xapi"
 	"github.com/RusselRustCode/teacher_analytics/core-service/proto"
 )
 
@@ -31,11 +52,34 @@ import (
 func main() {
 	cfg := config.LoadConfig()
 
+	logger, err := logging.New(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		slog.Error("invalid logging config", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+
+	lc := lifecycle.NewManager(cfg.ShutdownTimeout, logger)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		ServiceName:  cfg.Tracing.ServiceName,
+		Exporter:     cfg.Tracing.Exporter,
+		OTLPEndpoint: cfg.Tracing.OTLPEndpoint,
+		OTLPInsecure: cfg.Tracing.OTLPInsecure,
+		SampleRatio:  cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		fatal(logger, "failed to set up tracing", err)
+	}
+	// Регистрируется первым, чтобы закрыться последним и выгрузить спаны остановки
+	lc.AddCloser("tracing", func() error {
+		return shutdownTracing(context.Background())
+	})
+
 	repo, err := postgres.NewPostgresRepository(cfg.DBDSN)
 	if err != nil {
-		log.Fatalf("Не получилось подключсится к бд: %v", err)
+		fatal(logger, "failed to connect to postgres", err)
 	}
-	defer repo.Close()
 
 	kafkaBrokers := []string{os.Getenv("KAFKA_BOOTSTRAP_SERVERS")}
     redisAddr := fmt.Sprintf("%s:%s", os.Getenv("REDIS_HOST"), os.Getenv("REDIS_PORT"))
@@ -45,66 +89,467 @@ func main() {
 	var kafkaProducer interfaces.MessageProducer = nil 
 	var analyticsClient interfaces.AnalyticsClient = nil
 
-	redisCache = redis.NewRedisCache(redisAddr, os.Getenv("REDIS_PASSWORD"), 0)
-    defer redisCache.Close()
+	redisCache, err = newCache(cfg.Cache, redisAddr, os.Getenv("REDIS_PASSWORD"), logger)
+	if err != nil {
+		fatal(logger, "failed to set up cache", err)
+	}
 
-	kafkaProducer = kafka.NewKafkaProducer(kafkaBrokers)
-	defer kafkaProducer.Close()
+	kafkaProducer = kafka.NewKafkaProducer(kafkaBrokers, logger)
 
-	analyticsClient, err = grpc.NewGRPCAnalyticsClient(analyticsAddr)
+	var analyticsTLS *tls.Config
+	if cfg.AnalyticsClient.TLSEnabled {
+		reloader, err := certs.NewReloader(
+			cfg.AnalyticsClient.TLSCertFile,
+			cfg.AnalyticsClient.TLSKeyFile,
+			cfg.AnalyticsClient.TLSCAFile,
+			cfg.TLS.ReloadInterval,
+			logger,
+		)
+		if err != nil {
+			fatal(logger, "failed to load analytics client tls certificates", err)
+		}
+		analyticsTLS = reloader.ClientConfig(cfg.AnalyticsClient.TLSServerName)
+	}
+
+	analyticsClient, err = grpc.NewGRPCAnalyticsClient(analyticsAddr, grpc.ClientOptions{
+		CallTimeout:             cfg.AnalyticsClient.CallTimeout,
+		MaxAttempts:             cfg.AnalyticsClient.MaxAttempts,
+		InitialBackoff:          cfg.AnalyticsClient.InitialBackoff,
+		MaxBackoff:              cfg.AnalyticsClient.MaxBackoff,
+		BackoffMultiplier:       cfg.AnalyticsClient.BackoffMultiplier,
+		BreakerFailureThreshold: cfg.AnalyticsClient.BreakerFailureThreshold,
+		BreakerOpenTimeout:      cfg.AnalyticsClient.BreakerOpenTimeout,
+		KeepaliveTime:           cfg.AnalyticsClient.KeepaliveTime,
+		KeepaliveTimeout:        cfg.AnalyticsClient.KeepaliveTimeout,
+		TLS:                     analyticsTLS,
+	})
     if err != nil {
-        log.Fatalf("Не получилось подключиться к analytics client: %v", err)
+        fatal(logger, "failed to create analytics client", err)
     }
-    defer analyticsClient.Close()
 
+	webhookService := application.NewWebhookService(repo, application.WebhookOptions{
+		AllowPrivateNetworks: cfg.Webhooks.AllowPrivateNetworks,
+	}, logger)
+	// Ключи кэшируются в памяти процесса, а не в Redis: проверка ключа
+	// не должна зависеть от доступности кэша
+	apiKeyService := application.NewAPIKeyService(repo, memory.NewLRUCache(10000), application.APIKeyOptions{
+		CacheTTL: cfg.APIKeys.CacheTTL,
+	}, logger)
+	var (
+		auditService interfaces.AuditService
+		auditHandler *internal_http.AuditHandler
+	)
+	if cfg.Audit.Enabled {
+		service := application.NewAuditService(repo, application.AuditOptions{Retention: cfg.Audit.Retention}, logger)
+		auditService = service
+		auditHandler = internal_http.NewAuditHandler(service, logger)
+	}
 	analyticsService := application.NewAnalyticsService(
 		repo,
 		redisCache,
 		kafkaProducer,
 		analyticsClient,
+		webhookService,
+		application.AnalyticsOptions{
+			CacheTTL:    cfg.Cache.AnalyticsTTL,
+			StaleTTL:    cfg.Cache.AnalyticsStaleTTL,
+			LockTTL:     cfg.Cache.LockTTL,
+			StudentsTTL: cfg.Cache.StudentsTTL,
+			LogsTTL:     cfg.Cache.LogsTTL,
+			Background:  lc.Task,
+		},
+		logger,
+	)
+
+	healthChecker := application.NewHealthChecker(cfg.Health.Timeout)
+	healthChecker.Register("postgres", true, repo.Ping)
+	healthChecker.Register("kafka", true, kafkaProducer.Ping)
+	if cfg.Cache.Backend != "memory" {
+		healthChecker.Register("redis", false, redisCache.Ping)
+	}
+	healthChecker.Register("analytics", false, analyticsClient.HealthCheck)
+
+	lc.AddCloser("postgres", repo.Close)
+	lc.AddCloser("redis", redisCache.Close)
+	lc.AddCloser("kafka producer", kafkaProducer.Close)
+	lc.AddCloser("analytics client", analyticsClient.Close)
+
+	healthServer := health.NewServer()
+	lc.Go("health checker", func(ctx context.Context) {
+		healthChecker.Run(ctx, cfg.Health.Interval, func(report *domain.HealthReport) {
+			internal_grpc.UpdateHealthServer(healthServer, report)
+		})
+	})
+
+	alertNotifier := application.NewAlertNotifier(append(newNotifiers(cfg.Alerts), webhookService), cfg.Alerts.NotifyQueue, logger)
+	lc.Go("alert notifier", alertNotifier.Run)
+	alertService := application.NewAlertService(repo, alertNotifier, logger)
+	lc.Go("analytics listener", func(ctx context.Context) {
+		err := postgres.ListenAnalyticsSaved(ctx, cfg.DBDSN, logger, func(ctx context.Context, studentID uint64) {
+			logCtx := logging.WithStudentID(ctx, studentID)
+			if err := webhookService.AnalyticsUpdated(ctx, studentID); err != nil {
+				logger.ErrorContext(logCtx, "failed to publish analytics event", slog.String("error", err.Error()))
+			}
+			if err := alertService.EvaluateStudent(ctx, studentID); err != nil {
+				logger.ErrorContext(logCtx, "failed to evaluate alert rules", slog.String("error", err.Error()))
+			}
+		})
+		if err != nil {
+			logger.Error("analytics listener stopped", slog.String("error", err.Error()))
+		}
+	})
+
+	webhookDispatcher := application.NewWebhookDispatcher(repo, webhook.NewSender(cfg.Webhooks.Timeout, cfg.Webhooks.AllowPrivateNetworks), application.WebhookDispatcherOptions{
+		MaxAttempts:    cfg.Webhooks.MaxAttempts,
+		InitialBackoff: cfg.Webhooks.InitialBackoff,
+		MaxBackoff:     cfg.Webhooks.MaxBackoff,
+		PollInterval:   cfg.Webhooks.PollInterval,
+		Timeout:        cfg.Webhooks.Timeout,
+	}, logger)
+	lc.Go("webhook dispatcher", webhookDispatcher.Run)
+
+	if cfg.Scheduler.Enabled {
+		sched, err := newScheduler(cfg, repo, analyticsService, alertService, auditService, logger)
+		if err != nil {
+			fatal(logger, "failed to set up scheduler", err)
+		}
+		lc.Go("scheduler", sched.Run)
+	}
+
+	var serverCerts *certs.Reloader
+	if cfg.TLS.Enabled {
+		serverCerts, err = certs.NewReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile, cfg.TLS.ClientCAFile, cfg.TLS.ReloadInterval, logger)
+		if err != nil {
+			fatal(logger, "failed to load server tls certificates", err)
+		}
+	}
+
+	var (
+		rateLimiter      interfaces.RateLimiter
+		rateLimitHandler *internal_http.RateLimitHandler
+	)
+	if cfg.RateLimit.Enabled {
+		rateLimiter = newRateLimiter(cfg.Cache, redisAddr, os.Getenv("REDIS_PASSWORD"), lc)
+		rateLimitHandler = internal_http.NewRateLimitHandler(rateLimiter, map[string]domain.RateLimit{
+			internal_http.RateLimitIngest:    {Rate: cfg.RateLimit.IngestRate, Burst: cfg.RateLimit.IngestBurst},
+			internal_http.RateLimitDashboard: {Rate: cfg.RateLimit.DashboardRate, Burst: cfg.RateLimit.DashboardBurst},
+		}, logger)
+	}
+
+	grpcServer := newGRPCServer(logger, analyticsService, healthChecker, healthServer, serverCerts, cfg.TLS.ClientCAFile != "",
+		apiKeyService, cfg.APIKeys.RequiredScopes, auditService,
+		rateLimiter, domain.RateLimit{Rate: cfg.RateLimit.GRPCRate, Burst: cfg.RateLimit.GRPCBurst})
+	grpcListener, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+	if err != nil {
+		fatal(logger, "failed to listen grpc port", err)
+	}
+	lc.AddServer("grpc", func() error {
+		logger.Info("grpc server listening", slog.String("port", cfg.GRPCPort))
+		return grpcServer.Serve(grpcListener)
+	}, lifecycle.GRPCShutdown(grpcServer))
+
+	xapiMapper, err := newXAPIMapper(cfg.XAPI)
+	if err != nil {
+		fatal(logger, "failed to load xapi mapping", err)
+	}
+
+	exportService := application.NewExportService(repo)
+	reportService := application.NewReportService(repo, analyticsClient, logger)
+	privacyService := application.NewPrivacyService(repo, redisCache, kafkaProducer, logger)
+	var researchHandler *internal_http.ResearchHandler
+	if cfg.Research.PseudonymKey != "" {
+		researchHandler = internal_http.NewResearchHandler(application.NewResearchService(repo, application.ResearchOptions{
+			PseudonymKey: []byte(cfg.Research.PseudonymKey),
+			MinGroupSize: cfg.Research.MinGroupSize,
+		}, logger))
+	}
+	xapiService := application.NewXAPIService(repo, analyticsService, xapiMapper, logger)
+	importService := application.NewImportService(
+		repo,
+		redisCache,
+		analyticsService,
+		xapiMapper,
+		cfg.Import.BatchSize,
+		logger,
 	)
 
-	go startGRPCServer(cfg.GRPCPort, analyticsService)
-	go startHTTPServer(cfg.HTTPPort, analyticsService)
+	var ltiHandler *internal_http.LTIHandler
+	if cfg.LTI.Issuer != "" {
+		ltiService, err := newLTIService(cfg.LTI, repo, redisCache, logger)
+		if err != nil {
+			fatal(logger, "failed to set up lti", err)
+		}
+		ltiHandler = internal_http.NewLTIHandler(ltiService, internal_http.LTIHandlerOptions{
+			DashboardURL:   cfg.LTI.DashboardURL,
+			CookieSecure:   cfg.LTI.CookieSecure,
+			RequireSession: cfg.LTI.RequireSession,
+		})
+	}
+
+	httpServer := newHTTPServer(cfg, logger, internal_http.Handlers{
+		API:       internal_http.NewHTTPHandler(analyticsService),
+		Health:    internal_http.NewHealthHandler(healthChecker),
+		Export:    internal_http.NewExportHandler(exportService),
+		Report:    internal_http.NewReportHandler(reportService),
+		Alert:     internal_http.NewAlertHandler(alertService),
+		Webhook:   internal_http.NewWebhookHandler(webhookService),
+		Import:    internal_http.NewImportHandler(importService, cfg.Import.MaxUploadBytes),
+		XAPI:      internal_http.NewXAPIHandler(xapiService),
+		LTI:       ltiHandler,
+		RateLimit: rateLimitHandler,
+		APIKey: internal_http.NewAPIKeyHandler(apiKeyService, internal_http.APIKeyHandlerOptions{
+			RequiredScopes: cfg.APIKeys.RequiredScopes,
+			RotationGrace:  cfg.APIKeys.RotationGrace,
+		}),
+		Audit:    auditHandler,
+		Privacy:  internal_http.NewPrivacyHandler(privacyService),
+		Research: researchHandler,
+	}, serverCerts)
+	lc.AddServer("http", func() error {
+		logger.Info("http server listening", slog.String("port", cfg.HTTPPort))
+		var err error
+		if httpServer.TLSConfig != nil {
+			err = httpServer.ListenAndServeTLS("", "")
+		} else {
+			err = httpServer.ListenAndServe()
+		}
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	}, httpServer.Shutdown)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	if err := lc.Run(ctx); err != nil {
+		logger.Error("server stopped unexpectedly", slog.String("error", err.Error()))
+	}
 
-	log.Println("Выключение серверов...")
+	logger.Info("shutting down")
+	// Сначала сообщаем балансировщикам, что больше не принимаем трафик
+	healthServer.Shutdown()
+	if err := lc.Shutdown(); err != nil {
+		logger.Error("shutdown finished with errors", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	logger.Info("shutdown complete")
 }
 
-func startGRPCServer(port string, service interfaces.AnalyticsService) {
-	lis, err := net.Listen("tcp", ":"+port)
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, slog.String("error", err.Error()))
+	os.Exit(1)
+}
+
+// newCache выбирает бэкенд кэша. С memory сервис работает без Redis, но
+// у каждой реплики свой кэш, а блокировки не общие.
+func newCache(cfg config.CacheConfig, redisAddr, redisPassword string, logger *slog.Logger) (interfaces.Cache, error) {
+	switch cfg.Backend {
+	case "redis":
+		return redis.NewRedisCache(redisAddr, redisPassword, 0), nil
+	case "memory":
+		return memory.NewLRUCache(cfg.LocalMaxEntries), nil
+	case "tiered":
+		return redis.NewTieredCache(redisAddr, redisPassword, 0, redis.TieredOptions{
+			LocalMaxEntries: cfg.LocalMaxEntries,
+			LocalTTL:        cfg.LocalTTL,
+		}, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", cfg.Backend)
+	}
+}
+
+// newRateLimiter держит ведра там же, где кэш: с memory лимит у каждой
+// реплики свой.
+func newRateLimiter(cfg config.CacheConfig, redisAddr, redisPassword string, lc *lifecycle.Manager) interfaces.RateLimiter {
+	if cfg.Backend == "memory" {
+		return memory.NewRateLimiter()
+	}
+	limiter := redis.NewRateLimiter(redisAddr, redisPassword, 0)
+	lc.AddCloser("rate limiter", limiter.Close)
+	return limiter
+}
+
+func newXAPIMapper(cfg config.XAPIConfig) (*xapi.Mapper, error) {
+	if cfg.MappingFile == "" {
+		return xapi.NewMapper(xapi.DefaultMapping()), nil
+	}
+	mapping, err := xapi.LoadMapping(cfg.MappingFile)
 	if err != nil {
-		log.Fatalf("Не смог прослушать: %v", err)
+		return nil, err
 	}
+	return xapi.NewMapper(mapping), nil
+}
 
-	s := google_grpc.NewServer()
-	
-	grpcHandler := internal_grpc.NewGRPCHandler(service)
-	proto.RegisterAnalyticsServiceServer(s, grpcHandler)
+func newLTIService(
+	cfg config.LTIConfig,
+	repo interfaces.Repository,
+	cache interfaces.Cache,
+	logger *slog.Logger,
+) (interfaces.LTIService, error) {
+	keys, err := lti.LoadKeySet(cfg.KeySetFile)
+	if err != nil {
+		return nil, err
+	}
+	platform := &lti.Platform{
+		Issuer:        cfg.Issuer,
+		ClientID:      cfg.ClientID,
+		DeploymentIDs: cfg.DeploymentIDs,
+		Keys:          keys,
+	}
+	return application.NewLTIService(repo, cache, platform, application.LTIOptions{
+		AuthLoginURL: cfg.AuthLoginURL,
+		RedirectURI:  cfg.LaunchURL,
+		SessionTTL:   cfg.SessionTTL,
+	}, logger), nil
+}
+
+// newScheduler регистрирует задачи по расписанию. Блокировки между
+// репликами - advisory-блокировки Postgres.
+func newScheduler(
+	cfg *config.Config,
+	repo interfaces.Repository,
+	analytics interfaces.AnalyticsService,
+	alerts interfaces.AlertService,
+	audit interfaces.AuditService,
+	logger *slog.Logger,
+) (*scheduler.Scheduler, error) {
+	locker, ok := repo.(interfaces.Locker)
+	if !ok {
+		return nil, errors.New("repository does not support locking")
+	}
+	sched := scheduler.New(locker, logger)
+	if cfg.Scheduler.ReanalysisCron != "" {
+		job := application.NewReanalysisJob(repo, analytics, cfg.Scheduler.ReanalysisRate, logger)
+		if err := sched.Add("reanalysis", cfg.Scheduler.ReanalysisCron, job.Run); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Alerts.InactivityCron != "" {
+		if err := sched.Add("alerts-inactivity", cfg.Alerts.InactivityCron, alerts.EvaluateInactivity); err != nil {
+			return nil, err
+		}
+	}
+	if audit != nil && cfg.Audit.RetentionCron != "" {
+		if err := sched.Add("audit-retention", cfg.Audit.RetentionCron, audit.PurgeExpired); err != nil {
+			return nil, err
+		}
+	}
+	return sched, nil
+}
 
-	log.Printf("gRPC server прослушивает :%s", port)
-	if err := s.Serve(lis); err != nil {
-		log.Fatalf("Не удалось предоставить gRPC: %v", err)
+// newNotifiers - каналы доставки оповещений, включенные в конфиге.
+func newNotifiers(cfg config.AlertsConfig) []interfaces.Notifier {
+	var notifiers []interfaces.Notifier
+	if cfg.WebhookURL != "" {
+		notifiers = append(notifiers, notify.NewWebhookNotifier(cfg.WebhookURL, cfg.WebhookTimeout))
+	}
+	if cfg.SMTPAddr != "" && len(cfg.SMTPTo) > 0 {
+		notifiers = append(notifiers, notify.NewSMTPNotifier(notify.SMTPOptions{
+			Addr:     cfg.SMTPAddr,
+			From:     cfg.SMTPFrom,
+			To:       cfg.SMTPTo,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+		}))
 	}
+	return notifiers
 }
 
-func startHTTPServer(port string, service interfaces.AnalyticsService) {
-	router := gin.Default()
+func newGRPCServer(
+	logger *slog.Logger,
+	service interfaces.AnalyticsService,
+	checker interfaces.HealthChecker,
+	healthServer *health.Server,
+	serverCerts *certs.Reloader,
+	requireClientCert bool,
+	apiKeys interfaces.APIKeyService,
+	requiredScopes []string,
+	audit interfaces.AuditService,
+	rateLimiter interfaces.RateLimiter,
+	rateLimit domain.RateLimit,
+) *google_grpc.Server {
+	unary := []google_grpc.UnaryServerInterceptor{
+		internal_grpc.RequestIDUnaryInterceptor(),
+		internal_grpc.LoggingUnaryInterceptor(logger),
+		internal_grpc.MetricsUnaryInterceptor(),
+		// Ключ до лимита: ведро у интеграции свое
+		internal_grpc.APIKeyUnaryInterceptor(apiKeys, requiredScopes),
+	}
+	if rateLimiter != nil {
+		unary = append(unary, internal_grpc.RateLimitUnaryInterceptor(rateLimiter, rateLimit, logger))
+	}
+	if audit != nil {
+		unary = append(unary, internal_grpc.AuditUnaryInterceptor(audit, logger))
+	}
+	opts := []google_grpc.ServerOption{
+		google_grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		google_grpc.ChainUnaryInterceptor(unary...),
+		google_grpc.ChainStreamInterceptor(
+			internal_grpc.RequestIDStreamInterceptor(),
+			internal_grpc.MetricsStreamInterceptor(),
+		),
+	}
+	if serverCerts != nil {
+		clientAuth := tls.NoClientCert
+		if requireClientCert {
+			clientAuth = tls.RequireAndVerifyClientCert
+		}
+		opts = append(opts, google_grpc.Creds(credentials.NewTLS(serverCerts.ServerConfig(clientAuth))))
+	}
+
+	s := google_grpc.NewServer(opts...)
+
+	grpcHandler := internal_grpc.NewGRPCHandler(service, checker)
+	proto.RegisterAnalyticsServiceServer(s, grpcHandler)
+	healthpb.RegisterHealthServer(s, healthServer)
+	reflection.Register(s)
+
+	return s
+}
+
+func newHTTPServer(
+	cfg *config.Config,
+	logger *slog.Logger,
+	handlers internal_http.Handlers,
+	serverCerts *certs.Reloader,
+) *http.Server {
+	router := gin.New()
+	// Без этого gin верит X-Forwarded-For от любого клиента, а по ClientIP
+	// считаются лимиты и пишется журнал
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		fatal(logger, "invalid HTTP_TRUSTED_PROXIES", err)
+	}
+	router.Use(internal_http.RequestIDMiddleware())
+	router.Use(internal_http.LoggingMiddleware(logger))
+	router.Use(internal_http.RecoveryMiddleware(logger))
 
 	router.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", logging.RequestIDHeader+", Retry-After")
 		c.Next()
 	})
+	router.Use(otelgin.Middleware(cfg.Tracing.ServiceName, otelgin.WithFilter(func(r *http.Request) bool {
+		// Пробы и скрейпы Prometheus только засоряют трассы
+		switch r.URL.Path {
+		case "/metrics", "/healthz", "/readyz":
+			return false
+		}
+		return true
+	})))
+	router.Use(internal_http.MetricsMiddleware())
 
-	handler := internal_http.NewHTTPHandler(service)
-
-	internal_http.SetupRoutes(router, handler)
+	internal_http.SetupRoutes(router, handlers)
 
-	log.Printf("HTTP-сервер прослушивает :%s", port)
-	if err := router.Run(":" + port); err != nil {
-		log.Fatalf("Не удалось запустить HTTP-сервер.: %v", err)
+	srv := &http.Server{
+		Addr:     ":" + cfg.HTTPPort,
+		Handler:  router,
+		ErrorLog: slog.NewLogLogger(logger.Handler(), slog.LevelError),
 	}
-}
\ No newline at end of file
+	if serverCerts != nil {
+		// Браузеры ходят через nginx без клиентских сертификатов, поэтому
+		// для HTTP клиентский сертификат проверяется, только если он передан.
+		srv.TLSConfig = serverCerts.ServerConfig(tls.VerifyClientCertIfGiven)
+	}
+	return srv
+}
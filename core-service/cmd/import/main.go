@@ -0,0 +1,138 @@
+// Команда import загружает исторические логи из выгрузок старой LMS:
+//
+//	import -file history.csv -dry-run
+//	import -file statements.json -format xapi -analyze
+//
+// Отчет печатается в stdout в том же виде, что и ответ POST /api/import.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/config"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/importer"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/infrastructure/kafka"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/infrastructure/postgres"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/infrastructure/redis"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/xapi"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run возвращает код выхода: 0 - все строки приняты, 1 - есть отклоненные
+// строки, 2 - импорт не выполнен. Выход через код, а не os.Exit посреди
+// работы, чтобы defer'ы закрыли соединения и продюсер отправил команды.
+func run() int {
+	file := flag.String("file", "", "путь к файлу выгрузки (обязательно)")
+	format := flag.String("format", "", "csv или xapi; по умолчанию по расширению файла")
+	dryRun := flag.Bool("dry-run", false, "только проверить файл, ничего не записывая")
+	analyze := flag.Bool("analyze", false, "запустить анализ затронутых студентов")
+	columns := flag.String("columns", "", "свои названия колонок CSV: student_id=user,timestamp=date")
+	batchSize := flag.Int("batch-size", 0, "размер пачки вставки (по умолчанию IMPORT_BATCH_SIZE)")
+	flag.Parse()
+
+	cfg := config.LoadConfig()
+	// Лог пишется в stderr, чтобы stdout оставался чистым JSON-отчетом
+	logger, err := logging.New(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid logging config:", err)
+		return 2
+	}
+
+	if *file == "" {
+		flag.Usage()
+		return 2
+	}
+	opts := domain.ImportOptions{
+		Format:          domain.ImportFormat(strings.ToLower(*format)),
+		DryRun:          *dryRun,
+		TriggerAnalysis: *analyze,
+	}
+	if opts.Format == "" {
+		opts.Format = domain.ImportCSV
+		if ext := strings.ToLower(filepath.Ext(*file)); ext == ".json" || ext == ".ndjson" {
+			opts.Format = domain.ImportXAPI
+		}
+	}
+	if opts.CSVColumns, err = importer.ParseColumnMapping(*columns); err != nil {
+		return fail(logger, "invalid -columns", err)
+	}
+	if *batchSize <= 0 {
+		*batchSize = cfg.Import.BatchSize
+	}
+
+	mapping := xapi.DefaultMapping()
+	if cfg.XAPI.MappingFile != "" {
+		if mapping, err = xapi.LoadMapping(cfg.XAPI.MappingFile); err != nil {
+			return fail(logger, "failed to load xapi mapping", err)
+		}
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return fail(logger, "failed to open file", err)
+	}
+	defer f.Close()
+
+	repo, err := postgres.NewPostgresRepository(cfg.DBDSN)
+	if err != nil {
+		return fail(logger, "failed to connect to postgres", err)
+	}
+	defer repo.Close()
+
+	redisAddr := fmt.Sprintf("%s:%s", os.Getenv("REDIS_HOST"), os.Getenv("REDIS_PORT"))
+	cache := redis.NewRedisCache(redisAddr, os.Getenv("REDIS_PASSWORD"), 0)
+	defer cache.Close()
+
+	producer := kafka.NewKafkaProducer([]string{os.Getenv("KAFKA_BOOTSTRAP_SERVERS")}, logger)
+	defer producer.Close()
+
+	// Клиент сервиса анализа не нужен: анализ запускается командой в Kafka.
+	// Импорт не публикует log.ingested - подписчики узнают о нем по analytics.updated
+	analyticsService := application.NewAnalyticsService(repo, cache, producer, nil, nil, application.AnalyticsOptions{}, logger)
+	importService := application.NewImportService(
+		repo,
+		cache,
+		analyticsService,
+		xapi.NewMapper(mapping),
+		*batchSize,
+		logger,
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	report, err := importService.Import(ctx, f, opts)
+	if err != nil {
+		return fail(logger, "import failed", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fail(logger, "failed to write report", err)
+	}
+	if report.RejectedCount > 0 {
+		return 1
+	}
+	return 0
+}
+
+func fail(logger *slog.Logger, msg string, err error) int {
+	logger.Error(msg, slog.String("error", err.Error()))
+	return 2
+}
@@ -0,0 +1,142 @@
+// Package apierror переводит ошибки приложения в HTTP-статусы, gRPC-коды и
+// единую JSON-схему ошибки, чтобы оба транспорта отвечали одинаково.
+package apierror
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+)
+
+// CodeInternal отдается для всех ошибок, не описанных в application.Error.
+// Подробности таких ошибок пишутся только в лог.
+const CodeInternal = "internal_error"
+
+// Response - тело ответа с ошибкой:
+//
+//	{"error": {"code": "student_not_found", "message": "...", "request_id": "..."}}
+//
+// У ошибок валидации дополнительно есть fields: [{"field", "code", "message"}].
+type Response struct {
+	Error Detail `json:"error"`
+}
+
+type Detail struct {
+	Code      string                   `json:"code"`
+	Message   string                   `json:"message"`
+	Fields    []application.FieldError `json:"fields,omitempty"`
+	RequestID string                   `json:"request_id,omitempty"`
+}
+
+// HTTPStatus возвращает HTTP-статус для err.
+func HTTPStatus(err error) int {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout
+	}
+
+	appErr, ok := application.AsError(err)
+	if !ok {
+		return http.StatusInternalServerError
+	}
+	switch appErr.Kind {
+	case application.KindNotFound:
+		return http.StatusNotFound
+	case application.KindValidation:
+		return http.StatusBadRequest
+	case application.KindConflict:
+		return http.StatusConflict
+	case application.KindUnavailable:
+		return http.StatusServiceUnavailable
+	case application.KindTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case application.KindUnauthorized:
+		return http.StatusUnauthorized
+	case application.KindForbidden:
+		return http.StatusForbidden
+	case application.KindRateLimited:
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+// GRPCCode возвращает gRPC-код для err.
+func GRPCCode(err error) codes.Code {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return codes.DeadlineExceeded
+	}
+	if errors.Is(err, context.Canceled) {
+		return codes.Canceled
+	}
+
+	appErr, ok := application.AsError(err)
+	if !ok {
+		return codes.Internal
+	}
+	switch appErr.Kind {
+	case application.KindNotFound:
+		return codes.NotFound
+	case application.KindValidation:
+		return codes.InvalidArgument
+	case application.KindConflict:
+		return codes.AlreadyExists
+	case application.KindUnavailable:
+		return codes.Unavailable
+	case application.KindTooLarge:
+		return codes.ResourceExhausted
+	case application.KindUnauthorized:
+		return codes.Unauthenticated
+	case application.KindForbidden:
+		return codes.PermissionDenied
+	case application.KindRateLimited:
+		return codes.ResourceExhausted
+	}
+	return codes.Internal
+}
+
+// NewResponse собирает тело ответа. Текст внутренних ошибок наружу не попадает.
+func NewResponse(ctx context.Context, err error) Response {
+	detail := Detail{
+		Code:      CodeInternal,
+		Message:   "internal server error",
+		RequestID: logging.RequestID(ctx),
+	}
+	if appErr, ok := application.AsError(err); ok {
+		detail.Code = appErr.Code
+		detail.Message = appErr.Message
+		detail.Fields = appErr.Fields
+	} else if errors.Is(err, context.DeadlineExceeded) {
+		detail.Code = "timeout"
+		detail.Message = "request timed out"
+	}
+	return Response{Error: detail}
+}
+
+// GRPCError превращает err в gRPC status. Машиночитаемый код передается
+// префиксом сообщения, потому что proto сервиса не описывает деталей ошибки.
+// Клиент получает только status, а Error() сохраняет исходную причину для
+// логирующего интерсептора.
+func GRPCError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	detail := NewResponse(ctx, err).Error
+	return &grpcError{
+		status: status.New(GRPCCode(err), detail.Code+": "+detail.Message),
+		cause:  err,
+	}
+}
+
+type grpcError struct {
+	status *status.Status
+	cause  error
+}
+
+func (e *grpcError) Error() string              { return e.cause.Error() }
+func (e *grpcError) Unwrap() error              { return e.cause }
+func (e *grpcError) GRPCStatus() *status.Status { return e.status }
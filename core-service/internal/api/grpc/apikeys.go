@@ -0,0 +1,86 @@
+package grpc
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/api/apierror"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/auth"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+	pb "github.com/RusselRustCode/teacher_analytics/core-service/proto"
+)
+
+// methodScopes - какой scope нужен ключу для метода. Методы без записи
+// (HealthCheck, grpc.health.v1) ключ не проверяют.
+var methodScopes = map[string]string{
+	pb.AnalyticsService_AnalyzeStudent_FullMethodName: domain.ScopeReadAnalytics,
+	pb.AnalyticsService_BatchAnalyze_FullMethodName:   domain.ScopeReadAnalytics,
+}
+
+// APIKeyUnaryInterceptor принимает ключ из метаданных authorization:
+// "ApiKey <ключ>" и кладет его в контекст. Без ключа вызов проходит, если
+// scope метода не входит в requiredScopes.
+func APIKeyUnaryInterceptor(service interfaces.APIKeyService, requiredScopes []string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		scope, ok := methodScopes[info.FullMethod]
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		token, ok := apiKeyToken(ctx)
+		if !ok {
+			if slices.Contains(requiredScopes, scope) {
+				return nil, apierror.GRPCError(ctx, application.Unauthorized("api_key_required", "an api key with the "+scope+" scope is required"))
+			}
+			return handler(ctx, req)
+		}
+
+		key, err := service.Authenticate(ctx, token, peerIP(ctx))
+		if err != nil {
+			return nil, apierror.GRPCError(ctx, err)
+		}
+		if !key.HasScope(scope) {
+			return nil, apierror.GRPCError(ctx, application.Forbidden("api_key_scope_missing", "api key lacks the "+scope+" scope"))
+		}
+
+		ctx = auth.WithAPIKey(ctx, key)
+		ctx = logging.With(ctx, slog.Uint64("api_key_id", key.ID))
+		return handler(ctx, req)
+	}
+}
+
+func apiKeyToken(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	for _, value := range md.Get("authorization") {
+		scheme, token, ok := strings.Cut(strings.TrimSpace(value), " ")
+		if ok && strings.EqualFold(scheme, "ApiKey") && strings.TrimSpace(token) != "" {
+			return strings.TrimSpace(token), true
+		}
+	}
+	return "", false
+}
+
+// peerIP - адрес клиента без порта или пустая строка.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+		return host
+	}
+	return p.Addr.String()
+}
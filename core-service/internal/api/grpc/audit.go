@@ -0,0 +1,48 @@
+package grpc
+
+import (
+	"context"
+	"log/slog"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+)
+
+// AuditUnaryInterceptor записывает в журнал доступа чтение аналитики
+// (AnalyzeStudent, BatchAnalyze - запись на каждого студента). Status
+// в записи - код gRPC. Должен стоять после интерцептора API-ключей.
+func AuditUnaryInterceptor(service interfaces.AuditService, logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if _, ok := methodScopes[info.FullMethod]; !ok {
+			return handler(ctx, req)
+		}
+		resp, err := handler(ctx, req)
+
+		var students []uint64
+		switch r := req.(type) {
+		case interface{ GetStudentIds() []uint64 }:
+			students = r.GetStudentIds()
+		case interface{ GetStudentId() uint64 }:
+			students = []uint64{r.GetStudentId()}
+		}
+		entries := make([]*domain.AuditEntry, 0, len(students))
+		for _, id := range students {
+			entries = append(entries, &domain.AuditEntry{
+				Action:    domain.AuditRead,
+				Method:    "GRPC",
+				Endpoint:  info.FullMethod,
+				StudentID: &id,
+				Status:    int(status.Code(err)),
+				IP:        peerIP(ctx),
+			})
+		}
+		auditCtx := context.WithoutCancel(ctx)
+		if recErr := service.Record(auditCtx, entries); recErr != nil {
+			logger.ErrorContext(auditCtx, "failed to record audit entry", slog.String("endpoint", info.FullMethod), slog.String("error", recErr.Error()))
+		}
+		return resp, err
+	}
+}
@@ -2,29 +2,35 @@ package grpc
 
 import (
     "context"
-    
-    "google.golang.org/grpc/codes"
-    "google.golang.org/grpc/status"
+    "strings"
     
     pb "github.com/RusselRustCode/teacher_analytics/core-service/proto"
+    "github.com/RusselRustCode/teacher_analytics/core-service/internal/api/apierror"
+    "github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
     "github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
 )
 
 type GRPCHandler struct {
     pb.UnimplementedAnalyticsServiceServer
     service interfaces.AnalyticsService
+    health  interfaces.HealthChecker
 }
 
-func NewGRPCHandler(service interfaces.AnalyticsService) *GRPCHandler {
+func NewGRPCHandler(service interfaces.AnalyticsService, health interfaces.HealthChecker) *GRPCHandler {
     return &GRPCHandler{
         service: service,
+        health:  health,
     }
 }
 
 func (h *GRPCHandler) AnalyzeStudent(ctx context.Context, req *pb.AnalyzeStudentRequest) (*pb.AnalyzeStudentResponse, error) {
+    if req.StudentId == 0 {
+        return nil, apierror.GRPCError(ctx, application.Validation("invalid_student_id", "student_id must be a positive integer"))
+    }
+
     analytics, err := h.service.GetAnalytics(ctx, req.StudentId)
     if err != nil {
-        return nil, status.Errorf(codes.Internal, "не получилось получить аналитику: %v", err)
+        return nil, apierror.GRPCError(ctx, err)
     }
     
     return &pb.AnalyzeStudentResponse{
@@ -38,12 +44,24 @@ func (h *GRPCHandler) AnalyzeStudent(ctx context.Context, req *pb.AnalyzeStudent
     }, nil
 }
 
+// HealthCheck оставлен для совместимости со старыми клиентами, новые должны
+// ходить в стандартный grpc.health.v1.
 func (h *GRPCHandler) HealthCheck(ctx context.Context, req *pb.HealthCheckRequest) (*pb.HealthCheckResponse, error) {
-    _, err := h.service.GetStudents(ctx)
-    if err != nil {
+    report := h.health.Last()
+    if report == nil {
+        report = h.health.Check(ctx)
+    }
+
+    if !report.Healthy {
+        var failed []string
+        for _, dep := range report.Dependencies {
+            if dep.Required && !dep.Healthy {
+                failed = append(failed, dep.Name)
+            }
+        }
         return &pb.HealthCheckResponse{
             Healthy: false,
-            Message: "Зависимости сервиса недоступны",
+            Message: "Зависимости сервиса недоступны: " + strings.Join(failed, ", "),
         }, nil
     }
     
@@ -0,0 +1,31 @@
+package grpc
+
+import (
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+)
+
+// ServiceName - имя сервиса из proto, под ним же публикуется общий статус.
+const ServiceName = "analytics.v1.AnalyticsService"
+
+// UpdateHealthServer переносит отчет HealthChecker в стандартный
+// grpc.health.v1: общий статус публикуется для "" и ServiceName, а каждая
+// зависимость - под своим именем (postgres, redis, kafka, analytics).
+func UpdateHealthServer(server *health.Server, report *domain.HealthReport) {
+	overall := servingStatus(report.Healthy)
+	server.SetServingStatus("", overall)
+	server.SetServingStatus(ServiceName, overall)
+
+	for _, dep := range report.Dependencies {
+		server.SetServingStatus(dep.Name, servingStatus(dep.Healthy))
+	}
+}
+
+func servingStatus(healthy bool) healthpb.HealthCheckResponse_ServingStatus {
+	if healthy {
+		return healthpb.HealthCheckResponse_SERVING
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING
+}
@@ -0,0 +1,121 @@
+package grpc
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/metrics"
+)
+
+// requestIDKey - X-Request-ID в виде ключа метаданных gRPC (только нижний регистр).
+var requestIDKey = strings.ToLower(logging.RequestIDHeader)
+
+// RequestIDUnaryInterceptor - аналог HTTP RequestIDMiddleware: берет
+// x-request-id из метаданных или генерирует, отдает обратно в заголовке
+// ответа и кладет в контекст вместе с методом.
+func RequestIDUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx = withRequestID(ctx, info.FullMethod)
+		if r, ok := req.(interface{ GetStudentId() uint64 }); ok {
+			ctx = logging.WithStudentID(ctx, r.GetStudentId())
+		}
+		return handler(ctx, req)
+	}
+}
+
+func RequestIDStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &contextStream{ServerStream: ss, ctx: withRequestID(ss.Context(), info.FullMethod)})
+	}
+}
+
+func withRequestID(ctx context.Context, method string) context.Context {
+	var requestID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDKey); len(values) > 0 && len(values[0]) <= 128 {
+			requestID = values[0]
+		}
+	}
+	if requestID == "" {
+		requestID = logging.NewRequestID()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, requestID))
+
+	ctx = logging.WithRequestID(ctx, requestID)
+	return logging.With(ctx, slog.String("route", method))
+}
+
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// LoggingUnaryInterceptor пишет одну строку лога на вызов. Должен стоять
+// после RequestIDUnaryInterceptor, чтобы в запись попал request_id.
+func LoggingUnaryInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		level := slog.LevelInfo
+		switch code {
+		case codes.OK, codes.Canceled:
+			// Пробы health приходят каждые несколько секунд
+			if strings.HasPrefix(info.FullMethod, "/grpc.health.v1.") {
+				level = slog.LevelDebug
+			}
+		case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+			level = slog.LevelError
+		default:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("code", code.String()),
+			slog.Duration("duration", time.Since(start)),
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		logger.LogAttrs(ctx, level, "grpc request", attrs...)
+
+		return resp, err
+	}
+}
+
+func MetricsUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		metrics.GRPCRequestsTotal.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+		metrics.GRPCRequestDuration.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+
+		return resp, err
+	}
+}
+
+func MetricsStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+
+		metrics.GRPCRequestsTotal.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+		metrics.GRPCRequestDuration.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
@@ -0,0 +1,58 @@
+package grpc
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/api/apierror"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/auth"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/metrics"
+)
+
+// retryAfterKey - аналог HTTP-заголовка Retry-After в метаданных ответа.
+const retryAfterKey = "retry-after"
+
+// RateLimitUnaryInterceptor ограничивает частоту вызовов с одного адреса.
+// Отказ - RESOURCE_EXHAUSTED с retry-after в секундах в заголовке ответа.
+// Пробы grpc.health.v1 не ограничиваются.
+func RateLimitUnaryInterceptor(limiter interfaces.RateLimiter, limit domain.RateLimit, logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if limit.Rate <= 0 || strings.HasPrefix(info.FullMethod, "/grpc.health.v1.") {
+			return handler(ctx, req)
+		}
+
+		decision, err := limiter.Allow(ctx, "ratelimit:grpc:"+rateLimitClient(ctx), limit)
+		if err != nil {
+			logger.WarnContext(ctx, "rate limit check failed", slog.String("group", "grpc"), slog.String("error", err.Error()))
+			return handler(ctx, req)
+		}
+		if !decision.Allowed {
+			metrics.RateLimitRejectionsTotal.WithLabelValues("grpc").Inc()
+			seconds := max(1, int(math.Ceil(decision.RetryAfter.Seconds())))
+			grpc.SetHeader(ctx, metadata.Pairs(retryAfterKey, strconv.Itoa(seconds)))
+			return nil, apierror.GRPCError(ctx, application.RateLimited("rate_limited", "too many requests, retry later"))
+		}
+		return handler(ctx, req)
+	}
+}
+
+// rateLimitClient - интеграция по API-ключу, иначе адрес клиента.
+// Интерцептор ключей должен стоять раньше.
+func rateLimitClient(ctx context.Context) string {
+	if key := auth.APIKeyFrom(ctx); key != nil {
+		return "key:" + strconv.FormatUint(key.ID, 10)
+	}
+	if ip := peerIP(ctx); ip != "" {
+		return "ip:" + ip
+	}
+	return "ip:unknown"
+}
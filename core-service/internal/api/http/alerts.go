@@ -0,0 +1,186 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+)
+
+type AlertHandler struct {
+	alerts interfaces.AlertService
+}
+
+func NewAlertHandler(alerts interfaces.AlertService) *AlertHandler {
+	return &AlertHandler{alerts: alerts}
+}
+
+// CreateRule godoc
+// @Summary      Создать правило оповещения
+// @Description  Типы: success_rate_below (threshold, window_days), inactivity (window_days), cluster_changed (cluster). С сессией LMS правило привязывается к курсу преподавателя.
+// @Tags         Alerts
+// @Accept       json
+// @Produce      json
+// @Param        rule  body      domain.AlertRule  true  "Правило"
+// @Success      201   {object}  domain.AlertRule
+// @Failure      400   {object}  apierror.Response
+// @Failure      403   {object}  apierror.Response
+// @Router       /alert-rules [post]
+func (h *AlertHandler) CreateRule(c *gin.Context) {
+	var rule domain.AlertRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		respondError(c, application.Validation("invalid_body", "request body is not a valid alert rule"))
+		return
+	}
+	// id, автор и время создания задает сервер
+	rule.ID, rule.CreatedBy = 0, ""
+	if err := h.alerts.CreateRule(c.Request.Context(), &rule); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+// ListRules godoc
+// @Summary      Правила оповещений
+// @Tags         Alerts
+// @Produce      json
+// @Success      200  {array}   domain.AlertRule
+// @Router       /alert-rules [get]
+func (h *AlertHandler) ListRules(c *gin.Context) {
+	rules, err := h.alerts.ListRules(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if rules == nil {
+		rules = []*domain.AlertRule{}
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+// DeleteRule godoc
+// @Summary      Удалить правило оповещения
+// @Description  Созданные правилом оповещения остаются в списке.
+// @Tags         Alerts
+// @Param        rule_id  path  int  true  "ID правила"
+// @Success      204
+// @Failure      403  {object}  apierror.Response
+// @Failure      404  {object}  apierror.Response
+// @Router       /alert-rules/{rule_id} [delete]
+func (h *AlertHandler) DeleteRule(c *gin.Context) {
+	id, ok := parseID(c, "rule_id")
+	if !ok {
+		return
+	}
+	if err := h.alerts.DeleteRule(c.Request.Context(), id); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListAlerts godoc
+// @Summary      Оповещения
+// @Description  Новые сверху. С сессией LMS - только по студентам курса.
+// @Tags         Alerts
+// @Produce      json
+// @Param        status      query     string  false  "open, acknowledged или resolved"
+// @Param        student_id  query     int     false  "ID студента"
+// @Param        limit       query     int     false  "Не больше 500"
+// @Success      200         {array}   domain.Alert
+// @Failure      400         {object}  apierror.Response
+// @Router       /alerts [get]
+func (h *AlertHandler) ListAlerts(c *gin.Context) {
+	var filter domain.AlertFilter
+	switch status := domain.AlertStatus(c.Query("status")); status {
+	case "", domain.AlertOpen, domain.AlertAcknowledged, domain.AlertResolved:
+		filter.Status = status
+	default:
+		respondError(c, application.Validation("invalid_status", "status must be open, acknowledged or resolved"))
+		return
+	}
+	if v := c.Query("student_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || id == 0 {
+			respondError(c, application.Validation("invalid_student_id", "student_id must be a positive integer"))
+			return
+		}
+		filter.StudentID = id
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			respondError(c, application.Validation("invalid_limit", "limit must be a positive integer"))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	alerts, err := h.alerts.ListAlerts(c.Request.Context(), filter)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if alerts == nil {
+		alerts = []*domain.Alert{}
+	}
+	c.JSON(http.StatusOK, alerts)
+}
+
+// Acknowledge godoc
+// @Summary      Взять оповещение в работу
+// @Tags         Alerts
+// @Produce      json
+// @Param        alert_id  path      int  true  "ID оповещения"
+// @Success      200       {object}  domain.Alert
+// @Failure      404       {object}  apierror.Response
+// @Failure      409       {object}  apierror.Response
+// @Router       /alerts/{alert_id}/ack [post]
+func (h *AlertHandler) Acknowledge(c *gin.Context) {
+	id, ok := parseID(c, "alert_id")
+	if !ok {
+		return
+	}
+	alert, err := h.alerts.Acknowledge(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, alert)
+}
+
+// Resolve godoc
+// @Summary      Закрыть оповещение
+// @Tags         Alerts
+// @Produce      json
+// @Param        alert_id  path      int  true  "ID оповещения"
+// @Success      200       {object}  domain.Alert
+// @Failure      404       {object}  apierror.Response
+// @Failure      409       {object}  apierror.Response
+// @Router       /alerts/{alert_id}/resolve [post]
+func (h *AlertHandler) Resolve(c *gin.Context) {
+	id, ok := parseID(c, "alert_id")
+	if !ok {
+		return
+	}
+	alert, err := h.alerts.Resolve(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, alert)
+}
+
+func parseID(c *gin.Context, param string) (uint64, bool) {
+	id, err := strconv.ParseUint(c.Param(param), 10, 64)
+	if err != nil || id == 0 {
+		respondError(c, application.Validation("invalid_"+param, param+" must be a positive integer"))
+		return 0, false
+	}
+	return id, true
+}
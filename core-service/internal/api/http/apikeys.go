@@ -0,0 +1,209 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/auth"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+)
+
+// APIKeyScheme - схема заголовка Authorization: ApiKey <ключ>.
+const APIKeyScheme = "ApiKey"
+
+// APIKeyHandlerOptions - RequiredScopes: маршруты с этими scope закрыты
+// для запросов без ключа и без сессии (например, прием логов). RotationGrace
+// - сколько старый ключ живет после ротации, если grace_period не передан.
+type APIKeyHandlerOptions struct {
+	RequiredScopes []string
+	RotationGrace  time.Duration
+}
+
+type APIKeyHandler struct {
+	service interfaces.APIKeyService
+	opts    APIKeyHandlerOptions
+}
+
+func NewAPIKeyHandler(service interfaces.APIKeyService, opts APIKeyHandlerOptions) *APIKeyHandler {
+	return &APIKeyHandler{service: service, opts: opts}
+}
+
+// CreateKey godoc
+// @Summary      Выпустить API-ключ
+// @Description  Scopes: ingest:logs, read:analytics. С cohort_ids ключ видит только студентов этих курсов. Ключ возвращается только в этом ответе, в базе хранится его хэш.
+// @Tags         API keys
+// @Accept       json
+// @Produce      json
+// @Param        key  body      domain.APIKey  true  "Ключ"
+// @Success      201  {object}  domain.APIKey
+// @Failure      400  {object}  apierror.Response
+// @Router       /api-keys [post]
+func (h *APIKeyHandler) CreateKey(c *gin.Context) {
+	var req struct {
+		Name      string   `json:"name"`
+		Scopes    []string `json:"scopes"`
+		CohortIDs []uint64 `json:"cohort_ids"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, application.Validation("invalid_body", "request body is not a valid api key"))
+		return
+	}
+	key := &domain.APIKey{Name: req.Name, Scopes: req.Scopes, CohortIDs: req.CohortIDs}
+	if err := h.service.CreateKey(c.Request.Context(), key); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, key)
+}
+
+// ListKeys godoc
+// @Summary      API-ключи
+// @Description  Вместе с отозванными; у каждого ключа время и IP последнего использования.
+// @Tags         API keys
+// @Produce      json
+// @Success      200  {array}  domain.APIKey
+// @Router       /api-keys [get]
+func (h *APIKeyHandler) ListKeys(c *gin.Context) {
+	keys, err := h.service.ListKeys(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if keys == nil {
+		keys = []*domain.APIKey{}
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// RotateKey godoc
+// @Summary      Ротировать API-ключ
+// @Description  Выпускает новый ключ с теми же правами; старый работает еще grace_period (до 168h), чтобы интеграция успела переключиться.
+// @Tags         API keys
+// @Produce      json
+// @Param        key_id        path   int     true   "ID ключа"
+// @Param        grace_period  query  string  false  "Сколько жить старому ключу, например 24h или 0s"
+// @Success      201  {object}  domain.APIKey
+// @Failure      400  {object}  apierror.Response
+// @Failure      404  {object}  apierror.Response
+// @Failure      409  {object}  apierror.Response
+// @Router       /api-keys/{key_id}/rotate [post]
+func (h *APIKeyHandler) RotateKey(c *gin.Context) {
+	id, ok := parseID(c, "key_id")
+	if !ok {
+		return
+	}
+	grace := h.opts.RotationGrace
+	if raw := c.Query("grace_period"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			respondError(c, application.Validation("invalid_grace_period", "grace_period must be a duration like 24h"))
+			return
+		}
+		grace = parsed
+	}
+	next, err := h.service.RotateKey(c.Request.Context(), id, grace)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, next)
+}
+
+// RevokeKey godoc
+// @Summary      Отозвать API-ключ
+// @Tags         API keys
+// @Param        key_id  path  int  true  "ID ключа"
+// @Success      204
+// @Failure      404  {object}  apierror.Response
+// @Failure      409  {object}  apierror.Response
+// @Router       /api-keys/{key_id} [delete]
+func (h *APIKeyHandler) RevokeKey(c *gin.Context) {
+	id, ok := parseID(c, "key_id")
+	if !ok {
+		return
+	}
+	if err := h.service.RevokeKey(c.Request.Context(), id); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Middleware кладет ключ из Authorization: ApiKey в контекст запроса.
+// Запрос без заголовка проходит дальше как есть; неизвестный или
+// отозванный ключ - всегда 401, без тихого понижения до анонима.
+func (h *APIKeyHandler) Middleware() gin.HandlerFunc {
+	if h == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		token, ok := apiKeyToken(c.GetHeader("Authorization"))
+		if !ok {
+			c.Next()
+			return
+		}
+
+		key, err := h.service.Authenticate(c.Request.Context(), token, c.ClientIP())
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		ctx := auth.WithAPIKey(c.Request.Context(), key)
+		ctx = logging.With(ctx, slog.Uint64("api_key_id", key.ID))
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// RequireScope пускает запрос с ключом, только если у ключа есть scope.
+// Без ключа запрос проходит, если scope не входит в RequiredScopes или у
+// запроса есть сессия преподавателя.
+func (h *APIKeyHandler) RequireScope(scope string) gin.HandlerFunc {
+	if h == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	required := slices.Contains(h.opts.RequiredScopes, scope)
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		key := auth.APIKeyFrom(ctx)
+		switch {
+		case key != nil && !key.HasScope(scope):
+			respondError(c, application.Forbidden("api_key_scope_missing", "api key lacks the "+scope+" scope"))
+			return
+		case key == nil && required && auth.SessionFrom(ctx) == nil:
+			respondError(c, application.Unauthorized("api_key_required", "an api key with the "+scope+" scope is required"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// DenyAPIKeys закрывает маршрут для интеграций: управлять ключами,
+// подписками и правилами может только человек.
+func (h *APIKeyHandler) DenyAPIKeys() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if auth.APIKeyFrom(c.Request.Context()) != nil {
+			respondError(c, application.Forbidden("api_key_not_allowed", "this endpoint is not available to api keys"))
+			return
+		}
+		c.Next()
+	}
+}
+
+func apiKeyToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, APIKeyScheme) {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
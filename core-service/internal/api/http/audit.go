@@ -0,0 +1,176 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+)
+
+// AuditHandler пишет журнал доступа к данным студентов и отдает его
+// офицеру по защите данных.
+type AuditHandler struct {
+	service interfaces.AuditService
+	logger  *slog.Logger
+}
+
+func NewAuditHandler(service interfaces.AuditService, logger *slog.Logger) *AuditHandler {
+	return &AuditHandler{service: service, logger: logger}
+}
+
+// ListEntries godoc
+// @Summary      Журнал доступа
+// @Description  Кто и когда читал логи и аналитику студентов и менял настройки. Новые записи первыми; следующая страница - before_id = id последней записи.
+// @Tags         Audit
+// @Produce      json
+// @Param        actor_type  query     string  false  "user, api_key или anonymous"
+// @Param        actor       query     string  false  "Subject преподавателя или ID API-ключа"
+// @Param        action      query     string  false  "read или mutation"
+// @Param        student_id  query     int     false  "ID студента"
+// @Param        cohort_id   query     int     false  "ID когорты"
+// @Param        from        query     string  false  "Начало периода (RFC3339)"
+// @Param        to          query     string  false  "Конец периода (RFC3339)"
+// @Param        before_id   query     int     false  "Записи с меньшим id"
+// @Param        limit       query     int     false  "Не больше 500"
+// @Success      200         {array}   domain.AuditEntry
+// @Failure      400         {object}  apierror.Response
+// @Router       /audit-log [get]
+func (h *AuditHandler) ListEntries(c *gin.Context) {
+	filter := domain.AuditFilter{Actor: c.Query("actor")}
+	switch actorType := c.Query("actor_type"); actorType {
+	case "", domain.ActorUser, domain.ActorAPIKey, domain.ActorAnonymous:
+		filter.ActorType = actorType
+	default:
+		respondError(c, application.Validation("invalid_actor_type", "actor_type must be user, api_key or anonymous"))
+		return
+	}
+	switch action := c.Query("action"); action {
+	case "", domain.AuditRead, domain.AuditMutation:
+		filter.Action = action
+	default:
+		respondError(c, application.Validation("invalid_action", "action must be read or mutation"))
+		return
+	}
+
+	var ok bool
+	if filter.StudentID, ok = queryUint(c, "student_id"); !ok {
+		return
+	}
+	if filter.CohortID, ok = queryUint(c, "cohort_id"); !ok {
+		return
+	}
+	if filter.BeforeID, ok = queryUint(c, "before_id"); !ok {
+		return
+	}
+	if filter.From, ok = queryTime(c, "from"); !ok {
+		return
+	}
+	if filter.To, ok = queryTime(c, "to"); !ok {
+		return
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			respondError(c, application.Validation("invalid_limit", "limit must be a positive integer"))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	entries, err := h.service.List(c.Request.Context(), filter)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if entries == nil {
+		entries = []*domain.AuditEntry{}
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// Reads записывает каждое чтение данных студентов, в том числе
+// отклоненное (403): попытка доступа тоже важна для журнала.
+func (h *AuditHandler) Reads() gin.HandlerFunc {
+	return h.record(domain.AuditRead)
+}
+
+// Mutations записывает изменения настроек (подписки, ключи, правила,
+// статусы оповещений); GET в той же группе маршрутов не записывается.
+func (h *AuditHandler) Mutations() gin.HandlerFunc {
+	return h.record(domain.AuditMutation)
+}
+
+func (h *AuditHandler) record(action string) gin.HandlerFunc {
+	if h == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		c.Next()
+
+		method := c.Request.Method
+		if action == domain.AuditMutation && (method == http.MethodGet || method == http.MethodHead) {
+			return
+		}
+		entry := &domain.AuditEntry{
+			Action:    action,
+			Method:    method,
+			Endpoint:  c.FullPath(),
+			Path:      c.Request.URL.Path,
+			StudentID: auditID(c, "student_id"),
+			CohortID:  auditID(c, "cohort_id"),
+			Status:    c.Writer.Status(),
+			IP:        c.ClientIP(),
+		}
+		// Клиент мог уже отключиться, а запись в журнал нужна все равно
+		ctx := context.WithoutCancel(c.Request.Context())
+		if err := h.service.Record(ctx, []*domain.AuditEntry{entry}); err != nil {
+			h.logger.ErrorContext(ctx, "failed to record audit entry", slog.String("endpoint", entry.Endpoint), slog.String("error", err.Error()))
+		}
+	}
+}
+
+// auditID берет ID из пути, иначе из query (GET /alerts?student_id=).
+func auditID(c *gin.Context, name string) *uint64 {
+	raw := c.Param(name)
+	if raw == "" {
+		raw = c.Query(name)
+	}
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil || id == 0 {
+		return nil
+	}
+	return &id
+}
+
+func queryUint(c *gin.Context, name string) (uint64, bool) {
+	v := c.Query(name)
+	if v == "" {
+		return 0, true
+	}
+	id, err := strconv.ParseUint(v, 10, 64)
+	if err != nil || id == 0 {
+		respondError(c, application.Validation("invalid_"+name, name+" must be a positive integer"))
+		return 0, false
+	}
+	return id, true
+}
+
+func queryTime(c *gin.Context, name string) (time.Time, bool) {
+	v := c.Query(name)
+	if v == "" {
+		return time.Time{}, true
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		respondError(c, application.Validation("invalid_"+name, name+" must be an RFC3339 timestamp"))
+		return time.Time{}, false
+	}
+	return t, true
+}
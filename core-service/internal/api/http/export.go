@@ -0,0 +1,154 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/export"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+)
+
+type ExportHandler struct {
+	exporter interfaces.ExportService
+}
+
+func NewExportHandler(exporter interfaces.ExportService) *ExportHandler {
+	return &ExportHandler{exporter: exporter}
+}
+
+// ExportStudentLogs godoc
+// @Summary      Выгрузить логи студента
+// @Description  Отдает логи за период файлом CSV или XLSX. Заголовки колонок на русском или английском (lang или Accept-Language).
+// @Tags         Students
+// @Produce      text/csv
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param        student_id  path      int     true   "ID студента"
+// @Param        format      query     string  false  "csv (по умолчанию) или xlsx"
+// @Param        lang        query     string  false  "ru или en"
+// @Param        from        query     string  false  "Начало периода (RFC3339)"
+// @Param        to          query     string  false  "Конец периода (RFC3339)"
+// @Success      200         {file}    file
+// @Failure      400         {object}  apierror.Response
+// @Router       /students/{student_id}/logs/export [get]
+func (h *ExportHandler) ExportStudentLogs(c *gin.Context) {
+	studentID, ok := parseStudentID(c)
+	if !ok {
+		return
+	}
+	opts, ok := parseExportOptions(c)
+	if !ok {
+		return
+	}
+
+	withStudentID(c, studentID)
+	filename := fmt.Sprintf("student_%d_logs.%s", studentID, export.FileExtension(opts.Format))
+	streamFile(c, export.ContentType(opts.Format), filename, func(w io.Writer) error {
+		return h.exporter.ExportStudentLogs(c.Request.Context(), studentID, opts, w)
+	})
+}
+
+// ExportCohortAnalytics godoc
+// @Summary      Выгрузить аналитику когорты
+// @Description  Отдает последнюю аналитику студентов когорты, проанализированных за период, файлом CSV или XLSX.
+// @Tags         Cohorts
+// @Produce      text/csv
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param        cohort_id   path      int     true   "ID когорты"
+// @Param        format      query     string  false  "csv (по умолчанию) или xlsx"
+// @Param        lang        query     string  false  "ru или en"
+// @Param        from        query     string  false  "Начало периода (RFC3339)"
+// @Param        to          query     string  false  "Конец периода (RFC3339)"
+// @Success      200         {file}    file
+// @Failure      400         {object}  apierror.Response
+// @Failure      404         {object}  apierror.Response
+// @Router       /cohorts/{cohort_id}/analytics/export [get]
+func (h *ExportHandler) ExportCohortAnalytics(c *gin.Context) {
+	cohortID, err := strconv.ParseUint(c.Param("cohort_id"), 10, 64)
+	if err != nil || cohortID == 0 {
+		respondError(c, application.Validation("invalid_cohort_id", "cohort_id must be a positive integer"))
+		return
+	}
+	opts, ok := parseExportOptions(c)
+	if !ok {
+		return
+	}
+
+	filename := fmt.Sprintf("cohort_%d_analytics.%s", cohortID, export.FileExtension(opts.Format))
+	streamFile(c, export.ContentType(opts.Format), filename, func(w io.Writer) error {
+		return h.exporter.ExportCohortAnalytics(c.Request.Context(), cohortID, opts, w)
+	})
+}
+
+func parseExportOptions(c *gin.Context) (domain.ExportOptions, bool) {
+	var opts domain.ExportOptions
+
+	switch format := domain.ExportFormat(strings.ToLower(c.DefaultQuery("format", string(domain.ExportCSV)))); format {
+	case domain.ExportCSV, domain.ExportXLSX:
+		opts.Format = format
+	default:
+		respondError(c, application.Validation("invalid_format", "format must be csv or xlsx"))
+		return opts, false
+	}
+
+	opts.Lang = exportLanguage(c)
+
+	from, to, ok := parseDateRange(c)
+	if !ok {
+		return opts, false
+	}
+	opts.From, opts.To = from, to
+	return opts, true
+}
+
+// exportLanguage берет язык из параметра lang, затем из Accept-Language.
+// По умолчанию русский.
+func exportLanguage(c *gin.Context) domain.Language {
+	lang := c.Query("lang")
+	if lang == "" {
+		lang = c.GetHeader("Accept-Language")
+	}
+	if strings.HasPrefix(strings.ToLower(lang), string(domain.LangEN)) {
+		return domain.LangEN
+	}
+	return domain.LangRU
+}
+
+// fileWriter откладывает заголовки ответа до первой записи: пока в тело
+// ничего не ушло, ошибку еще можно отдать обычным JSON.
+type fileWriter struct {
+	c           *gin.Context
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (w *fileWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.c.Header("Content-Type", w.contentType)
+		w.c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", w.filename))
+		w.c.Status(http.StatusOK)
+	}
+	return w.c.Writer.Write(p)
+}
+
+// streamFile отдает файл вложением по мере того, как write пишет в w.
+func streamFile(c *gin.Context, contentType, filename string, write func(w io.Writer) error) {
+	w := &fileWriter{c: c, contentType: contentType, filename: filename}
+	if err := write(w); err != nil {
+		if !w.started {
+			respondError(c, err)
+			return
+		}
+		// Файл уже частично отправлен: остается оборвать ответ и записать ошибку в лог
+		c.Error(err)
+		c.Abort()
+	}
+}
@@ -9,8 +9,11 @@ import (
     
     "github.com/gin-gonic/gin"
     
+    "github.com/RusselRustCode/teacher_analytics/core-service/internal/api/apierror"
+    "github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
     "github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
     "github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+    "github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
 )
 
 type HTTPHandler struct {
@@ -23,6 +26,54 @@ func NewHTTPHandler(service interfaces.AnalyticsService) *HTTPHandler {
     }
 }
 
+// withStudentID добавляет ID студента ко всем логам запроса, включая access-лог.
+func withStudentID(c *gin.Context, studentID uint64) {
+    c.Request = c.Request.WithContext(logging.WithStudentID(c.Request.Context(), studentID))
+}
+
+// respondError отвечает единой схемой ошибки (см. apierror.Response), а
+// исходную ошибку со всеми подробностями отдает в access-лог.
+func respondError(c *gin.Context, err error) {
+    c.Error(err)
+    c.AbortWithStatusJSON(apierror.HTTPStatus(err), apierror.NewResponse(c.Request.Context(), err))
+}
+
+func parseStudentID(c *gin.Context) (uint64, bool) {
+    studentID, err := strconv.ParseUint(c.Param("student_id"), 10, 64)
+    if err != nil || studentID == 0 {
+        respondError(c, application.Validation("invalid_student_id", "student_id must be a positive integer"))
+        return 0, false
+    }
+    return studentID, true
+}
+
+// parseDateRange читает параметры from и to (RFC3339). По умолчанию -
+// последний месяц.
+func parseDateRange(c *gin.Context) (time.Time, time.Time, bool) {
+    from := time.Now().AddDate(0, -1, 0)
+    to := time.Now()
+    
+    if fromStr := c.Query("from"); fromStr != "" {
+        parsed, err := time.Parse(time.RFC3339, fromStr)
+        if err != nil {
+            respondError(c, application.Validation("invalid_from", "from must be an RFC3339 timestamp"))
+            return time.Time{}, time.Time{}, false
+        }
+        from = parsed
+    }
+    
+    if toStr := c.Query("to"); toStr != "" {
+        parsed, err := time.Parse(time.RFC3339, toStr)
+        if err != nil {
+            respondError(c, application.Validation("invalid_to", "to must be an RFC3339 timestamp"))
+            return time.Time{}, time.Time{}, false
+        }
+        to = parsed
+    }
+    
+    return from, to, true
+}
+
 // SendLog godoc
 // @Summary Отправить лог активности
 // @Tags logs
@@ -30,23 +81,20 @@ func NewHTTPHandler(service interfaces.AnalyticsService) *HTTPHandler {
 // @Produce json
 // @Param log body domain.StudentLog true "Данные лога"
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} apierror.Response
+// @Failure 503 {object} apierror.Response
 // @Router /log [post]
 func (h *HTTPHandler) SendLog(c *gin.Context) {
     var log domain.StudentLog
     
-    if err := c.BindJSON(&log); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "error":   "Invalid request body",
-            "details": err.Error(),
-        })
+    if err := c.ShouldBindJSON(&log); err != nil {
+        respondError(c, application.Validation("invalid_body", "request body is not a valid student log"))
         return
     }
     
+    withStudentID(c, log.StudentID)
     if err := h.service.SendLog(c.Request.Context(), &log); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "error":   "Failed to process log",
-            "details": err.Error(),
-        })
+        respondError(c, err)
         return
     }
     
@@ -63,23 +111,19 @@ func (h *HTTPHandler) SendLog(c *gin.Context) {
 // @Produce json
 // @Param student_id path int true "ID Студента"
 // @Success 200 {object} domain.StudentAnalytics
+// @Failure 400 {object} apierror.Response
+// @Failure 503 {object} apierror.Response
 // @Router /analytics/{student_id} [get]
 func (h *HTTPHandler) GetAnalytics(c *gin.Context) {
-    studentIDStr := c.Param("student_id")
-    studentID, err := strconv.ParseUint(studentIDStr, 10, 64)
-    if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "error": "Invalid student ID",
-        })
+    studentID, ok := parseStudentID(c)
+    if !ok {
         return
     }
     
+    withStudentID(c, studentID)
     analytics, err := h.service.GetAnalytics(c.Request.Context(), studentID)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "error":   "Failed to get analytics",
-            "details": err.Error(),
-        })
+        respondError(c, err)
         return
     }
     
@@ -95,48 +139,24 @@ func (h *HTTPHandler) GetAnalytics(c *gin.Context) {
 // @Param        from        query     string  false  "Начало периода (RFC3339, e.g. 2026-01-01T00:00:00Z)"
 // @Param        to          query     string  false  "Конец периода (RFC3339)"
 // @Success      200         {object}  map[string]interface{}
-// @Failure      400         {object}  map[string]string
-// @Failure      500         {object}  map[string]string
+// @Failure      400         {object}  apierror.Response
+// @Failure      500         {object}  apierror.Response
 // @Router       /students/{student_id}/logs [get]
 func (h *HTTPHandler) GetStudentLogs(c *gin.Context) {
-    studentIDStr := c.Param("student_id")
-    studentID, err := strconv.ParseUint(studentIDStr, 10, 64)
-    if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid student ID"})
+    studentID, ok := parseStudentID(c)
+    if !ok {
         return
     }
     
-    fromStr := c.DefaultQuery("from", "")
-    toStr := c.DefaultQuery("to", "")
-    
-    var from, to time.Time
-    
-    if fromStr != "" {
-        from, err = time.Parse(time.RFC3339, fromStr)
-        if err != nil {
-            c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date format"})
-            return
-        }
-    } else {
-        from = time.Now().AddDate(0, -1, 0) // Месяц назад по умолчанию сделаем
-    }
-    
-    if toStr != "" {
-        to, err = time.Parse(time.RFC3339, toStr)
-        if err != nil {
-            c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date format"})
-            return
-        }
-    } else {
-        to = time.Now()
+    from, to, ok := parseDateRange(c)
+    if !ok {
+        return
     }
     
+    withStudentID(c, studentID)
     logs, err := h.service.GetStudentLogs(c.Request.Context(), studentID, from, to)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "error":   "Failed to get logs",
-            "details": err.Error(),
-        })
+        respondError(c, err)
         return
     }
     
@@ -154,15 +174,12 @@ func (h *HTTPHandler) GetStudentLogs(c *gin.Context) {
 // @Tags         Students
 // @Produce      json
 // @Success      200         {object}  map[string]interface{}
-// @Failure      500         {object}  map[string]string
+// @Failure      500         {object}  apierror.Response
 // @Router       /students [get]
 func (h *HTTPHandler) GetStudents(c *gin.Context) {
     students, err := h.service.GetStudents(c.Request.Context())
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "error":   "Failed to get students",
-            "details": err.Error(),
-        })
+        respondError(c, err)
         return
     }
     
@@ -177,16 +194,14 @@ func (h *HTTPHandler) TriggerAnalysis(c *gin.Context) {
         StudentID uint64 `json:"student_id"`
     }
     
-    if err := c.BindJSON(&request); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+    if err := c.ShouldBindJSON(&request); err != nil || request.StudentID == 0 {
+        respondError(c, application.Validation("invalid_body", "student_id is required"))
         return
     }
     
+    withStudentID(c, request.StudentID)
     if err := h.service.TriggerAnalysis(c.Request.Context(), request.StudentID); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "error":   "Failed to trigger analysis",
-            "details": err.Error(),
-        })
+        respondError(c, err)
         return
     }
     
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+)
+
+type HealthHandler struct {
+	checker interfaces.HealthChecker
+}
+
+func NewHealthHandler(checker interfaces.HealthChecker) *HealthHandler {
+	return &HealthHandler{
+		checker: checker,
+	}
+}
+
+// Liveness godoc
+// @Summary      Liveness-проба
+// @Description  Процесс жив и обслуживает запросы. Отдает последний отчет о зависимостях, но не опрашивает их.
+// @Tags         Health
+// @Produce      json
+// @Success      200  {object}  domain.HealthReport
+// @Router       /healthz [get]
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	report := h.checker.Last()
+	if report == nil {
+		c.JSON(http.StatusOK, gin.H{"healthy": true})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// Readiness godoc
+// @Summary      Readiness-проба
+// @Description  503, если недоступна хотя бы одна обязательная зависимость (Postgres, Kafka).
+// @Tags         Health
+// @Produce      json
+// @Success      200  {object}  domain.HealthReport
+// @Failure      503  {object}  domain.HealthReport
+// @Router       /readyz [get]
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	report := h.checker.Last()
+	if report == nil {
+		report = h.checker.Check(c.Request.Context())
+	}
+
+	if !report.Healthy {
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,128 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/importer"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+)
+
+type ImportHandler struct {
+	importer       interfaces.ImportService
+	maxUploadBytes int64
+}
+
+func NewImportHandler(importer interfaces.ImportService, maxUploadBytes int64) *ImportHandler {
+	return &ImportHandler{importer: importer, maxUploadBytes: maxUploadBytes}
+}
+
+// Import godoc
+// @Summary      Загрузить исторические логи
+// @Description  Принимает CSV или xAPI (JSON-массив, {"statements": [...]} или NDJSON) полем file multipart-формы либо телом запроса. Строки проверяются как в /log, дубли пропускаются. С dry_run=true база не меняется, а в отчете видно, какие строки будут отклонены.
+// @Tags         Import
+// @Accept       multipart/form-data
+// @Accept       text/csv
+// @Accept       application/json
+// @Produce      json
+// @Param        file     formData  file    false  "Файл выгрузки"
+// @Param        format   query     string  false  "csv или xapi; по умолчанию определяется по расширению файла"
+// @Param        dry_run  query     bool    false  "Только проверить файл"
+// @Param        analyze  query     bool    false  "Запустить анализ затронутых студентов"
+// @Param        columns  query     string  false  "Свои названия колонок CSV: student_id=user,timestamp=date"
+// @Success      200      {object}  domain.ImportReport
+// @Failure      400      {object}  apierror.Response
+// @Failure      413      {object}  apierror.Response
+// @Router       /import [post]
+func (h *ImportHandler) Import(c *gin.Context) {
+	if h.maxUploadBytes > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxUploadBytes)
+	}
+
+	body, filename, err := importSource(c)
+	if err != nil {
+		respondBodyError(c, err)
+		return
+	}
+	defer body.Close()
+
+	opts, ok := parseImportOptions(c, filename)
+	if !ok {
+		return
+	}
+
+	report, err := h.importer.Import(c.Request.Context(), body, opts)
+	if err != nil {
+		respondBodyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// importSource возвращает файл из multipart-формы или само тело запроса.
+func importSource(c *gin.Context) (io.ReadCloser, string, error) {
+	if !strings.HasPrefix(c.ContentType(), "multipart/") {
+		return c.Request.Body, "", nil
+	}
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, "", err
+		}
+		return nil, "", application.Validation("file_required", "multipart form must contain a file field")
+	}
+	return file, header.Filename, nil
+}
+
+func parseImportOptions(c *gin.Context, filename string) (domain.ImportOptions, bool) {
+	opts := domain.ImportOptions{
+		DryRun:          queryBool(c, "dry_run"),
+		TriggerAnalysis: queryBool(c, "analyze"),
+	}
+
+	format := strings.ToLower(c.Query("format"))
+	if format == "" {
+		format = string(domain.ImportCSV)
+		if ext := strings.ToLower(filepath.Ext(filename)); ext == ".json" || ext == ".ndjson" {
+			format = string(domain.ImportXAPI)
+		}
+	}
+	switch opts.Format = domain.ImportFormat(format); opts.Format {
+	case domain.ImportCSV, domain.ImportXAPI:
+	default:
+		respondError(c, application.Validation("invalid_format", "format must be csv or xapi"))
+		return opts, false
+	}
+
+	columns, err := importer.ParseColumnMapping(c.Query("columns"))
+	if err != nil {
+		respondError(c, application.Validation("invalid_columns", err.Error()))
+		return opts, false
+	}
+	opts.CSVColumns = columns
+	return opts, true
+}
+
+func queryBool(c *gin.Context, name string) bool {
+	v, _ := strconv.ParseBool(c.Query(name))
+	return v
+}
+
+// respondBodyError - respondError, который отвечает 413, если тело
+// запроса превысило лимит MaxBytesReader.
+func respondBodyError(c *gin.Context, err error) {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		err = application.TooLarge("file_too_large", "file exceeds upload limit", err)
+	}
+	respondError(c, err)
+}
@@ -0,0 +1,217 @@
+package http
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/auth"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+)
+
+const (
+	SessionCookie = "ta_session"
+	stateCookie   = "lti_state"
+)
+
+// LTIHandlerOptions - DashboardURL - куда отправить преподавателя после
+// запуска. RequireSession закрывает дашборд от запросов без сессии.
+type LTIHandlerOptions struct {
+	DashboardURL   string
+	CookieSecure   bool
+	RequireSession bool
+}
+
+type LTIHandler struct {
+	service interfaces.LTIService
+	opts    LTIHandlerOptions
+}
+
+func NewLTIHandler(service interfaces.LTIService, opts LTIHandlerOptions) *LTIHandler {
+	if opts.DashboardURL == "" {
+		opts.DashboardURL = "/"
+	}
+	return &LTIHandler{service: service, opts: opts}
+}
+
+// Login godoc
+// @Summary      Инициация входа LTI 1.3
+// @Description  Третья сторона OIDC-входа: платформа (Moodle) передает iss, login_hint и lti_message_hint, сервис перенаправляет браузер обратно на платформу за id_token.
+// @Tags         LTI
+// @Param        iss               query  string  true   "Issuer платформы"
+// @Param        login_hint        query  string  true   "Подсказка платформы о пользователе"
+// @Param        target_link_uri   query  string  false  "Запрошенный адрес инструмента"
+// @Param        lti_message_hint  query  string  false  "Подсказка платформы о запуске"
+// @Param        client_id         query  string  false  "client_id инструмента"
+// @Success      302
+// @Failure      400  {object}  apierror.Response
+// @Router       /lti/login [get]
+// @Router       /lti/login [post]
+func (h *LTIHandler) Login(c *gin.Context) {
+	req := domain.LTILoginRequest{
+		Issuer:         c.Request.FormValue("iss"),
+		LoginHint:      c.Request.FormValue("login_hint"),
+		TargetLinkURI:  c.Request.FormValue("target_link_uri"),
+		LTIMessageHint: c.Request.FormValue("lti_message_hint"),
+		ClientID:       c.Request.FormValue("client_id"),
+		DeploymentID:   c.Request.FormValue("lti_deployment_id"),
+	}
+
+	redirect, err := h.service.InitiateLogin(c.Request.Context(), req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	h.setCookie(c, &http.Cookie{
+		Name:   stateCookie,
+		Value:  redirect.State,
+		Path:   "/api/lti",
+		MaxAge: int((10 * time.Minute).Seconds()),
+	})
+	c.Redirect(http.StatusFound, redirect.URL)
+}
+
+// Launch godoc
+// @Summary      Запуск LTI 1.3
+// @Description  Принимает id_token от платформы (form_post), открывает сессию, ограниченную курсом запуска, и перенаправляет на дашборд.
+// @Tags         LTI
+// @Accept       x-www-form-urlencoded
+// @Param        id_token  formData  string  true  "id_token платформы"
+// @Param        state     formData  string  true  "state из инициации входа"
+// @Success      303
+// @Failure      401  {object}  apierror.Response
+// @Failure      403  {object}  apierror.Response
+// @Router       /lti/launch [post]
+func (h *LTIHandler) Launch(c *gin.Context) {
+	cookieState, _ := c.Cookie(stateCookie)
+	session, err := h.service.Launch(c.Request.Context(), c.PostForm("id_token"), c.PostForm("state"), cookieState)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	h.setCookie(c, &http.Cookie{Name: stateCookie, Path: "/api/lti", MaxAge: -1})
+	h.setCookie(c, &http.Cookie{
+		Name:    SessionCookie,
+		Value:   session.ID,
+		Path:    "/",
+		Expires: session.ExpiresAt,
+	})
+	c.Redirect(http.StatusSeeOther, h.opts.DashboardURL)
+}
+
+// Session godoc
+// @Summary      Текущая сессия
+// @Description  Возвращает преподавателя и курс, которыми ограничен дашборд.
+// @Tags         LTI
+// @Produce      json
+// @Success      200  {object}  domain.Session
+// @Failure      401  {object}  apierror.Response
+// @Router       /session [get]
+func (h *LTIHandler) Session(c *gin.Context) {
+	session := auth.SessionFrom(c.Request.Context())
+	if session == nil {
+		respondError(c, application.Unauthorized("session_required", "sign in through your LMS"))
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// Logout godoc
+// @Summary      Завершить сессию
+// @Tags         LTI
+// @Success      204
+// @Router       /session/logout [post]
+func (h *LTIHandler) Logout(c *gin.Context) {
+	token, _ := c.Cookie(SessionCookie)
+	if err := h.service.EndSession(c.Request.Context(), token); err != nil {
+		respondError(c, err)
+		return
+	}
+	h.setCookie(c, &http.Cookie{Name: SessionCookie, Path: "/", MaxAge: -1})
+	c.Status(http.StatusNoContent)
+}
+
+// SessionMiddleware кладет сессию из cookie в контекст запроса. Запрос
+// без cookie проходит анонимно, если сессия не обязательна; просроченная
+// или чужая cookie - всегда 401, чтобы дашборд не показал лишнего.
+func (h *LTIHandler) SessionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, _ := c.Cookie(SessionCookie)
+		// Интеграции с API-ключом приходят без сессии
+		if token == "" && (!h.opts.RequireSession || auth.APIKeyFrom(c.Request.Context()) != nil) {
+			c.Next()
+			return
+		}
+
+		if h.loadSession(c, token) {
+			c.Next()
+		}
+	}
+}
+
+// RequireUser пускает только запросы с сессией преподавателя, а если
+// заданы roles - с одной из этих ролей, независимо от RequireSession:
+// так закрыты необратимые операции и настройки, общие для всех курсов.
+// Без LTI сессию получить негде, и такие маршруты закрыты для всех.
+func (h *LTIHandler) RequireUser(roles ...domain.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := auth.SessionFrom(c.Request.Context())
+		if session == nil && h != nil {
+			if token, _ := c.Cookie(SessionCookie); token != "" {
+				if !h.loadSession(c, token) {
+					return
+				}
+				session = auth.SessionFrom(c.Request.Context())
+			}
+		}
+		if session == nil {
+			respondError(c, application.Unauthorized("session_required", "a teacher session is required"))
+			return
+		}
+		if len(roles) > 0 && !slices.Contains(roles, session.Role) {
+			respondError(c, application.Forbidden("role_not_allowed", fmt.Sprintf("role %q is not allowed here", session.Role)))
+			return
+		}
+		c.Next()
+	}
+}
+
+// loadSession кладет сессию в контекст запроса; при ошибке отвечает сам
+// и возвращает false.
+func (h *LTIHandler) loadSession(c *gin.Context, token string) bool {
+	session, err := h.service.GetSession(c.Request.Context(), token)
+	if err != nil {
+		if application.IsKind(err, application.KindUnauthorized) && token != "" {
+			h.setCookie(c, &http.Cookie{Name: SessionCookie, Path: "/", MaxAge: -1})
+		}
+		respondError(c, err)
+		return false
+	}
+
+	ctx := auth.WithSession(c.Request.Context(), session)
+	ctx = logging.With(ctx, slog.String("user", session.Subject), slog.Uint64("cohort_id", session.CohortID))
+	c.Request = c.Request.WithContext(ctx)
+	return true
+}
+
+// setCookie ставит cookie для работы внутри iframe LMS: сайт платформы
+// другой, поэтому нужен SameSite=None, а с ним браузеры требуют Secure.
+func (h *LTIHandler) setCookie(c *gin.Context, cookie *http.Cookie) {
+	cookie.HttpOnly = true
+	cookie.Secure = h.opts.CookieSecure
+	cookie.SameSite = http.SameSiteNoneMode
+	if !h.opts.CookieSecure {
+		// Без TLS (локальная разработка) SameSite=None браузер отвергнет
+		cookie.SameSite = http.SameSiteLaxMode
+	}
+	http.SetCookie(c.Writer, cookie)
+}
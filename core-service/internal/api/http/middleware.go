@@ -0,0 +1,87 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/metrics"
+)
+
+// RequestIDMiddleware берет X-Request-ID из запроса или генерирует новый,
+// возвращает его в ответе и кладет в контекст вместе с маршрутом, чтобы
+// все логи этого запроса можно было связать между собой.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(logging.RequestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = logging.NewRequestID()
+		}
+		c.Header(logging.RequestIDHeader, requestID)
+
+		ctx := logging.WithRequestID(c.Request.Context(), requestID)
+		ctx = logging.With(ctx, slog.String("route", c.FullPath()))
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// LoggingMiddleware пишет одну строку access-лога на запрос вместо
+// стандартного логгера gin.
+func LoggingMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		level := slog.LevelInfo
+		status := c.Writer.Status()
+		if status >= 500 {
+			level = slog.LevelError
+		} else if status >= 400 {
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.String()))
+		}
+		logger.LogAttrs(c.Request.Context(), level, "http request", attrs...)
+	}
+}
+
+// RecoveryMiddleware перехватывает панику в хендлере, логирует ее и отвечает 500.
+func RecoveryMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, err any) {
+		logger.ErrorContext(c.Request.Context(), "panic recovered", slog.Any("panic", err))
+		c.AbortWithStatus(http.StatusInternalServerError)
+	})
+}
+
+// MetricsMiddleware считает запросы и их длительность по шаблону маршрута
+// (/api/analytics/:student_id), а не по фактическому пути, чтобы не плодить
+// метки на каждый ID студента.
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		metrics.HTTPRequestsTotal.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,72 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+)
+
+// PrivacyHandler - запросы студентов как субъектов данных: выгрузка
+// всего, что о них хранится, и удаление.
+type PrivacyHandler struct {
+	service interfaces.PrivacyService
+}
+
+func NewPrivacyHandler(service interfaces.PrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{service: service}
+}
+
+// ExportStudentData godoc
+// @Summary      Выгрузить все данные студента
+// @Description  ZIP с profile.json, logs.json (все логи), analytics.json и analytics_history.json.
+// @Tags         Privacy
+// @Produce      application/zip
+// @Param        student_id  path      int  true  "ID студента"
+// @Success      200         {file}    file
+// @Failure      400         {object}  apierror.Response
+// @Failure      403         {object}  apierror.Response
+// @Failure      404         {object}  apierror.Response
+// @Router       /students/{student_id}/data-export [get]
+func (h *PrivacyHandler) ExportStudentData(c *gin.Context) {
+	studentID, ok := parseStudentID(c)
+	if !ok {
+		return
+	}
+
+	withStudentID(c, studentID)
+	filename := fmt.Sprintf("student_%d_data.zip", studentID)
+	streamFile(c, "application/zip", filename, func(w io.Writer) error {
+		return h.service.ExportStudentData(c.Request.Context(), studentID, w)
+	})
+}
+
+// DeleteStudent godoc
+// @Summary      Удалить данные студента
+// @Description  erase удаляет профиль, логи, аналитику, оповещения и statement'ы; anonymize переносит логи и аналитику на случайный псевдоним, а профиль удаляет. Журнал доступа сохраняется.
+// @Tags         Privacy
+// @Produce      json
+// @Param        student_id  path      int     true  "ID студента"
+// @Param        mode        query     string  true  "erase или anonymize"
+// @Success      200         {object}  domain.StudentErasure
+// @Failure      400         {object}  apierror.Response
+// @Failure      403         {object}  apierror.Response
+// @Failure      503         {object}  apierror.Response
+// @Router       /students/{student_id} [delete]
+func (h *PrivacyHandler) DeleteStudent(c *gin.Context) {
+	studentID, ok := parseStudentID(c)
+	if !ok {
+		return
+	}
+
+	withStudentID(c, studentID)
+	erasure, err := h.service.DeleteStudent(c.Request.Context(), studentID, c.Query("mode"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, erasure)
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/auth"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/metrics"
+)
+
+// Группы маршрутов со своими лимитами.
+const (
+	RateLimitIngest    = "ingest"
+	RateLimitDashboard = "dashboard"
+)
+
+// RateLimitHandler ограничивает частоту запросов клиента в группе
+// маршрутов. У каждой группы свое ведро, так что поток логов от LMS не
+// мешает тому же клиенту читать дашборд.
+type RateLimitHandler struct {
+	limiter interfaces.RateLimiter
+	limits  map[string]domain.RateLimit
+	logger  *slog.Logger
+}
+
+func NewRateLimitHandler(limiter interfaces.RateLimiter, limits map[string]domain.RateLimit, logger *slog.Logger) *RateLimitHandler {
+	return &RateLimitHandler{limiter: limiter, limits: limits, logger: logger}
+}
+
+// Middleware пропускает все запросы, если ограничение не настроено (nil
+// или Rate = 0 у группы). Для дашборда должен стоять после
+// SessionMiddleware, иначе все преподаватели за одним NAT делят ведро.
+func (h *RateLimitHandler) Middleware(group string) gin.HandlerFunc {
+	var limit domain.RateLimit
+	if h != nil {
+		limit = h.limits[group]
+	}
+	if limit.Rate <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		decision, err := h.limiter.Allow(ctx, "ratelimit:"+group+":"+rateLimitClient(c), limit)
+		if err != nil {
+			// Без Redis лимит не проверить; отказывать всем клиентам хуже
+			h.logger.WarnContext(ctx, "rate limit check failed", slog.String("group", group), slog.String("error", err.Error()))
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		if !decision.Allowed {
+			metrics.RateLimitRejectionsTotal.WithLabelValues(group).Inc()
+			c.Header("Retry-After", strconv.Itoa(retryAfterSeconds(decision.RetryAfter)))
+			respondError(c, application.RateLimited("rate_limited", "too many requests, retry later"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimitClient - кто расходует ведро: интеграция по API-ключу,
+// преподаватель из сессии, иначе IP.
+func rateLimitClient(c *gin.Context) string {
+	if key := auth.APIKeyFrom(c.Request.Context()); key != nil {
+		return "key:" + strconv.FormatUint(key.ID, 10)
+	}
+	if session := auth.SessionFrom(c.Request.Context()); session != nil {
+		return "user:" + session.Subject
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// retryAfterSeconds округляет ожидание вверх до целых секунд, как того
+// требует заголовок Retry-After.
+func retryAfterSeconds(d time.Duration) int {
+	return max(1, int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,92 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/report"
+)
+
+type ReportHandler struct {
+	reports interfaces.ReportService
+}
+
+func NewReportHandler(reports interfaces.ReportService) *ReportHandler {
+	return &ReportHandler{reports: reports}
+}
+
+// StudentReport godoc
+// @Summary      PDF-отчет по студенту
+// @Description  Итоги анализа, эффективность по темам, динамика за период и рекомендации. Графики строятся на сервере.
+// @Tags         Students
+// @Produce      application/pdf
+// @Param        student_id  path      int     true   "ID студента"
+// @Param        lang        query     string  false  "ru или en"
+// @Param        from        query     string  false  "Начало периода динамики (RFC3339)"
+// @Param        to          query     string  false  "Конец периода динамики (RFC3339)"
+// @Success      200         {file}    file
+// @Failure      400         {object}  apierror.Response
+// @Failure      403         {object}  apierror.Response
+// @Failure      404         {object}  apierror.Response
+// @Router       /students/{student_id}/report.pdf [get]
+func (h *ReportHandler) StudentReport(c *gin.Context) {
+	studentID, ok := parseStudentID(c)
+	if !ok {
+		return
+	}
+	opts, ok := parseReportOptions(c)
+	if !ok {
+		return
+	}
+
+	withStudentID(c, studentID)
+	filename := fmt.Sprintf("student_%d_report.pdf", studentID)
+	streamFile(c, report.ContentType, filename, func(w io.Writer) error {
+		return h.reports.StudentReport(c.Request.Context(), studentID, opts, w)
+	})
+}
+
+// CohortReports godoc
+// @Summary      PDF-отчеты по когорте
+// @Description  ZIP с отчетами всех проанализированных студентов когорты; остальные перечислены в skipped.txt.
+// @Tags         Cohorts
+// @Produce      application/zip
+// @Param        cohort_id   path      int     true   "ID когорты"
+// @Param        lang        query     string  false  "ru или en"
+// @Param        from        query     string  false  "Начало периода динамики (RFC3339)"
+// @Param        to          query     string  false  "Конец периода динамики (RFC3339)"
+// @Success      200         {file}    file
+// @Failure      400         {object}  apierror.Response
+// @Failure      403         {object}  apierror.Response
+// @Failure      404         {object}  apierror.Response
+// @Router       /cohorts/{cohort_id}/reports.zip [get]
+func (h *ReportHandler) CohortReports(c *gin.Context) {
+	cohortID, err := strconv.ParseUint(c.Param("cohort_id"), 10, 64)
+	if err != nil || cohortID == 0 {
+		respondError(c, application.Validation("invalid_cohort_id", "cohort_id must be a positive integer"))
+		return
+	}
+	opts, ok := parseReportOptions(c)
+	if !ok {
+		return
+	}
+
+	filename := fmt.Sprintf("cohort_%d_reports.zip", cohortID)
+	streamFile(c, "application/zip", filename, func(w io.Writer) error {
+		return h.reports.CohortReports(c.Request.Context(), cohortID, opts, w)
+	})
+}
+
+func parseReportOptions(c *gin.Context) (domain.ReportOptions, bool) {
+	from, to, ok := parseDateRange(c)
+	if !ok {
+		return domain.ReportOptions{}, false
+	}
+	return domain.ReportOptions{Lang: exportLanguage(c), From: from, To: to}, true
+}
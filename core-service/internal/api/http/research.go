@@ -0,0 +1,50 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+)
+
+type ResearchHandler struct {
+	service interfaces.ResearchService
+}
+
+func NewResearchHandler(service interfaces.ResearchService) *ResearchHandler {
+	return &ResearchHandler{service: service}
+}
+
+// Export godoc
+// @Summary      Выгрузка для исследований
+// @Description  ZIP с logs.csv и analytics.csv (псевдонимы HMAC вместо ID студентов, время огрублено до granularity), cohort_aggregates.csv (группы меньше k студентов скрыты) и manifest.json. Без cohort_id - все когорты.
+// @Tags         Research
+// @Produce      application/zip
+// @Param        cohort_id    query     int     false  "ID когорты"
+// @Param        granularity  query     string  false  "day, week (по умолчанию) или month"
+// @Param        from         query     string  false  "Начало периода (RFC3339)"
+// @Param        to           query     string  false  "Конец периода (RFC3339)"
+// @Success      200          {file}    file
+// @Failure      400          {object}  apierror.Response
+// @Failure      403          {object}  apierror.Response
+// @Failure      404          {object}  apierror.Response
+// @Router       /research/export [get]
+func (h *ResearchHandler) Export(c *gin.Context) {
+	opts := domain.ResearchExportOptions{Granularity: c.Query("granularity")}
+	var ok bool
+	if opts.CohortID, ok = queryUint(c, "cohort_id"); !ok {
+		return
+	}
+	if opts.From, opts.To, ok = parseDateRange(c); !ok {
+		return
+	}
+
+	filename := fmt.Sprintf("research_%s.zip", time.Now().UTC().Format("20060102"))
+	streamFile(c, "application/zip", filename, func(w io.Writer) error {
+		return h.service.Export(c.Request.Context(), opts, w)
+	})
+}
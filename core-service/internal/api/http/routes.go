@@ -4,17 +4,116 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/swaggo/files"
 	"github.com/swaggo/gin-swagger"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	_ "github.com/RusselRustCode/teacher_analytics/core-service/docs" 
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
 )
 
-func SetupRoutes(router *gin.Engine, handler *HTTPHandler) {
-	api := router.Group("/api")
+// Handlers - все HTTP-хендлеры сервиса, собранные в main. LTI может
+// быть nil, если вход из LMS не настроен; RateLimit - если частота
+// запросов не ограничивается; APIKey - если ключи интеграций выключены;
+// Audit - если журнал доступа не ведется.
+type Handlers struct {
+	API       *HTTPHandler
+	Health    *HealthHandler
+	Export    *ExportHandler
+	Import    *ImportHandler
+	XAPI      *XAPIHandler
+	Report    *ReportHandler
+	Alert     *AlertHandler
+	Webhook   *WebhookHandler
+	LTI       *LTIHandler
+	RateLimit *RateLimitHandler
+	APIKey    *APIKeyHandler
+	Audit     *AuditHandler
+	Privacy   *PrivacyHandler
+	Research  *ResearchHandler
+}
+
+func SetupRoutes(router *gin.Engine, h Handlers) {
+	// Ключ разбирается до лимита, чтобы у каждой интеграции было свое ведро
+	api := router.Group("/api", h.APIKey.Middleware())
+	ingest := api.Group("", h.APIKey.RequireScope(domain.ScopeIngestLogs), h.RateLimit.Middleware(RateLimitIngest))
+	{
+		ingest.POST("/log", h.API.SendLog)
+		ingest.POST("/import", h.Import.Import)
+		ingest.POST("/xapi/statements", h.XAPI.SaveStatements)
+	}
+
+	// Подписки, ключи, журнал и выгрузка для исследований видят все курсы,
+	// поэтому они вне дашборда с его ограничением курсом: только
+	// администратор LMS с сессией, даже если дашборд открыт без нее.
+	// Ключам и анонимам закрыто
+	admin := api.Group("", h.Audit.Mutations(), h.APIKey.DenyAPIKeys(),
+		h.LTI.RequireUser(domain.RoleAdministrator), h.RateLimit.Middleware(RateLimitDashboard))
+	{
+		admin.GET("/webhooks", h.Webhook.ListSubscriptions)
+		admin.POST("/webhooks", h.Webhook.CreateSubscription)
+		admin.DELETE("/webhooks/:webhook_id", h.Webhook.DeleteSubscription)
+		admin.GET("/webhooks/:webhook_id/deliveries", h.Webhook.ListDeliveries)
+		admin.POST("/webhook-deliveries/:delivery_id/redeliver", h.Webhook.Redeliver)
+		if h.APIKey != nil {
+			admin.GET("/api-keys", h.APIKey.ListKeys)
+			admin.POST("/api-keys", h.APIKey.CreateKey)
+			admin.POST("/api-keys/:key_id/rotate", h.APIKey.RotateKey)
+			admin.DELETE("/api-keys/:key_id", h.APIKey.RevokeKey)
+		}
+		if h.Audit != nil {
+			admin.GET("/audit-log", h.Audit.ListEntries)
+		}
+		// Выгрузка по всем когортам - данные всего учреждения; GET группа
+		// не журналирует, поэтому чтение записывается отдельно
+		if h.Research != nil {
+			admin.GET("/research/export", h.Audit.Reads(), h.Research.Export)
+		}
+	}
+
+	// Дашборд: с сессией из LMS ответы ограничены курсом преподавателя
+	dashboard := api.Group("")
+	if h.LTI != nil {
+		api.GET("/lti/login", h.LTI.Login)
+		api.POST("/lti/login", h.LTI.Login)
+		api.POST("/lti/launch", h.LTI.Launch)
+		api.POST("/session/logout", h.LTI.Logout)
+
+		dashboard.Use(h.LTI.SessionMiddleware())
+	}
+	// Лимит после сессии: ведро у каждого преподавателя свое
+	dashboard.Use(h.RateLimit.Middleware(RateLimitDashboard))
+	if h.LTI != nil {
+		dashboard.GET("/session", h.LTI.Session)
+	}
+	// Ключу с read:analytics доступно чтение; правила и алерты меняют люди.
+	// Журнал стоит первым, чтобы в него попали и отказы в доступе
+	reads := dashboard.Group("", h.Audit.Reads(), h.APIKey.RequireScope(domain.ScopeReadAnalytics))
+	{
+		reads.GET("/analytics/:student_id", h.API.GetAnalytics)
+		reads.GET("/students", h.API.GetStudents)
+		reads.GET("/students/:student_id/logs", h.API.GetStudentLogs)
+		reads.GET("/students/:student_id/logs/export", h.Export.ExportStudentLogs)
+		reads.GET("/cohorts/:cohort_id/analytics/export", h.Export.ExportCohortAnalytics)
+		reads.GET("/students/:student_id/report.pdf", h.Report.StudentReport)
+		reads.GET("/cohorts/:cohort_id/reports.zip", h.Report.CohortReports)
+		reads.GET("/alert-rules", h.Alert.ListRules)
+		reads.GET("/alerts", h.Alert.ListAlerts)
+	}
+	// Полная выгрузка данных студента - как удаление: только человеку с
+	// сессией LMS, даже если дашборд открыт без нее, и не ключу
+	dashboard.GET("/students/:student_id/data-export", h.Audit.Reads(), h.APIKey.DenyAPIKeys(), h.LTI.RequireUser(),
+		h.Privacy.ExportStudentData)
+	mutations := dashboard.Group("", h.Audit.Mutations(), h.APIKey.DenyAPIKeys())
 	{
-		api.POST("/log", handler.SendLog)
-		api.GET("/analytics/:student_id", handler.GetAnalytics)
-		api.GET("/students/:student_id/logs", handler.GetStudentLogs)
-        api.GET("/students", handler.GetStudents)
+		mutations.POST("/alert-rules", h.Alert.CreateRule)
+		mutations.DELETE("/alert-rules/:rule_id", h.Alert.DeleteRule)
+		mutations.POST("/alerts/:alert_id/ack", h.Alert.Acknowledge)
+		mutations.POST("/alerts/:alert_id/resolve", h.Alert.Resolve)
+		// Удаление необратимо: нужна сессия LMS, даже если дашборд открыт
+		// без нее; преподаватель удаляет только студентов своего курса
+		mutations.DELETE("/students/:student_id", h.LTI.RequireUser(), h.Privacy.DeleteStudent)
 	}
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	router.GET("/healthz", h.Health.Liveness)
+	router.GET("/readyz", h.Health.Readiness)
 	router.GET("/ping-swagger", func(c *gin.Context) {
 		c.String(200, "Router is working")
 	})
@@ -0,0 +1,148 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+)
+
+type WebhookHandler struct {
+	webhooks interfaces.WebhookService
+}
+
+func NewWebhookHandler(webhooks interfaces.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhooks: webhooks}
+}
+
+// CreateSubscription godoc
+// @Summary      Подписаться на события
+// @Description  События: analytics.updated, log.ingested, alert.raised. Без secret он генерируется; секрет возвращается только в этом ответе. Тело каждого запроса подписывается: X-Webhook-Signature = sha256=hex(HMAC-SHA256(secret, "<X-Webhook-Timestamp>.<тело>")).
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Param        subscription  body      domain.WebhookSubscription  true  "Подписка"
+// @Success      201           {object}  domain.WebhookSubscription
+// @Failure      400           {object}  apierror.Response
+// @Router       /webhooks [post]
+func (h *WebhookHandler) CreateSubscription(c *gin.Context) {
+	var sub domain.WebhookSubscription
+	if err := c.ShouldBindJSON(&sub); err != nil {
+		respondError(c, application.Validation("invalid_body", "request body is not a valid webhook subscription"))
+		return
+	}
+	sub.ID = 0
+	if err := h.webhooks.CreateSubscription(c.Request.Context(), &sub); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, sub)
+}
+
+// ListSubscriptions godoc
+// @Summary      Подписки на события
+// @Tags         Webhooks
+// @Produce      json
+// @Success      200  {array}  domain.WebhookSubscription
+// @Router       /webhooks [get]
+func (h *WebhookHandler) ListSubscriptions(c *gin.Context) {
+	subs, err := h.webhooks.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if subs == nil {
+		subs = []*domain.WebhookSubscription{}
+	}
+	c.JSON(http.StatusOK, subs)
+}
+
+// DeleteSubscription godoc
+// @Summary      Удалить подписку
+// @Description  Вместе с подпиской удаляется ее журнал доставок.
+// @Tags         Webhooks
+// @Param        webhook_id  path  int  true  "ID подписки"
+// @Success      204
+// @Failure      404  {object}  apierror.Response
+// @Router       /webhooks/{webhook_id} [delete]
+func (h *WebhookHandler) DeleteSubscription(c *gin.Context) {
+	id, ok := parseID(c, "webhook_id")
+	if !ok {
+		return
+	}
+	if err := h.webhooks.DeleteSubscription(c.Request.Context(), id); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries godoc
+// @Summary      Журнал доставок подписки
+// @Description  Новые сверху.
+// @Tags         Webhooks
+// @Produce      json
+// @Param        webhook_id  path      int     true   "ID подписки"
+// @Param        status      query     string  false  "pending, succeeded или failed"
+// @Param        limit       query     int     false  "Не больше 500"
+// @Success      200         {array}   domain.WebhookDelivery
+// @Failure      400         {object}  apierror.Response
+// @Router       /webhooks/{webhook_id}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	id, ok := parseID(c, "webhook_id")
+	if !ok {
+		return
+	}
+	filter := domain.WebhookDeliveryFilter{SubscriptionID: id}
+	switch status := domain.WebhookDeliveryStatus(c.Query("status")); status {
+	case "", domain.DeliveryPending, domain.DeliverySucceeded, domain.DeliveryFailed:
+		filter.Status = status
+	default:
+		respondError(c, application.Validation("invalid_status", "status must be pending, succeeded or failed"))
+		return
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			respondError(c, application.Validation("invalid_limit", "limit must be a positive integer"))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	deliveries, err := h.webhooks.ListDeliveries(c.Request.Context(), filter)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if deliveries == nil {
+		deliveries = []*domain.WebhookDelivery{}
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// Redeliver godoc
+// @Summary      Доставить событие повторно
+// @Description  Доставка возвращается в очередь с полным набором попыток, в том числе уже успешная.
+// @Tags         Webhooks
+// @Produce      json
+// @Param        delivery_id  path      int  true  "ID доставки"
+// @Success      202          {object}  domain.WebhookDelivery
+// @Failure      404          {object}  apierror.Response
+// @Router       /webhook-deliveries/{delivery_id}/redeliver [post]
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	id, ok := parseID(c, "delivery_id")
+	if !ok {
+		return
+	}
+	delivery, err := h.webhooks.Redeliver(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, delivery)
+}
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+)
+
+// XAPIVersion - версия xAPI, которую сервис заявляет в ответах.
+const XAPIVersion = "1.0.3"
+
+// maxStatementsBody ограничивает пакет statement'ов. Источники шлют их
+// порциями по десятку-сотне, для истории есть POST /api/import.
+const maxStatementsBody = 5 << 20
+
+type XAPIHandler struct {
+	service interfaces.XAPIService
+}
+
+func NewXAPIHandler(service interfaces.XAPIService) *XAPIHandler {
+	return &XAPIHandler{service: service}
+}
+
+// SaveStatements godoc
+// @Summary      Принять xAPI statements
+// @Description  Принимает один statement или массив, как LRS. Глаголы и типы активностей переводятся в логи по настраиваемому сопоставлению (XAPI_MAPPING_FILE), исходный statement сохраняется. Возвращает id statement'ов в порядке запроса.
+// @Tags         xAPI
+// @Accept       json
+// @Produce      json
+// @Param        statements  body      object    true  "Statement или массив statement'ов"
+// @Success      200         {array}   string
+// @Failure      400         {object}  apierror.Response
+// @Failure      413         {object}  apierror.Response
+// @Router       /xapi/statements [post]
+func (h *XAPIHandler) SaveStatements(c *gin.Context) {
+	c.Header("X-Experience-API-Version", XAPIVersion)
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxStatementsBody))
+	if err != nil {
+		respondBodyError(c, err)
+		return
+	}
+
+	statements, err := decodeStatements(body)
+	if err != nil {
+		respondError(c, application.Validation("invalid_json", "body must be a statement or an array of statements"))
+		return
+	}
+
+	ids, err := h.service.SaveStatements(c.Request.Context(), statements)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, ids)
+}
+
+func decodeStatements(body []byte) ([]json.RawMessage, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var statements []json.RawMessage
+		err := json.Unmarshal(body, &statements)
+		return statements, err
+	}
+	var statement json.RawMessage
+	if err := json.Unmarshal(body, &statement); err != nil {
+		return nil, err
+	}
+	return []json.RawMessage{statement}, nil
+}
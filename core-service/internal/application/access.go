@@ -0,0 +1,80 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/auth"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+)
+
+// authorizeStudent пропускает запрос, если студент входит в курс сессии
+// или в один из курсов API-ключа. Без сессии и ключа (gRPC, внутренние
+// вызовы) доступ не ограничивается: от анонимных запросов HTTP-слой
+// закрывается сам.
+func authorizeStudent(ctx context.Context, repo interfaces.Repository, studentID uint64) error {
+	if session := auth.SessionFrom(ctx); session != nil {
+		ok, err := repo.IsStudentInCohort(ctx, session.CohortID, studentID)
+		if err != nil {
+			return fmt.Errorf("failed to check course access: %w", err)
+		}
+		if !ok {
+			return Forbidden("student_not_in_course", fmt.Sprintf("student %d is not in your course", studentID))
+		}
+		return nil
+	}
+
+	key := auth.APIKeyFrom(ctx)
+	if key == nil || len(key.CohortIDs) == 0 {
+		return nil
+	}
+	for _, cohortID := range key.CohortIDs {
+		ok, err := repo.IsStudentInCohort(ctx, cohortID, studentID)
+		if err != nil {
+			return fmt.Errorf("failed to check course access: %w", err)
+		}
+		if ok {
+			return nil
+		}
+	}
+	return Forbidden("student_not_in_course", fmt.Sprintf("student %d is not in the api key's courses", studentID))
+}
+
+func authorizeCohort(ctx context.Context, cohortID uint64) error {
+	if session := auth.SessionFrom(ctx); session != nil {
+		if session.CohortID == cohortID {
+			return nil
+		}
+		return Forbidden("cohort_not_allowed", fmt.Sprintf("cohort %d is not your course", cohortID))
+	}
+	key := auth.APIKeyFrom(ctx)
+	if key == nil || len(key.CohortIDs) == 0 || slices.Contains(key.CohortIDs, cohortID) {
+		return nil
+	}
+	return Forbidden("cohort_not_allowed", fmt.Sprintf("cohort %d is not in the api key's courses", cohortID))
+}
+
+// allowedStudents - студенты курсов API-ключа для проверки множества
+// строк сразу (импорт). nil - ограничения нет.
+func allowedStudents(ctx context.Context, repo interfaces.Repository) (map[uint64]struct{}, error) {
+	if auth.SessionFrom(ctx) != nil {
+		// Импорт и прием логов сессией не ограничиваются
+		return nil, nil
+	}
+	key := auth.APIKeyFrom(ctx)
+	if key == nil || len(key.CohortIDs) == 0 {
+		return nil, nil
+	}
+	allowed := make(map[uint64]struct{})
+	for _, cohortID := range key.CohortIDs {
+		ids, err := repo.GetCohortStudentIDs(ctx, cohortID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cohort students: %w", err)
+		}
+		for _, id := range ids {
+			allowed[id] = struct{}{}
+		}
+	}
+	return allowed, nil
+}
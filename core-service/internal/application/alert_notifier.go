@@ -0,0 +1,78 @@
+package application
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/metrics"
+)
+
+// AlertNotifier рассылает оповещения в фоне: каналы вроде SMTP отвечают
+// секундами, а оповещения создаются в обработчике LISTEN и в проверках
+// по расписанию, которые не должны их ждать. Оповещение уже сохранено в
+// базе, поэтому при переполненной очереди или остановке теряется только
+// рассылка.
+type AlertNotifier struct {
+	notifiers []interfaces.Notifier
+	queue     chan *domain.Alert
+	logger    *slog.Logger
+}
+
+func NewAlertNotifier(notifiers []interfaces.Notifier, queueSize int, logger *slog.Logger) *AlertNotifier {
+	if queueSize <= 0 {
+		queueSize = 1000
+	}
+	return &AlertNotifier{notifiers: notifiers, queue: make(chan *domain.Alert, queueSize), logger: logger}
+}
+
+// Enqueue не блокируется: если очередь полна, рассылка пропускается.
+func (n *AlertNotifier) Enqueue(ctx context.Context, alert *domain.Alert) {
+	if len(n.notifiers) == 0 {
+		return
+	}
+	select {
+	case n.queue <- alert:
+	default:
+		for _, notifier := range n.notifiers {
+			metrics.AlertNotificationsTotal.WithLabelValues(notifier.Name(), "dropped").Inc()
+		}
+		n.logger.WarnContext(logging.WithStudentID(ctx, alert.StudentID), "alert notification queue is full, notification dropped",
+			slog.Uint64("alert_id", alert.ID))
+	}
+}
+
+// Run рассылает оповещения из очереди до отмены ctx.
+func (n *AlertNotifier) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			if pending := len(n.queue); pending > 0 {
+				n.logger.Warn("alert notifications not delivered before shutdown", slog.Int("pending", pending))
+			}
+			return
+		case alert := <-n.queue:
+			n.deliver(ctx, alert)
+		}
+	}
+}
+
+// deliver отправляет оповещение во все каналы; сбой одного канала не
+// мешает остальным.
+func (n *AlertNotifier) deliver(ctx context.Context, alert *domain.Alert) {
+	logCtx := logging.WithStudentID(ctx, alert.StudentID)
+	for _, notifier := range n.notifiers {
+		if err := notifier.Notify(ctx, alert); err != nil {
+			metrics.AlertNotificationsTotal.WithLabelValues(notifier.Name(), "failure").Inc()
+			n.logger.WarnContext(logCtx, "failed to deliver alert",
+				slog.String("notifier", notifier.Name()),
+				slog.Uint64("alert_id", alert.ID),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+		metrics.AlertNotificationsTotal.WithLabelValues(notifier.Name(), "success").Inc()
+	}
+}
@@ -0,0 +1,296 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/auth"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/metrics"
+)
+
+const (
+	maxRuleName   = 200
+	maxWindowDays = 365
+	// maxAlertsPage - больше оповещений за один запрос не отдаем
+	maxAlertsPage = 500
+)
+
+type AlertServiceImpl struct {
+	repo     interfaces.Repository
+	notifier *AlertNotifier
+	logger   *slog.Logger
+}
+
+func NewAlertService(repo interfaces.Repository, notifier *AlertNotifier, logger *slog.Logger) interfaces.AlertService {
+	return &AlertServiceImpl{repo: repo, notifier: notifier, logger: logger}
+}
+
+// CreateRule привязывает правило к курсу сессии: преподаватель из LMS
+// заводит правила только для своих студентов.
+func (s *AlertServiceImpl) CreateRule(ctx context.Context, rule *domain.AlertRule) error {
+	if session := auth.SessionFrom(ctx); session != nil {
+		if rule.CohortID == 0 {
+			rule.CohortID = session.CohortID
+		}
+		if err := authorizeCohort(ctx, rule.CohortID); err != nil {
+			return err
+		}
+		rule.CreatedBy = session.Subject
+	}
+	if err := validateAlertRule(rule); err != nil {
+		return err
+	}
+	if rule.CohortID != 0 {
+		cohort, err := s.repo.GetCohortByID(ctx, rule.CohortID)
+		if err != nil {
+			return fmt.Errorf("failed to get cohort: %w", err)
+		}
+		if cohort == nil {
+			return NotFound("cohort_not_found", fmt.Sprintf("cohort %d not found", rule.CohortID))
+		}
+	}
+
+	if err := s.repo.CreateAlertRule(ctx, rule); err != nil {
+		return fmt.Errorf("failed to create alert rule: %w", err)
+	}
+	s.logger.InfoContext(ctx, "alert rule created",
+		slog.Uint64("rule_id", rule.ID),
+		slog.String("type", string(rule.Type)),
+		slog.Uint64("cohort_id", rule.CohortID),
+	)
+	return nil
+}
+
+func validateAlertRule(rule *domain.AlertRule) error {
+	var fields []FieldError
+	add := func(field, code, message string) {
+		fields = append(fields, FieldError{Field: field, Code: code, Message: message})
+	}
+	checkWindow := func() {
+		if rule.WindowDays < 1 || rule.WindowDays > maxWindowDays {
+			add("window_days", "out_of_range", fmt.Sprintf("window_days must be between 1 and %d", maxWindowDays))
+		}
+	}
+
+	switch {
+	case rule.Name == "":
+		add("name", "required", "name is required")
+	case len(rule.Name) > maxRuleName:
+		add("name", "too_long", fmt.Sprintf("name must be at most %d characters", maxRuleName))
+	}
+
+	switch rule.Type {
+	case domain.RuleSuccessRateBelow:
+		if rule.Threshold <= 0 || rule.Threshold > 1 {
+			add("threshold", "out_of_range", "threshold must be greater than 0 and at most 1")
+		}
+		checkWindow()
+	case domain.RuleInactivity:
+		checkWindow()
+	case domain.RuleClusterChanged:
+		if rule.Cluster == "" {
+			add("cluster", "required", "cluster is required")
+		}
+	case "":
+		add("type", "required", "type is required")
+	default:
+		add("type", "unknown_value", fmt.Sprintf("unknown type %q", rule.Type))
+	}
+
+	if len(fields) > 0 {
+		return InvalidFields("invalid_alert_rule", "alert rule is invalid", fields)
+	}
+	return nil
+}
+
+func (s *AlertServiceImpl) ListRules(ctx context.Context) ([]*domain.AlertRule, error) {
+	var cohortID uint64
+	if session := auth.SessionFrom(ctx); session != nil {
+		cohortID = session.CohortID
+	}
+	return s.repo.ListAlertRules(ctx, cohortID)
+}
+
+func (s *AlertServiceImpl) DeleteRule(ctx context.Context, id uint64) error {
+	rule, err := s.repo.GetAlertRule(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get alert rule: %w", err)
+	}
+	if rule == nil {
+		return NotFound("alert_rule_not_found", fmt.Sprintf("alert rule %d not found", id))
+	}
+	if err := authorizeCohort(ctx, rule.CohortID); err != nil {
+		return err
+	}
+	// Оповещения остаются в истории, у них только обнуляется rule_id
+	return s.repo.DeleteAlertRule(ctx, id)
+}
+
+// EvaluateStudent не проверяет правила неактивности: раз анализ только
+// что прошел, у студента есть свежие логи.
+func (s *AlertServiceImpl) EvaluateStudent(ctx context.Context, studentID uint64) error {
+	rules, err := s.repo.GetAlertRulesForStudent(ctx, studentID)
+	if err != nil {
+		return fmt.Errorf("failed to get alert rules: %w", err)
+	}
+
+	now := time.Now()
+	for _, rule := range rules {
+		var message string
+		switch rule.Type {
+		case domain.RuleSuccessRateBelow:
+			message, err = s.checkSuccessRate(ctx, rule, studentID, now)
+		case domain.RuleClusterChanged:
+			message, err = s.checkClusterChanged(ctx, rule, studentID, now)
+		default:
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to evaluate alert rule %d: %w", rule.ID, err)
+		}
+		if message != "" {
+			s.raise(ctx, rule, studentID, message)
+		}
+	}
+	return nil
+}
+
+func (s *AlertServiceImpl) checkSuccessRate(ctx context.Context, rule *domain.AlertRule, studentID uint64, now time.Time) (string, error) {
+	answers, correct, err := s.repo.GetAnswerStats(ctx, studentID, now.AddDate(0, 0, -rule.WindowDays))
+	if err != nil || answers == 0 {
+		return "", err
+	}
+	rate := float64(correct) / float64(answers)
+	if rate >= rule.Threshold {
+		return "", nil
+	}
+	return fmt.Sprintf("success rate %.0f%% over the last %d days is below %.0f%%",
+		rate*100, rule.WindowDays, rule.Threshold*100), nil
+}
+
+// checkClusterChanged срабатывает только на переход: студент, который
+// уже был в кластере до последнего анализа, повторно не попадает.
+func (s *AlertServiceImpl) checkClusterChanged(ctx context.Context, rule *domain.AlertRule, studentID uint64, now time.Time) (string, error) {
+	history, err := s.repo.GetAnalyticsHistory(ctx, studentID, time.Time{}, now)
+	if err != nil || len(history) == 0 {
+		return "", err
+	}
+	last := history[len(history)-1]
+	if last.ClusterGroup != rule.Cluster {
+		return "", nil
+	}
+	if len(history) == 1 {
+		return fmt.Sprintf("assigned to cluster %q", rule.Cluster), nil
+	}
+	prev := history[len(history)-2]
+	if prev.ClusterGroup == rule.Cluster {
+		return "", nil
+	}
+	return fmt.Sprintf("moved to cluster %q from %q", rule.Cluster, prev.ClusterGroup), nil
+}
+
+func (s *AlertServiceImpl) EvaluateInactivity(ctx context.Context) error {
+	rules, err := s.repo.ListAlertRules(ctx, 0)
+	if err != nil {
+		return fmt.Errorf("failed to get alert rules: %w", err)
+	}
+
+	now := time.Now()
+	for _, rule := range rules {
+		if rule.Type != domain.RuleInactivity {
+			continue
+		}
+		ids, err := s.repo.GetInactiveStudents(ctx, rule.CohortID, now.AddDate(0, 0, -rule.WindowDays))
+		if err != nil {
+			return fmt.Errorf("failed to evaluate alert rule %d: %w", rule.ID, err)
+		}
+		for _, id := range ids {
+			s.raise(ctx, rule, id, fmt.Sprintf("no activity for at least %d days", rule.WindowDays))
+		}
+	}
+	return nil
+}
+
+// raise создает оповещение и ставит его в очередь рассылки. Ошибки только
+// пишутся в лог: одно неудачное оповещение не должно останавливать
+// проверку остальных.
+func (s *AlertServiceImpl) raise(ctx context.Context, rule *domain.AlertRule, studentID uint64, message string) {
+	logCtx := logging.WithStudentID(ctx, studentID)
+	alert := &domain.Alert{
+		RuleID:    rule.ID,
+		RuleName:  rule.Name,
+		Type:      rule.Type,
+		StudentID: studentID,
+		Message:   message,
+		Status:    domain.AlertOpen,
+	}
+	created, err := s.repo.CreateAlert(ctx, alert)
+	if err != nil {
+		s.logger.ErrorContext(logCtx, "failed to save alert", slog.Uint64("rule_id", rule.ID), slog.String("error", err.Error()))
+		return
+	}
+	if !created {
+		return
+	}
+
+	metrics.AlertsRaisedTotal.WithLabelValues(string(rule.Type)).Inc()
+	s.logger.InfoContext(logCtx, "alert raised", slog.Uint64("alert_id", alert.ID), slog.Uint64("rule_id", rule.ID))
+	s.notifier.Enqueue(ctx, alert)
+}
+
+func (s *AlertServiceImpl) ListAlerts(ctx context.Context, filter domain.AlertFilter) ([]*domain.Alert, error) {
+	if session := auth.SessionFrom(ctx); session != nil {
+		filter.CohortID = session.CohortID
+	}
+	if filter.Limit <= 0 || filter.Limit > maxAlertsPage {
+		filter.Limit = maxAlertsPage
+	}
+	return s.repo.ListAlerts(ctx, filter)
+}
+
+func (s *AlertServiceImpl) Acknowledge(ctx context.Context, id uint64) (*domain.Alert, error) {
+	return s.transition(ctx, id, domain.AlertAcknowledged, domain.AlertOpen)
+}
+
+func (s *AlertServiceImpl) Resolve(ctx context.Context, id uint64) (*domain.Alert, error) {
+	return s.transition(ctx, id, domain.AlertResolved, domain.AlertOpen, domain.AlertAcknowledged)
+}
+
+func (s *AlertServiceImpl) transition(ctx context.Context, id uint64, to domain.AlertStatus, from ...domain.AlertStatus) (*domain.Alert, error) {
+	alert, err := s.repo.GetAlert(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert: %w", err)
+	}
+	if alert == nil {
+		return nil, NotFound("alert_not_found", fmt.Sprintf("alert %d not found", id))
+	}
+	if err := authorizeStudent(ctx, s.repo, alert.StudentID); err != nil {
+		return nil, err
+	}
+
+	allowed := false
+	for _, status := range from {
+		allowed = allowed || alert.Status == status
+	}
+	if !allowed {
+		return nil, Conflict("invalid_alert_status", fmt.Sprintf("alert %d is %s", id, alert.Status))
+	}
+
+	var actor string
+	if session := auth.SessionFrom(ctx); session != nil {
+		actor = session.Subject
+	}
+	ok, err := s.repo.UpdateAlertStatus(ctx, id, alert.Status, to, actor, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to update alert: %w", err)
+	}
+	if !ok {
+		return nil, Conflict("invalid_alert_status", fmt.Sprintf("alert %d was changed concurrently", id))
+	}
+	return s.repo.GetAlert(ctx, id)
+}
@@ -4,10 +4,18 @@ import (
     "context"
     "encoding/json"
     "fmt"
+    "log/slog"
+    "maps"
+    "slices"
     "time"
     
+    "golang.org/x/sync/singleflight"
+    
+    "github.com/RusselRustCode/teacher_analytics/core-service/internal/auth"
     "github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
     "github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+    "github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+    "github.com/RusselRustCode/teacher_analytics/core-service/internal/metrics"
 )
 
 type AnalyticsServiceImpl struct {
@@ -15,34 +23,109 @@ type AnalyticsServiceImpl struct {
     cache   interfaces.Cache
     producer interfaces.MessageProducer
     client  interfaces.AnalyticsClient
+    events  interfaces.EventPublisher
+    opts    AnalyticsOptions
+    logger  *slog.Logger
+    // flight склеивает одновременные промахи кэша по одному студенту
+    flight  singleflight.Group
+}
+
+// AnalyticsOptions - сроки жизни аналитики в кэше. CacheTTL - сколько
+// запись считается свежей; StaleTTL - сколько хранится копия, которую
+// отдают со stale: true, пока загружается свежая; LockTTL - срок
+// блокировки загрузки, общей для реплик. StudentsTTL и LogsTTL - сроки
+// списков студентов и выборок логов; раньше их сбрасывают новые логи.
+// Background запускает фоновое обновление устаревшей копии (в сервисе -
+// lifecycle.Manager.Task, чтобы остановка его дождалась); без него
+// обновление идет в отдельной горутине.
+type AnalyticsOptions struct {
+    CacheTTL    time.Duration
+    StaleTTL    time.Duration
+    LockTTL     time.Duration
+    StudentsTTL time.Duration
+    LogsTTL     time.Duration
+    Background  func(fn func(ctx context.Context))
 }
 
+const (
+    // analyticsLockWait - сколько ждать, пока другая реплика положит
+    // аналитику в кэш, прежде чем идти в базу самим
+    analyticsLockWait = time.Second
+    analyticsLockPoll = 50 * time.Millisecond
+    // analyticsRefreshTimeout ограничивает фоновое обновление устаревшей копии
+    analyticsRefreshTimeout = 10 * time.Second
+)
+
 func NewAnalyticsService(
     repo interfaces.Repository,
     cache interfaces.Cache,
     producer interfaces.MessageProducer,
     client interfaces.AnalyticsClient,
+    events interfaces.EventPublisher,
+    opts AnalyticsOptions,
+    logger *slog.Logger,
 ) interfaces.AnalyticsService {
+    if opts.CacheTTL <= 0 {
+        opts.CacheTTL = 5 * time.Minute
+    }
+    if opts.StaleTTL <= 0 {
+        opts.StaleTTL = 24 * time.Hour
+    }
+    if opts.LockTTL <= 0 {
+        opts.LockTTL = 5 * time.Second
+    }
+    if opts.StudentsTTL <= 0 {
+        opts.StudentsTTL = 5 * time.Minute
+    }
+    if opts.LogsTTL <= 0 {
+        opts.LogsTTL = 5 * time.Minute
+    }
+    if opts.Background == nil {
+        opts.Background = func(fn func(ctx context.Context)) {
+            go fn(context.Background())
+        }
+    }
     return &AnalyticsServiceImpl{
         repo:     repo,
         cache:    cache,
         producer: producer,
         client:   client,
+        events:   events,
+        opts:     opts,
+        logger:   logger,
     }
 }
 
+func analyticsCacheKey(studentID uint64) string {
+    return fmt.Sprintf("analytics:%d", studentID)
+}
+
+// analyticsStaleKey - копия последней загруженной аналитики. SendLog ее не
+// трогает, поэтому после сброса свежей записи есть что отдать сразу.
+func analyticsStaleKey(studentID uint64) string {
+    return fmt.Sprintf("analytics:stale:%d", studentID)
+}
+
+func analyticsLockKey(studentID uint64) string {
+    return fmt.Sprintf("lock:analytics:%d", studentID)
+}
+
 func (s *AnalyticsServiceImpl) SendLog(ctx context.Context, log *domain.StudentLog) error {
-    if log.StudentID == 0 || log.ActionType == "" {
-        return fmt.Errorf("Неверная запись: требуются поля student_id и action_type.")
-    }
-    
     if log.Timestamp.IsZero() {
         log.Timestamp = time.Now()
     }
+    if err := ValidateLog(log, time.Now()); err != nil {
+        return err
+    }
+    if err := authorizeStudent(ctx, s.repo, log.StudentID); err != nil {
+        return err
+    }
+    logCtx := logging.WithStudentID(ctx, log.StudentID)
     
     if err := s.repo.SaveLog(ctx, log); err != nil {
         return fmt.Errorf("Не получилось созранить лог: %w", err)
     }
+    metrics.LogsIngestedTotal.Inc()
     
     kafkaData := map[string]interface{}{
         "type":       "student_log",
@@ -52,40 +135,165 @@ func (s *AnalyticsServiceImpl) SendLog(ctx context.Context, log *domain.StudentL
     }
     
     if err := s.producer.SendJSON(ctx, "student-logs", kafkaData); err != nil {
-        return fmt.Errorf("не получилось отправить в кафку: %w", err)
+        return Unavailable("event_bus_unavailable", "failed to publish student log", err)
     }
     
-    cacheKey := fmt.Sprintf("analytics:%d", log.StudentID)
-    s.cache.Delete(ctx, cacheKey)
-    
+    cacheKey := analyticsCacheKey(log.StudentID)
+    if err := s.cache.Delete(ctx, cacheKey); err != nil {
+        // Не ошибка запроса: лог сохранен, просто аналитика в кэше проживет до TTL
+        s.logger.WarnContext(logCtx, "failed to invalidate analytics cache", slog.String("error", err.Error()))
+    }
+    s.invalidateStudent(ctx, log.StudentID)
+    // events = nil - без подписчиков (утилита импорта)
+    if s.events != nil {
+        if err := s.events.Publish(ctx, domain.EventLogIngested, log); err != nil {
+            s.logger.WarnContext(logCtx, "failed to publish log event", slog.String("error", err.Error()))
+        }
+    }
+
+    s.logger.DebugContext(logCtx, "student log ingested", slog.String("action_type", log.ActionType))
     return nil
 }
 
+// GetAnalytics не дает промахам кэша лавиной уйти в базу: одновременные
+// промахи внутри реплики склеиваются, между репликами базу читает держатель
+// блокировки в Redis. Если есть устаревшая копия, она отдается сразу со
+// stale: true, а свежая загружается в фоне.
 func (s *AnalyticsServiceImpl) GetAnalytics(ctx context.Context, studentID uint64) (*domain.StudentAnalytics, error) {
-    cacheKey := fmt.Sprintf("analytics:%d", studentID)
-    
-    cached, err := s.cache.Get(ctx, cacheKey)
-    if err == nil && cached != "" {
-        var analytics domain.StudentAnalytics
-        if err := json.Unmarshal([]byte(cached), &analytics); err == nil {
-            return &analytics, nil
-        }
+    if err := authorizeStudent(ctx, s.repo, studentID); err != nil {
+        return nil, err
     }
-    
-    analytics, err := s.repo.GetAnalyticsByStudentID(ctx, studentID)
-    if err == nil && analytics != nil {
-        // Кэшируем
-        analyticsJSON, _ := json.Marshal(analytics)
-        s.cache.Set(ctx, cacheKey, analyticsJSON, 5*time.Minute)
+    logCtx := logging.WithStudentID(ctx, studentID)
+    flightKey := analyticsCacheKey(studentID)
+
+    if analytics := s.cachedAnalytics(ctx, analyticsCacheKey(studentID)); analytics != nil {
+        metrics.CacheLookupsTotal.WithLabelValues("analytics", "hit").Inc()
         return analytics, nil
     }
-    
-    // Если нет аналитики, запускаем анализ
-    if err := s.TriggerAnalysis(ctx, studentID); err != nil {
-        return nil, fmt.Errorf("не удалось запустить анализ: %w", err)
+
+    if stale := s.cachedAnalytics(ctx, analyticsStaleKey(studentID)); stale != nil {
+        metrics.CacheLookupsTotal.WithLabelValues("analytics", "stale").Inc()
+        // Ответ не ждет обновления: оно переживает запрос, но отменяется
+        // остановкой сервиса (bgCtx), и остановка его дожидается
+        s.opts.Background(func(bgCtx context.Context) {
+            _, err, shared := s.flight.Do(flightKey, func() (any, error) {
+                refreshCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), analyticsRefreshTimeout)
+                defer cancel()
+                stop := context.AfterFunc(bgCtx, cancel)
+                defer stop()
+                return s.refreshAnalytics(refreshCtx, studentID)
+            })
+            if err != nil && !shared {
+                s.logger.WarnContext(logCtx, "failed to refresh stale analytics", slog.String("error", err.Error()))
+            }
+        })
+        stale.Stale = true
+        return stale, nil
     }
-    
-    // Возвращаем заглушку
+
+    metrics.CacheLookupsTotal.WithLabelValues("analytics", "miss").Inc()
+    // Загрузка общая для всех ждущих, поэтому не отменяется вместе с
+    // запросом, который ее начал
+    v, err, _ := s.flight.Do(flightKey, func() (any, error) {
+        return s.refreshAnalytics(context.WithoutCancel(ctx), studentID)
+    })
+    if err != nil {
+        return nil, err
+    }
+    return v.(*domain.StudentAnalytics), nil
+}
+
+// cachedAnalytics возвращает nil при промахе; ошибки кэша только пишутся
+// в лог - без кэша данные берутся из базы.
+func (s *AnalyticsServiceImpl) cachedAnalytics(ctx context.Context, key string) *domain.StudentAnalytics {
+    cached, err := s.cache.Get(ctx, key)
+    if err != nil {
+        metrics.CacheLookupsTotal.WithLabelValues("analytics", "error").Inc()
+        s.logger.WarnContext(ctx, "analytics cache lookup failed", slog.String("key", key), slog.String("error", err.Error()))
+        return nil
+    }
+    if cached == "" {
+        return nil
+    }
+    var analytics domain.StudentAnalytics
+    if err := json.Unmarshal([]byte(cached), &analytics); err != nil {
+        return nil
+    }
+    return &analytics
+}
+
+// refreshAnalytics загружает аналитику из базы под блокировкой в Redis и
+// кладет в кэш свежую запись и копию для stale-ответов.
+func (s *AnalyticsServiceImpl) refreshAnalytics(ctx context.Context, studentID uint64) (*domain.StudentAnalytics, error) {
+    logCtx := logging.WithStudentID(ctx, studentID)
+    lockKey := analyticsLockKey(studentID)
+
+    locked, err := s.cache.SetNX(ctx, lockKey, "1", s.opts.LockTTL)
+    if err != nil {
+        // Без Redis согласовать реплики нечем - читаем базу сами
+        s.logger.WarnContext(logCtx, "failed to take analytics lock", slog.String("error", err.Error()))
+        locked = true
+    }
+    if locked {
+        // Если загрузка дольше LockTTL, можно снять чужую блокировку; цена -
+        // лишнее чтение базы, поэтому токен не проверяется
+        defer func() {
+            if err := s.cache.Delete(ctx, lockKey); err != nil {
+                s.logger.WarnContext(logCtx, "failed to release analytics lock", slog.String("error", err.Error()))
+            }
+        }()
+    } else if analytics := s.waitForAnalytics(ctx, studentID); analytics != nil {
+        return analytics, nil
+    }
+
+    analytics, err := s.repo.GetAnalyticsByStudentID(ctx, studentID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get analytics: %w", err)
+    }
+    if analytics == nil {
+        // Анализ запускает только держатель блокировки, иначе каждая
+        // реплика отправила бы свою команду
+        if locked {
+            if err := s.TriggerAnalysis(ctx, studentID); err != nil {
+                return nil, fmt.Errorf("не удалось запустить анализ: %w", err)
+            }
+        }
+        return processingAnalytics(studentID), nil
+    }
+
+    analyticsJSON, _ := json.Marshal(analytics)
+    if err := s.cache.Set(ctx, analyticsCacheKey(studentID), analyticsJSON, s.opts.CacheTTL); err != nil {
+        s.logger.WarnContext(logCtx, "failed to cache analytics", slog.String("error", err.Error()))
+    }
+    if err := s.cache.Set(ctx, analyticsStaleKey(studentID), analyticsJSON, s.opts.StaleTTL); err != nil {
+        s.logger.WarnContext(logCtx, "failed to cache stale analytics copy", slog.String("error", err.Error()))
+    }
+    return analytics, nil
+}
+
+// waitForAnalytics ждет, пока держатель блокировки положит аналитику в
+// кэш, но не дольше analyticsLockWait.
+func (s *AnalyticsServiceImpl) waitForAnalytics(ctx context.Context, studentID uint64) *domain.StudentAnalytics {
+    ticker := time.NewTicker(analyticsLockPoll)
+    defer ticker.Stop()
+    timeout := time.NewTimer(analyticsLockWait)
+    defer timeout.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return nil
+        case <-timeout.C:
+            return nil
+        case <-ticker.C:
+            if analytics := s.cachedAnalytics(ctx, analyticsCacheKey(studentID)); analytics != nil {
+                return analytics
+            }
+        }
+    }
+}
+
+// processingAnalytics - заглушка, пока идет первый анализ студента
+func processingAnalytics(studentID uint64) *domain.StudentAnalytics {
     return &domain.StudentAnalytics{
         StudentID:       studentID,
         ClusterGroup:    "processing",
@@ -93,7 +301,7 @@ func (s *AnalyticsServiceImpl) GetAnalytics(ctx context.Context, studentID uint6
         SuccessRate:     0,
         Recommendations: []string{"Анализ запущен, пожалуйста, подождите..."},
         AnalyzedAt:      time.Now(),
-    }, nil
+    }
 }
 
 func (s *AnalyticsServiceImpl) TriggerAnalysis(ctx context.Context, studentID uint64) error {
@@ -105,20 +313,79 @@ func (s *AnalyticsServiceImpl) TriggerAnalysis(ctx context.Context, studentID ui
     }
     
     if err := s.producer.SendJSON(ctx, "analysis-commands", analysisCmd); err != nil {
-        return fmt.Errorf("failed to send analysis command: %w", err)
+        return Unavailable("analysis_unavailable", "failed to send analysis command", err)
     }
+    metrics.AnalysesTriggeredTotal.Inc()
+    s.logger.InfoContext(logging.WithStudentID(ctx, studentID), "analysis triggered")
     
     return nil
 }
 
 func (s *AnalyticsServiceImpl) GetStudentLogs(ctx context.Context, studentID uint64, from, to time.Time) ([]*domain.StudentLog, error) {
-    return s.repo.GetLogsByStudentID(ctx, studentID, from, to)
+    if err := authorizeStudent(ctx, s.repo, studentID); err != nil {
+        return nil, err
+    }
+    key, lo, hi := logsCacheKey(studentID, from, to, time.Now())
+    var logs []*domain.StudentLog
+    if !s.cachedJSON(ctx, "logs", key, &logs) {
+        loaded, err := s.repo.GetLogsByStudentID(ctx, studentID, lo, hi)
+        if err != nil {
+            return nil, err
+        }
+        logs = loaded
+        if len(logs) <= maxCachedLogs {
+            s.cacheJSON(ctx, key, logs, s.opts.LogsTTL, studentTag(studentID))
+        }
+    }
+    return logsBetween(logs, from, to), nil
 }
 
 func (s *AnalyticsServiceImpl) GetStudents(ctx context.Context) ([]uint64, error) {
-    return s.repo.GetStudents(ctx)
+    cacheKey, tags := "students:all", []string{studentsTag}
+    load := s.repo.GetStudents
+    // Преподаватель из LMS видит только студентов своего курса, ключ
+    // интеграции - студентов своих курсов
+    if session := auth.SessionFrom(ctx); session != nil {
+        cacheKey, tags = fmt.Sprintf("students:cohort:%d", session.CohortID), []string{cohortTag(session.CohortID)}
+        load = func(ctx context.Context) ([]uint64, error) {
+            return s.repo.GetCohortStudentIDs(ctx, session.CohortID)
+        }
+    } else if key := auth.APIKeyFrom(ctx); key != nil && len(key.CohortIDs) > 0 {
+        cacheKey, tags = fmt.Sprintf("students:api-key:%d", key.ID), nil
+        for _, id := range key.CohortIDs {
+            tags = append(tags, cohortTag(id))
+        }
+        load = func(ctx context.Context) ([]uint64, error) {
+            allowed, err := allowedStudents(ctx, s.repo)
+            if err != nil {
+                return nil, err
+            }
+            return slices.Sorted(maps.Keys(allowed)), nil
+        }
+    }
+
+    var ids []uint64
+    if s.cachedJSON(ctx, "students", cacheKey, &ids) {
+        return ids, nil
+    }
+    ids, err := load(ctx)
+    if err != nil {
+        return nil, err
+    }
+    s.cacheJSON(ctx, cacheKey, ids, s.opts.StudentsTTL, tags...)
+    return ids, nil
 }
 
 func (s *AnalyticsServiceImpl) GetStudentByID(ctx context.Context, id uint64) (*domain.Student, error) {
-    return s.repo.GetStudentByID(ctx, id)
+    if err := authorizeStudent(ctx, s.repo, id); err != nil {
+        return nil, err
+    }
+    student, err := s.repo.GetStudentByID(ctx, id)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get student: %w", err)
+    }
+    if student == nil {
+        return nil, NotFound("student_not_found", fmt.Sprintf("student %d not found", id))
+    }
+    return student, nil
 }
\ No newline at end of file
@@ -0,0 +1,300 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+)
+
+const (
+	// apiKeyTokenPrefix отличает ключи сервиса от чужих секретов (например,
+	// в сканерах утечек); prefix ключа - он же плюс 8 символов
+	apiKeyTokenPrefix = "ta_"
+	apiKeyDisplayLen  = len(apiKeyTokenPrefix) + 8
+	maxAPIKeyName     = 100
+	// maxAPIKeyGrace - дольше старый ключ после ротации не живет
+	maxAPIKeyGrace = 7 * 24 * time.Hour
+	// apiKeyTouchInterval - чаще last_used_at не обновляется, чтобы
+	// каждый запрос интеграции не писал в базу
+	apiKeyTouchInterval = time.Minute
+)
+
+// apiKeyUnknown - значение в кэше для хэша, которому не соответствует
+// ни один действующий ключ
+const apiKeyUnknown = "-"
+
+// APIKeyOptions - CacheTTL: сколько результат проверки ключа (и
+// найденного, и ненайденного) хранится в кэше; 0 - каждый запрос идет в
+// базу. Отзыв на другой реплике вступает в силу не позже чем через
+// CacheTTL.
+type APIKeyOptions struct {
+	CacheTTL time.Duration
+}
+
+type APIKeyServiceImpl struct {
+	repo   interfaces.Repository
+	cache  interfaces.Cache
+	opts   APIKeyOptions
+	logger *slog.Logger
+}
+
+// NewAPIKeyService - cache должен быть локальным для процесса: через него
+// идет каждый запрос с ключом, в том числе с подобранным.
+func NewAPIKeyService(repo interfaces.Repository, cache interfaces.Cache, opts APIKeyOptions, logger *slog.Logger) *APIKeyServiceImpl {
+	return &APIKeyServiceImpl{repo: repo, cache: cache, opts: opts, logger: logger}
+}
+
+var _ interfaces.APIKeyService = (*APIKeyServiceImpl)(nil)
+
+// hashAPIKey - в ключе 192 случайных бита, поэтому соль и медленный хэш
+// не нужны, а поиск по хэшу идет через индекс.
+func hashAPIKey(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+func newAPIKeyToken() (string, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	return apiKeyTokenPrefix + hex.EncodeToString(secret), nil
+}
+
+func (s *APIKeyServiceImpl) CreateKey(ctx context.Context, key *domain.APIKey) error {
+	if err := s.validateKey(ctx, key); err != nil {
+		return err
+	}
+	slices.Sort(key.Scopes)
+	key.Scopes = slices.Compact(key.Scopes)
+	slices.Sort(key.CohortIDs)
+	key.CohortIDs = slices.Compact(key.CohortIDs)
+
+	token, err := newAPIKeyToken()
+	if err != nil {
+		return err
+	}
+	key.Prefix = token[:apiKeyDisplayLen]
+	if err := s.repo.CreateAPIKey(ctx, key, hashAPIKey(token)); err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	key.Key = token
+	s.logger.InfoContext(ctx, "api key issued",
+		slog.Uint64("api_key_id", key.ID),
+		slog.String("prefix", key.Prefix),
+		slog.Any("scopes", key.Scopes),
+	)
+	return nil
+}
+
+func (s *APIKeyServiceImpl) validateKey(ctx context.Context, key *domain.APIKey) error {
+	var fields []FieldError
+	add := func(field, code, message string) {
+		fields = append(fields, FieldError{Field: field, Code: code, Message: message})
+	}
+
+	key.Name = strings.TrimSpace(key.Name)
+	if key.Name == "" {
+		add("name", "required", "name is required")
+	} else if len(key.Name) > maxAPIKeyName {
+		add("name", "too_long", fmt.Sprintf("name must be at most %d characters", maxAPIKeyName))
+	}
+
+	if len(key.Scopes) == 0 {
+		add("scopes", "required", "at least one scope is required")
+	}
+	for _, scope := range key.Scopes {
+		if !slices.Contains(domain.APIKeyScopes(), scope) {
+			add("scopes", "unknown_value", fmt.Sprintf("unknown scope %q", scope))
+		}
+	}
+
+	for _, id := range key.CohortIDs {
+		cohort, err := s.repo.GetCohortByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get cohort: %w", err)
+		}
+		if cohort == nil {
+			add("cohort_ids", "not_found", fmt.Sprintf("cohort %d not found", id))
+		}
+	}
+
+	if len(fields) > 0 {
+		return InvalidFields("invalid_api_key", "api key is invalid", fields)
+	}
+	return nil
+}
+
+func (s *APIKeyServiceImpl) ListKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	return s.repo.ListAPIKeys(ctx)
+}
+
+func (s *APIKeyServiceImpl) RotateKey(ctx context.Context, id uint64, grace time.Duration) (*domain.APIKey, error) {
+	if grace < 0 || grace > maxAPIKeyGrace {
+		return nil, Validation("invalid_grace_period", fmt.Sprintf("grace period must be between 0 and %s", maxAPIKeyGrace))
+	}
+	old, err := s.activeKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := newAPIKeyToken()
+	if err != nil {
+		return nil, err
+	}
+	next := &domain.APIKey{
+		Name:      old.Name,
+		Prefix:    token[:apiKeyDisplayLen],
+		Scopes:    old.Scopes,
+		CohortIDs: old.CohortIDs,
+	}
+	if err := s.repo.RotateAPIKey(ctx, id, next, hashAPIKey(token), time.Now().Add(grace)); err != nil {
+		return nil, fmt.Errorf("failed to rotate api key: %w", err)
+	}
+	s.forgetKey(ctx, id)
+	next.Key = token
+	s.logger.InfoContext(ctx, "api key rotated",
+		slog.Uint64("api_key_id", id),
+		slog.Uint64("new_api_key_id", next.ID),
+		slog.Duration("grace", grace),
+	)
+	return next, nil
+}
+
+func (s *APIKeyServiceImpl) RevokeKey(ctx context.Context, id uint64) error {
+	if _, err := s.activeKey(ctx, id); err != nil {
+		return err
+	}
+	if err := s.repo.RevokeAPIKey(ctx, id, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	s.forgetKey(ctx, id)
+	s.logger.InfoContext(ctx, "api key revoked", slog.Uint64("api_key_id", id))
+	return nil
+}
+
+// activeKey - ключ, который еще можно ротировать или отозвать.
+func (s *APIKeyServiceImpl) activeKey(ctx context.Context, id uint64) (*domain.APIKey, error) {
+	key, err := s.repo.GetAPIKey(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	if key == nil {
+		return nil, NotFound("api_key_not_found", fmt.Sprintf("api key %d not found", id))
+	}
+	if key.RevokedAt != nil && !key.RevokedAt.After(time.Now()) {
+		return nil, Conflict("api_key_revoked", fmt.Sprintf("api key %d is already revoked", id))
+	}
+	return key, nil
+}
+
+// Authenticate сначала смотрит в кэш, поэтому запросы с одним и тем же
+// ключом, действующим или нет, не ходят в базу до ограничения частоты.
+func (s *APIKeyServiceImpl) Authenticate(ctx context.Context, token, ip string) (*domain.APIKey, error) {
+	invalid := Unauthorized("invalid_api_key", "api key is invalid or revoked")
+	if !strings.HasPrefix(token, apiKeyTokenPrefix) {
+		return nil, invalid
+	}
+	now := time.Now()
+	hash := hashAPIKey(token)
+	cacheKey := "apikey:" + hex.EncodeToString(hash)
+
+	key, cached := s.cachedKey(ctx, cacheKey)
+	if !cached {
+		var err error
+		key, err = s.repo.GetActiveAPIKeyByHash(ctx, hash, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up api key: %w", err)
+		}
+		s.cacheKey(ctx, cacheKey, key, now)
+	}
+	if key == nil {
+		return nil, invalid
+	}
+	s.touchKey(ctx, key.ID, ip, now)
+	return key, nil
+}
+
+// cachedKey - второй результат false, если в кэше ничего нет и ключ надо
+// искать в базе.
+func (s *APIKeyServiceImpl) cachedKey(ctx context.Context, cacheKey string) (*domain.APIKey, bool) {
+	if s.cache == nil || s.opts.CacheTTL <= 0 {
+		return nil, false
+	}
+	data, err := s.cache.Get(ctx, cacheKey)
+	if err != nil || data == "" {
+		return nil, false
+	}
+	if data == apiKeyUnknown {
+		return nil, true
+	}
+	var key domain.APIKey
+	if err := json.Unmarshal([]byte(data), &key); err != nil {
+		return nil, false
+	}
+	// Старый ключ после ротации перестает действовать в свой срок, даже
+	// если запись в кэше еще жива
+	if key.RevokedAt != nil && !key.RevokedAt.After(time.Now()) {
+		return nil, true
+	}
+	return &key, true
+}
+
+func (s *APIKeyServiceImpl) cacheKey(ctx context.Context, cacheKey string, key *domain.APIKey, now time.Time) {
+	if s.cache == nil || s.opts.CacheTTL <= 0 {
+		return
+	}
+	var err error
+	if key == nil {
+		err = s.cache.Set(ctx, cacheKey, apiKeyUnknown, s.opts.CacheTTL)
+	} else {
+		ttl := s.opts.CacheTTL
+		if key.RevokedAt != nil && key.RevokedAt.Sub(now) < ttl {
+			ttl = key.RevokedAt.Sub(now)
+		}
+		err = s.cache.SetWithTags(ctx, cacheKey, key, ttl, apiKeyTag(key.ID))
+	}
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to cache api key lookup", slog.String("error", err.Error()))
+	}
+}
+
+// touchKey обновляет last_used_at не чаще apiKeyTouchInterval для пары
+// ключ и IP; с кэшем лишние UPDATE отсекаются, не доходя до базы.
+func (s *APIKeyServiceImpl) touchKey(ctx context.Context, id uint64, ip string, now time.Time) {
+	if s.cache != nil && s.opts.CacheTTL > 0 {
+		first, err := s.cache.SetNX(ctx, fmt.Sprintf("apikey:touch:%d:%s", id, ip), "1", apiKeyTouchInterval)
+		if err == nil && !first {
+			return
+		}
+	}
+	if err := s.repo.TouchAPIKey(ctx, id, ip, now, apiKeyTouchInterval); err != nil {
+		// Аудит не должен ронять прием логов
+		s.logger.WarnContext(ctx, "failed to record api key use", slog.Uint64("api_key_id", id), slog.String("error", err.Error()))
+	}
+}
+
+// forgetKey убирает ключ из кэша этой реплики сразу после отзыва или
+// ротации.
+func (s *APIKeyServiceImpl) forgetKey(ctx context.Context, id uint64) {
+	if s.cache == nil {
+		return
+	}
+	if err := s.cache.InvalidateTags(ctx, apiKeyTag(id)); err != nil {
+		s.logger.WarnContext(ctx, "failed to invalidate cached api key", slog.Uint64("api_key_id", id), slog.String("error", err.Error()))
+	}
+}
+
+func apiKeyTag(id uint64) string {
+	return fmt.Sprintf("api_key:%d", id)
+}
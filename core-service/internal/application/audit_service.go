@@ -0,0 +1,89 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/auth"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/metrics"
+)
+
+const maxAuditPage = 500
+
+// AuditOptions - Retention: сколько хранить записи журнала; 0 - бессрочно.
+type AuditOptions struct {
+	Retention time.Duration
+}
+
+type AuditServiceImpl struct {
+	repo   interfaces.Repository
+	opts   AuditOptions
+	logger *slog.Logger
+}
+
+func NewAuditService(repo interfaces.Repository, opts AuditOptions, logger *slog.Logger) *AuditServiceImpl {
+	return &AuditServiceImpl{repo: repo, opts: opts, logger: logger}
+}
+
+var _ interfaces.AuditService = (*AuditServiceImpl)(nil)
+
+func (s *AuditServiceImpl) Record(ctx context.Context, entries []*domain.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	actorType, actor := auditActor(ctx)
+	now := time.Now()
+	for _, e := range entries {
+		if e.ActorType == "" {
+			e.ActorType, e.Actor = actorType, actor
+		}
+		if e.OccurredAt.IsZero() {
+			e.OccurredAt = now
+		}
+	}
+	if err := s.repo.SaveAuditEntries(ctx, entries); err != nil {
+		metrics.AuditWriteFailuresTotal.Add(float64(len(entries)))
+		return fmt.Errorf("failed to save audit entries: %w", err)
+	}
+	return nil
+}
+
+// auditActor - кто выполняет запрос: преподаватель из сессии, API-ключ
+// или аноним (внутренние вызовы, дашборд без LTI).
+func auditActor(ctx context.Context) (string, string) {
+	if session := auth.SessionFrom(ctx); session != nil {
+		return domain.ActorUser, session.Subject
+	}
+	if key := auth.APIKeyFrom(ctx); key != nil {
+		return domain.ActorAPIKey, strconv.FormatUint(key.ID, 10)
+	}
+	return domain.ActorAnonymous, ""
+}
+
+func (s *AuditServiceImpl) List(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, Validation("invalid_range", "from must be before to")
+	}
+	if filter.Limit <= 0 || filter.Limit > maxAuditPage {
+		filter.Limit = maxAuditPage
+	}
+	return s.repo.ListAuditEntries(ctx, filter)
+}
+
+func (s *AuditServiceImpl) PurgeExpired(ctx context.Context) error {
+	if s.opts.Retention <= 0 {
+		return nil
+	}
+	before := time.Now().Add(-s.opts.Retention)
+	n, err := s.repo.DeleteAuditEntriesBefore(ctx, before)
+	if err != nil {
+		return fmt.Errorf("failed to purge audit log: %w", err)
+	}
+	s.logger.InfoContext(ctx, "audit log purged", slog.Int64("deleted", n), slog.Time("before", before))
+	return nil
+}
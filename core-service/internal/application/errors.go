@@ -0,0 +1,116 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrorKind - класс ошибки, по которому API-слой выбирает HTTP-статус и gRPC-код.
+type ErrorKind string
+
+const (
+	KindNotFound    ErrorKind = "not_found"
+	KindValidation  ErrorKind = "validation"
+	KindConflict    ErrorKind = "conflict"
+	KindUnavailable ErrorKind = "unavailable"
+	KindTooLarge    ErrorKind = "too_large"
+	// KindUnauthorized - нет сессии или учетные данные не прошли проверку.
+	KindUnauthorized ErrorKind = "unauthorized"
+	// KindForbidden - пользователь известен, но доступа к ресурсу у него нет.
+	KindForbidden ErrorKind = "forbidden"
+	// KindRateLimited - клиент превысил допустимую частоту запросов.
+	KindRateLimited ErrorKind = "rate_limited"
+)
+
+// Error - ошибка уровня приложения. Code - стабильный машиночитаемый код
+// (student_not_found, invalid_log), который клиенты могут сравнивать;
+// Message - описание для человека. Err - исходная причина, наружу она
+// не отдается и нужна только для логов. Fields заполняется у ошибок
+// валидации и перечисляет все неверные поля сразу.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+// FieldError описывает одно неверное поле; Field - имя поля в JSON.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	if len(e.Fields) > 0 {
+		fields := make([]string, len(e.Fields))
+		for i, f := range e.Fields {
+			fields[i] = f.Field + ": " + f.Message
+		}
+		return fmt.Sprintf("%s (%s)", e.Message, strings.Join(fields, "; "))
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func NotFound(code, message string) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: message}
+}
+
+func Validation(code, message string) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message}
+}
+
+// InvalidFields возвращает ошибку валидации со списком неверных полей.
+func InvalidFields(code, message string, fields []FieldError) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message, Fields: fields}
+}
+
+func Conflict(code, message string) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message}
+}
+
+func Unavailable(code, message string, err error) *Error {
+	return &Error{Kind: KindUnavailable, Code: code, Message: message, Err: err}
+}
+
+func Unauthorized(code, message string) *Error {
+	return &Error{Kind: KindUnauthorized, Code: code, Message: message}
+}
+
+func Forbidden(code, message string) *Error {
+	return &Error{Kind: KindForbidden, Code: code, Message: message}
+}
+
+func RateLimited(code, message string) *Error {
+	return &Error{Kind: KindRateLimited, Code: code, Message: message}
+}
+
+// TooLarge - запрос больше допустимого (например, загружаемый файл).
+func TooLarge(code, message string, err error) *Error {
+	return &Error{Kind: KindTooLarge, Code: code, Message: message, Err: err}
+}
+
+// AsError достает *Error из цепочки обертки. Для прочих ошибок возвращает false:
+// API-слой считает их внутренними.
+func AsError(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
+
+// IsKind сообщает, относится ли err к классу kind.
+func IsKind(err error, kind ErrorKind) bool {
+	appErr, ok := AsError(err)
+	return ok && appErr.Kind == kind
+}
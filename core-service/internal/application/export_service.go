@@ -0,0 +1,83 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/export"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+)
+
+var logColumns = []export.Column{
+	{RU: "ID студента", EN: "Student ID"},
+	{RU: "Время", EN: "Timestamp"},
+	{RU: "Тип действия", EN: "Action type"},
+	{RU: "Верно", EN: "Correct"},
+	{RU: "Затрачено, сек", EN: "Time spent, sec"},
+}
+
+var analyticsColumns = []export.Column{
+	{RU: "ID студента", EN: "Student ID"},
+	{RU: "Кластер", EN: "Cluster"},
+	{RU: "Вовлеченность", EN: "Engagement score"},
+	{RU: "Среднее время на задание", EN: "Avg time per task"},
+	{RU: "Доля верных ответов", EN: "Success rate"},
+	{RU: "Дата анализа", EN: "Analyzed at"},
+}
+
+type ExportServiceImpl struct {
+	repo interfaces.Repository
+}
+
+func NewExportService(repo interfaces.Repository) interfaces.ExportService {
+	return &ExportServiceImpl{repo: repo}
+}
+
+func (s *ExportServiceImpl) ExportStudentLogs(ctx context.Context, studentID uint64, opts domain.ExportOptions, w io.Writer) error {
+	if err := authorizeStudent(ctx, s.repo, studentID); err != nil {
+		return err
+	}
+	tw, err := export.NewTableWriter(opts.Format, opts.Lang, logColumns, w)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.StreamLogsByStudentID(ctx, studentID, opts.From, opts.To, func(l *domain.StudentLog) error {
+		return tw.WriteRow(l.StudentID, l.Timestamp, l.ActionType, l.Correct, l.TimeSpentSec)
+	})
+	if err != nil {
+		tw.Abort()
+		return fmt.Errorf("failed to export logs: %w", err)
+	}
+	return tw.Close()
+}
+
+func (s *ExportServiceImpl) ExportCohortAnalytics(ctx context.Context, cohortID uint64, opts domain.ExportOptions, w io.Writer) error {
+	if err := authorizeCohort(ctx, cohortID); err != nil {
+		return err
+	}
+	// Проверяем когорту до первой записи в w, пока еще можно ответить 404
+	cohort, err := s.repo.GetCohortByID(ctx, cohortID)
+	if err != nil {
+		return fmt.Errorf("failed to get cohort: %w", err)
+	}
+	if cohort == nil {
+		return NotFound("cohort_not_found", fmt.Sprintf("cohort %d not found", cohortID))
+	}
+
+	tw, err := export.NewTableWriter(opts.Format, opts.Lang, analyticsColumns, w)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.StreamCohortAnalytics(ctx, cohortID, opts.From, opts.To, func(a *domain.StudentAnalytics) error {
+		return tw.WriteRow(a.StudentID, a.ClusterGroup, a.EngagementScore, a.AvgTimePerTask, a.SuccessRate, a.AnalyzedAt)
+	})
+	if err != nil {
+		tw.Abort()
+		return fmt.Errorf("failed to export cohort analytics: %w", err)
+	}
+	return tw.Close()
+}
@@ -0,0 +1,113 @@
+package application
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+)
+
+type HealthCheckFunc func(ctx context.Context) error
+
+type dependencyCheck struct {
+	name     string
+	required bool
+	check    HealthCheckFunc
+}
+
+// HealthChecker опрашивает зависимости сервиса. Сервис считается готовым,
+// пока живы все обязательные зависимости; необязательные (кэш, Python
+// анализатор) только попадают в отчет.
+type HealthChecker struct {
+	timeout time.Duration
+	checks  []dependencyCheck
+
+	mu   sync.RWMutex
+	last *domain.HealthReport
+}
+
+func NewHealthChecker(timeout time.Duration) *HealthChecker {
+	return &HealthChecker{timeout: timeout}
+}
+
+func (h *HealthChecker) Register(name string, required bool, check HealthCheckFunc) {
+	h.checks = append(h.checks, dependencyCheck{name: name, required: required, check: check})
+}
+
+func (h *HealthChecker) Check(ctx context.Context) *domain.HealthReport {
+	report := &domain.HealthReport{
+		Healthy:      true,
+		Dependencies: make([]domain.DependencyStatus, len(h.checks)),
+		CheckedAt:    time.Now(),
+	}
+
+	var wg sync.WaitGroup
+	for i, dep := range h.checks {
+		wg.Add(1)
+		go func(i int, dep dependencyCheck) {
+			defer wg.Done()
+			report.Dependencies[i] = h.checkOne(ctx, dep)
+		}(i, dep)
+	}
+	wg.Wait()
+
+	for _, dep := range report.Dependencies {
+		if dep.Required && !dep.Healthy {
+			report.Healthy = false
+		}
+	}
+
+	h.mu.Lock()
+	h.last = report
+	h.mu.Unlock()
+
+	return report
+}
+
+func (h *HealthChecker) checkOne(ctx context.Context, dep dependencyCheck) domain.DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := dep.check(ctx)
+
+	status := domain.DependencyStatus{
+		Name:      dep.name,
+		Healthy:   err == nil,
+		Required:  dep.required,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// Last возвращает результат последней проверки, не трогая зависимости.
+// До первой проверки возвращает nil.
+func (h *HealthChecker) Last() *domain.HealthReport {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.last
+}
+
+// Run периодически проверяет зависимости и отдает отчет в onReport,
+// пока не будет отменен ctx.
+func (h *HealthChecker) Run(ctx context.Context, interval time.Duration, onReport func(*domain.HealthReport)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		report := h.Check(ctx)
+		if onReport != nil {
+			onReport(report)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/importer"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/metrics"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/xapi"
+)
+
+// maxReportedRejects ограничивает размер отчета: на битом файле в миллион
+// строк клиенту хватит первых примеров, остальное видно по счетчику.
+const maxReportedRejects = 500
+
+type ImportServiceImpl struct {
+	repo      interfaces.Repository
+	cache     interfaces.Cache
+	analytics interfaces.AnalyticsService
+	mapper    *xapi.Mapper
+	batchSize int
+	logger    *slog.Logger
+}
+
+func NewImportService(
+	repo interfaces.Repository,
+	cache interfaces.Cache,
+	analytics interfaces.AnalyticsService,
+	mapper *xapi.Mapper,
+	batchSize int,
+	logger *slog.Logger,
+) interfaces.ImportService {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	return &ImportServiceImpl{
+		repo:      repo,
+		cache:     cache,
+		analytics: analytics,
+		mapper:    mapper,
+		batchSize: batchSize,
+		logger:    logger,
+	}
+}
+
+// Import разбирает файл, проверяет каждую строку теми же правилами, что и
+// SendLog, отбрасывает дубли внутри файла и пишет остальное пачками.
+// Дубли с уже сохраненными логами отсекает репозиторий. В режиме DryRun
+// база не меняется, а Valid показывает, сколько строк было бы загружено.
+func (s *ImportServiceImpl) Import(ctx context.Context, r io.Reader, opts domain.ImportOptions) (*domain.ImportReport, error) {
+	report := &domain.ImportReport{DryRun: opts.DryRun, Rejected: []domain.RejectedRow{}}
+	seen := make(map[uint64]struct{})
+	affected := make(map[uint64]struct{})
+	batch := make([]*domain.StudentLog, 0, s.batchSize)
+	now := time.Now()
+	allowed, err := allowedStudents(ctx, s.repo)
+	if err != nil {
+		return nil, err
+	}
+
+	flush := func() error {
+		if len(batch) == 0 || opts.DryRun {
+			batch = batch[:0]
+			return nil
+		}
+		inserted, err := s.repo.SaveLogs(ctx, batch)
+		if err != nil {
+			return fmt.Errorf("failed to save logs: %w", err)
+		}
+		report.Imported += inserted
+		report.Duplicates += len(batch) - inserted
+		// Студент считается затронутым, даже если часть его строк оказалась
+		// дублями: лишний анализ дешевле пропущенного
+		if inserted > 0 {
+			for _, l := range batch {
+				affected[l.StudentID] = struct{}{}
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	handle := func(rec importer.Record) error {
+		report.TotalRows++
+		if rec.Err == nil && rec.Log.Timestamp.IsZero() {
+			rec.Err = errors.New("timestamp is required")
+		}
+		if rec.Err == nil {
+			rec.Err = ValidateLog(rec.Log, now)
+		}
+		if rec.Err == nil && allowed != nil {
+			if _, ok := allowed[rec.Log.StudentID]; !ok {
+				rec.Err = fmt.Errorf("student %d is not in the api key's courses", rec.Log.StudentID)
+			}
+		}
+		if rec.Err != nil {
+			report.RejectedCount++
+			if len(report.Rejected) < maxReportedRejects {
+				report.Rejected = append(report.Rejected, domain.RejectedRow{Row: rec.Row, Reason: rec.Err.Error()})
+			}
+			return nil
+		}
+
+		key := dedupeKey(rec.Log)
+		if _, ok := seen[key]; ok {
+			report.Duplicates++
+			return nil
+		}
+		seen[key] = struct{}{}
+		report.Valid++
+
+		batch = append(batch, rec.Log)
+		if len(batch) >= s.batchSize {
+			return flush()
+		}
+		return nil
+	}
+
+	switch opts.Format {
+	case domain.ImportCSV:
+		err = importer.ParseCSV(r, importer.CSVOptions{Columns: opts.CSVColumns}, handle)
+	case domain.ImportXAPI:
+		err = importer.ParseXAPI(r, s.mapper, handle)
+	default:
+		return nil, Validation("invalid_format", "format must be csv or xapi")
+	}
+	if err == nil {
+		err = flush()
+	}
+	if errors.Is(err, importer.ErrInvalidFile) {
+		return nil, Validation("invalid_import_file", err.Error())
+	}
+	if err != nil {
+		// Уже загруженные пачки остаются в базе: повторный запуск их пропустит
+		return nil, err
+	}
+
+	metrics.LogsIngestedTotal.Add(float64(report.Imported))
+	report.AffectedStudents = len(affected)
+	s.afterImport(ctx, affected, opts.TriggerAnalysis, report)
+
+	s.logger.InfoContext(ctx, "logs imported",
+		slog.String("format", string(opts.Format)),
+		slog.Bool("dry_run", opts.DryRun),
+		slog.Int("total", report.TotalRows),
+		slog.Int("imported", report.Imported),
+		slog.Int("duplicates", report.Duplicates),
+		slog.Int("rejected", report.RejectedCount),
+	)
+	return report, nil
+}
+
+// afterImport сбрасывает кэш затронутых студентов и, если
+// попросили, запускает для них анализ. Ошибки здесь не отменяют импорт.
+func (s *ImportServiceImpl) afterImport(ctx context.Context, students map[uint64]struct{}, analyze bool, report *domain.ImportReport) {
+	if len(students) == 0 {
+		return
+	}
+	// Импорт мог добавить студентов, поэтому общий список сбрасывается всегда
+	tags := []string{studentsTag}
+	for _, id := range slices.Sorted(maps.Keys(students)) {
+		tags = append(tags, studentTag(id))
+	}
+	if err := s.cache.InvalidateTags(ctx, tags...); err != nil {
+		s.logger.WarnContext(ctx, "failed to invalidate cached queries", slog.String("error", err.Error()))
+	}
+	for id := range students {
+		if err := s.cache.Delete(ctx, analyticsCacheKey(id)); err != nil {
+			s.logger.WarnContext(ctx, "failed to invalidate analytics cache", slog.Uint64("student_id", id), slog.String("error", err.Error()))
+		}
+		if !analyze {
+			continue
+		}
+		if err := s.analytics.TriggerAnalysis(ctx, id); err != nil {
+			s.logger.WarnContext(ctx, "failed to trigger analysis after import", slog.Uint64("student_id", id), slog.String("error", err.Error()))
+			continue
+		}
+		report.AnalysisTriggered++
+	}
+}
+
+// dedupeKey - хэш тех же полей, по которым дубли ищет репозиторий. Хранить
+// 8 байт на строку дешевле, чем сами ключи, а коллизии на истории одной
+// LMS практически исключены.
+func dedupeKey(l *domain.StudentLog) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(strconv.FormatUint(l.StudentID, 10)))
+	h.Write([]byte{0})
+	h.Write([]byte(l.ActionType))
+	h.Write([]byte{0})
+	h.Write([]byte(l.Timestamp.UTC().Format(time.RFC3339Nano)))
+	return h.Sum64()
+}
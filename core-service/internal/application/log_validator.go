@@ -0,0 +1,91 @@
+package application
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+)
+
+const (
+	// maxTimeSpentSec - одно действие дольше 4 часов почти наверняка значит,
+	// что вкладку забыли закрыть, и такие значения портят средние.
+	maxTimeSpentSec = 4 * 60 * 60
+	maxAttempts     = 100
+	minDifficulty   = 1
+	maxDifficulty   = 5
+	maxMaterialID   = 128
+
+	// maxClockSkew допускает небольшое расхождение часов клиента и сервера.
+	maxClockSkew = 5 * time.Minute
+	// maxLogAge отсекает явно битые даты (нулевой год, 1970 из unix 0),
+	// но пропускает импорт истории за несколько лет.
+	maxLogAge = 5 * 365 * 24 * time.Hour
+)
+
+// ValidateLog проверяет лог целиком и возвращает все ошибки по полям сразу,
+// чтобы клиент мог исправить их за один заход. now передается явно ради тестов.
+func ValidateLog(log *domain.StudentLog, now time.Time) error {
+	var fields []FieldError
+	add := func(field, code, message string) {
+		fields = append(fields, FieldError{Field: field, Code: code, Message: message})
+	}
+
+	if log.StudentID == 0 {
+		add("student_id", "required", "student_id is required")
+	}
+
+	switch {
+	case log.ActionType == "":
+		add("action_type", "required", "action_type is required")
+	case !domain.IsKnownAction(log.ActionType):
+		add("action_type", "unknown_value", fmt.Sprintf("unknown action_type %q", log.ActionType))
+	}
+
+	if len(log.MaterialID) > maxMaterialID {
+		add("material_id", "too_long", fmt.Sprintf("material_id must be at most %d characters", maxMaterialID))
+	}
+
+	for _, f := range []struct {
+		name  string
+		value int
+	}{
+		{"time_spent_sec", log.TimeSpentSec},
+		{"time_spent_on_mat", log.TimeSpentOnMat},
+		{"time_spent_on_question", log.TimeSpentOnQuestion},
+	} {
+		if f.value < 0 || f.value > maxTimeSpentSec {
+			add(f.name, "out_of_range", fmt.Sprintf("%s must be between 0 and %d", f.name, maxTimeSpentSec))
+		}
+	}
+
+	// 0 - сложность не указана
+	if log.Difficulty != 0 && (log.Difficulty < minDifficulty || log.Difficulty > maxDifficulty) {
+		add("difficulty", "out_of_range", fmt.Sprintf("difficulty must be between %d and %d", minDifficulty, maxDifficulty))
+	}
+
+	isAnswer := domain.IsAnswerAction(log.ActionType)
+	switch {
+	case log.Attempts < 0 || log.Attempts > maxAttempts:
+		add("attempts", "out_of_range", fmt.Sprintf("attempts must be between 0 and %d", maxAttempts))
+	case isAnswer && log.Attempts < 1:
+		add("attempts", "out_of_range", "attempts must be at least 1 for answers")
+	}
+
+	// Для неизвестного типа действия уже есть ошибка выше, дублировать ее не нужно
+	if log.SelectedDistractor != "" && domain.IsKnownAction(log.ActionType) && (!isAnswer || log.Correct) {
+		add("selected_distractor", "not_allowed", "selected_distractor is only allowed on wrong answers")
+	}
+
+	switch {
+	case log.Timestamp.After(now.Add(maxClockSkew)):
+		add("timestamp", "in_future", "timestamp must not be in the future")
+	case log.Timestamp.Before(now.Add(-maxLogAge)):
+		add("timestamp", "too_old", "timestamp is too far in the past")
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	return InvalidFields("invalid_log", "student log is invalid", fields)
+}
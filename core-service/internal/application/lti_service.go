@@ -0,0 +1,219 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/lti"
+)
+
+// LTIOptions - AuthLoginURL - OIDC-эндпоинт платформы, RedirectURI - наш
+// адрес приема запуска, зарегистрированный в платформе.
+type LTIOptions struct {
+	AuthLoginURL string
+	RedirectURI  string
+	SessionTTL   time.Duration
+	StateTTL     time.Duration
+}
+
+type LTIServiceImpl struct {
+	repo     interfaces.Repository
+	cache    interfaces.Cache
+	platform *lti.Platform
+	opts     LTIOptions
+	logger   *slog.Logger
+}
+
+func NewLTIService(
+	repo interfaces.Repository,
+	cache interfaces.Cache,
+	platform *lti.Platform,
+	opts LTIOptions,
+	logger *slog.Logger,
+) interfaces.LTIService {
+	if opts.StateTTL <= 0 {
+		opts.StateTTL = 10 * time.Minute
+	}
+	if opts.SessionTTL <= 0 {
+		opts.SessionTTL = 8 * time.Hour
+	}
+	return &LTIServiceImpl{
+		repo:     repo,
+		cache:    cache,
+		platform: platform,
+		opts:     opts,
+		logger:   logger,
+	}
+}
+
+func ltiStateKey(state string) string {
+	return "lti:state:" + state
+}
+
+// sessionCacheKey хранит сессию под хэшем токена: дамп Redis не дает
+// готовых cookie.
+func sessionCacheKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "session:" + hex.EncodeToString(sum[:])
+}
+
+func (s *LTIServiceImpl) InitiateLogin(ctx context.Context, req domain.LTILoginRequest) (*domain.LTIAuthRedirect, error) {
+	if req.Issuer != s.platform.Issuer {
+		return nil, Validation("lti_unknown_platform", "iss is not a registered platform")
+	}
+	if req.ClientID != "" && req.ClientID != s.platform.ClientID {
+		return nil, Validation("lti_unknown_client", "client_id is not registered for this platform")
+	}
+	if req.LoginHint == "" {
+		return nil, Validation("lti_invalid_login", "login_hint is required")
+	}
+
+	state, nonce := randomToken(), randomToken()
+	if err := s.cache.Set(ctx, ltiStateKey(state), nonce, s.opts.StateTTL); err != nil {
+		return nil, Unavailable("session_store_unavailable", "failed to start login", err)
+	}
+
+	redirect, err := url.Parse(s.opts.AuthLoginURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid platform auth login url: %w", err)
+	}
+	q := redirect.Query()
+	q.Set("scope", "openid")
+	q.Set("response_type", "id_token")
+	q.Set("response_mode", "form_post")
+	q.Set("prompt", "none")
+	q.Set("client_id", s.platform.ClientID)
+	q.Set("redirect_uri", s.opts.RedirectURI)
+	q.Set("login_hint", req.LoginHint)
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	if req.LTIMessageHint != "" {
+		q.Set("lti_message_hint", req.LTIMessageHint)
+	}
+	redirect.RawQuery = q.Encode()
+
+	return &domain.LTIAuthRedirect{URL: redirect.String(), State: state}, nil
+}
+
+// Launch не различает наружу причины отказа в токене - они пишутся в лог.
+// State должен совпасть с cookie браузера, начавшего вход: без этого
+// чужой запуск можно подсунуть в браузер жертвы (login CSRF). Из
+// хранилища state забирается одной операцией, поэтому два одновременных
+// запуска с ним не пройдут оба.
+func (s *LTIServiceImpl) Launch(ctx context.Context, idToken, state, cookieState string) (*domain.Session, error) {
+	if idToken == "" || state == "" {
+		return nil, Unauthorized("lti_invalid_launch", "id_token and state are required")
+	}
+	if cookieState != state {
+		return nil, Unauthorized("lti_state_mismatch", "state does not match this browser")
+	}
+
+	nonce, err := s.cache.GetDel(ctx, ltiStateKey(state))
+	if err != nil {
+		return nil, Unavailable("session_store_unavailable", "failed to consume login state", err)
+	}
+	if nonce == "" {
+		return nil, Unauthorized("lti_state_expired", "login state is unknown or expired, start the launch again")
+	}
+
+	now := time.Now()
+	claims, err := s.platform.Validate(idToken, now)
+	if err != nil {
+		s.logger.WarnContext(ctx, "lti launch rejected", slog.String("error", err.Error()))
+		return nil, Unauthorized("lti_invalid_token", "id_token is invalid")
+	}
+	if claims.Nonce != nonce {
+		s.logger.WarnContext(ctx, "lti launch rejected", slog.String("error", "nonce mismatch"))
+		return nil, Unauthorized("lti_invalid_token", "id_token is invalid")
+	}
+
+	var role domain.Role
+	switch {
+	case claims.IsAdministrator():
+		role = domain.RoleAdministrator
+	case claims.IsInstructor():
+		role = domain.RoleInstructor
+	default:
+		return nil, Forbidden("lti_role_not_allowed", "only instructors can open the dashboard")
+	}
+
+	title := claims.Context.Title
+	if title == "" {
+		title = claims.Context.Label
+	}
+	if title == "" {
+		title = claims.Context.ID
+	}
+	cohort, err := s.repo.EnsureLTICohort(ctx, claims.Issuer, claims.Context.ID, title)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map lti context to cohort: %w", err)
+	}
+
+	session := &domain.Session{
+		ID:          randomToken(),
+		Subject:     claims.Subject,
+		Name:        claims.Name,
+		Issuer:      claims.Issuer,
+		CohortID:    cohort.ID,
+		CourseTitle: cohort.Name,
+		Role:        role,
+		ExpiresAt:   now.Add(s.opts.SessionTTL),
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cache.Set(ctx, sessionCacheKey(session.ID), data, s.opts.SessionTTL); err != nil {
+		return nil, Unavailable("session_store_unavailable", "failed to create session", err)
+	}
+
+	s.logger.InfoContext(ctx, "lti launch accepted",
+		slog.String("subject", session.Subject),
+		slog.Uint64("cohort_id", session.CohortID),
+		slog.String("role", string(session.Role)),
+	)
+	return session, nil
+}
+
+func (s *LTIServiceImpl) GetSession(ctx context.Context, token string) (*domain.Session, error) {
+	if token == "" {
+		return nil, Unauthorized("session_required", "sign in through your LMS")
+	}
+	data, err := s.cache.Get(ctx, sessionCacheKey(token))
+	if err != nil {
+		return nil, Unavailable("session_store_unavailable", "failed to load session", err)
+	}
+	if data == "" {
+		return nil, Unauthorized("session_expired", "session expired, open the dashboard from your LMS again")
+	}
+
+	var session domain.Session
+	if err := json.Unmarshal([]byte(data), &session); err != nil {
+		return nil, fmt.Errorf("corrupted session: %w", err)
+	}
+	session.ID = token
+	return &session, nil
+}
+
+func (s *LTIServiceImpl) EndSession(ctx context.Context, token string) error {
+	if token == "" {
+		return nil
+	}
+	return s.cache.Delete(ctx, sessionCacheKey(token))
+}
+
+func randomToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,213 @@
+package application
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+)
+
+// erasureTopic читает аналитический сервис: по событию он удаляет свои
+// копии данных студента (кэш аналитики в Redis).
+const erasureTopic = "student-erasure"
+
+// errStopStream прерывает потоковое чтение, когда первой строки достаточно.
+var errStopStream = errors.New("stop stream")
+
+type PrivacyServiceImpl struct {
+	repo     interfaces.Repository
+	cache    interfaces.Cache
+	producer interfaces.MessageProducer
+	logger   *slog.Logger
+}
+
+func NewPrivacyService(repo interfaces.Repository, cache interfaces.Cache, producer interfaces.MessageProducer, logger *slog.Logger) *PrivacyServiceImpl {
+	return &PrivacyServiceImpl{repo: repo, cache: cache, producer: producer, logger: logger}
+}
+
+var _ interfaces.PrivacyService = (*PrivacyServiceImpl)(nil)
+
+func (s *PrivacyServiceImpl) ExportStudentData(ctx context.Context, studentID uint64, w io.Writer) error {
+	if err := authorizeStudent(ctx, s.repo, studentID); err != nil {
+		return err
+	}
+	student, err := s.repo.GetStudentByID(ctx, studentID)
+	if err != nil {
+		return fmt.Errorf("failed to get student: %w", err)
+	}
+	analytics, err := s.repo.GetAnalyticsByStudentID(ctx, studentID)
+	if err != nil {
+		return fmt.Errorf("failed to get analytics: %w", err)
+	}
+	// Все логи и вся история: верхняя граница с запасом на логи, чьи
+	// часы спешат
+	to := time.Now().Add(maxClockSkew)
+	history, err := s.repo.GetAnalyticsHistory(ctx, studentID, time.Time{}, to)
+	if err != nil {
+		return fmt.Errorf("failed to get analytics history: %w", err)
+	}
+
+	// Проверяем, что о студенте что-то есть, до первой записи в w, пока
+	// еще можно ответить 404
+	if student == nil && analytics == nil && len(history) == 0 {
+		err := s.repo.StreamLogsByStudentID(ctx, studentID, time.Time{}, to, func(*domain.StudentLog) error {
+			return errStopStream
+		})
+		if err == nil {
+			return NotFound("student_not_found", fmt.Sprintf("student %d not found", studentID))
+		}
+		if !errors.Is(err, errStopStream) {
+			return fmt.Errorf("failed to get logs: %w", err)
+		}
+	}
+
+	zw := zip.NewWriter(w)
+	if err := writeZipJSON(zw, "profile.json", student); err != nil {
+		return err
+	}
+	if err := writeZipLogs(ctx, s.repo, zw, studentID, to); err != nil {
+		return err
+	}
+	if err := writeZipJSON(zw, "analytics.json", analytics); err != nil {
+		return err
+	}
+	if history == nil {
+		history = []*domain.StudentAnalytics{}
+	}
+	if err := writeZipJSON(zw, "analytics_history.json", history); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func writeZipJSON(zw *zip.Writer, name string, v any) error {
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeZipLogs пишет logs.json массивом по мере чтения из базы: логов
+// у студента может быть много.
+func writeZipLogs(ctx context.Context, repo interfaces.Repository, zw *zip.Writer, studentID uint64, to time.Time) error {
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: "logs.json", Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, "["); err != nil {
+		return err
+	}
+	sep := "\n"
+	err = repo.StreamLogsByStudentID(ctx, studentID, time.Time{}, to, func(l *domain.StudentLog) error {
+		b, err := json.Marshal(l)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, sep); err != nil {
+			return err
+		}
+		sep = ",\n"
+		_, err = f.Write(b)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export logs: %w", err)
+	}
+	_, err = io.WriteString(f, "\n]\n")
+	return err
+}
+
+// DeleteStudent сначала публикует событие об удалении: если шина
+// недоступна, данные еще не тронуты и запрос можно просто повторить.
+// Повтор после сбоя базы тоже безопасен - событие идемпотентно.
+func (s *PrivacyServiceImpl) DeleteStudent(ctx context.Context, studentID uint64, mode string) (*domain.StudentErasure, error) {
+	if mode != domain.ErasureModeErase && mode != domain.ErasureModeAnonymize {
+		return nil, Validation("invalid_mode", "mode must be erase or anonymize")
+	}
+	if err := authorizeStudent(ctx, s.repo, studentID); err != nil {
+		return nil, err
+	}
+	logCtx := logging.WithStudentID(ctx, studentID)
+
+	var pseudonymID uint64
+	if mode == domain.ErasureModeAnonymize {
+		var err error
+		if pseudonymID, err = newPseudonymID(); err != nil {
+			return nil, fmt.Errorf("failed to generate pseudonym: %w", err)
+		}
+	}
+
+	event := map[string]interface{}{
+		"type":         "student_erased",
+		"student_id":   studentID,
+		"mode":         mode,
+		"requested_at": time.Now().Unix(),
+	}
+	if pseudonymID != 0 {
+		event["pseudonym_id"] = pseudonymID
+	}
+	if err := s.producer.SendJSON(ctx, erasureTopic, event); err != nil {
+		return nil, Unavailable("event_bus_unavailable", "failed to publish student erasure", err)
+	}
+
+	var (
+		erasure *domain.StudentErasure
+		err     error
+	)
+	if mode == domain.ErasureModeAnonymize {
+		erasure, err = s.repo.AnonymizeStudent(ctx, studentID, pseudonymID)
+	} else {
+		erasure, err = s.repo.EraseStudent(ctx, studentID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to erase student data: %w", err)
+	}
+	erasure.ErasedAt = time.Now()
+	s.purgeCache(logCtx, erasure)
+
+	s.logger.InfoContext(logCtx, "student data erased", slog.String("mode", mode), slog.Any("rows", erasure.Rows))
+	return erasure, nil
+}
+
+// purgeCache удаляет из Redis аналитику студента и все выборки, где он
+// мог попасть: его логи, общий список и списки его когорт. Ошибка не
+// возвращается - в базе данных уже нет, а у ключей есть срок жизни.
+func (s *PrivacyServiceImpl) purgeCache(ctx context.Context, erasure *domain.StudentErasure) {
+	id := erasure.StudentID
+	for _, key := range []string{analyticsCacheKey(id), analyticsStaleKey(id), analyticsLockKey(id), studentSeenKey(id)} {
+		if err := s.cache.Delete(ctx, key); err != nil {
+			s.logger.ErrorContext(ctx, "failed to delete cached student data", slog.String("key", key), slog.String("error", err.Error()))
+		}
+	}
+	tags := []string{studentTag(id), studentsTag}
+	for _, cohortID := range erasure.CohortIDs {
+		tags = append(tags, cohortTag(cohortID))
+	}
+	if err := s.cache.InvalidateTags(ctx, tags...); err != nil {
+		s.logger.ErrorContext(ctx, "failed to invalidate cached student lists", slog.String("error", err.Error()))
+	}
+}
+
+// newPseudonymID выбирает случайный ID из [2^62, 2^63): настоящие ID
+// студентов (BIGSERIAL) до этого диапазона не дорастут, а в BIGINT он
+// помещается.
+func newPseudonymID() (uint64, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0, err
+	}
+	return 1<<62 | binary.BigEndian.Uint64(b[:])>>2, nil
+}
@@ -0,0 +1,109 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/metrics"
+)
+
+// Списки студентов и выборки логов кэшируются с тегами: student:<id>
+// сбрасывается новым логом студента, students - появлением нового
+// студента, cohort:<id> - изменением состава когорты.
+const (
+	studentsTag = "students"
+	// studentSeenTTL - сколько помнить, что студент уже попал в общий список
+	studentSeenTTL = 24 * time.Hour
+	// openRangeSlack - период, кончающийся не раньше этого, считается "по сейчас"
+	openRangeSlack = time.Minute
+	// maxCachedLogs - выборки больше не кладем в кэш
+	maxCachedLogs = 5000
+)
+
+func studentTag(studentID uint64) string {
+	return fmt.Sprintf("student:%d", studentID)
+}
+
+func cohortTag(cohortID uint64) string {
+	return fmt.Sprintf("cohort:%d", cohortID)
+}
+
+// studentSeenKey ставится первым логом студента: пока ключ жив, новые
+// логи этого студента общий список не меняют.
+func studentSeenKey(studentID uint64) string {
+	return fmt.Sprintf("students:seen:%d", studentID)
+}
+
+// logsCacheKey - дашборд не передает период, и его границы сдвигаются с
+// каждым запросом. Поэтому в кэше лежит выборка с началом, округленным
+// до минуты вниз, и концом "по сейчас" (now) или округленным до минуты
+// вверх, а точный период вырезается из нее. Возвращает ключ и границы
+// выборки для базы.
+func logsCacheKey(studentID uint64, from, to, now time.Time) (string, time.Time, time.Time) {
+	lo := from.Truncate(time.Minute)
+	if !to.Before(now.Add(-openRangeSlack)) {
+		// Выборку "по сейчас" сбрасывают новые логи через тег; запас на
+		// логи с часами клиента, спешащими в пределах maxClockSkew
+		return fmt.Sprintf("logs:%d:%d:now", studentID, lo.Unix()), lo, now.Add(maxClockSkew)
+	}
+	hi := to.Truncate(time.Minute)
+	if hi.Before(to) {
+		hi = hi.Add(time.Minute)
+	}
+	return fmt.Sprintf("logs:%d:%d:%d", studentID, lo.Unix(), hi.Unix()), lo, hi
+}
+
+// logsBetween оставляет логи с from <= timestamp <= to, как BETWEEN в
+// запросе, сохраняя порядок.
+func logsBetween(logs []*domain.StudentLog, from, to time.Time) []*domain.StudentLog {
+	var out []*domain.StudentLog
+	for _, l := range logs {
+		if !l.Timestamp.Before(from) && !l.Timestamp.After(to) {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// cachedJSON сообщает, нашлось ли значение; ошибки кэша только пишутся в
+// лог - без кэша данные берутся из базы.
+func (s *AnalyticsServiceImpl) cachedJSON(ctx context.Context, cache, key string, v any) bool {
+	cached, err := s.cache.Get(ctx, key)
+	if err != nil {
+		metrics.CacheLookupsTotal.WithLabelValues(cache, "error").Inc()
+		s.logger.WarnContext(ctx, "cache lookup failed", slog.String("key", key), slog.String("error", err.Error()))
+		return false
+	}
+	if cached == "" || json.Unmarshal([]byte(cached), v) != nil {
+		metrics.CacheLookupsTotal.WithLabelValues(cache, "miss").Inc()
+		return false
+	}
+	metrics.CacheLookupsTotal.WithLabelValues(cache, "hit").Inc()
+	return true
+}
+
+func (s *AnalyticsServiceImpl) cacheJSON(ctx context.Context, key string, v any, ttl time.Duration, tags ...string) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	if err := s.cache.SetWithTags(ctx, key, data, ttl, tags...); err != nil {
+		s.logger.WarnContext(ctx, "failed to cache query result", slog.String("key", key), slog.String("error", err.Error()))
+	}
+}
+
+// invalidateStudent сбрасывает записи, которые меняет новый лог студента.
+func (s *AnalyticsServiceImpl) invalidateStudent(ctx context.Context, studentID uint64) {
+	tags := []string{studentTag(studentID)}
+	// Если не удалось проверить, новый ли студент, список сбрасываем на всякий случай
+	if first, err := s.cache.SetNX(ctx, studentSeenKey(studentID), "1", studentSeenTTL); err != nil || first {
+		tags = append(tags, studentsTag)
+	}
+	if err := s.cache.InvalidateTags(ctx, tags...); err != nil {
+		s.logger.WarnContext(ctx, "failed to invalidate cached queries", slog.Uint64("student_id", studentID), slog.String("error", err.Error()))
+	}
+}
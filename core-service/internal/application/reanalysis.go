@@ -0,0 +1,100 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+)
+
+const (
+	reanalysisJobName = "reanalysis"
+	// reanalysisOverlap - логи, принятые незадолго до начала прошлого
+	// запуска, берутся повторно: часы реплик и Postgres могут расходиться,
+	// а лишняя команда анализа безвредна
+	reanalysisOverlap = time.Minute
+)
+
+// ReanalysisJob отправляет на переанализ студентов, у которых появились
+// логи после последнего анализа и после прошлого успешного запуска.
+// Анализ асинхронный, поэтому без второго условия реплика, чей cron
+// сработал сразу после быстрого прогона другой, отправила бы всех заново.
+// Команды идут с ограниченной частотой, чтобы ночной прогон не забил
+// очередь analytics-service.
+type ReanalysisJob struct {
+	repo      interfaces.Repository
+	analytics interfaces.AnalyticsService
+	interval  time.Duration
+	logger    *slog.Logger
+}
+
+// NewReanalysisJob - rate - команд в секунду; 0 и меньше - без ограничения.
+func NewReanalysisJob(repo interfaces.Repository, analytics interfaces.AnalyticsService, rate float64, logger *slog.Logger) *ReanalysisJob {
+	var interval time.Duration
+	if rate > 0 {
+		interval = time.Duration(float64(time.Second) / rate)
+	}
+	return &ReanalysisJob{repo: repo, analytics: analytics, interval: interval, logger: logger}
+}
+
+// Run вызывается под блокировкой планировщика, поэтому чтение и запись
+// времени прошлого запуска не гоняются между репликами.
+func (j *ReanalysisJob) Run(ctx context.Context) error {
+	started := time.Now()
+	lastRun, err := j.repo.LastJobRun(ctx, reanalysisJobName)
+	if err != nil {
+		return fmt.Errorf("failed to get last reanalysis run: %w", err)
+	}
+	var since time.Time
+	if !lastRun.IsZero() {
+		since = lastRun.Add(-reanalysisOverlap)
+	}
+	ids, err := j.repo.GetStudentsWithNewLogs(ctx, since)
+	if err != nil {
+		return fmt.Errorf("failed to select students for reanalysis: %w", err)
+	}
+
+	var tick <-chan time.Time
+	if j.interval > 0 {
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	triggered, failed := 0, 0
+	for i, id := range ids {
+		if i > 0 && tick != nil {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-tick:
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := j.analytics.TriggerAnalysis(ctx, id); err != nil {
+			failed++
+			j.logger.WarnContext(logging.WithStudentID(ctx, id), "failed to trigger reanalysis", slog.String("error", err.Error()))
+			continue
+		}
+		triggered++
+	}
+
+	j.logger.InfoContext(ctx, "reanalysis enqueued",
+		slog.Int("students", len(ids)),
+		slog.Int("triggered", triggered),
+		slog.Int("failed", failed),
+	)
+	// Запуск с ошибками не запоминается: его студенты попадут в следующий
+	if failed > 0 {
+		return fmt.Errorf("failed to trigger analysis for %d of %d students", failed, len(ids))
+	}
+	if err := j.repo.RecordJobRun(ctx, reanalysisJobName, started); err != nil {
+		return fmt.Errorf("failed to record reanalysis run: %w", err)
+	}
+	return nil
+}
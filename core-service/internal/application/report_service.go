@@ -0,0 +1,150 @@
+package application
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/report"
+)
+
+type ReportServiceImpl struct {
+	repo     interfaces.Repository
+	analyzer interfaces.AnalyticsClient
+	logger   *slog.Logger
+}
+
+func NewReportService(repo interfaces.Repository, analyzer interfaces.AnalyticsClient, logger *slog.Logger) interfaces.ReportService {
+	return &ReportServiceImpl{repo: repo, analyzer: analyzer, logger: logger}
+}
+
+func (s *ReportServiceImpl) StudentReport(ctx context.Context, studentID uint64, opts domain.ReportOptions, w io.Writer) error {
+	if err := authorizeStudent(ctx, s.repo, studentID); err != nil {
+		return err
+	}
+	r, err := s.load(ctx, studentID, opts)
+	if err != nil {
+		return err
+	}
+	if r == nil {
+		return NotFound("analytics_not_found", fmt.Sprintf("student %d has not been analyzed yet", studentID))
+	}
+	return report.Render(w, r, opts.Lang)
+}
+
+// CohortReports загружает, рисует и пишет в архив отчеты по одному, чтобы
+// в памяти был отчет одного студента, а не всей когорты. Архив начинает
+// уходить клиенту только с первым отчетом: пустую когорту и ошибку на
+// первом студенте еще можно отдать обычным ответом. Студенты без
+// аналитики перечисляются в skipped.txt.
+func (s *ReportServiceImpl) CohortReports(ctx context.Context, cohortID uint64, opts domain.ReportOptions, w io.Writer) error {
+	if err := authorizeCohort(ctx, cohortID); err != nil {
+		return err
+	}
+	cohort, err := s.repo.GetCohortByID(ctx, cohortID)
+	if err != nil {
+		return fmt.Errorf("failed to get cohort: %w", err)
+	}
+	if cohort == nil {
+		return NotFound("cohort_not_found", fmt.Sprintf("cohort %d not found", cohortID))
+	}
+	ids, err := s.repo.GetCohortStudentIDs(ctx, cohortID)
+	if err != nil {
+		return fmt.Errorf("failed to get cohort students: %w", err)
+	}
+
+	zw := zip.NewWriter(w)
+	var skipped []uint64
+	written := 0
+	for _, id := range ids {
+		r, err := s.load(ctx, id, opts)
+		if err != nil {
+			return err
+		}
+		if r == nil {
+			skipped = append(skipped, id)
+			continue
+		}
+		f, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     fmt.Sprintf("student_%d.pdf", r.StudentID),
+			Method:   zip.Deflate,
+			Modified: r.GeneratedAt,
+		})
+		if err != nil {
+			return err
+		}
+		if err := report.Render(f, r, opts.Lang); err != nil {
+			return err
+		}
+		written++
+	}
+	if written == 0 {
+		return NotFound("analytics_not_found", fmt.Sprintf("no students of cohort %d have been analyzed yet", cohortID))
+	}
+	if len(skipped) > 0 {
+		f, err := zw.Create("skipped.txt")
+		if err != nil {
+			return err
+		}
+		lines := make([]string, len(skipped))
+		for i, id := range skipped {
+			lines[i] = fmt.Sprintf("%d\tnot analyzed yet", id)
+		}
+		if _, err := io.WriteString(f, strings.Join(lines, "\n")+"\n"); err != nil {
+			return err
+		}
+	}
+
+	s.logger.InfoContext(ctx, "cohort reports generated",
+		slog.Uint64("cohort_id", cohortID),
+		slog.Int("reports", written),
+		slog.Int("skipped", len(skipped)),
+	)
+	return zw.Close()
+}
+
+// load собирает данные отчета. nil без ошибки - студент не
+// анализировался. В базе лежат только итоговые показатели: эффективность
+// по темам и рекомендации Python-сервис отдает лишь в ответе AnalyzeStudent.
+// Если он недоступен, отчет выходит без этих разделов.
+func (s *ReportServiceImpl) load(ctx context.Context, studentID uint64, opts domain.ReportOptions) (*domain.StudentReport, error) {
+	logCtx := logging.WithStudentID(ctx, studentID)
+
+	analytics, err := s.repo.GetAnalyticsByStudentID(ctx, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get analytics: %w", err)
+	}
+	if analytics == nil {
+		return nil, nil
+	}
+	if details, err := s.analyzer.AnalyzeStudent(ctx, studentID); err != nil {
+		s.logger.WarnContext(logCtx, "failed to get topic efficiency for report", slog.String("error", err.Error()))
+	} else {
+		analytics.TopicEfficiency = details.TopicEfficiency
+		analytics.Recommendations = details.Recommendations
+	}
+
+	student, err := s.repo.GetStudentByID(ctx, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get student: %w", err)
+	}
+	history, err := s.repo.GetAnalyticsHistory(ctx, studentID, opts.From, opts.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get analytics history: %w", err)
+	}
+
+	return &domain.StudentReport{
+		StudentID:   studentID,
+		Student:     student,
+		Analytics:   analytics,
+		History:     history,
+		GeneratedAt: time.Now(),
+	}, nil
+}
@@ -0,0 +1,222 @@
+package application
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/export"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+)
+
+// Колонки исследовательской выгрузки - для скриптов, а не для Excel,
+// поэтому имена одинаковые на обоих языках.
+var (
+	researchLogColumns       = researchColumns("student", "period", "action_type", "correct", "time_spent_sec")
+	researchAnalyticsColumns = researchColumns("student", "period", "cluster_group", "engagement_score",
+		"avg_time_per_task", "success_rate")
+	researchAggregateColumns = researchColumns("cohort_id", "cluster_group", "students", "avg_engagement_score",
+		"avg_success_rate", "avg_time_per_task")
+)
+
+func researchColumns(names ...string) []export.Column {
+	columns := make([]export.Column, len(names))
+	for i, name := range names {
+		columns[i] = export.Column{RU: name, EN: name}
+	}
+	return columns
+}
+
+// ResearchOptions - PseudonymKey: ключ HMAC; пока он тот же, псевдоним
+// студента одинаков во всех выгрузках и их можно связывать. MinGroupSize
+// - k: группы меньше k студентов не попадают ни в агрегаты, ни в
+// analytics.csv.
+type ResearchOptions struct {
+	PseudonymKey []byte
+	MinGroupSize int
+}
+
+type ResearchServiceImpl struct {
+	repo   interfaces.Repository
+	opts   ResearchOptions
+	logger *slog.Logger
+}
+
+func NewResearchService(repo interfaces.Repository, opts ResearchOptions, logger *slog.Logger) *ResearchServiceImpl {
+	if opts.MinGroupSize <= 0 {
+		opts.MinGroupSize = 5
+	}
+	return &ResearchServiceImpl{repo: repo, opts: opts, logger: logger}
+}
+
+var _ interfaces.ResearchService = (*ResearchServiceImpl)(nil)
+
+// researchManifest описывает выгрузку, чтобы по файлу было видно, как
+// она получена. SuppressedAnalyticsRows - строки analytics.csv студентов
+// из групп меньше k или вне когорт.
+type researchManifest struct {
+	GeneratedAt             time.Time `json:"generated_at"`
+	CohortID                uint64    `json:"cohort_id,omitempty"`
+	From                    time.Time `json:"from"`
+	To                      time.Time `json:"to"`
+	Granularity             string    `json:"granularity"`
+	MinGroupSize            int       `json:"min_group_size"`
+	SuppressedGroups        int       `json:"suppressed_groups"`
+	SuppressedStudents      int       `json:"suppressed_students"`
+	SuppressedAnalyticsRows int       `json:"suppressed_analytics_rows"`
+}
+
+// Export пишет logs.csv и analytics.csv с псевдонимами вместо ID и
+// временем, огрубленным до opts.Granularity, cohort_aggregates.csv со
+// средними по когорте и кластеру и manifest.json. Имен и почты в
+// выгрузке нет: таблица students не читается.
+func (s *ResearchServiceImpl) Export(ctx context.Context, opts domain.ResearchExportOptions, w io.Writer) error {
+	switch opts.Granularity {
+	case "":
+		opts.Granularity = domain.GranularityWeek
+	case domain.GranularityDay, domain.GranularityWeek, domain.GranularityMonth:
+	default:
+		return Validation("invalid_granularity", "granularity must be day, week or month")
+	}
+	if opts.From.After(opts.To) {
+		return Validation("invalid_range", "from must be before to")
+	}
+	// Выгрузка по всем когортам доступна только без ограничения курсом
+	if err := authorizeCohort(ctx, opts.CohortID); err != nil {
+		return err
+	}
+	if opts.CohortID != 0 {
+		cohort, err := s.repo.GetCohortByID(ctx, opts.CohortID)
+		if err != nil {
+			return fmt.Errorf("failed to get cohort: %w", err)
+		}
+		if cohort == nil {
+			return NotFound("cohort_not_found", fmt.Sprintf("cohort %d not found", opts.CohortID))
+		}
+	}
+	aggregates, err := s.repo.GetCohortAggregates(ctx, opts.CohortID, opts.From, opts.To)
+	if err != nil {
+		return fmt.Errorf("failed to get cohort aggregates: %w", err)
+	}
+
+	manifest := researchManifest{
+		GeneratedAt:  time.Now().UTC(),
+		CohortID:     opts.CohortID,
+		From:         opts.From,
+		To:           opts.To,
+		Granularity:  opts.Granularity,
+		MinGroupSize: s.opts.MinGroupSize,
+	}
+	pseudonym := s.pseudonymizer()
+
+	zw := zip.NewWriter(w)
+	err = writeZipTable(zw, "logs.csv", researchLogColumns, func(tw export.TableWriter) error {
+		return s.repo.StreamResearchLogs(ctx, opts.CohortID, opts.From, opts.To, func(l *domain.StudentLog) error {
+			return tw.WriteRow(pseudonym(l.StudentID), coarsenTime(l.Timestamp, opts.Granularity),
+				l.ActionType, l.Correct, l.TimeSpentSec)
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export research logs: %w", err)
+	}
+	err = writeZipTable(zw, "analytics.csv", researchAnalyticsColumns, func(tw export.TableWriter) error {
+		return s.repo.StreamResearchAnalytics(ctx, opts.CohortID, opts.From, opts.To, func(a *domain.ResearchAnalytics) error {
+			// Кластер и оценки студента из малой группы выдали бы то, что
+			// скрыто в агрегатах, поэтому строка не пишется
+			if a.GroupSize < s.opts.MinGroupSize {
+				manifest.SuppressedAnalyticsRows++
+				return nil
+			}
+			return tw.WriteRow(pseudonym(a.StudentID), coarsenTime(a.AnalyzedAt, opts.Granularity),
+				a.ClusterGroup, a.EngagementScore, a.AvgTimePerTask, a.SuccessRate)
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export research analytics: %w", err)
+	}
+	// Итогов по когорте нет намеренно: вычитанием кластеров из итога
+	// можно было бы восстановить скрытую малую группу
+	err = writeZipTable(zw, "cohort_aggregates.csv", researchAggregateColumns, func(tw export.TableWriter) error {
+		for _, a := range aggregates {
+			if a.Students < s.opts.MinGroupSize {
+				manifest.SuppressedGroups++
+				manifest.SuppressedStudents += a.Students
+				continue
+			}
+			if err := tw.WriteRow(a.CohortID, a.ClusterGroup, a.Students, a.AvgEngagement, a.AvgSuccessRate, a.AvgTimePerTask); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := writeZipJSON(zw, "manifest.json", manifest); err != nil {
+		return err
+	}
+
+	s.logger.InfoContext(ctx, "research export generated",
+		slog.Uint64("cohort_id", opts.CohortID),
+		slog.String("granularity", opts.Granularity),
+		slog.Int("suppressed_groups", manifest.SuppressedGroups),
+		slog.Int("suppressed_analytics_rows", manifest.SuppressedAnalyticsRows))
+	return zw.Close()
+}
+
+func writeZipTable(zw *zip.Writer, name string, columns []export.Column, write func(tw export.TableWriter) error) error {
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	tw, err := export.NewTableWriter(domain.ExportCSV, domain.LangEN, columns, f)
+	if err != nil {
+		return err
+	}
+	if err := write(tw); err != nil {
+		tw.Abort()
+		return err
+	}
+	return tw.Close()
+}
+
+// pseudonymizer возвращает HMAC-SHA256 от ID студента, усеченный до 128
+// бит. Строки идут подряд по студенту, поэтому последний псевдоним
+// запоминается.
+func (s *ResearchServiceImpl) pseudonymizer() func(studentID uint64) string {
+	var (
+		lastID        uint64
+		lastPseudonym string
+	)
+	return func(studentID uint64) string {
+		if studentID == lastID && lastPseudonym != "" {
+			return lastPseudonym
+		}
+		mac := hmac.New(sha256.New, s.opts.PseudonymKey)
+		mac.Write([]byte(strconv.FormatUint(studentID, 10)))
+		lastID, lastPseudonym = studentID, hex.EncodeToString(mac.Sum(nil)[:16])
+		return lastPseudonym
+	}
+}
+
+// coarsenTime заменяет время началом дня, недели (с понедельника) или
+// месяца в UTC.
+func coarsenTime(t time.Time, granularity string) string {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch granularity {
+	case domain.GranularityWeek:
+		day = day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case domain.GranularityMonth:
+		day = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return day.Format(time.DateOnly)
+}
@@ -0,0 +1,147 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/metrics"
+)
+
+// WebhookDispatcherOptions - Timeout - таймаут одного запроса к
+// подписчику; из него и BatchSize считается lease.
+type WebhookDispatcherOptions struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	PollInterval   time.Duration
+	BatchSize      int
+	Timeout        time.Duration
+}
+
+// leaseMargin - запас lease на запись результатов пачки.
+const leaseMargin = time.Minute
+
+// WebhookDispatcher отправляет доставки из очереди в webhook_deliveries.
+// Очередь общая для реплик, поэтому dispatcher запускается на каждой.
+type WebhookDispatcher struct {
+	repo   interfaces.Repository
+	sender interfaces.WebhookSender
+	opts   WebhookDispatcherOptions
+	lease  time.Duration
+	logger *slog.Logger
+}
+
+func NewWebhookDispatcher(repo interfaces.Repository, sender interfaces.WebhookSender, opts WebhookDispatcherOptions, logger *slog.Logger) *WebhookDispatcher {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 50
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 1
+	}
+	// Пачка отправляется последовательно: на время lease взятая доставка
+	// скрыта от других реплик, и последняя в пачке должна успеть до его
+	// истечения, даже если все перед ней ждали таймаута
+	lease := time.Duration(opts.BatchSize)*opts.Timeout + leaseMargin
+	return &WebhookDispatcher{repo: repo, sender: sender, opts: opts, lease: lease, logger: logger}
+}
+
+// Run опрашивает очередь до отмены ctx.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		// Полная пачка - скорее всего, в очереди есть еще
+		for {
+			n, err := d.DeliverDue(ctx)
+			if err != nil && ctx.Err() == nil {
+				d.logger.ErrorContext(ctx, "failed to deliver webhooks", slog.String("error", err.Error()))
+			}
+			if err != nil || n < d.opts.BatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue делает один проход по очереди и возвращает число попыток.
+func (d *WebhookDispatcher) DeliverDue(ctx context.Context) (int, error) {
+	tasks, err := d.repo.ClaimWebhookDeliveries(ctx, d.opts.BatchSize, d.lease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	for _, task := range tasks {
+		if err := d.attempt(ctx, task); err != nil {
+			return 0, err
+		}
+	}
+	return len(tasks), nil
+}
+
+func (d *WebhookDispatcher) attempt(ctx context.Context, task *domain.WebhookDeliveryTask) error {
+	delivery := task.Delivery
+	status, sendErr := d.sender.Send(ctx, task)
+	if sendErr != nil && ctx.Err() != nil {
+		// Остановка сервиса: доставку возьмут снова после lease
+		return ctx.Err()
+	}
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastStatusCode = status
+	result := "success"
+	switch {
+	case sendErr == nil:
+		delivery.Status = domain.DeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= d.opts.MaxAttempts:
+		result = "failed"
+		delivery.Status = domain.DeliveryFailed
+		delivery.LastError = sendErr.Error()
+	default:
+		result = "retry"
+		delivery.LastError = sendErr.Error()
+		delivery.NextAttemptAt = now.Add(d.backoff(delivery.Attempts))
+	}
+	metrics.WebhookDeliveriesTotal.WithLabelValues(delivery.Event, result).Inc()
+	if sendErr != nil {
+		d.logger.WarnContext(ctx, "webhook delivery failed",
+			slog.Uint64("delivery_id", delivery.ID),
+			slog.Uint64("subscription_id", delivery.SubscriptionID),
+			slog.Int("attempt", delivery.Attempts),
+			slog.String("error", sendErr.Error()),
+		)
+	}
+
+	saved, err := d.repo.UpdateWebhookDelivery(ctx, task)
+	if err != nil {
+		return fmt.Errorf("failed to save webhook delivery %d: %w", delivery.ID, err)
+	}
+	if !saved {
+		// Результат теперь запишет тот, кто взял доставку после нас
+		d.logger.WarnContext(ctx, "webhook delivery claim lost, result discarded",
+			slog.Uint64("delivery_id", delivery.ID),
+			slog.String("result", result),
+		)
+	}
+	return nil
+}
+
+// backoff - пауза перед следующей попыткой: InitialBackoff, удваиваясь
+// после каждой неудачи, но не больше MaxBackoff.
+func (d *WebhookDispatcher) backoff(attempts int) time.Duration {
+	wait := d.opts.InitialBackoff
+	for i := 1; i < attempts && wait < d.opts.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, d.opts.MaxBackoff)
+}
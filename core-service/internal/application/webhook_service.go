@@ -0,0 +1,198 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/webhook"
+)
+
+const (
+	minWebhookSecret = 16
+	maxWebhookURL    = 2048
+	// maxDeliveriesPage - больше записей журнала за один запрос не отдаем
+	maxDeliveriesPage = 500
+)
+
+// webhookEnvelope - тело запроса к подписчику.
+type webhookEnvelope struct {
+	Event      string    `json:"event"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+// WebhookOptions - AllowPrivateNetworks разрешает подписки на адреса во
+// внутренней сети; по умолчанию они отклоняются.
+type WebhookOptions struct {
+	AllowPrivateNetworks bool
+}
+
+type WebhookServiceImpl struct {
+	repo   interfaces.Repository
+	opts   WebhookOptions
+	logger *slog.Logger
+}
+
+func NewWebhookService(repo interfaces.Repository, opts WebhookOptions, logger *slog.Logger) *WebhookServiceImpl {
+	return &WebhookServiceImpl{repo: repo, opts: opts, logger: logger}
+}
+
+var (
+	_ interfaces.WebhookService = (*WebhookServiceImpl)(nil)
+	_ interfaces.Notifier       = (*WebhookServiceImpl)(nil)
+)
+
+// CreateSubscription генерирует секрет, если он не передан. Секрет
+// остается в sub и больше нигде не отдается.
+func (s *WebhookServiceImpl) CreateSubscription(ctx context.Context, sub *domain.WebhookSubscription) error {
+	if err := s.validateSubscription(ctx, sub); err != nil {
+		return err
+	}
+	if sub.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+		sub.Secret = hex.EncodeToString(secret)
+	}
+	slices.Sort(sub.Events)
+	sub.Events = slices.Compact(sub.Events)
+
+	if err := s.repo.CreateWebhookSubscription(ctx, sub); err != nil {
+		return fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+	s.logger.InfoContext(ctx, "webhook subscription created",
+		slog.Uint64("subscription_id", sub.ID),
+		slog.Any("events", sub.Events),
+	)
+	return nil
+}
+
+func (s *WebhookServiceImpl) validateSubscription(ctx context.Context, sub *domain.WebhookSubscription) error {
+	var fields []FieldError
+	add := func(field, code, message string) {
+		fields = append(fields, FieldError{Field: field, Code: code, Message: message})
+	}
+
+	if sub.URL == "" {
+		add("url", "required", "url is required")
+	} else if u, err := url.Parse(sub.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		add("url", "invalid_format", "url must be an absolute http or https URL")
+	} else if len(sub.URL) > maxWebhookURL {
+		add("url", "too_long", fmt.Sprintf("url must be at most %d characters", maxWebhookURL))
+	} else if !s.opts.AllowPrivateNetworks && webhook.CheckHost(ctx, u.Hostname()) != nil {
+		// Иначе подпиской можно было бы заставить сервис ходить во
+		// внутреннюю сеть и к метаданным облака
+		add("url", "forbidden_destination", "url must point to a public address")
+	}
+
+	if len(sub.Events) == 0 {
+		add("events", "required", "at least one event is required")
+	}
+	for _, event := range sub.Events {
+		if !slices.Contains(domain.WebhookEvents(), event) {
+			add("events", "unknown_value", fmt.Sprintf("unknown event %q", event))
+		}
+	}
+
+	if sub.Secret != "" && len(sub.Secret) < minWebhookSecret {
+		add("secret", "too_short", fmt.Sprintf("secret must be at least %d characters", minWebhookSecret))
+	}
+
+	if len(fields) > 0 {
+		return InvalidFields("invalid_webhook_subscription", "webhook subscription is invalid", fields)
+	}
+	return nil
+}
+
+func (s *WebhookServiceImpl) ListSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error) {
+	return s.repo.ListWebhookSubscriptions(ctx)
+}
+
+func (s *WebhookServiceImpl) DeleteSubscription(ctx context.Context, id uint64) error {
+	sub, err := s.repo.GetWebhookSubscription(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+	if sub == nil {
+		return NotFound("webhook_not_found", fmt.Sprintf("webhook subscription %d not found", id))
+	}
+	// Журнал доставок подписки удаляется вместе с ней
+	return s.repo.DeleteWebhookSubscription(ctx, id)
+}
+
+// Publish только ставит доставки в очередь: отправляет их WebhookDispatcher,
+// так что медленный подписчик не задерживает запрос, породивший событие.
+func (s *WebhookServiceImpl) Publish(ctx context.Context, event string, data any) error {
+	return s.enqueue(ctx, event, "", data)
+}
+
+func (s *WebhookServiceImpl) enqueue(ctx context.Context, event, key string, data any) error {
+	payload, err := json.Marshal(webhookEnvelope{Event: event, OccurredAt: time.Now().UTC(), Data: data})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", event, err)
+	}
+	n, err := s.repo.EnqueueWebhookDeliveries(ctx, event, key, payload)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue %s deliveries: %w", event, err)
+	}
+	if n > 0 {
+		s.logger.DebugContext(ctx, "webhook deliveries enqueued", slog.String("event", event), slog.Int("count", n))
+	}
+	return nil
+}
+
+// AnalyticsUpdated вызывается на каждой реплике по одному и тому же
+// NOTIFY: ключ из студента и времени анализа оставляет одну доставку.
+func (s *WebhookServiceImpl) AnalyticsUpdated(ctx context.Context, studentID uint64) error {
+	analytics, err := s.repo.GetAnalyticsByStudentID(ctx, studentID)
+	if err != nil {
+		return fmt.Errorf("failed to get analytics: %w", err)
+	}
+	if analytics == nil {
+		return nil
+	}
+	key := fmt.Sprintf("%d@%s", studentID, analytics.AnalyzedAt.UTC().Format(time.RFC3339Nano))
+	return s.enqueue(ctx, domain.EventAnalyticsUpdated, key, analytics)
+}
+
+// Name и Notify подключают подписки к оповещениям как еще один канал
+// доставки: новое оповещение становится событием alert.raised.
+func (s *WebhookServiceImpl) Name() string {
+	return "webhooks"
+}
+
+func (s *WebhookServiceImpl) Notify(ctx context.Context, alert *domain.Alert) error {
+	return s.Publish(ctx, domain.EventAlertRaised, alert)
+}
+
+func (s *WebhookServiceImpl) ListDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error) {
+	if filter.Limit <= 0 || filter.Limit > maxDeliveriesPage {
+		filter.Limit = maxDeliveriesPage
+	}
+	return s.repo.ListWebhookDeliveries(ctx, filter)
+}
+
+func (s *WebhookServiceImpl) Redeliver(ctx context.Context, id uint64) (*domain.WebhookDelivery, error) {
+	delivery, err := s.repo.GetWebhookDelivery(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	if delivery == nil {
+		return nil, NotFound("webhook_delivery_not_found", fmt.Sprintf("webhook delivery %d not found", id))
+	}
+	if err := s.repo.RequeueWebhookDelivery(ctx, id, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to requeue webhook delivery: %w", err)
+	}
+	s.logger.InfoContext(ctx, "webhook delivery requeued", slog.Uint64("delivery_id", id))
+	return s.repo.GetWebhookDelivery(ctx, id)
+}
@@ -0,0 +1,192 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/xapi"
+)
+
+// xapiFields переводит поля лога в пути statement'а, чтобы ошибка
+// указывала туда, где ее можно исправить.
+var xapiFields = map[string]string{
+	"student_id":             "actor.account.name",
+	"material_id":            "object.id",
+	"time_spent_sec":         "result.duration",
+	"time_spent_on_mat":      "result.duration",
+	"time_spent_on_question": "result.duration",
+	"selected_distractor":    "result.response",
+	"timestamp":              "timestamp",
+}
+
+type XAPIServiceImpl struct {
+	repo      interfaces.Repository
+	analytics interfaces.AnalyticsService
+	mapper    *xapi.Mapper
+	logger    *slog.Logger
+}
+
+func NewXAPIService(
+	repo interfaces.Repository,
+	analytics interfaces.AnalyticsService,
+	mapper *xapi.Mapper,
+	logger *slog.Logger,
+) interfaces.XAPIService {
+	return &XAPIServiceImpl{
+		repo:      repo,
+		analytics: analytics,
+		mapper:    mapper,
+		logger:    logger,
+	}
+}
+
+type preparedStatement struct {
+	record *domain.XAPIStatement
+	log    *domain.StudentLog
+}
+
+// SaveStatements сначала проверяет весь пакет и при любой ошибке не
+// сохраняет ничего, как это делает LRS. Statement с глаголом без
+// сопоставления хранится, но логом не становится. Повторно присланный
+// statement (тот же id) пропускается, если его лог уже отправлен, поэтому
+// источники могут безопасно переотправлять пакеты после сбоя: лог, который
+// в прошлый раз не отправился, будет отправлен.
+func (s *XAPIServiceImpl) SaveStatements(ctx context.Context, statements []json.RawMessage) ([]string, error) {
+	if len(statements) == 0 {
+		return nil, Validation("no_statements", "request contains no statements")
+	}
+
+	now := time.Now()
+	prepared := make([]preparedStatement, 0, len(statements))
+	seen := make(map[string]struct{}, len(statements))
+	var fields []FieldError
+	for i, raw := range statements {
+		p, errs := s.prepare(raw, now)
+		for _, f := range errs {
+			f.Field = fmt.Sprintf("statements[%d].%s", i, f.Field)
+			fields = append(fields, f)
+		}
+		if p == nil {
+			continue
+		}
+		if _, dup := seen[p.record.ID]; dup {
+			fields = append(fields, FieldError{Field: fmt.Sprintf("statements[%d].id", i), Code: "duplicate", Message: "statement id is repeated in the request"})
+			continue
+		}
+		seen[p.record.ID] = struct{}{}
+		prepared = append(prepared, *p)
+	}
+	if len(fields) > 0 {
+		return nil, InvalidFields("invalid_statement", "xAPI statements are invalid", fields)
+	}
+
+	// Студенты вне курсов ключа отклоняются до записи, иначе statement
+	// сохранился бы без лога
+	for _, p := range prepared {
+		if p.log == nil {
+			continue
+		}
+		if err := authorizeStudent(ctx, s.repo, p.log.StudentID); err != nil {
+			return nil, err
+		}
+	}
+
+	ids := make([]string, 0, len(prepared))
+	for _, p := range prepared {
+		ids = append(ids, p.record.ID)
+		pending, err := s.repo.SaveXAPIStatement(ctx, p.record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save xapi statement: %w", err)
+		}
+		if !pending {
+			s.logger.DebugContext(ctx, "xapi statement already stored", slog.String("statement_id", p.record.ID))
+			continue
+		}
+		if err := s.analytics.SendLog(ctx, p.log); err != nil {
+			return nil, err
+		}
+		// Если отметка не записалась, повтор пакета отправит лог еще раз:
+		// лучше дубль, чем потерянное действие
+		if err := s.repo.MarkXAPILogSent(ctx, p.record.ID); err != nil {
+			return nil, fmt.Errorf("failed to mark xapi log as sent: %w", err)
+		}
+	}
+	return ids, nil
+}
+
+func (s *XAPIServiceImpl) prepare(raw json.RawMessage, now time.Time) (*preparedStatement, []FieldError) {
+	var st xapi.Statement
+	if err := json.Unmarshal(raw, &st); err != nil {
+		return nil, []FieldError{{Field: "statement", Code: "invalid_json", Message: err.Error()}}
+	}
+	if st.Verb.ID == "" {
+		return nil, []FieldError{{Field: "verb.id", Code: "required", Message: "verb.id is required"}}
+	}
+
+	switch {
+	case st.ID == "":
+		st.ID = xapi.NewID()
+		withID, err := xapi.WithID(raw, st.ID)
+		if err != nil {
+			return nil, []FieldError{{Field: "statement", Code: "invalid_json", Message: err.Error()}}
+		}
+		raw = withID
+	case !xapi.ValidID(st.ID):
+		return nil, []FieldError{{Field: "id", Code: "invalid_format", Message: "id must be a UUID"}}
+	}
+
+	log, err := s.mapper.ToLog(&st)
+	switch {
+	case errors.Is(err, xapi.ErrUnsupportedVerb):
+		log = nil
+	case err != nil:
+		return nil, []FieldError{{Field: "statement", Code: "unmappable", Message: err.Error()}}
+	}
+
+	record := &domain.XAPIStatement{
+		ID:        st.ID,
+		VerbID:    st.Verb.ID,
+		Raw:       raw,
+		Timestamp: now,
+		StoredAt:  now,
+	}
+	if log == nil {
+		// Без сопоставленного действия время берется из statement, если оно есть
+		if ts, err := time.Parse(time.RFC3339Nano, st.Timestamp); err == nil {
+			record.Timestamp = ts
+		}
+		return &preparedStatement{record: record}, nil
+	}
+
+	// По спецификации timestamp без значения равен времени сохранения
+	if log.Timestamp.IsZero() {
+		log.Timestamp = now
+	}
+	if err := ValidateLog(log, now); err != nil {
+		appErr, _ := AsError(err)
+		var fields []FieldError
+		reported := make(map[string]bool)
+		for _, f := range appErr.Fields {
+			if path, ok := xapiFields[f.Field]; ok {
+				f.Field = path
+			}
+			// Все три времени лога берутся из result.duration
+			if !reported[f.Field] {
+				reported[f.Field] = true
+				fields = append(fields, f)
+			}
+		}
+		return nil, fields
+	}
+
+	record.StudentID = log.StudentID
+	record.ActionType = log.ActionType
+	record.Timestamp = log.Timestamp
+	return &preparedStatement{record: record, log: log}, nil
+}
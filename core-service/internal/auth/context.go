@@ -0,0 +1,34 @@
+// Package auth переносит сессию пользователя и API-ключ интеграции через
+// context.Context от транспорта до сервисов.
+package auth
+
+import (
+	"context"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+)
+
+type sessionKey struct{}
+
+func WithSession(ctx context.Context, session *domain.Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, session)
+}
+
+// SessionFrom возвращает сессию или nil, если запрос пришел без нее
+// (внутренние вызовы, gRPC, прием логов).
+func SessionFrom(ctx context.Context) *domain.Session {
+	session, _ := ctx.Value(sessionKey{}).(*domain.Session)
+	return session
+}
+
+type apiKeyKey struct{}
+
+func WithAPIKey(ctx context.Context, key *domain.APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyKey{}, key)
+}
+
+// APIKeyFrom возвращает API-ключ, с которым пришел запрос, или nil.
+func APIKeyFrom(ctx context.Context) *domain.APIKey {
+	key, _ := ctx.Value(apiKeyKey{}).(*domain.APIKey)
+	return key
+}
@@ -1,6 +1,11 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type Config struct {
 	HTTPPort    string
@@ -9,12 +14,220 @@ type Config struct {
 	RedisAddr   string
 	KafkaAddr   string
 	AnalyticsAddr string
+
+	// ShutdownTimeout ограничивает всю остановку: дренаж запросов, воркеров и сброс Kafka.
+	ShutdownTimeout time.Duration
+
+	// TrustedProxies - адреса и подсети прокси (nginx), чьим X-Forwarded-For
+	// и X-Real-IP верим. Пусто - IP клиента берется из соединения, иначе
+	// любой обошел бы лимиты и подменил IP в журнале своим заголовком.
+	TrustedProxies []string
+
+	AnalyticsClient AnalyticsClientConfig
+	TLS             TLSConfig
+	Health          HealthConfig
+	Tracing         TracingConfig
+	Log             LogConfig
+	Import          ImportConfig
+	XAPI            XAPIConfig
+	LTI             LTIConfig
+	Scheduler       SchedulerConfig
+	Alerts          AlertsConfig
+	Webhooks        WebhooksConfig
+	Cache           CacheConfig
+	RateLimit       RateLimitConfig
+	APIKeys         APIKeysConfig
+	Audit           AuditConfig
+	Research        ResearchConfig
+}
+
+// ResearchConfig - псевдонимизированные выгрузки для исследований.
+// PseudonymKey - ключ HMAC для псевдонимов студентов; пустой ключ
+// отключает выгрузку. MinGroupSize - k: агрегаты по группам меньше k
+// студентов не выгружаются.
+type ResearchConfig struct {
+	PseudonymKey string
+	MinGroupSize int
+}
+
+// AuditConfig - журнал доступа к данным студентов. Retention - срок
+// хранения записей (0 - бессрочно), RetentionCron - когда удалять старые
+// записи (задача планировщика; пустое расписание ее отключает).
+type AuditConfig struct {
+	Enabled       bool
+	Retention     time.Duration
+	RetentionCron string
+}
+
+// APIKeysConfig - ключи интеграций (Authorization: ApiKey). RequiredScopes
+// - scope, без ключа с которыми запрос отклоняется, если у него нет и
+// сессии преподавателя; например, ingest:logs закрывает прием логов от
+// анонимов. RotationGrace - сколько старый ключ живет после ротации.
+// CacheTTL - сколько реплика помнит результат проверки ключа; отзыв на
+// другой реплике вступает в силу не позже.
+type APIKeysConfig struct {
+	RequiredScopes []string
+	RotationGrace  time.Duration
+	CacheTTL       time.Duration
+}
+
+// RateLimitConfig - ограничение частоты запросов на клиента (сессия
+// преподавателя, иначе IP) по группам: прием логов (/api/log, /api/import,
+// /api/xapi), остальной HTTP API и gRPC. Rate - запросов в секунду, Burst -
+// сколько можно отправить подряд; Rate = 0 снимает ограничение с группы.
+type RateLimitConfig struct {
+	Enabled        bool
+	IngestRate     float64
+	IngestBurst    int
+	DashboardRate  float64
+	DashboardBurst int
+	GRPCRate       float64
+	GRPCBurst      int
+}
+
+// CacheConfig - Backend: redis, memory (LRU в памяти процесса, без Redis;
+// для разработки и тестов - реплики его не разделяют) или tiered (LRU
+// перед Redis, LocalTTL - срок локальной копии). Дальше - сроки жизни
+// аналитики: свежая запись, копия для ответов со stale: true и блокировка
+// загрузки между репликами. StudentsTTL и LogsTTL - сроки списков
+// студентов и выборок логов.
+type CacheConfig struct {
+	Backend           string
+	LocalMaxEntries   int
+	LocalTTL          time.Duration
+	AnalyticsTTL      time.Duration
+	AnalyticsStaleTTL time.Duration
+	LockTTL           time.Duration
+	StudentsTTL       time.Duration
+	LogsTTL           time.Duration
+}
+
+// WebhooksConfig - доставка событий подписчикам. Неудачная попытка
+// повторяется через InitialBackoff, 2*InitialBackoff и так далее до
+// MaxBackoff; после MaxAttempts попыток доставка помечается failed.
+// AllowPrivateNetworks разрешает подписчиков во внутренней сети.
+type WebhooksConfig struct {
+	Timeout              time.Duration
+	MaxAttempts          int
+	InitialBackoff       time.Duration
+	MaxBackoff           time.Duration
+	PollInterval         time.Duration
+	AllowPrivateNetworks bool
+}
+
+// AlertsConfig - каналы доставки оповещений. Пустой WebhookURL или
+// SMTPAddr отключает канал; список в дашборде работает всегда.
+// InactivityCron - когда проверять правила неактивности. NotifyQueue -
+// сколько оповещений ждут рассылки, прежде чем новые начнут пропускаться.
+type AlertsConfig struct {
+	InactivityCron string
+	NotifyQueue    int
+	WebhookURL     string
+	WebhookTimeout time.Duration
+	SMTPAddr       string
+	SMTPFrom       string
+	SMTPTo         []string
+	SMTPUsername   string
+	SMTPPassword   string
+}
+
+// SchedulerConfig - фоновые задачи по расписанию (cron из 5 полей, можно
+// с префиксом CRON_TZ=). Пустое расписание отключает задачу.
+// ReanalysisRate - сколько команд analyze_student в секунду отправляет
+// ночной переанализ.
+type SchedulerConfig struct {
+	Enabled        bool
+	ReanalysisCron string
+	ReanalysisRate float64
+}
+
+// LTIConfig - вход преподавателей из LMS по LTI 1.3. Пустой Issuer
+// отключает LTI. KeySetFile - JWKS платформы, LaunchURL - наш адрес
+// приема запуска (redirect_uri), зарегистрированный в платформе.
+// RequireSession (по умолчанию включен) закрывает дашбордные эндпоинты
+// от запросов без сессии; без LTI дашборд открыт, сессий нет.
+type LTIConfig struct {
+	Issuer         string
+	ClientID       string
+	DeploymentIDs  []string
+	AuthLoginURL   string
+	KeySetFile     string
+	LaunchURL      string
+	DashboardURL   string
+	SessionTTL     time.Duration
+	CookieSecure   bool
+	RequireSession bool
+}
+
+// XAPIConfig - MappingFile дополняет стандартное сопоставление глаголов и
+// типов активностей xAPI с действиями (формат - см. xapi.LoadMapping).
+type XAPIConfig struct {
+	MappingFile string
+}
+
+// ImportConfig - загрузка исторических логов. MaxUploadBytes ограничивает
+// файл, принимаемый через HTTP; cmd/import читает файлы любого размера.
+type ImportConfig struct {
+	BatchSize      int
+	MaxUploadBytes int64
+}
+
+// LogConfig - Level: debug, info, warn, error; Format: json или text.
+type LogConfig struct {
+	Level  string
+	Format string
+}
+
+// TracingConfig - экспорт трасс OpenTelemetry. Exporter: none, stdout или otlp.
+type TracingConfig struct {
+	ServiceName  string
+	Exporter     string
+	OTLPEndpoint string
+	OTLPInsecure bool
+	SampleRatio  float64
+}
+
+type HealthConfig struct {
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+// TLSConfig - TLS для собственных gRPC и HTTP серверов. Если задан
+// ClientCAFile, gRPC-сервер требует клиентский сертификат (mTLS).
+type TLSConfig struct {
+	Enabled        bool
+	CertFile       string
+	KeyFile        string
+	ClientCAFile   string
+	ReloadInterval time.Duration
+}
+
+// AnalyticsClientConfig - настройки gRPC-клиента к Python analytics-service.
+type AnalyticsClientConfig struct {
+	CallTimeout time.Duration
+
+	MaxAttempts       int
+	InitialBackoff    time.Duration
+	MaxBackoff        time.Duration
+	BackoffMultiplier float64
+
+	BreakerFailureThreshold int
+	BreakerOpenTimeout      time.Duration
+
+	KeepaliveTime    time.Duration
+	KeepaliveTimeout time.Duration
+
+	TLSEnabled    bool
+	TLSCertFile   string
+	TLSKeyFile    string
+	TLSCAFile     string
+	TLSServerName string
 }
 
 func LoadConfig() *Config {
     user := getEnv("DB_USER", "admin")
     pass := getEnv("DB_PASSWORD", "admin_password")
-    host := getEnv("DB_HOST", "student-analytics-postgres") 
+    host := getEnv("DB_HOST", "student-analytics-postgres")
     port := getEnv("DB_PORT", "5432")
     name := getEnv("DB_NAME", "student_analytics")
 
@@ -27,6 +240,139 @@ func LoadConfig() *Config {
         RedisAddr:     getEnv("REDIS_HOST", "redis") + ":" + getEnv("REDIS_PORT", "6379"),
         KafkaAddr:     getEnv("KAFKA_BOOTSTRAP_SERVERS", "kafka:9094"),
         AnalyticsAddr: getEnv("ANALYTICS_GRPC_HOST", "analytics-service") + ":" + getEnv("ANALYTICS_GRPC_PORT", "50052"),
+
+        ShutdownTimeout: getEnvDuration("SHUTDOWN_TIMEOUT", 15*time.Second),
+        TrustedProxies:  getEnvList("HTTP_TRUSTED_PROXIES"),
+
+        AnalyticsClient: AnalyticsClientConfig{
+            CallTimeout:             getEnvDuration("ANALYTICS_CALL_TIMEOUT", 10*time.Second),
+            MaxAttempts:             getEnvInt("ANALYTICS_RETRY_MAX_ATTEMPTS", 3),
+            InitialBackoff:          getEnvDuration("ANALYTICS_RETRY_INITIAL_BACKOFF", 200*time.Millisecond),
+            MaxBackoff:              getEnvDuration("ANALYTICS_RETRY_MAX_BACKOFF", 2*time.Second),
+            BackoffMultiplier:       getEnvFloat("ANALYTICS_RETRY_BACKOFF_MULTIPLIER", 2),
+            BreakerFailureThreshold: getEnvInt("ANALYTICS_BREAKER_FAILURES", 5),
+            BreakerOpenTimeout:      getEnvDuration("ANALYTICS_BREAKER_OPEN_TIMEOUT", 30*time.Second),
+            KeepaliveTime:           getEnvDuration("ANALYTICS_KEEPALIVE_TIME", 5*time.Minute),
+            KeepaliveTimeout:        getEnvDuration("ANALYTICS_KEEPALIVE_TIMEOUT", 20*time.Second),
+            TLSEnabled:              getEnvBool("ANALYTICS_TLS_ENABLED", false),
+            TLSCertFile:             getEnv("ANALYTICS_TLS_CERT_FILE", ""),
+            TLSKeyFile:              getEnv("ANALYTICS_TLS_KEY_FILE", ""),
+            TLSCAFile:               getEnv("ANALYTICS_TLS_CA_FILE", ""),
+            TLSServerName:           getEnv("ANALYTICS_TLS_SERVER_NAME", ""),
+        },
+
+        TLS: TLSConfig{
+            Enabled:        getEnvBool("TLS_ENABLED", false),
+            CertFile:       getEnv("TLS_CERT_FILE", ""),
+            KeyFile:        getEnv("TLS_KEY_FILE", ""),
+            ClientCAFile:   getEnv("TLS_CLIENT_CA_FILE", ""),
+            ReloadInterval: getEnvDuration("TLS_RELOAD_INTERVAL", 30*time.Second),
+        },
+
+        Health: HealthConfig{
+            Interval: getEnvDuration("HEALTH_CHECK_INTERVAL", 10*time.Second),
+            Timeout:  getEnvDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+        },
+
+        Tracing: TracingConfig{
+            ServiceName:  getEnv("OTEL_SERVICE_NAME", "core-service"),
+            Exporter:     getEnv("TRACING_EXPORTER", "none"),
+            OTLPEndpoint: getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "localhost:4317"),
+            OTLPInsecure: getEnvBool("OTEL_EXPORTER_OTLP_INSECURE", true),
+            SampleRatio:  getEnvFloat("TRACING_SAMPLE_RATIO", 1),
+        },
+
+        Log: LogConfig{
+            Level:  getEnv("LOG_LEVEL", "info"),
+            Format: getEnv("LOG_FORMAT", "json"),
+        },
+
+        Import: ImportConfig{
+            BatchSize:      getEnvInt("IMPORT_BATCH_SIZE", 500),
+            MaxUploadBytes: int64(getEnvInt("IMPORT_MAX_UPLOAD_MB", 50)) << 20,
+        },
+
+        XAPI: XAPIConfig{
+            MappingFile: getEnv("XAPI_MAPPING_FILE", ""),
+        },
+
+        LTI: LTIConfig{
+            Issuer:         getEnv("LTI_ISSUER", ""),
+            ClientID:       getEnv("LTI_CLIENT_ID", ""),
+            DeploymentIDs:  getEnvList("LTI_DEPLOYMENT_IDS"),
+            AuthLoginURL:   getEnv("LTI_AUTH_LOGIN_URL", ""),
+            KeySetFile:     getEnv("LTI_KEYSET_FILE", ""),
+            LaunchURL:      getEnv("LTI_LAUNCH_URL", ""),
+            DashboardURL:   getEnv("LTI_DASHBOARD_URL", "/"),
+            SessionTTL:     getEnvDuration("SESSION_TTL", 8*time.Hour),
+            CookieSecure:   getEnvBool("SESSION_COOKIE_SECURE", true),
+            RequireSession: getEnvBool("AUTH_REQUIRE_SESSION", true),
+        },
+
+        Scheduler: SchedulerConfig{
+            Enabled:        getEnvBool("SCHEDULER_ENABLED", true),
+            ReanalysisCron: getEnv("SCHEDULER_REANALYSIS_CRON", "0 2 * * *"),
+            ReanalysisRate: getEnvFloat("SCHEDULER_REANALYSIS_RATE", 5),
+        },
+
+        Alerts: AlertsConfig{
+            InactivityCron: getEnv("ALERT_INACTIVITY_CRON", "0 7 * * *"),
+            NotifyQueue:    getEnvInt("ALERT_NOTIFY_QUEUE", 1000),
+            WebhookURL:     getEnv("ALERT_WEBHOOK_URL", ""),
+            WebhookTimeout: getEnvDuration("ALERT_WEBHOOK_TIMEOUT", 10*time.Second),
+            SMTPAddr:       getEnv("ALERT_SMTP_ADDR", ""),
+            SMTPFrom:       getEnv("ALERT_SMTP_FROM", "teacher-analytics@localhost"),
+            SMTPTo:         getEnvList("ALERT_SMTP_TO"),
+            SMTPUsername:   getEnv("ALERT_SMTP_USERNAME", ""),
+            SMTPPassword:   getEnv("ALERT_SMTP_PASSWORD", ""),
+        },
+
+        Cache: CacheConfig{
+            Backend:           getEnv("CACHE_BACKEND", "redis"),
+            LocalMaxEntries:   getEnvInt("CACHE_LOCAL_MAX_ENTRIES", 10000),
+            LocalTTL:          getEnvDuration("CACHE_LOCAL_TTL", 30*time.Second),
+            AnalyticsTTL:      getEnvDuration("CACHE_ANALYTICS_TTL", 5*time.Minute),
+            AnalyticsStaleTTL: getEnvDuration("CACHE_ANALYTICS_STALE_TTL", 24*time.Hour),
+            LockTTL:           getEnvDuration("CACHE_LOCK_TTL", 5*time.Second),
+            StudentsTTL:       getEnvDuration("CACHE_STUDENTS_TTL", 5*time.Minute),
+            LogsTTL:           getEnvDuration("CACHE_LOGS_TTL", 5*time.Minute),
+        },
+
+        Webhooks: WebhooksConfig{
+            Timeout:              getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+            MaxAttempts:          getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+            InitialBackoff:       getEnvDuration("WEBHOOK_INITIAL_BACKOFF", 30*time.Second),
+            MaxBackoff:           getEnvDuration("WEBHOOK_MAX_BACKOFF", time.Hour),
+            PollInterval:         getEnvDuration("WEBHOOK_POLL_INTERVAL", 2*time.Second),
+            AllowPrivateNetworks: getEnvBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+        },
+
+        RateLimit: RateLimitConfig{
+            Enabled:        getEnvBool("RATE_LIMIT_ENABLED", true),
+            IngestRate:     getEnvFloat("RATE_LIMIT_INGEST_RATE", 50),
+            IngestBurst:    getEnvInt("RATE_LIMIT_INGEST_BURST", 100),
+            DashboardRate:  getEnvFloat("RATE_LIMIT_DASHBOARD_RATE", 20),
+            DashboardBurst: getEnvInt("RATE_LIMIT_DASHBOARD_BURST", 60),
+            GRPCRate:       getEnvFloat("RATE_LIMIT_GRPC_RATE", 20),
+            GRPCBurst:      getEnvInt("RATE_LIMIT_GRPC_BURST", 40),
+        },
+
+        APIKeys: APIKeysConfig{
+            RequiredScopes: getEnvList("API_KEY_REQUIRED_SCOPES"),
+            RotationGrace:  getEnvDuration("API_KEY_ROTATION_GRACE", 24*time.Hour),
+            CacheTTL:       getEnvDuration("API_KEY_CACHE_TTL", 30*time.Second),
+        },
+
+        Audit: AuditConfig{
+            Enabled:       getEnvBool("AUDIT_ENABLED", true),
+            Retention:     getEnvDuration("AUDIT_RETENTION", 365*24*time.Hour),
+            RetentionCron: getEnv("AUDIT_RETENTION_CRON", "30 3 * * *"),
+        },
+
+        Research: ResearchConfig{
+            PseudonymKey: getEnv("RESEARCH_PSEUDONYM_KEY", ""),
+            MinGroupSize: getEnvInt("RESEARCH_MIN_GROUP_SIZE", 5),
+        },
     }
 }
 
@@ -35,4 +381,52 @@ func getEnv(key, fallback string) string {
 		return value
 	}
 	return fallback
-}
\ No newline at end of file
+}
+
+// getEnvList читает список через запятую, пропуская пустые элементы.
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func getEnvInt(key string, fallback int) int {
+	if value, ok := os.LookupEnv(key); ok {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	if value, ok := os.LookupEnv(key); ok {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return fallback
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	if value, ok := os.LookupEnv(key); ok {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return fallback
+}
+
+// getEnvDuration принимает значения в формате time.ParseDuration ("500ms", "30s").
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if value, ok := os.LookupEnv(key); ok {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return fallback
+}
@@ -1,6 +1,10 @@
 package domain
 
-import "time"
+import (
+    "encoding/json"
+    "sort"
+    "time"
+)
 
 type Student struct {
     ID        uint64    `json:"id"`
@@ -26,6 +30,49 @@ type StudentLog struct {
     Timestamp           time.Time `json:"timestamp"`
 }
 
+// Типы действий в StudentLog. Ответы на вопросы отличаются от просмотров
+// тем, что у них есть правильность, попытки и выбранный дистрактор.
+const (
+    ActionViewLesson     = "view_lesson"
+    ActionViewMaterial   = "view_material"
+    ActionWatchVideo     = "watch_video"
+    ActionAnswerQuestion = "answer_question"
+    ActionTestAnswer     = "test_answer"
+    ActionTestQuestion   = "test_question"
+    ActionExam           = "exam"
+)
+
+var answerActions = map[string]bool{
+    ActionAnswerQuestion: true,
+    ActionTestAnswer:     true,
+    ActionTestQuestion:   true,
+    ActionExam:           true,
+}
+
+var viewActions = map[string]bool{
+    ActionViewLesson:   true,
+    ActionViewMaterial: true,
+    ActionWatchVideo:   true,
+}
+
+func IsKnownAction(actionType string) bool {
+    return answerActions[actionType] || viewActions[actionType]
+}
+
+func IsAnswerAction(actionType string) bool {
+    return answerActions[actionType]
+}
+
+// AnswerActions - типы действий-ответов списком, для запросов к базе.
+func AnswerActions() []string {
+    actions := make([]string, 0, len(answerActions))
+    for a := range answerActions {
+        actions = append(actions, a)
+    }
+    sort.Strings(actions)
+    return actions
+}
+
 type StudentAnalytics struct {
     ID                uint64             `json:"id"`
     StudentID         uint64             `json:"student_id"`
@@ -36,6 +83,8 @@ type StudentAnalytics struct {
     TopicEfficiency   map[string]float64 `json:"topic_efficiency"`
     Recommendations   []string           `json:"recommendations"`
     AnalyzedAt        time.Time          `json:"analyzed_at"`
+    // Stale - отдана предыдущая версия из кэша, свежая загружается
+    Stale             bool               `json:"stale,omitempty"`
 }
 
 type MaterialAnalytics struct {
@@ -46,4 +95,424 @@ type MaterialAnalytics struct {
     DistractorStats   map[string]int     `json:"distractor_stats"`
     StudentsCompleted int                `json:"students_completed"`
     AvgTimeSpent      float64            `json:"avg_time_spent"`
-}
\ No newline at end of file
+}
+
+type DependencyStatus struct {
+    Name      string    `json:"name"`
+    Healthy   bool      `json:"healthy"`
+    Required  bool      `json:"required"`
+    Error     string    `json:"error,omitempty"`
+    LatencyMs int64     `json:"latency_ms"`
+}
+
+type HealthReport struct {
+    Healthy      bool               `json:"healthy"`
+    Dependencies []DependencyStatus `json:"dependencies"`
+    CheckedAt    time.Time          `json:"checked_at"`
+}
+
+type Cohort struct {
+    ID   uint64 `json:"id"`
+    Name string `json:"name"`
+}
+
+type ExportFormat string
+
+const (
+    ExportCSV  ExportFormat = "csv"
+    ExportXLSX ExportFormat = "xlsx"
+)
+
+// Language - язык заголовков в выгрузках.
+type Language string
+
+const (
+    LangRU Language = "ru"
+    LangEN Language = "en"
+)
+
+type ExportOptions struct {
+    Format ExportFormat
+    Lang   Language
+    From   time.Time
+    To     time.Time
+}
+
+type ImportFormat string
+
+const (
+    ImportCSV  ImportFormat = "csv"
+    ImportXAPI ImportFormat = "xapi"
+)
+
+// ImportOptions - параметры загрузки исторических логов. CSVColumns
+// переопределяет колонки CSV: поле лога -> заголовок в файле.
+type ImportOptions struct {
+    Format          ImportFormat
+    DryRun          bool
+    TriggerAnalysis bool
+    CSVColumns      map[string]string
+}
+
+type RejectedRow struct {
+    Row    int    `json:"row"`
+    Reason string `json:"reason"`
+}
+
+// ImportReport - итог загрузки. Rejected содержит не больше первых
+// нескольких сотен строк, полное число отклоненных - в RejectedCount.
+type ImportReport struct {
+    DryRun            bool          `json:"dry_run"`
+    TotalRows         int           `json:"total_rows"`
+    Valid             int           `json:"valid"`
+    Imported          int           `json:"imported"`
+    Duplicates        int           `json:"duplicates"`
+    RejectedCount     int           `json:"rejected_count"`
+    Rejected          []RejectedRow `json:"rejected"`
+    AffectedStudents  int           `json:"affected_students"`
+    AnalysisTriggered int           `json:"analysis_triggered"`
+}
+
+// XAPIStatement - statement в том виде, в каком его прислал источник
+// (с присвоенным id). StudentID и ActionType пустые, если глагол не
+// сопоставлен ни с одним действием: такой statement только хранится.
+type XAPIStatement struct {
+    ID         string
+    StudentID  uint64
+    VerbID     string
+    ActionType string
+    Raw        []byte
+    Timestamp  time.Time
+    StoredAt   time.Time
+}
+
+// Role - роль преподавателя в курсе, полученная из LTI-запуска.
+type Role string
+
+const (
+    RoleInstructor    Role = "instructor"
+    RoleAdministrator Role = "administrator"
+)
+
+// Session - вход преподавателя из LMS. Доступ ограничен студентами
+// когорты CohortID, которая соответствует курсу (LTI context).
+// ID - токен сессии, наружу отдается только в cookie.
+type Session struct {
+    ID          string    `json:"-"`
+    Subject     string    `json:"subject"`
+    Name        string    `json:"name,omitempty"`
+    Issuer      string    `json:"issuer"`
+    CohortID    uint64    `json:"cohort_id"`
+    CourseTitle string    `json:"course_title"`
+    Role        Role      `json:"role"`
+    ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// LTILoginRequest - параметры инициации OIDC-входа от платформы.
+type LTILoginRequest struct {
+    Issuer         string
+    LoginHint      string
+    TargetLinkURI  string
+    LTIMessageHint string
+    ClientID       string
+    DeploymentID   string
+}
+
+// LTIAuthRedirect - куда отправить браузер после инициации входа и с
+// каким state; state дополнительно кладется в cookie.
+type LTIAuthRedirect struct {
+    URL   string
+    State string
+}
+
+// ReportOptions - язык отчета и период, за который в него попадает
+// история анализов.
+type ReportOptions struct {
+    Lang Language
+    From time.Time
+    To   time.Time
+}
+
+// StudentReport - данные для PDF-отчета по студенту. Student может быть
+// nil, если в students нет записи, а аналитика есть.
+type StudentReport struct {
+    StudentID   uint64
+    Student     *Student
+    Analytics   *StudentAnalytics
+    History     []*StudentAnalytics
+    GeneratedAt time.Time
+}
+
+// AlertRuleType - условие правила оповещения.
+type AlertRuleType string
+
+const (
+    // Доля верных ответов за последние WindowDays дней ниже Threshold
+    RuleSuccessRateBelow AlertRuleType = "success_rate_below"
+    // Нет активности WindowDays дней
+    RuleInactivity AlertRuleType = "inactivity"
+    // Последний анализ перевел студента в кластер Cluster
+    RuleClusterChanged AlertRuleType = "cluster_changed"
+)
+
+// AlertRule - правило преподавателя. CohortID = 0 - правило для всех
+// студентов; такие правила заводятся без сессии LMS.
+type AlertRule struct {
+    ID         uint64        `json:"id"`
+    Name       string        `json:"name"`
+    Type       AlertRuleType `json:"type"`
+    CohortID   uint64        `json:"cohort_id,omitempty"`
+    Threshold  float64       `json:"threshold,omitempty"`
+    WindowDays int           `json:"window_days,omitempty"`
+    Cluster    string        `json:"cluster,omitempty"`
+    CreatedBy  string        `json:"created_by,omitempty"`
+    CreatedAt  time.Time     `json:"created_at"`
+}
+
+type AlertStatus string
+
+const (
+    AlertOpen         AlertStatus = "open"
+    AlertAcknowledged AlertStatus = "acknowledged"
+    AlertResolved     AlertStatus = "resolved"
+)
+
+// Alert - сработавшее правило по студенту. Пока оповещение не решено,
+// повторное срабатывание того же правила нового оповещения не создает.
+// RuleID = 0, если правило уже удалено.
+type Alert struct {
+    ID             uint64        `json:"id"`
+    RuleID         uint64        `json:"rule_id,omitempty"`
+    RuleName       string        `json:"rule_name"`
+    Type           AlertRuleType `json:"type"`
+    StudentID      uint64        `json:"student_id"`
+    Message        string        `json:"message"`
+    Status         AlertStatus   `json:"status"`
+    RaisedAt       time.Time     `json:"raised_at"`
+    AcknowledgedAt *time.Time    `json:"acknowledged_at,omitempty"`
+    AcknowledgedBy string        `json:"acknowledged_by,omitempty"`
+    ResolvedAt     *time.Time    `json:"resolved_at,omitempty"`
+    ResolvedBy     string        `json:"resolved_by,omitempty"`
+}
+
+// AlertFilter - отбор оповещений для списка. Нулевые поля не ограничивают.
+type AlertFilter struct {
+    Status    AlertStatus
+    StudentID uint64
+    CohortID  uint64
+    Limit     int
+}
+
+// События для внешних подписчиков (webhooks).
+const (
+    EventAnalyticsUpdated = "analytics.updated"
+    EventLogIngested      = "log.ingested"
+    EventAlertRaised      = "alert.raised"
+)
+
+// WebhookEvents - события, на которые можно подписаться.
+func WebhookEvents() []string {
+    return []string{EventAnalyticsUpdated, EventLogIngested, EventAlertRaised}
+}
+
+// WebhookSubscription - подписка внешней системы на события. Secret
+// подписывает тела запросов (HMAC-SHA256) и отдается только при создании.
+type WebhookSubscription struct {
+    ID        uint64    `json:"id"`
+    URL       string    `json:"url"`
+    Events    []string  `json:"events"`
+    Secret    string    `json:"secret,omitempty"`
+    CreatedAt time.Time `json:"created_at"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+    DeliveryPending   WebhookDeliveryStatus = "pending"
+    DeliverySucceeded WebhookDeliveryStatus = "succeeded"
+    DeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery - доставка одного события одной подписке. Pending
+// доставки отправляются повторно в NextAttemptAt, пока не кончатся попытки.
+type WebhookDelivery struct {
+    ID             uint64                `json:"id"`
+    SubscriptionID uint64                `json:"subscription_id"`
+    Event          string                `json:"event"`
+    Payload        json.RawMessage       `json:"payload"`
+    Status         WebhookDeliveryStatus `json:"status"`
+    Attempts       int                   `json:"attempts"`
+    NextAttemptAt  time.Time             `json:"next_attempt_at"`
+    LastStatusCode int                   `json:"last_status_code,omitempty"`
+    LastError      string                `json:"last_error,omitempty"`
+    CreatedAt      time.Time             `json:"created_at"`
+    DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+}
+
+// WebhookDeliveryTask - взятая в работу доставка вместе с адресом и
+// секретом подписки. ClaimedUntil - срок, до которого она взята этой
+// репликой.
+type WebhookDeliveryTask struct {
+    Delivery     *WebhookDelivery
+    URL          string
+    Secret       string
+    ClaimedUntil time.Time
+}
+
+// WebhookDeliveryFilter - отбор журнала доставок. Нулевые поля не ограничивают.
+type WebhookDeliveryFilter struct {
+    SubscriptionID uint64
+    Status         WebhookDeliveryStatus
+    Limit          int
+}
+
+// RateLimit - маркерное ведро: Rate маркеров в секунду, не больше Burst
+// запросов подряд. Rate = 0 - без ограничения.
+type RateLimit struct {
+    Rate  float64
+    Burst int
+}
+
+// RateLimitDecision - итог проверки: Remaining - сколько запросов еще
+// можно сделать сразу, RetryAfter - через сколько появится маркер для
+// отклоненного запроса.
+type RateLimitDecision struct {
+    Allowed    bool
+    Remaining  int
+    RetryAfter time.Duration
+}
+
+// Области действия API-ключа.
+const (
+    ScopeIngestLogs    = "ingest:logs"
+    ScopeReadAnalytics = "read:analytics"
+)
+
+func APIKeyScopes() []string {
+    return []string{ScopeIngestLogs, ScopeReadAnalytics}
+}
+
+// APIKey - ключ для интеграций (коннекторы LMS), которые не могут войти
+// как преподаватель. В базе хранится только хэш; Key заполнен один раз -
+// в ответе на выпуск или ротацию. CohortIDs ограничивает ключ студентами
+// этих когорт, пустой список - без ограничения. После ротации старый ключ
+// действует до RevokedAt.
+type APIKey struct {
+    ID         uint64     `json:"id"`
+    Name       string     `json:"name"`
+    Prefix     string     `json:"prefix"`
+    Key        string     `json:"key,omitempty"`
+    Scopes     []string   `json:"scopes"`
+    CohortIDs  []uint64   `json:"cohort_ids"`
+    CreatedAt  time.Time  `json:"created_at"`
+    RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+    LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+    LastUsedIP string     `json:"last_used_ip,omitempty"`
+}
+
+func (k *APIKey) HasScope(scope string) bool {
+    for _, s := range k.Scopes {
+        if s == scope {
+            return true
+        }
+    }
+    return false
+}
+
+// Действия в журнале доступа.
+const (
+    AuditRead     = "read"
+    AuditMutation = "mutation"
+)
+
+// Кто выполнил действие из журнала доступа.
+const (
+    ActorUser      = "user"
+    ActorAPIKey    = "api_key"
+    ActorAnonymous = "anonymous"
+)
+
+// AuditEntry - запись журнала доступа: кто (Actor - subject сессии или ID
+// API-ключа), что (Endpoint - шаблон маршрута или метод gRPC) и чьи
+// данные (StudentID или CohortID для выгрузок по когорте) смотрел или менял.
+type AuditEntry struct {
+    ID         uint64    `json:"id"`
+    OccurredAt time.Time `json:"occurred_at"`
+    ActorType  string    `json:"actor_type"`
+    Actor      string    `json:"actor,omitempty"`
+    Action     string    `json:"action"`
+    Method     string    `json:"method"`
+    Endpoint   string    `json:"endpoint"`
+    Path       string    `json:"path,omitempty"`
+    StudentID  *uint64   `json:"student_id,omitempty"`
+    CohortID   *uint64   `json:"cohort_id,omitempty"`
+    Status     int       `json:"status"`
+    IP         string    `json:"ip,omitempty"`
+}
+
+// AuditFilter - отбор журнала доступа. Нулевые поля не ограничивают;
+// BeforeID - курсор: записи с меньшим ID (следующая страница).
+type AuditFilter struct {
+    ActorType string
+    Actor     string
+    Action    string
+    StudentID uint64
+    CohortID  uint64
+    From      time.Time
+    To        time.Time
+    BeforeID  uint64
+    Limit     int
+}
+
+// Режимы удаления данных студента по запросу субъекта данных: erase
+// удаляет все, anonymize переносит логи и аналитику на случайный
+// псевдоним и удаляет профиль, чтобы данные остались в агрегатах.
+const (
+    ErasureModeErase     = "erase"
+    ErasureModeAnonymize = "anonymize"
+)
+
+// StudentErasure - итог удаления: сколько строк затронуто в каждой
+// таблице. CohortIDs - курсы студента, чьи кэшированные списки надо сбросить.
+type StudentErasure struct {
+    StudentID   uint64           `json:"student_id"`
+    Mode        string           `json:"mode"`
+    PseudonymID uint64           `json:"pseudonym_id,omitempty"`
+    Rows        map[string]int64 `json:"rows"`
+    ErasedAt    time.Time        `json:"erased_at"`
+    CohortIDs   []uint64         `json:"-"`
+}
+
+// Шаг огрубления времени в исследовательской выгрузке: время лога или
+// анализа заменяется началом дня, недели (с понедельника) или месяца.
+const (
+    GranularityDay   = "day"
+    GranularityWeek  = "week"
+    GranularityMonth = "month"
+)
+
+// ResearchExportOptions - CohortID 0 - все когорты.
+type ResearchExportOptions struct {
+    CohortID    uint64
+    From        time.Time
+    To          time.Time
+    Granularity string
+}
+
+// ResearchAnalytics - аналитика студента для исследовательской выгрузки.
+// GroupSize - размер самой малой группы (когорта, кластер) с этим
+// студентом среди выгружаемых когорт; 0 - студент ни в одной из них.
+type ResearchAnalytics struct {
+    StudentAnalytics
+    GroupSize int
+}
+
+// CohortAggregate - средние по студентам когорты одного кластера.
+type CohortAggregate struct {
+    CohortID       uint64
+    ClusterGroup   string
+    Students       int
+    AvgEngagement  float64
+    AvgSuccessRate float64
+    AvgTimePerTask float64
+}
@@ -0,0 +1,216 @@
+// Package export пишет табличные выгрузки в CSV и XLSX построчно, чтобы
+// размер выгрузки не упирался в память сервиса.
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+)
+
+const timeLayout = "2006-01-02 15:04:05"
+
+// Column - колонка выгрузки с заголовками на поддерживаемых языках.
+type Column struct {
+	RU string
+	EN string
+}
+
+func (c Column) Title(lang domain.Language) string {
+	if lang == domain.LangEN {
+		return c.EN
+	}
+	return c.RU
+}
+
+// TableWriter пишет строки выгрузки. Close обязателен: для XLSX файл
+// целиком уходит в w только в Close. Если выгрузка прервалась, вместо
+// Close вызывается Abort - он ничего не дописывает в w, только удаляет
+// временные файлы.
+type TableWriter interface {
+	WriteRow(values ...interface{}) error
+	Close() error
+	Abort()
+}
+
+// NewTableWriter создает writer нужного формата и сразу пишет строку заголовков.
+func NewTableWriter(format domain.ExportFormat, lang domain.Language, columns []Column, w io.Writer) (TableWriter, error) {
+	header := make([]interface{}, len(columns))
+	for i, c := range columns {
+		header[i] = c.Title(lang)
+	}
+
+	var tw TableWriter
+	switch format {
+	case domain.ExportCSV:
+		tw = newCSVWriter(w, lang)
+	case domain.ExportXLSX:
+		xw, err := newXLSXWriter(w, lang)
+		if err != nil {
+			return nil, err
+		}
+		tw = xw
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+
+	if err := tw.WriteRow(header...); err != nil {
+		return nil, err
+	}
+	return tw, nil
+}
+
+// ContentType и FileExtension нужны HTTP-слою для заголовков ответа.
+func ContentType(format domain.ExportFormat) string {
+	if format == domain.ExportXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+func FileExtension(format domain.ExportFormat) string {
+	return string(format)
+}
+
+// formatValue приводит значение к строке для CSV. Даты пишутся без зоны в
+// UTC, булевы значения - словами на языке выгрузки.
+func formatValue(v interface{}, lang domain.Language) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case bool:
+		return boolTitle(v, lang)
+	case time.Time:
+		return v.UTC().Format(timeLayout)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func boolTitle(v bool, lang domain.Language) string {
+	switch {
+	case lang == domain.LangEN && v:
+		return "yes"
+	case lang == domain.LangEN:
+		return "no"
+	case v:
+		return "да"
+	default:
+		return "нет"
+	}
+}
+
+// csvWriter буферизует вывод, поэтому ошибка в первых строках (например,
+// упавший запрос к базе) еще не успевает попасть в ответ.
+type csvWriter struct {
+	buf  *bufio.Writer
+	w    *csv.Writer
+	lang domain.Language
+	rows int
+}
+
+func newCSVWriter(w io.Writer, lang domain.Language) *csvWriter {
+	buf := bufio.NewWriterSize(w, 64*1024)
+	// BOM нужен, чтобы Excel открыл UTF-8 с кириллицей без танцев с импортом
+	buf.WriteString("\ufeff")
+	return &csvWriter{buf: buf, w: csv.NewWriter(buf), lang: lang}
+}
+
+// csvFlushEvery - как часто сбрасывать буфер в ответ, чтобы клиент
+// начинал получать файл сразу, а не после последней строки.
+const csvFlushEvery = 500
+
+func (c *csvWriter) WriteRow(values ...interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = formatValue(v, c.lang)
+	}
+	if err := c.w.Write(record); err != nil {
+		return err
+	}
+
+	c.rows++
+	if c.rows%csvFlushEvery == 0 {
+		return c.flush()
+	}
+	return nil
+}
+
+func (c *csvWriter) Close() error {
+	return c.flush()
+}
+
+// Abort: строки CSV уже ушли в w, освобождать нечего.
+func (c *csvWriter) Abort() {}
+
+func (c *csvWriter) flush() error {
+	c.w.Flush()
+	if err := c.w.Error(); err != nil {
+		return err
+	}
+	return c.buf.Flush()
+}
+
+// xlsxWriter использует потоковую запись excelize: строки сразу уходят во
+// временный файл, а не копятся в памяти.
+type xlsxWriter struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	lang   domain.Language
+	row    int
+}
+
+func newXLSXWriter(w io.Writer, lang domain.Language) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter("Sheet1")
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to create xlsx stream: %w", err)
+	}
+	return &xlsxWriter{out: w, file: file, stream: stream, lang: lang}, nil
+}
+
+func (x *xlsxWriter) WriteRow(values ...interface{}) error {
+	x.row++
+	cells := make([]interface{}, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case bool, time.Time:
+			cells[i] = formatValue(v, x.lang)
+		default:
+			cells[i] = v
+		}
+	}
+
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	return x.stream.SetRow(cell, cells)
+}
+
+func (x *xlsxWriter) Abort() {
+	x.file.Close()
+}
+
+func (x *xlsxWriter) Close() error {
+	defer x.file.Close()
+
+	if err := x.stream.Flush(); err != nil {
+		return fmt.Errorf("failed to flush xlsx stream: %w", err)
+	}
+	if _, err := x.file.WriteTo(x.out); err != nil {
+		return fmt.Errorf("failed to write xlsx: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+// Package importer разбирает выгрузки старых LMS (CSV и xAPI) в
+// domain.StudentLog. Файлы читаются потоково: строки отдаются в колбэк
+// по одной, поэтому размер файла не ограничен памятью.
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+)
+
+// Record - одна строка источника. Row - номер строки (для CSV с учетом
+// заголовка, для xAPI - порядковый номер statement, с 1). Если строку не
+// удалось разобрать, Log равен nil, а причина в Err.
+type Record struct {
+	Row int
+	Log *domain.StudentLog
+	Err error
+}
+
+// ErrInvalidFile - файл нельзя разобрать целиком (нет заголовка, битый JSON).
+var ErrInvalidFile = errors.New("invalid import file")
+
+// Поля StudentLog, которые можно загрузить из CSV.
+const (
+	FieldStudentID          = "student_id"
+	FieldActionType         = "action_type"
+	FieldMaterialID         = "material_id"
+	FieldCorrect            = "correct"
+	FieldTimeSpentSec       = "time_spent_sec"
+	FieldDifficulty         = "difficulty"
+	FieldAttempts           = "attempts"
+	FieldSelectedDistractor = "selected_distractor"
+	FieldTimestamp          = "timestamp"
+)
+
+// columnAliases - типовые названия колонок в выгрузках LMS, включая
+// заголовки собственного экспорта, чтобы его можно было загрузить обратно.
+var columnAliases = map[string][]string{
+	FieldStudentID:          {"student_id", "user_id", "learner_id", "student id", "id студента"},
+	FieldActionType:         {"action_type", "action", "event", "action type", "тип действия"},
+	FieldMaterialID:         {"material_id", "material", "resource_id", "activity_id"},
+	FieldCorrect:            {"correct", "is_correct", "success", "верно"},
+	FieldTimeSpentSec:       {"time_spent_sec", "duration_sec", "duration", "time spent, sec", "затрачено, сек"},
+	FieldDifficulty:         {"difficulty"},
+	FieldAttempts:           {"attempts", "attempt_count"},
+	FieldSelectedDistractor: {"selected_distractor", "response", "answer"},
+	FieldTimestamp:          {"timestamp", "time", "created_at", "date", "время"},
+}
+
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"}
+
+// CSVOptions.Columns переопределяет колонки: поле StudentLog -> заголовок в файле.
+type CSVOptions struct {
+	Columns map[string]string
+}
+
+// ParseCSV читает CSV с заголовком. Обязательны колонки student_id,
+// action_type и timestamp; остальные можно опустить.
+func ParseCSV(r io.Reader, opts CSVOptions, fn func(Record) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("%w: failed to read header: %v", ErrInvalidFile, err)
+	}
+	index, err := resolveColumns(header, opts.Columns)
+	if err != nil {
+		return err
+	}
+
+	row := 1
+	for {
+		values, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		row++
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				if err := fn(Record{Row: row, Err: err}); err != nil {
+					return err
+				}
+				continue
+			}
+			return err
+		}
+
+		log, err := parseCSVRow(values, index)
+		if err := fn(Record{Row: row, Log: log, Err: err}); err != nil {
+			return err
+		}
+	}
+}
+
+// ParseColumnMapping разбирает "student_id=user,timestamp=date" в
+// CSVOptions.Columns.
+func ParseColumnMapping(s string) (map[string]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	columns := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		field, column, ok := strings.Cut(pair, "=")
+		field, column = strings.TrimSpace(field), strings.TrimSpace(column)
+		if !ok || field == "" || column == "" {
+			return nil, errors.New("columns must look like field=header,field=header")
+		}
+		if _, known := columnAliases[field]; !known {
+			return nil, fmt.Errorf("unknown field %q", field)
+		}
+		columns[field] = column
+	}
+	return columns, nil
+}
+
+func resolveColumns(header []string, overrides map[string]string) (map[string]int, error) {
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		positions[name] = i
+	}
+
+	index := make(map[string]int)
+	for field, aliases := range columnAliases {
+		if column, ok := overrides[field]; ok {
+			aliases = []string{column}
+		}
+		for _, alias := range aliases {
+			if i, ok := positions[strings.ToLower(alias)]; ok {
+				index[field] = i
+				break
+			}
+		}
+	}
+
+	for _, required := range []string{FieldStudentID, FieldActionType, FieldTimestamp} {
+		if _, ok := index[required]; !ok {
+			return nil, fmt.Errorf("%w: column for %s not found", ErrInvalidFile, required)
+		}
+	}
+	return index, nil
+}
+
+func parseCSVRow(values []string, index map[string]int) (*domain.StudentLog, error) {
+	get := func(field string) string {
+		i, ok := index[field]
+		if !ok || i >= len(values) {
+			return ""
+		}
+		return strings.TrimSpace(values[i])
+	}
+
+	log := &domain.StudentLog{
+		ActionType:         get(FieldActionType),
+		MaterialID:         get(FieldMaterialID),
+		SelectedDistractor: get(FieldSelectedDistractor),
+	}
+
+	var err error
+	if log.StudentID, err = strconv.ParseUint(get(FieldStudentID), 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid student_id %q", get(FieldStudentID))
+	}
+	if log.Timestamp, err = parseTime(get(FieldTimestamp)); err != nil {
+		return nil, err
+	}
+	if log.Correct, err = parseBool(get(FieldCorrect)); err != nil {
+		return nil, err
+	}
+	if log.TimeSpentSec, err = parseInt(FieldTimeSpentSec, get(FieldTimeSpentSec)); err != nil {
+		return nil, err
+	}
+	if log.Difficulty, err = parseInt(FieldDifficulty, get(FieldDifficulty)); err != nil {
+		return nil, err
+	}
+	if log.Attempts, err = parseInt(FieldAttempts, get(FieldAttempts)); err != nil {
+		return nil, err
+	}
+
+	if domain.IsAnswerAction(log.ActionType) {
+		log.TimeSpentOnQuestion = log.TimeSpentSec
+		// Старые выгрузки часто пишут каждую попытку отдельной строкой без счетчика
+		if get(FieldAttempts) == "" {
+			log.Attempts = 1
+		}
+	} else {
+		log.TimeSpentOnMat = log.TimeSpentSec
+	}
+	return log, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, errors.New("timestamp is required")
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", s)
+}
+
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "", "0", "false", "no", "нет", "f", "n":
+		return false, nil
+	case "1", "true", "yes", "да", "t", "y":
+		return true, nil
+	}
+	return false, fmt.Errorf("invalid correct value %q", s)
+}
+
+func parseInt(field, s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", field, s)
+	}
+	return n, nil
+}
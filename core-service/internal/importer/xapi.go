@@ -0,0 +1,98 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/xapi"
+)
+
+// ParseXAPI читает statement'ы в любом из форматов, которые отдают LRS:
+// JSON-массив, ответ GET /statements ({"statements": [...]}) или по одному
+// объекту на строку (NDJSON).
+func ParseXAPI(r io.Reader, mapper *xapi.Mapper, fn func(Record) error) error {
+	br := bufio.NewReader(r)
+	dec := json.NewDecoder(br)
+
+	row := 0
+	emit := func(raw json.RawMessage) error {
+		row++
+		var st xapi.Statement
+		if err := json.Unmarshal(raw, &st); err != nil {
+			return fn(Record{Row: row, Err: fmt.Errorf("invalid statement: %w", err)})
+		}
+		log, err := mapper.ToLog(&st)
+		return fn(Record{Row: row, Log: log, Err: err})
+	}
+
+	first, err := peekNonSpace(br)
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if first == '[' {
+		return decodeArray(dec, emit)
+	}
+
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+
+		var envelope struct {
+			Statements []json.RawMessage `json:"statements"`
+		}
+		if err := json.Unmarshal(raw, &envelope); err == nil && envelope.Statements != nil {
+			for _, st := range envelope.Statements {
+				if err := emit(st); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := emit(raw); err != nil {
+			return err
+		}
+	}
+}
+
+func decodeArray(dec *json.Decoder, emit func(json.RawMessage) error) error {
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		if err := emit(raw); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	return nil
+}
+
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b, br.UnreadByte()
+	}
+}
@@ -0,0 +1,191 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Reloader держит в памяти пару сертификат/ключ и CA-бандл и перечитывает
+// их с диска, когда у файлов меняется время модификации. Проверка ленивая:
+// выполняется при TLS-рукопожатии, но не чаще чем раз в checkInterval.
+type Reloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+
+	checkInterval time.Duration
+	logger        *slog.Logger
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	pool      *x509.CertPool
+	modTimes  [3]time.Time
+	lastCheck time.Time
+}
+
+// NewReloader загружает файлы сразу, чтобы ошибки конфигурации всплывали
+// при старте, а не на первом соединении. caFile может быть пустым.
+func NewReloader(certFile, keyFile, caFile string, checkInterval time.Duration, logger *slog.Logger) (*Reloader, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("tls cert and key files are required")
+	}
+
+	r := &Reloader{
+		certFile:      certFile,
+		keyFile:       keyFile,
+		caFile:        caFile,
+		checkInterval: checkInterval,
+		logger:        logger,
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ServerConfig возвращает конфигурацию сервера. Каждое рукопожатие получает
+// актуальные сертификат и CA клиентов через GetConfigForClient.
+func (r *Reloader) ServerConfig(clientAuth tls.ClientAuthType) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, pool := r.current()
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*cert},
+				ClientCAs:    pool,
+				ClientAuth:   clientAuth,
+				NextProtos:   []string{"h2", "http/1.1"},
+			}, nil
+		},
+	}
+}
+
+// ClientConfig возвращает конфигурацию клиента для mTLS. Стандартная проверка
+// цепочки отключена только потому, что RootCAs нельзя подменить на лету:
+// сертификат сервера проверяется вручную в VerifyConnection по текущему CA.
+func (r *Reloader) ClientConfig(serverName string) *tls.Config {
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         serverName,
+		InsecureSkipVerify: true,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, _ := r.current()
+			return cert, nil
+		},
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			_, pool := r.current()
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("server did not present a certificate")
+			}
+
+			name := serverName
+			if name == "" {
+				name = cs.ServerName
+			}
+			opts := x509.VerifyOptions{
+				Roots:         pool,
+				DNSName:       name,
+				Intermediates: x509.NewCertPool(),
+			}
+			for _, c := range cs.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(c)
+			}
+			_, err := cs.PeerCertificates[0].Verify(opts)
+			return err
+		},
+	}
+}
+
+func (r *Reloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.maybeReload()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, r.pool
+}
+
+func (r *Reloader) maybeReload() {
+	r.mu.RLock()
+	due := time.Since(r.lastCheck) >= r.checkInterval
+	r.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	// Битые файлы на диске не должны ронять уже работающие соединения:
+	// продолжаем отдавать последнюю удачно загруженную версию.
+	if err := r.reload(); err != nil {
+		r.logger.Error("failed to reload tls certificates", slog.String("cert_file", r.certFile), slog.String("error", err.Error()))
+	}
+}
+
+func (r *Reloader) reload() error {
+	// Время проверки ставим до stat: если файлы пропали, следующая
+	// попытка будет через checkInterval, а не на каждом рукопожатии
+	r.mu.Lock()
+	r.lastCheck = time.Now()
+	r.mu.Unlock()
+
+	modTimes, err := r.stat()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	unchanged := r.cert != nil && modTimes == r.modTimes
+	r.mu.Unlock()
+	if unchanged {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load key pair: %w", err)
+	}
+
+	var pool *x509.CertPool
+	if r.caFile != "" {
+		pem, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read ca file: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in %s", r.caFile)
+		}
+	}
+
+	r.mu.Lock()
+	reloaded := r.cert != nil
+	r.cert = &cert
+	r.pool = pool
+	r.modTimes = modTimes
+	r.mu.Unlock()
+
+	if reloaded {
+		r.logger.Info("tls certificates reloaded", slog.String("cert_file", r.certFile))
+	}
+	return nil
+}
+
+func (r *Reloader) stat() ([3]time.Time, error) {
+	var modTimes [3]time.Time
+	for i, file := range []string{r.certFile, r.keyFile, r.caFile} {
+		if file == "" {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return modTimes, fmt.Errorf("failed to stat %s: %w", file, err)
+		}
+		modTimes[i] = info.ModTime()
+	}
+	return modTimes, nil
+}
@@ -0,0 +1,128 @@
+package grpc
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitBreaker размыкается после failureThreshold ошибок подряд и
+// перестает пускать вызовы на openTimeout. После этого пропускается один
+// пробный вызов (half-open): успех замыкает цепь, ошибка снова размыкает.
+//
+// Каждая смена состояния начинает новое поколение, и Allow выдает номер
+// текущего. Результат вызова из прошлого поколения (например, медленного
+// вызова, пропущенного еще до размыкания) состояние не меняет: иначе его
+// запоздалый успех снял бы пробу и замкнул разомкнутую цепь.
+type CircuitBreaker struct {
+	mu               sync.Mutex
+	state            BreakerState
+	generation       uint64
+	failures         int
+	failureThreshold int
+	openTimeout      time.Duration
+	openedAt         time.Time
+	probeInFlight    bool
+	now              func() time.Time
+}
+
+func NewCircuitBreaker(failureThreshold int, openTimeout time.Duration) *CircuitBreaker {
+	return NewCircuitBreakerWithClock(failureThreshold, openTimeout, time.Now)
+}
+
+// NewCircuitBreakerWithClock - now заменяет часы, например в тестах.
+func NewCircuitBreakerWithClock(failureThreshold int, openTimeout time.Duration, now func() time.Time) *CircuitBreaker {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		openTimeout:      openTimeout,
+		now:              now,
+	}
+}
+
+// Allow сообщает, можно ли выполнить вызов, и возвращает поколение, в
+// котором он пропущен. Если вызов разрешен, после него обязательно нужно
+// вызвать Done с этим поколением.
+func (b *CircuitBreaker) Allow() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.currentState() {
+	case BreakerOpen:
+		return 0, ErrCircuitOpen
+	case BreakerHalfOpen:
+		if b.probeInFlight {
+			return 0, ErrCircuitOpen
+		}
+		b.transition(BreakerHalfOpen)
+		b.probeInFlight = true
+	}
+	return b.generation, nil
+}
+
+// Done фиксирует результат вызова, разрешенного через Allow в поколении
+// generation; результаты прошлых поколений отбрасываются.
+func (b *CircuitBreaker) Done(generation uint64, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation != b.generation {
+		return
+	}
+	if success {
+		if b.state != BreakerClosed {
+			b.transition(BreakerClosed)
+		}
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.failureThreshold {
+		b.transition(BreakerOpen)
+		b.openedAt = b.now()
+	}
+}
+
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.currentState()
+}
+
+func (b *CircuitBreaker) transition(state BreakerState) {
+	b.state = state
+	b.generation++
+	b.probeInFlight = false
+}
+
+func (b *CircuitBreaker) currentState() BreakerState {
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.openTimeout {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
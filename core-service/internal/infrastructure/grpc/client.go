@@ -2,48 +2,186 @@ package grpc
 
 import (
     "context"
+    "crypto/tls"
+    "encoding/json"
     "fmt"
+    "strings"
     "time"
-    
+
+    "go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
     "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/credentials"
     "google.golang.org/grpc/credentials/insecure"
-    
+    _ "google.golang.org/grpc/health" // клиентский health checking для healthCheckConfig
+    "google.golang.org/grpc/keepalive"
+    "google.golang.org/grpc/metadata"
+    "google.golang.org/grpc/status"
+
     pb "github.com/RusselRustCode/teacher_analytics/core-service/proto"
     "github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
     "github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+    "github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
 )
 
+type ClientOptions struct {
+    CallTimeout time.Duration
+
+    MaxAttempts       int
+    InitialBackoff    time.Duration
+    MaxBackoff        time.Duration
+    BackoffMultiplier float64
+
+    BreakerFailureThreshold int
+    BreakerOpenTimeout      time.Duration
+
+    KeepaliveTime    time.Duration
+    KeepaliveTimeout time.Duration
+
+    // TLS включает (m)TLS до analytics-service; nil - plaintext.
+    TLS *tls.Config
+}
+
 type GRPCAnalyticsClient struct {
-    conn   *grpc.ClientConn
-    client pb.AnalyticsServiceClient
+    conn        *grpc.ClientConn
+    client      pb.AnalyticsServiceClient
+    breaker     *CircuitBreaker
+    callTimeout time.Duration
 }
 
-func NewGRPCAnalyticsClient(addr string) (interfaces.AnalyticsClient, error) {
+func NewGRPCAnalyticsClient(addr string, opts ClientOptions) (interfaces.AnalyticsClient, error) {
+    serviceConfig, err := buildServiceConfig(opts)
+    if err != nil {
+        return nil, fmt.Errorf("failed to build service config: %w", err)
+    }
+
+    creds := insecure.NewCredentials()
+    if opts.TLS != nil {
+        creds = credentials.NewTLS(opts.TLS)
+    }
+
     conn, err := grpc.NewClient(
         addr,
-        grpc.WithTransportCredentials(insecure.NewCredentials()),
-        grpc.WithTimeout(10*time.Second),
+        grpc.WithTransportCredentials(creds),
+        grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+        grpc.WithChainUnaryInterceptor(requestIDClientInterceptor),
+        grpc.WithDefaultServiceConfig(serviceConfig),
+        grpc.WithKeepaliveParams(keepalive.ClientParameters{
+            Time:    opts.KeepaliveTime,
+            Timeout: opts.KeepaliveTimeout,
+        }),
     )
     if err != nil {
         return nil, fmt.Errorf("failed to create gRPC client: %w", err)
     }
-    
+
     return &GRPCAnalyticsClient{
-        conn:   conn,
-        client: pb.NewAnalyticsServiceClient(conn),
+        conn:        conn,
+        client:      pb.NewAnalyticsServiceClient(conn),
+        breaker:     NewCircuitBreaker(opts.BreakerFailureThreshold, opts.BreakerOpenTimeout),
+        callTimeout: opts.CallTimeout,
     }, nil
 }
 
+// buildServiceConfig собирает service config: round_robin по адресам из DNS
+// с учетом grpc.health.v1 и ретраи с экспоненциальной задержкой. Ретраятся
+// только идемпотентные методы - все RPC analytics-service только читают.
+func buildServiceConfig(opts ClientOptions) (string, error) {
+    type methodName struct {
+        Service string `json:"service"`
+        Method  string `json:"method,omitempty"`
+    }
+    type retryPolicy struct {
+        MaxAttempts          int      `json:"maxAttempts"`
+        InitialBackoff       string   `json:"initialBackoff"`
+        MaxBackoff           string   `json:"maxBackoff"`
+        BackoffMultiplier    float64  `json:"backoffMultiplier"`
+        RetryableStatusCodes []string `json:"retryableStatusCodes"`
+    }
+    type methodConfig struct {
+        Name        []methodName `json:"name"`
+        Timeout     string       `json:"timeout,omitempty"`
+        RetryPolicy *retryPolicy `json:"retryPolicy,omitempty"`
+    }
+
+    mc := methodConfig{
+        Name: []methodName{
+            {Service: "analytics.v1.AnalyticsService", Method: "AnalyzeStudent"},
+            {Service: "analytics.v1.AnalyticsService", Method: "HealthCheck"},
+            {Service: "analytics.v1.AnalyticsService", Method: "BatchAnalyze"},
+        },
+    }
+    if opts.CallTimeout > 0 {
+        mc.Timeout = durationString(opts.CallTimeout)
+    }
+    // gRPC требует maxAttempts > 1, иначе retryPolicy считается невалидной
+    if opts.MaxAttempts > 1 {
+        mc.RetryPolicy = &retryPolicy{
+            MaxAttempts:          opts.MaxAttempts,
+            InitialBackoff:       durationString(opts.InitialBackoff),
+            MaxBackoff:           durationString(opts.MaxBackoff),
+            BackoffMultiplier:    opts.BackoffMultiplier,
+            RetryableStatusCodes: []string{"UNAVAILABLE", "RESOURCE_EXHAUSTED"},
+        }
+    }
+
+    cfg := map[string]interface{}{
+        "loadBalancingConfig": []map[string]interface{}{{"round_robin": map[string]interface{}{}}},
+        "healthCheckConfig":   map[string]string{"serviceName": ""},
+        "methodConfig":        []methodConfig{mc},
+    }
+
+    data, err := json.Marshal(cfg)
+    if err != nil {
+        return "", err
+    }
+    return string(data), nil
+}
+
+// requestIDClientInterceptor передает X-Request-ID входящего запроса в
+// analytics-service, чтобы его логи можно было связать с нашими.
+func requestIDClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+    if requestID := logging.RequestID(ctx); requestID != "" {
+        ctx = metadata.AppendToOutgoingContext(ctx, strings.ToLower(logging.RequestIDHeader), requestID)
+    }
+    return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// durationString переводит длительность в формат service config ("1.5s").
+func durationString(d time.Duration) string {
+    return fmt.Sprintf("%gs", d.Seconds())
+}
+
+// withDeadline ставит дедлайн на вызов, если вызывающий не задал более короткий.
+func (c *GRPCAnalyticsClient) withDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+    if c.callTimeout <= 0 {
+        return ctx, func() {}
+    }
+    if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= c.callTimeout {
+        return ctx, func() {}
+    }
+    return context.WithTimeout(ctx, c.callTimeout)
+}
+
 func (c *GRPCAnalyticsClient) AnalyzeStudent(ctx context.Context, studentID uint64) (*domain.StudentAnalytics, error) {
+    generation, err := c.breaker.Allow()
+    if err != nil {
+        return nil, fmt.Errorf("analytics service unavailable: %w", err)
+    }
+
+    ctx, cancel := c.withDeadline(ctx)
+    defer cancel()
+
     req := &pb.AnalyzeStudentRequest{
         StudentId: studentID,
     }
-    
+
     resp, err := c.client.AnalyzeStudent(ctx, req)
+    c.breaker.Done(generation, !isBreakerFailure(err))
     if err != nil {
         return nil, fmt.Errorf("gRPC call failed: %w", err)
     }
-    
+
     // Конвертируем protobuf в доменную модель
     analytics := &domain.StudentAnalytics{
         StudentID:       resp.StudentId,
@@ -54,17 +192,43 @@ func (c *GRPCAnalyticsClient) AnalyzeStudent(ctx context.Context, studentID uint
         Recommendations: resp.Recommendations,
         AnalyzedAt:      time.Now(),
     }
-    
+
     return analytics, nil
 }
 
+// HealthCheck не проходит через breaker, но сообщает о его состоянии:
+// пока цепь разомкнута, сервис считается недоступным.
 func (c *GRPCAnalyticsClient) HealthCheck(ctx context.Context) error {
+    if state := c.breaker.State(); state == BreakerOpen {
+        return fmt.Errorf("circuit breaker %s: %w", state, ErrCircuitOpen)
+    }
+
+    ctx, cancel := c.withDeadline(ctx)
+    defer cancel()
+
     req := &pb.HealthCheckRequest{}
-    
+
     _, err := c.client.HealthCheck(ctx, req)
     return err
 }
 
+func (c *GRPCAnalyticsClient) BreakerState() BreakerState {
+    return c.breaker.State()
+}
+
 func (c *GRPCAnalyticsClient) Close() error {
     return c.conn.Close()
-}
\ No newline at end of file
+}
+
+// isBreakerFailure отделяет сбои транспорта и перегрузку сервиса от
+// ошибок уровня запроса, которые не говорят о его недоступности.
+func isBreakerFailure(err error) bool {
+    if err == nil {
+        return false
+    }
+    switch status.Code(err) {
+    case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown:
+        return true
+    }
+    return false
+}
@@ -0,0 +1,38 @@
+package kafka
+
+import (
+    "github.com/segmentio/kafka-go"
+)
+
+// HeaderCarrier позволяет пропагатору OpenTelemetry писать traceparent и
+// baggage прямо в заголовки сообщения Kafka.
+type HeaderCarrier struct {
+    Headers *[]kafka.Header
+}
+
+func (c HeaderCarrier) Get(key string) string {
+    for _, h := range *c.Headers {
+        if h.Key == key {
+            return string(h.Value)
+        }
+    }
+    return ""
+}
+
+func (c HeaderCarrier) Set(key, value string) {
+    for i, h := range *c.Headers {
+        if h.Key == key {
+            (*c.Headers)[i].Value = []byte(value)
+            return
+        }
+    }
+    *c.Headers = append(*c.Headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+func (c HeaderCarrier) Keys() []string {
+    keys := make([]string, 0, len(*c.Headers))
+    for _, h := range *c.Headers {
+        keys = append(keys, h.Key)
+    }
+    return keys
+}
@@ -4,37 +4,96 @@ import (
     "context"
     "encoding/json"
     "fmt"
+    "log/slog"
     
     "github.com/segmentio/kafka-go"
+    "go.opentelemetry.io/otel"
+    "go.opentelemetry.io/otel/codes"
+    semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+    "go.opentelemetry.io/otel/trace"
     
     "github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+    "github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+    "github.com/RusselRustCode/teacher_analytics/core-service/internal/metrics"
+    "github.com/RusselRustCode/teacher_analytics/core-service/internal/tracing"
 )
 
+var tracer = tracing.Tracer("kafka")
+
+// MessageWriter - то, чем KafkaProducer пользуется у kafka.Writer.
+type MessageWriter interface {
+    WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+    Close() error
+}
+
 type KafkaProducer struct {
-    writer *kafka.Writer
+    writer  MessageWriter
+    brokers []string
 }
 
-func NewKafkaProducer(brokers []string) interfaces.MessageProducer {
+func NewKafkaProducer(brokers []string, logger *slog.Logger) interfaces.MessageProducer {
     writer := &kafka.Writer{
         Addr:                   kafka.TCP(brokers...),
         Balancer:               &kafka.LeastBytes{},
         AllowAutoTopicCreation: true,
         Async:                  true, // Асинхронная отправка
+        // В асинхронном режиме WriteMessages не возвращает ошибок доставки,
+        // поэтому итог считаем по callback-у writer-а
+        Completion: func(messages []kafka.Message, err error) {
+            result := "success"
+            if err != nil {
+                result = "failure"
+                logger.Error("kafka delivery failed", slog.Int("messages", len(messages)), slog.String("error", err.Error()))
+            }
+            for _, m := range messages {
+                metrics.KafkaMessagesTotal.WithLabelValues(m.Topic, result).Inc()
+            }
+        },
     }
     
+    return NewKafkaProducerWithWriter(writer, brokers)
+}
+
+// NewKafkaProducerWithWriter - producer поверх готового writer-а; в тестах
+// это позволяет увидеть отправленные сообщения без брокера.
+func NewKafkaProducerWithWriter(writer MessageWriter, brokers []string) interfaces.MessageProducer {
     return &KafkaProducer{
-        writer: writer,
+        writer:  writer,
+        brokers: brokers,
     }
 }
 
 func (p *KafkaProducer) Send(ctx context.Context, topic string, key, value []byte) error {
+    ctx, span := tracer.Start(ctx, topic+" publish",
+        trace.WithSpanKind(trace.SpanKindProducer),
+        trace.WithAttributes(
+            semconv.MessagingSystemKafka,
+            semconv.MessagingDestinationName(topic),
+            semconv.MessagingOperationTypePublish,
+        ),
+    )
+    defer span.End()
+
     message := kafka.Message{
         Topic: topic,
         Key:   key,
         Value: value,
     }
-    
-    return p.writer.WriteMessages(ctx, message)
+    // traceparent и X-Request-ID в заголовках - для консьюмеров с
+    // трассировкой; Python-сервис их пока не читает, и трасса
+    // заканчивается на публикации
+    otel.GetTextMapPropagator().Inject(ctx, HeaderCarrier{Headers: &message.Headers})
+    if requestID := logging.RequestID(ctx); requestID != "" {
+        HeaderCarrier{Headers: &message.Headers}.Set(logging.RequestIDHeader, requestID)
+    }
+
+    if err := p.writer.WriteMessages(ctx, message); err != nil {
+        metrics.KafkaMessagesTotal.WithLabelValues(topic, "failure").Inc()
+        span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+        return err
+    }
+    return nil
 }
 
 func (p *KafkaProducer) SendJSON(ctx context.Context, topic string, data interface{}) error {
@@ -47,6 +106,23 @@ func (p *KafkaProducer) SendJSON(ctx context.Context, topic string, data interfa
     return p.Send(ctx, topic, key, jsonData)
 }
 
+// Ping считает Kafka доступной, если удалось подключиться хотя бы к одному брокеру.
+func (p *KafkaProducer) Ping(ctx context.Context) error {
+    var lastErr error
+    for _, broker := range p.brokers {
+        conn, err := kafka.DialContext(ctx, "tcp", broker)
+        if err != nil {
+            lastErr = err
+            continue
+        }
+        return conn.Close()
+    }
+    if lastErr == nil {
+        lastErr = fmt.Errorf("no kafka brokers configured")
+    }
+    return lastErr
+}
+
 func (p *KafkaProducer) Close() error {
     return p.writer.Close()
 }
\ No newline at end of file
@@ -0,0 +1,246 @@
+// Package memory - кэш в памяти процесса: для разработки и тестов без
+// Redis и как ближний уровень двухуровневого кэша.
+package memory
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+)
+
+type entry struct {
+	key       string
+	value     string
+	expiresAt time.Time
+	tags      []string
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// LRUCache - кэш с TTL и ограничением числа записей: при переполнении
+// вытесняется запись, к которой дольше всех не обращались.
+type LRUCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List // от новых к старым
+	items      map[string]*list.Element
+	// tags - ключи записей по тегам; запись снимается с тегов при удалении
+	tags map[string]map[string]struct{}
+}
+
+var _ interfaces.Cache = (*LRUCache)(nil)
+
+func NewLRUCache(maxEntries int) *LRUCache {
+	if maxEntries <= 0 {
+		maxEntries = 10000
+	}
+	return &LRUCache{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		items:      make(map[string]*list.Element),
+		tags:       make(map[string]map[string]struct{}),
+	}
+}
+
+func (c *LRUCache) Get(ctx context.Context, key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.lookup(key)
+	if e == nil {
+		return "", nil
+	}
+	return e.value, nil
+}
+
+func (c *LRUCache) GetDel(ctx context.Context, key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.lookup(key)
+	if e == nil {
+		return "", nil
+	}
+	c.remove(c.items[key])
+	return e.value, nil
+}
+
+// Set с expiration = 0 хранит запись, пока ее не вытеснят.
+func (c *LRUCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	data, err := encode(value)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, data, expiration)
+	return nil
+}
+
+func (c *LRUCache) SetWithTags(ctx context.Context, key string, value interface{}, expiration time.Duration, tags ...string) error {
+	data, err := encode(value)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, data, expiration)
+	el, ok := c.items[key]
+	if !ok {
+		// maxEntries = 1 и запись уже вытеснена - привязывать нечего
+		return nil
+	}
+	e := el.Value.(*entry)
+	for _, tag := range tags {
+		keys, ok := c.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			c.tags[tag] = keys
+		}
+		if _, ok := keys[key]; !ok {
+			keys[key] = struct{}{}
+			e.tags = append(e.tags, tag)
+		}
+	}
+	return nil
+}
+
+func (c *LRUCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			if el, ok := c.items[key]; ok {
+				c.remove(el)
+			}
+		}
+		delete(c.tags, tag)
+	}
+	return nil
+}
+
+func (c *LRUCache) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	data, err := encode(value)
+	if err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.lookup(key) != nil {
+		return false, nil
+	}
+	c.set(key, data, expiration)
+	return true, nil
+}
+
+func (c *LRUCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+	return nil
+}
+
+func (c *LRUCache) Exists(ctx context.Context, key string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lookup(key) != nil, nil
+}
+
+// Len - число записей, включая истекшие, но еще не удаленные.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Clear удаляет все записи.
+func (c *LRUCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	clear(c.items)
+	clear(c.tags)
+}
+
+func (c *LRUCache) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (c *LRUCache) Close() error {
+	return nil
+}
+
+// lookup возвращает живую запись и поднимает ее в начало очереди.
+// Истекшая запись удаляется при обращении.
+func (c *LRUCache) lookup(key string) *entry {
+	el, ok := c.items[key]
+	if !ok {
+		return nil
+	}
+	e := el.Value.(*entry)
+	if e.expired(time.Now()) {
+		c.remove(el)
+		return nil
+	}
+	c.order.MoveToFront(el)
+	return e
+}
+
+func (c *LRUCache) set(key, value string, expiration time.Duration) {
+	var expiresAt time.Time
+	if expiration > 0 {
+		expiresAt = time.Now().Add(expiration)
+	}
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry)
+		e.value, e.expiresAt = value, expiresAt
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&entry{key: key, value: value, expiresAt: expiresAt})
+	for c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *LRUCache) remove(el *list.Element) {
+	e := el.Value.(*entry)
+	c.order.Remove(el)
+	delete(c.items, e.key)
+	c.untag(e)
+}
+
+func (c *LRUCache) untag(e *entry) {
+	for _, tag := range e.tags {
+		if keys, ok := c.tags[tag]; ok {
+			delete(keys, e.key)
+			if len(keys) == 0 {
+				delete(c.tags, tag)
+			}
+		}
+	}
+	e.tags = nil
+}
+
+// encode - как в Redis: строки и []byte как есть, остальное в JSON
+func encode(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal value: %w", err)
+		}
+		return string(data), nil
+	}
+}
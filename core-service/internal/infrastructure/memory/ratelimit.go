@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+)
+
+// maxIdleBuckets - при стольких ведрах полные (простаивающие) удаляются
+const maxIdleBuckets = 10000
+
+type bucket struct {
+	tokens float64
+	at     time.Time
+}
+
+// RateLimiter - маркерные ведра в памяти процесса. Лимит действует на
+// каждую реплику отдельно, поэтому годится только вместе с кэшем memory.
+type RateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+var _ interfaces.RateLimiter = (*RateLimiter)(nil)
+
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{buckets: make(map[string]*bucket)}
+}
+
+func (l *RateLimiter) Allow(ctx context.Context, key string, limit domain.RateLimit) (domain.RateLimitDecision, error) {
+	burst := float64(max(limit.Burst, 1))
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxIdleBuckets {
+			l.prune(now, limit)
+		}
+		b = &bucket{tokens: burst, at: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.at).Seconds()*limit.Rate)
+	b.at = now
+
+	if b.tokens < 1 {
+		wait := time.Duration(math.Ceil((1 - b.tokens) / limit.Rate * float64(time.Second)))
+		return domain.RateLimitDecision{Remaining: 0, RetryAfter: wait}, nil
+	}
+	b.tokens--
+	return domain.RateLimitDecision{Allowed: true, Remaining: int(b.tokens)}, nil
+}
+
+// prune удаляет ведра, которые уже наполнились бы целиком: для них
+// новое ведро ничем не отличается от старого.
+func (l *RateLimiter) prune(now time.Time, limit domain.RateLimit) {
+	full := time.Duration(float64(max(limit.Burst, 1)) / limit.Rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.at) >= full {
+			delete(l.buckets, key)
+		}
+	}
+}
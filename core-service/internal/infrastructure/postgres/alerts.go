@@ -0,0 +1,226 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+)
+
+// --- ПРАВИЛА ОПОВЕЩЕНИЙ ---
+
+const alertRuleColumns = `id, name, type, COALESCE(cohort_id, 0), threshold, window_days, cluster, created_by, created_at`
+
+func scanAlertRule(row interface{ Scan(...any) error }) (*domain.AlertRule, error) {
+	r := &domain.AlertRule{}
+	err := row.Scan(&r.ID, &r.Name, &r.Type, &r.CohortID, &r.Threshold, &r.WindowDays, &r.Cluster, &r.CreatedBy, &r.CreatedAt)
+	return r, err
+}
+
+func (r *PostgresRepository) CreateAlertRule(ctx context.Context, rule *domain.AlertRule) error {
+	query := `
+		INSERT INTO alert_rules (name, type, cohort_id, threshold, window_days, cluster, created_by)
+		VALUES ($1, $2, NULLIF($3::bigint, 0), $4, $5, $6, $7)
+		RETURNING id, created_at`
+	return r.db.QueryRowContext(ctx, query,
+		rule.Name, rule.Type, rule.CohortID, rule.Threshold, rule.WindowDays, rule.Cluster, rule.CreatedBy,
+	).Scan(&rule.ID, &rule.CreatedAt)
+}
+
+func (r *PostgresRepository) GetAlertRule(ctx context.Context, id uint64) (*domain.AlertRule, error) {
+	rule, err := scanAlertRule(r.db.QueryRowContext(ctx, `SELECT `+alertRuleColumns+` FROM alert_rules WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return rule, err
+}
+
+func (r *PostgresRepository) ListAlertRules(ctx context.Context, cohortID uint64) ([]*domain.AlertRule, error) {
+	query := `SELECT ` + alertRuleColumns + ` FROM alert_rules
+		WHERE $1::bigint = 0 OR cohort_id = $1
+		ORDER BY id`
+	return r.queryAlertRules(ctx, query, cohortID)
+}
+
+func (r *PostgresRepository) GetAlertRulesForStudent(ctx context.Context, studentID uint64) ([]*domain.AlertRule, error) {
+	query := `SELECT ` + alertRuleColumns + ` FROM alert_rules
+		WHERE cohort_id IS NULL
+			OR cohort_id IN (SELECT cohort_id FROM cohort_students WHERE student_id = $1)
+		ORDER BY id`
+	return r.queryAlertRules(ctx, query, studentID)
+}
+
+func (r *PostgresRepository) queryAlertRules(ctx context.Context, query string, args ...any) ([]*domain.AlertRule, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []*domain.AlertRule
+	for rows.Next() {
+		rule, err := scanAlertRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func (r *PostgresRepository) DeleteAlertRule(ctx context.Context, id uint64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM alert_rules WHERE id = $1`, id)
+	return err
+}
+
+// --- ДАННЫЕ ДЛЯ ПРАВИЛ ---
+
+func (r *PostgresRepository) GetAnswerStats(ctx context.Context, studentID uint64, since time.Time) (int, int, error) {
+	var answers, correct int
+	query := `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE correct)
+		FROM student_logs
+		WHERE student_id = $1 AND timestamp >= $2 AND action_type = ANY($3)`
+	err := r.db.QueryRowContext(ctx, query, studentID, since, pq.Array(domain.AnswerActions())).Scan(&answers, &correct)
+	return answers, correct, err
+}
+
+// GetInactiveStudents: таблицы студентов нет, поэтому без когорты
+// известны только записанные в какую-нибудь когорту и те, у кого есть
+// логи.
+func (r *PostgresRepository) GetInactiveStudents(ctx context.Context, cohortID uint64, since time.Time) ([]uint64, error) {
+	query := `
+		SELECT s.student_id FROM (
+			SELECT student_id FROM cohort_students WHERE $1::bigint = 0 OR cohort_id = $1
+			UNION
+			SELECT student_id FROM student_logs WHERE $1::bigint = 0
+		) s
+		LEFT JOIN student_logs l ON l.student_id = s.student_id AND l.timestamp >= $2
+		WHERE l.student_id IS NULL
+		ORDER BY s.student_id`
+	rows, err := r.db.QueryContext(ctx, query, cohortID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uint64
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// --- ОПОВЕЩЕНИЯ ---
+
+const alertColumns = `id, COALESCE(rule_id, 0), rule_name, type, student_id, message, status, raised_at,
+	acknowledged_at, acknowledged_by, resolved_at, resolved_by`
+
+func scanAlert(row interface{ Scan(...any) error }) (*domain.Alert, error) {
+	a := &domain.Alert{}
+	var ackAt, resolvedAt sql.NullTime
+	err := row.Scan(&a.ID, &a.RuleID, &a.RuleName, &a.Type, &a.StudentID, &a.Message, &a.Status, &a.RaisedAt,
+		&ackAt, &a.AcknowledgedBy, &resolvedAt, &a.ResolvedBy)
+	if ackAt.Valid {
+		a.AcknowledgedAt = &ackAt.Time
+	}
+	if resolvedAt.Valid {
+		a.ResolvedAt = &resolvedAt.Time
+	}
+	return a, err
+}
+
+func (r *PostgresRepository) CreateAlert(ctx context.Context, a *domain.Alert) (bool, error) {
+	query := `
+		INSERT INTO alerts (rule_id, rule_name, type, student_id, message, status)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (rule_id, student_id) WHERE status <> 'resolved' DO NOTHING
+		RETURNING id, raised_at`
+	err := r.db.QueryRowContext(ctx, query, a.RuleID, a.RuleName, a.Type, a.StudentID, a.Message, a.Status).
+		Scan(&a.ID, &a.RaisedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *PostgresRepository) GetAlert(ctx context.Context, id uint64) (*domain.Alert, error) {
+	a, err := scanAlert(r.db.QueryRowContext(ctx, `SELECT `+alertColumns+` FROM alerts WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return a, err
+}
+
+func (r *PostgresRepository) ListAlerts(ctx context.Context, f domain.AlertFilter) ([]*domain.Alert, error) {
+	var where []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.Status != "" {
+		add("status = $%d", f.Status)
+	}
+	if f.StudentID != 0 {
+		add("student_id = $%d", f.StudentID)
+	}
+	if f.CohortID != 0 {
+		add("student_id IN (SELECT student_id FROM cohort_students WHERE cohort_id = $%d)", f.CohortID)
+	}
+
+	query := `SELECT ` + alertColumns + ` FROM alerts`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	query += ` ORDER BY raised_at DESC, id DESC`
+	if f.Limit > 0 {
+		args = append(args, f.Limit)
+		query += fmt.Sprintf(` LIMIT $%d`, len(args))
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var alerts []*domain.Alert
+	for rows.Next() {
+		a, err := scanAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts, rows.Err()
+}
+
+// UpdateAlertStatus меняет статус, только если оповещение все еще в
+// статусе from: два преподавателя не перезапишут решения друг друга.
+func (r *PostgresRepository) UpdateAlertStatus(ctx context.Context, id uint64, from, to domain.AlertStatus, actor string, at time.Time) (bool, error) {
+	var query string
+	switch to {
+	case domain.AlertAcknowledged:
+		query = `UPDATE alerts SET status = $3, acknowledged_at = $4, acknowledged_by = $5 WHERE id = $1 AND status = $2`
+	case domain.AlertResolved:
+		query = `UPDATE alerts SET status = $3, resolved_at = $4, resolved_by = $5 WHERE id = $1 AND status = $2`
+	default:
+		return false, fmt.Errorf("unsupported alert status %q", to)
+	}
+	res, err := r.db.ExecContext(ctx, query, id, from, to, at, actor)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
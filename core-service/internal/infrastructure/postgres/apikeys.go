@@ -0,0 +1,128 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+)
+
+const apiKeyColumns = `id, name, prefix, scopes, cohort_ids, created_at, revoked_at, last_used_at, last_used_ip`
+
+func scanAPIKey(row interface{ Scan(...any) error }) (*domain.APIKey, error) {
+	k := &domain.APIKey{}
+	var cohorts pq.Int64Array
+	var revokedAt, lastUsedAt sql.NullTime
+	err := row.Scan(&k.ID, &k.Name, &k.Prefix, pq.Array(&k.Scopes), &cohorts, &k.CreatedAt, &revokedAt, &lastUsedAt, &k.LastUsedIP)
+	k.CohortIDs = make([]uint64, len(cohorts))
+	for i, id := range cohorts {
+		k.CohortIDs[i] = uint64(id)
+	}
+	if revokedAt.Valid {
+		k.RevokedAt = &revokedAt.Time
+	}
+	if lastUsedAt.Valid {
+		k.LastUsedAt = &lastUsedAt.Time
+	}
+	return k, err
+}
+
+func cohortArray(ids []uint64) pq.Int64Array {
+	arr := make(pq.Int64Array, len(ids))
+	for i, id := range ids {
+		arr[i] = int64(id)
+	}
+	return arr
+}
+
+func (r *PostgresRepository) CreateAPIKey(ctx context.Context, key *domain.APIKey, hash []byte) error {
+	return createAPIKey(ctx, r.db, key, hash)
+}
+
+func createAPIKey(ctx context.Context, q interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}, key *domain.APIKey, hash []byte) error {
+	query := `
+		INSERT INTO api_keys (name, prefix, key_hash, scopes, cohort_ids)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+	return q.QueryRowContext(ctx, query, key.Name, key.Prefix, hash, pq.Array(key.Scopes), cohortArray(key.CohortIDs)).
+		Scan(&key.ID, &key.CreatedAt)
+}
+
+func (r *PostgresRepository) GetAPIKey(ctx context.Context, id uint64) (*domain.APIKey, error) {
+	k, err := scanAPIKey(r.db.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return k, err
+}
+
+func (r *PostgresRepository) GetActiveAPIKeyByHash(ctx context.Context, hash []byte, now time.Time) (*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys
+		WHERE key_hash = $1 AND (revoked_at IS NULL OR revoked_at > $2)`
+	k, err := scanAPIKey(r.db.QueryRowContext(ctx, query, hash, now))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return k, err
+}
+
+func (r *PostgresRepository) ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*domain.APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (r *PostgresRepository) RotateAPIKey(ctx context.Context, id uint64, next *domain.APIKey, hash []byte, revokeAt time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Отзыв, назначенный раньше, не откладываем
+	res, err := tx.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = LEAST(COALESCE(revoked_at, $2), $2)
+		WHERE id = $1`, id, revokeAt)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("api key %d not found", id)
+	}
+	if err := createAPIKey(ctx, tx, next, hash); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresRepository) RevokeAPIKey(ctx context.Context, id uint64, at time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE api_keys SET revoked_at = LEAST(COALESCE(revoked_at, $2), $2) WHERE id = $1`, id, at)
+	return err
+}
+
+func (r *PostgresRepository) TouchAPIKey(ctx context.Context, id uint64, ip string, at time.Time, minInterval time.Duration) error {
+	query := `
+		UPDATE api_keys SET last_used_at = $2, last_used_ip = $3
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $4)`
+	_, err := r.db.ExecContext(ctx, query, id, at, ip, at.Add(-minInterval))
+	return err
+}
@@ -0,0 +1,149 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+)
+
+// SaveAuditEntries пишет записи одним запросом через unnest, чтобы
+// BatchAnalyze по сотне студентов не делал сотню вставок.
+func (r *PostgresRepository) SaveAuditEntries(ctx context.Context, entries []*domain.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	n := len(entries)
+	var (
+		occurredAt = make([]time.Time, n)
+		actorTypes = make([]string, n)
+		actors     = make([]string, n)
+		actions    = make([]string, n)
+		methods    = make([]string, n)
+		endpoints  = make([]string, n)
+		paths      = make([]string, n)
+		students   = make([]sql.NullInt64, n)
+		cohorts    = make([]sql.NullInt64, n)
+		statuses   = make([]int64, n)
+		ips        = make([]string, n)
+	)
+	for i, e := range entries {
+		occurredAt[i] = e.OccurredAt
+		actorTypes[i], actors[i], actions[i] = e.ActorType, e.Actor, e.Action
+		methods[i], endpoints[i], paths[i] = e.Method, e.Endpoint, e.Path
+		if e.StudentID != nil {
+			students[i] = sql.NullInt64{Int64: int64(*e.StudentID), Valid: true}
+		}
+		if e.CohortID != nil {
+			cohorts[i] = sql.NullInt64{Int64: int64(*e.CohortID), Valid: true}
+		}
+		statuses[i] = int64(e.Status)
+		ips[i] = e.IP
+	}
+
+	query := `
+		INSERT INTO audit_log (occurred_at, actor_type, actor, action, method, endpoint, path, student_id, cohort_id, status, ip)
+		SELECT * FROM unnest($1::timestamptz[], $2::text[], $3::text[], $4::text[], $5::text[], $6::text[], $7::text[],
+			$8::bigint[], $9::bigint[], $10::integer[], $11::text[])`
+	_, err := r.db.ExecContext(ctx, query,
+		pq.Array(occurredAt), pq.Array(actorTypes), pq.Array(actors), pq.Array(actions), pq.Array(methods),
+		pq.Array(endpoints), pq.Array(paths), pq.Array(students), pq.Array(cohorts), pq.Array(statuses), pq.Array(ips))
+	return err
+}
+
+func (r *PostgresRepository) ListAuditEntries(ctx context.Context, f domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	var where []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.ActorType != "" {
+		add("actor_type = $%d", f.ActorType)
+	}
+	if f.Actor != "" {
+		add("actor = $%d", f.Actor)
+	}
+	if f.Action != "" {
+		add("action = $%d", f.Action)
+	}
+	if f.StudentID != 0 {
+		add("student_id = $%d", f.StudentID)
+	}
+	if f.CohortID != 0 {
+		add("cohort_id = $%d", f.CohortID)
+	}
+	if !f.From.IsZero() {
+		add("occurred_at >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		add("occurred_at < $%d", f.To)
+	}
+	if f.BeforeID != 0 {
+		add("id < $%d", f.BeforeID)
+	}
+
+	query := `SELECT id, occurred_at, actor_type, actor, action, method, endpoint, path, student_id, cohort_id, status, ip FROM audit_log`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	query += ` ORDER BY id DESC`
+	if f.Limit > 0 {
+		args = append(args, f.Limit)
+		query += fmt.Sprintf(` LIMIT $%d`, len(args))
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*domain.AuditEntry
+	for rows.Next() {
+		e := &domain.AuditEntry{}
+		var studentID, cohortID sql.NullInt64
+		if err := rows.Scan(&e.ID, &e.OccurredAt, &e.ActorType, &e.Actor, &e.Action, &e.Method, &e.Endpoint, &e.Path,
+			&studentID, &cohortID, &e.Status, &e.IP); err != nil {
+			return nil, err
+		}
+		if studentID.Valid {
+			id := uint64(studentID.Int64)
+			e.StudentID = &id
+		}
+		if cohortID.Valid {
+			id := uint64(cohortID.Int64)
+			e.CohortID = &id
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// DeleteAuditEntriesBefore - единственный способ удалить записи журнала:
+// триггер пропускает DELETE только с audit.retention = on в транзакции.
+func (r *PostgresRepository) DeleteAuditEntriesBefore(ctx context.Context, before time.Time) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT set_config('audit.retention', 'on', true)`); err != nil {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM audit_log WHERE occurred_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
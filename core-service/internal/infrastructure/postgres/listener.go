@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// AnalyticsSavedChannel - канал NOTIFY, в который триггер на
+// student_analytics пишет id студента после каждого анализа.
+const AnalyticsSavedChannel = "student_analytics_saved"
+
+// ListenAnalyticsSaved вызывает fn на каждый сохраненный анализ и
+// блокируется до отмены ctx. Уведомления, пришедшие во время обрыва
+// соединения, теряются: NOTIFY не хранится.
+func ListenAnalyticsSaved(ctx context.Context, dsn string, logger *slog.Logger, fn func(ctx context.Context, studentID uint64)) error {
+	listener := pq.NewListener(dsn, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		switch ev {
+		case pq.ListenerEventDisconnected:
+			logger.Warn("analytics listener disconnected", slog.String("error", fmt.Sprint(err)))
+		case pq.ListenerEventReconnected:
+			logger.Info("analytics listener reconnected")
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(AnalyticsSavedChannel); err != nil {
+		return fmt.Errorf("failed to listen %s: %w", AnalyticsSavedChannel, err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n := <-listener.Notify:
+			// nil приходит после переподключения
+			if n == nil {
+				continue
+			}
+			studentID, err := strconv.ParseUint(n.Extra, 10, 64)
+			if err != nil {
+				logger.Warn("invalid analytics notification", slog.String("payload", n.Extra))
+				continue
+			}
+			fn(ctx, studentID)
+		case <-time.After(90 * time.Second):
+			// Проверяем соединение, иначе тихий обрыв заметим не скоро
+			go listener.Ping()
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+)
+
+var _ interfaces.Locker = (*PostgresRepository)(nil)
+
+// TryLock берет сессионную advisory-блокировку Postgres. Она живет на
+// соединении, поэтому соединение держится из пула на все время fn.
+func (r *PostgresRepository) TryLock(ctx context.Context, name string, fn func(ctx context.Context) error) (bool, error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get connection for lock: %w", err)
+	}
+	defer conn.Close()
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, name).Scan(&acquired); err != nil {
+		return false, fmt.Errorf("failed to acquire lock %q: %w", name, err)
+	}
+	if !acquired {
+		return false, nil
+	}
+
+	defer func() {
+		// Снимаем блокировку и при отмененном ctx; если не вышло, соединение
+		// выбрасывается из пула, и Postgres отпускает блокировку сам
+		unlockCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		if _, err := conn.ExecContext(unlockCtx, `SELECT pg_advisory_unlock(hashtext($1))`, name); err != nil {
+			conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}()
+	return true, fn(ctx)
+}
@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+)
+
+// Таблицы, где студент встречается по student_id. students (профиль) и
+// xapi_statements (исходные statement'ы с именем и почтой актора)
+// удаляются в обоих режимах; audit_log не трогаем - это след того, кто
+// и когда обращался к данным.
+var (
+	erasedTables = []string{
+		"student_logs",
+		"student_analytics_history",
+		"student_analytics",
+		"alerts",
+		"cohort_students",
+		"xapi_statements",
+	}
+	pseudonymizedTables = []string{
+		"student_logs",
+		"student_analytics_history",
+		"student_analytics",
+		"alerts",
+		"cohort_students",
+	}
+)
+
+// EraseStudent удаляет все данные студента одной транзакцией.
+func (r *PostgresRepository) EraseStudent(ctx context.Context, studentID uint64) (*domain.StudentErasure, error) {
+	erasure := &domain.StudentErasure{StudentID: studentID, Mode: domain.ErasureModeErase, Rows: make(map[string]int64)}
+	err := r.withErasureTx(ctx, func(tx *sql.Tx) error {
+		if err := studentCohorts(ctx, tx, studentID, erasure); err != nil {
+			return err
+		}
+		for _, table := range erasedTables {
+			if err := execCount(ctx, tx, erasure, table,
+				fmt.Sprintf(`DELETE FROM %s WHERE student_id = $1`, table), studentID); err != nil {
+				return err
+			}
+		}
+		return deleteStudentRecords(ctx, tx, studentID, erasure)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return erasure, nil
+}
+
+// AnonymizeStudent переносит логи, аналитику, оповещения и членство в
+// когортах на pseudonymID, а профиль и все, что содержит имя или почту,
+// удаляет. Агрегаты по когортам после этого не меняются. pseudonymID
+// должен быть новым: совпадение с существующим студентом откатит
+// транзакцию на первичном ключе student_analytics.
+func (r *PostgresRepository) AnonymizeStudent(ctx context.Context, studentID, pseudonymID uint64) (*domain.StudentErasure, error) {
+	erasure := &domain.StudentErasure{
+		StudentID:   studentID,
+		Mode:        domain.ErasureModeAnonymize,
+		PseudonymID: pseudonymID,
+		Rows:        make(map[string]int64),
+	}
+	err := r.withErasureTx(ctx, func(tx *sql.Tx) error {
+		if err := studentCohorts(ctx, tx, studentID, erasure); err != nil {
+			return err
+		}
+		for _, table := range pseudonymizedTables {
+			if err := execCount(ctx, tx, erasure, table,
+				fmt.Sprintf(`UPDATE %s SET student_id = $2 WHERE student_id = $1`, table), studentID, pseudonymID); err != nil {
+				return err
+			}
+		}
+		if err := execCount(ctx, tx, erasure, "xapi_statements",
+			`DELETE FROM xapi_statements WHERE student_id = $1`, studentID); err != nil {
+			return err
+		}
+		return deleteStudentRecords(ctx, tx, studentID, erasure)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return erasure, nil
+}
+
+// withErasureTx выполняет fn с student_data.erasure = on: триггер истории
+// аналитики не пишет перенос на псевдоним как новый анализ и не шлет NOTIFY.
+func (r *PostgresRepository) withErasureTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT set_config('student_data.erasure', 'on', true)`); err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func studentCohorts(ctx context.Context, tx *sql.Tx, studentID uint64, erasure *domain.StudentErasure) error {
+	rows, err := tx.QueryContext(ctx, `SELECT cohort_id FROM cohort_students WHERE student_id = $1`, studentID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		erasure.CohortIDs = append(erasure.CohortIDs, id)
+	}
+	return rows.Err()
+}
+
+// deleteStudentRecords удаляет доставки вебхуков о студенте (в payload
+// его ID и аналитика) и сам профиль.
+func deleteStudentRecords(ctx context.Context, tx *sql.Tx, studentID uint64, erasure *domain.StudentErasure) error {
+	if err := execCount(ctx, tx, erasure, "webhook_deliveries",
+		`DELETE FROM webhook_deliveries WHERE payload->'data'->>'student_id' = $1::text`, studentID); err != nil {
+		return err
+	}
+	return execCount(ctx, tx, erasure, "students", `DELETE FROM students WHERE id = $1`, studentID)
+}
+
+func execCount(ctx context.Context, tx *sql.Tx, erasure *domain.StudentErasure, table, query string, args ...any) error {
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to erase %s: %w", table, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	erasure.Rows[table] = n
+	return nil
+}
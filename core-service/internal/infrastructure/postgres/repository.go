@@ -4,11 +4,15 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/XSAM/otelsql"
 	_ "github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
 	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/metrics"
 )
 
 type PostgresRepository struct {
@@ -16,7 +20,8 @@ type PostgresRepository struct {
 }
 
 func NewPostgresRepository(dsn string) (interfaces.Repository, error) {
-	db, err := sql.Open("postgres", dsn)
+	// otelsql оборачивает драйвер: каждый запрос становится дочерним спаном
+	db, err := otelsql.Open("postgres", dsn, otelsql.WithAttributes(semconv.DBSystemPostgreSQL))
 	if err != nil {
 		return nil, fmt.Errorf("error opening db: %w", err)
 	}
@@ -28,7 +33,16 @@ func NewPostgresRepository(dsn string) (interfaces.Repository, error) {
 		return nil, fmt.Errorf("db unreachable: %w", err)
 	}
 
-	return &PostgresRepository{db: db}, nil
+	if err := metrics.RegisterDBStats(db, "student_analytics"); err != nil {
+		return nil, fmt.Errorf("failed to register db metrics: %w", err)
+	}
+
+	repo := &PostgresRepository{db: db}
+	if err := repo.migrate(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return repo, nil
 }
 
 // --- СТУДЕНТЫ ---
@@ -82,7 +96,110 @@ func (r *PostgresRepository) SaveLog(ctx context.Context, log *domain.StudentLog
 	return err
 }
 
+// maxLogsPerInsert - в запросе Postgres не больше 65535 параметров, на
+// строку лога их 5
+const maxLogsPerInsert = 65535 / 5
+
+// SaveLogs делит большую пачку на несколько INSERT в одной транзакции:
+// пачка по-прежнему сохраняется целиком или не сохраняется вовсе.
+func (r *PostgresRepository) SaveLogs(ctx context.Context, logs []*domain.StudentLog) (int, error) {
+	if len(logs) == 0 {
+		return 0, nil
+	}
+	if len(logs) <= maxLogsPerInsert {
+		return insertLogs(ctx, r.db, logs)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	total := 0
+	for start := 0; start < len(logs); start += maxLogsPerInsert {
+		n, err := insertLogs(ctx, tx, logs[start:min(start+maxLogsPerInsert, len(logs))])
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, tx.Commit()
+}
+
+func insertLogs(ctx context.Context, q interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}, logs []*domain.StudentLog) (int, error) {
+	var values strings.Builder
+	args := make([]interface{}, 0, len(logs)*5)
+	for i, l := range logs {
+		if i > 0 {
+			values.WriteString(", ")
+		}
+		n := i * 5
+		fmt.Fprintf(&values, "($%d::bigint, $%d::text, $%d::boolean, $%d::integer, $%d::timestamptz)", n+1, n+2, n+3, n+4, n+5)
+		args = append(args, l.StudentID, l.ActionType, l.Correct, l.TimeSpentSec, l.Timestamp)
+	}
+
+	// Уникального ключа у student_logs нет, поэтому дубли отсекаются NOT EXISTS
+	query := `
+		INSERT INTO student_logs (student_id, action_type, correct, time_spent_sec, timestamp)
+		SELECT v.student_id, v.action_type, v.correct, v.time_spent_sec, v.ts
+		FROM (VALUES ` + values.String() + `) AS v (student_id, action_type, correct, time_spent_sec, ts)
+		WHERE NOT EXISTS (
+			SELECT 1 FROM student_logs l
+			WHERE l.student_id = v.student_id AND l.action_type = v.action_type AND l.timestamp = v.ts
+		)`
+	res, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	inserted, err := res.RowsAffected()
+	return int(inserted), err
+}
+
+func (r *PostgresRepository) SaveXAPIStatement(ctx context.Context, st *domain.XAPIStatement) (bool, error) {
+	// Вставленная строка не видна второму SELECT (тот же снимок), поэтому
+	// строка в ответе всегда одна: новая или уже сохраненная
+	query := `
+		WITH inserted AS (
+			INSERT INTO xapi_statements (id, student_id, verb_id, action_type, statement, timestamp, stored_at, log_pending)
+			VALUES ($1, NULLIF($2::bigint, 0), $3, NULLIF($4::text, ''), $5, $6, $7, $4::text <> '')
+			ON CONFLICT (id) DO NOTHING
+			RETURNING log_pending
+		)
+		SELECT log_pending FROM inserted
+		UNION ALL
+		SELECT log_pending FROM xapi_statements WHERE id = $1`
+	// Raw передается строкой: []byte lib/pq отправляет как bytea
+	var pending bool
+	err := r.db.QueryRowContext(ctx, query,
+		st.ID, int64(st.StudentID), st.VerbID, st.ActionType, string(st.Raw), st.Timestamp, st.StoredAt).Scan(&pending)
+	if err == sql.ErrNoRows {
+		// Statement удален между вставкой и чтением - отправлять нечего
+		return false, nil
+	}
+	return pending, err
+}
+
+func (r *PostgresRepository) MarkXAPILogSent(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE xapi_statements SET log_pending = FALSE WHERE id = $1`, id)
+	return err
+}
+
 func (r *PostgresRepository) GetLogsByStudentID(ctx context.Context, id uint64, f, t time.Time) ([]*domain.StudentLog, error) {
+	var logs []*domain.StudentLog
+	err := r.StreamLogsByStudentID(ctx, id, f, t, func(l *domain.StudentLog) error {
+		logs = append(logs, l)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+func (r *PostgresRepository) StreamLogsByStudentID(ctx context.Context, id uint64, f, t time.Time, fn func(*domain.StudentLog) error) error {
 	query := `
 		SELECT student_id, action_type, correct, time_spent_sec, timestamp 
 		FROM student_logs 
@@ -90,19 +207,20 @@ func (r *PostgresRepository) GetLogsByStudentID(ctx context.Context, id uint64,
 		ORDER BY timestamp DESC`
 	rows, err := r.db.QueryContext(ctx, query, id, f, t)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
-	var logs []*domain.StudentLog
 	for rows.Next() {
 		l := &domain.StudentLog{}
 		if err := rows.Scan(&l.StudentID, &l.ActionType, &l.Correct, &l.TimeSpentSec, &l.Timestamp); err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(l); err != nil {
+			return err
 		}
-		logs = append(logs, l)
 	}
-	return logs, nil
+	return rows.Err()
 }
 
 func (r *PostgresRepository) GetLogsByMaterialID(ctx context.Context, m string) ([]*domain.StudentLog, error) {
@@ -143,6 +261,165 @@ func (r *PostgresRepository) UpdateAnalytics(ctx context.Context, a *domain.Stud
 	return err
 }
 
+// GetStudentsWithNewLogs сравнивает время приема лога (created_at), а не
+// timestamp клиента: с ним лог за прошлую неделю, принятый после анализа,
+// выглядел бы старым.
+func (r *PostgresRepository) GetStudentsWithNewLogs(ctx context.Context, since time.Time) ([]uint64, error) {
+	query := `
+		SELECT l.student_id FROM student_logs l
+		WHERE l.created_at > $1
+		GROUP BY l.student_id
+		HAVING MAX(l.created_at) > COALESCE(
+			(SELECT MAX(a.analyzed_at) FROM student_analytics a WHERE a.student_id = l.student_id),
+			'-infinity')
+		ORDER BY l.student_id`
+	rows, err := r.db.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uint64
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *PostgresRepository) GetAnalyticsHistory(ctx context.Context, id uint64, f, t time.Time) ([]*domain.StudentAnalytics, error) {
+	query := `
+		SELECT id, student_id, COALESCE(cluster_group, ''), COALESCE(engagement_score, 0),
+			COALESCE(avg_time_per_task, 0), COALESCE(success_rate, 0), analyzed_at
+		FROM student_analytics_history
+		WHERE student_id = $1 AND analyzed_at BETWEEN $2 AND $3
+		ORDER BY analyzed_at`
+	rows, err := r.db.QueryContext(ctx, query, id, f, t)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []*domain.StudentAnalytics
+	for rows.Next() {
+		a := &domain.StudentAnalytics{}
+		if err := rows.Scan(&a.ID, &a.StudentID, &a.ClusterGroup, &a.EngagementScore, &a.AvgTimePerTask, &a.SuccessRate, &a.AnalyzedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, a)
+	}
+	return history, rows.Err()
+}
+
+// --- КОГОРТЫ ---
+
+func (r *PostgresRepository) GetCohortByID(ctx context.Context, id uint64) (*domain.Cohort, error) {
+	c := &domain.Cohort{}
+	query := `SELECT id, name FROM cohorts WHERE id = $1`
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&c.ID, &c.Name)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+func (r *PostgresRepository) EnsureLTICohort(ctx context.Context, issuer, contextID, title string) (*domain.Cohort, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	c := &domain.Cohort{}
+	query := `
+		SELECT c.id, c.name FROM lti_contexts l
+		JOIN cohorts c ON c.id = l.cohort_id
+		WHERE l.issuer = $1 AND l.context_id = $2
+		FOR UPDATE OF c`
+	err = tx.QueryRowContext(ctx, query, issuer, contextID).Scan(&c.ID, &c.Name)
+	switch {
+	case err == sql.ErrNoRows:
+		if err := tx.QueryRowContext(ctx, `INSERT INTO cohorts (name) VALUES ($1) RETURNING id`, title).Scan(&c.ID); err != nil {
+			return nil, err
+		}
+		c.Name = title
+		// Параллельный первый запуск из того же курса: побеждает первая
+		// вставка, наша транзакция откатится и запуск можно повторить
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO lti_contexts (issuer, context_id, cohort_id) VALUES ($1, $2, $3)`,
+			issuer, contextID, c.ID); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	case title != "" && c.Name != title:
+		// Курс переименовали в LMS
+		if _, err := tx.ExecContext(ctx, `UPDATE cohorts SET name = $2 WHERE id = $1`, c.ID, title); err != nil {
+			return nil, err
+		}
+		c.Name = title
+	}
+	return c, tx.Commit()
+}
+
+func (r *PostgresRepository) GetCohortStudentIDs(ctx context.Context, cohortID uint64) ([]uint64, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT student_id FROM cohort_students WHERE cohort_id = $1 ORDER BY student_id`, cohortID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uint64
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *PostgresRepository) IsStudentInCohort(ctx context.Context, cohortID, studentID uint64) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM cohort_students WHERE cohort_id = $1 AND student_id = $2)`
+	err := r.db.QueryRowContext(ctx, query, cohortID, studentID).Scan(&exists)
+	return exists, err
+}
+
+func (r *PostgresRepository) StreamCohortAnalytics(ctx context.Context, cohortID uint64, f, t time.Time, fn func(*domain.StudentAnalytics) error) error {
+	query := `
+		SELECT a.student_id, a.cluster_group, a.engagement_score, a.avg_time_per_task, a.success_rate, a.analyzed_at
+		FROM student_analytics a
+		JOIN cohort_students cs ON cs.student_id = a.student_id
+		WHERE cs.cohort_id = $1 AND a.analyzed_at BETWEEN $2 AND $3
+		ORDER BY a.student_id`
+	rows, err := r.db.QueryContext(ctx, query, cohortID, f, t)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		a := &domain.StudentAnalytics{}
+		if err := rows.Scan(&a.StudentID, &a.ClusterGroup, &a.EngagementScore, &a.AvgTimePerTask, &a.SuccessRate, &a.AnalyzedAt); err != nil {
+			return err
+		}
+		if err := fn(a); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *PostgresRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
 func (r *PostgresRepository) Close() error {
 	return r.db.Close()
 }
\ No newline at end of file
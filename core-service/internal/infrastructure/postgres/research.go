@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+)
+
+// cohortFilter - условие на студентов когорты $1; 0 - все студенты.
+const cohortFilter = `($1 = 0 OR student_id IN (SELECT student_id FROM cohort_students WHERE cohort_id = $1))`
+
+// StreamResearchLogs отдает логи студентов когорты (0 - всех) за период,
+// сгруппированные по студенту.
+func (r *PostgresRepository) StreamResearchLogs(ctx context.Context, cohortID uint64, f, t time.Time, fn func(*domain.StudentLog) error) error {
+	query := `
+		SELECT student_id, action_type, correct, time_spent_sec, timestamp
+		FROM student_logs
+		WHERE timestamp BETWEEN $2 AND $3 AND ` + cohortFilter + `
+		ORDER BY student_id, timestamp`
+	rows, err := r.db.QueryContext(ctx, query, cohortID, f, t)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		l := &domain.StudentLog{}
+		if err := rows.Scan(&l.StudentID, &l.ActionType, &l.Correct, &l.TimeSpentSec, &l.Timestamp); err != nil {
+			return err
+		}
+		if err := fn(l); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// StreamResearchAnalytics отдает аналитику вместе с размером самой малой
+// группы (когорта, кластер) студента - той же, что в GetCohortAggregates,
+// чтобы сервис применил к строкам тот же порог k.
+func (r *PostgresRepository) StreamResearchAnalytics(ctx context.Context, cohortID uint64, f, t time.Time, fn func(*domain.ResearchAnalytics) error) error {
+	query := `
+		WITH sizes AS (
+			SELECT cs.cohort_id, a.cluster_group, COUNT(*) AS students
+			FROM student_analytics a
+			JOIN cohort_students cs ON cs.student_id = a.student_id
+			WHERE a.analyzed_at BETWEEN $2 AND $3 AND ($1 = 0 OR cs.cohort_id = $1)
+			GROUP BY cs.cohort_id, a.cluster_group
+		)
+		SELECT student_id, cluster_group, engagement_score, avg_time_per_task, success_rate, analyzed_at,
+			COALESCE((
+				SELECT MIN(s.students)
+				FROM cohort_students cs
+				JOIN sizes s ON s.cohort_id = cs.cohort_id AND s.cluster_group = a.cluster_group
+				WHERE cs.student_id = a.student_id
+			), 0)
+		FROM student_analytics a
+		WHERE analyzed_at BETWEEN $2 AND $3 AND ` + cohortFilter + `
+		ORDER BY student_id`
+	rows, err := r.db.QueryContext(ctx, query, cohortID, f, t)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		a := &domain.ResearchAnalytics{}
+		if err := rows.Scan(&a.StudentID, &a.ClusterGroup, &a.EngagementScore, &a.AvgTimePerTask, &a.SuccessRate, &a.AnalyzedAt, &a.GroupSize); err != nil {
+			return err
+		}
+		if err := fn(a); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// GetCohortAggregates считает средние последней аналитики по когорте и
+// кластеру. Малые группы не отбрасываются: порог k применяет сервис.
+func (r *PostgresRepository) GetCohortAggregates(ctx context.Context, cohortID uint64, f, t time.Time) ([]*domain.CohortAggregate, error) {
+	query := `
+		SELECT cs.cohort_id, a.cluster_group, COUNT(*),
+			AVG(a.engagement_score), AVG(a.success_rate), AVG(a.avg_time_per_task)
+		FROM student_analytics a
+		JOIN cohort_students cs ON cs.student_id = a.student_id
+		WHERE a.analyzed_at BETWEEN $2 AND $3 AND ($1 = 0 OR cs.cohort_id = $1)
+		GROUP BY cs.cohort_id, a.cluster_group
+		ORDER BY cs.cohort_id, a.cluster_group`
+	rows, err := r.db.QueryContext(ctx, query, cohortID, f, t)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var aggregates []*domain.CohortAggregate
+	for rows.Next() {
+		a := &domain.CohortAggregate{}
+		if err := rows.Scan(&a.CohortID, &a.ClusterGroup, &a.Students, &a.AvgEngagement, &a.AvgSuccessRate, &a.AvgTimePerTask); err != nil {
+			return nil, err
+		}
+		aggregates = append(aggregates, a)
+	}
+	return aggregates, rows.Err()
+}
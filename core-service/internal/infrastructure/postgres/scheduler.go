@@ -0,0 +1,25 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+func (r *PostgresRepository) LastJobRun(ctx context.Context, job string) (time.Time, error) {
+	var startedAt time.Time
+	err := r.db.QueryRowContext(ctx, `SELECT started_at FROM scheduler_runs WHERE job = $1`, job).Scan(&startedAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	return startedAt, err
+}
+
+func (r *PostgresRepository) RecordJobRun(ctx context.Context, job string, startedAt time.Time) error {
+	query := `
+		INSERT INTO scheduler_runs (job, started_at, finished_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (job) DO UPDATE SET started_at = EXCLUDED.started_at, finished_at = EXCLUDED.finished_at`
+	_, err := r.db.ExecContext(ctx, query, job, startedAt)
+	return err
+}
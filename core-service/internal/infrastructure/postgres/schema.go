@@ -0,0 +1,229 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+)
+
+// schema - таблицы, которыми владеет core-service. Базовые таблицы
+// (students, student_logs, student_analytics) создаются init.sql, здесь
+// только то, что добавлялось позже; все запросы идемпотентны.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS cohorts (
+		id   BIGSERIAL PRIMARY KEY,
+		name TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS cohort_students (
+		cohort_id  BIGINT NOT NULL REFERENCES cohorts (id) ON DELETE CASCADE,
+		student_id BIGINT NOT NULL,
+		PRIMARY KEY (cohort_id, student_id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS xapi_statements (
+		id          UUID PRIMARY KEY,
+		student_id  BIGINT,
+		verb_id     TEXT NOT NULL,
+		action_type TEXT,
+		statement   JSONB NOT NULL,
+		timestamp   TIMESTAMPTZ NOT NULL,
+		stored_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE INDEX IF NOT EXISTS xapi_statements_student_idx ON xapi_statements (student_id, timestamp)`,
+	// Лог по statement'у отправляется после его записи; флаг снимается,
+	// когда отправка удалась, и повтор пакета досылает неотправленные.
+	// Statement'ы, сохраненные до появления флага, считаются отправленными
+	`ALTER TABLE xapi_statements ADD COLUMN IF NOT EXISTS log_pending BOOLEAN NOT NULL DEFAULT FALSE`,
+	`CREATE TABLE IF NOT EXISTS lti_contexts (
+		issuer     TEXT NOT NULL,
+		context_id TEXT NOT NULL,
+		cohort_id  BIGINT NOT NULL REFERENCES cohorts (id) ON DELETE CASCADE,
+		PRIMARY KEY (issuer, context_id)
+	)`,
+	// student_analytics хранит одну строку на студента, история копится
+	// триггером при каждой записи, кто бы ее ни делал (core или Python).
+	// Тот же триггер уведомляет core-service о новом анализе (NOTIFY)
+	`CREATE TABLE IF NOT EXISTS student_analytics_history (
+		id                BIGSERIAL PRIMARY KEY,
+		student_id        BIGINT NOT NULL,
+		cluster_group     TEXT,
+		engagement_score  INTEGER,
+		avg_time_per_task DOUBLE PRECISION,
+		success_rate      DOUBLE PRECISION,
+		analyzed_at       TIMESTAMPTZ NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS student_analytics_history_student_idx ON student_analytics_history (student_id, analyzed_at)`,
+	`CREATE OR REPLACE FUNCTION record_student_analytics_history() RETURNS trigger AS $$
+	BEGIN
+		-- Перенос на псевдоним при анонимизации - не новый анализ
+		IF current_setting('student_data.erasure', true) = 'on' THEN
+			RETURN NEW;
+		END IF;
+		INSERT INTO student_analytics_history
+			(student_id, cluster_group, engagement_score, avg_time_per_task, success_rate, analyzed_at)
+		VALUES
+			(NEW.student_id, NEW.cluster_group, NEW.engagement_score, NEW.avg_time_per_task, NEW.success_rate, COALESCE(NEW.analyzed_at, NOW()));
+		PERFORM pg_notify('student_analytics_saved', NEW.student_id::text);
+		RETURN NEW;
+	END
+	$$ LANGUAGE plpgsql`,
+	// Базовой таблицы может не быть, если init.sql еще не применен
+	`DO $$
+	BEGIN
+		IF to_regclass('student_analytics') IS NOT NULL THEN
+			DROP TRIGGER IF EXISTS student_analytics_history_trg ON student_analytics;
+			CREATE TRIGGER student_analytics_history_trg
+				AFTER INSERT OR UPDATE ON student_analytics
+				FOR EACH ROW EXECUTE FUNCTION record_student_analytics_history();
+		END IF;
+	END
+	$$`,
+	// Время приема лога сервером: timestamp присылает клиент, и лог с
+	// прошлой датой, принятый сегодня, иначе не попал бы на переанализ.
+	// Уже лежащим логам достается время миграции
+	`DO $$
+	BEGIN
+		IF to_regclass('student_logs') IS NOT NULL THEN
+			ALTER TABLE student_logs ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+			CREATE INDEX IF NOT EXISTS student_logs_created_idx ON student_logs (created_at);
+		END IF;
+	END
+	$$`,
+	// Последний успешный запуск задачи планировщика - общий для реплик
+	`CREATE TABLE IF NOT EXISTS scheduler_runs (
+		job         TEXT PRIMARY KEY,
+		started_at  TIMESTAMPTZ NOT NULL,
+		finished_at TIMESTAMPTZ NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS alert_rules (
+		id          BIGSERIAL PRIMARY KEY,
+		name        TEXT NOT NULL,
+		type        TEXT NOT NULL,
+		cohort_id   BIGINT REFERENCES cohorts (id) ON DELETE CASCADE,
+		threshold   DOUBLE PRECISION NOT NULL DEFAULT 0,
+		window_days INTEGER NOT NULL DEFAULT 0,
+		cluster     TEXT NOT NULL DEFAULT '',
+		created_by  TEXT NOT NULL DEFAULT '',
+		created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE TABLE IF NOT EXISTS alerts (
+		id              BIGSERIAL PRIMARY KEY,
+		rule_id         BIGINT REFERENCES alert_rules (id) ON DELETE SET NULL,
+		rule_name       TEXT NOT NULL,
+		type            TEXT NOT NULL,
+		student_id      BIGINT NOT NULL,
+		message         TEXT NOT NULL,
+		status          TEXT NOT NULL DEFAULT 'open',
+		raised_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		acknowledged_at TIMESTAMPTZ,
+		acknowledged_by TEXT NOT NULL DEFAULT '',
+		resolved_at     TIMESTAMPTZ,
+		resolved_by     TEXT NOT NULL DEFAULT ''
+	)`,
+	// Одно нерешенное оповещение на правило и студента: реплики получают
+	// одно и то же уведомление, создаст оповещение только первая
+	`CREATE UNIQUE INDEX IF NOT EXISTS alerts_unresolved_idx ON alerts (rule_id, student_id) WHERE status <> 'resolved'`,
+	`CREATE INDEX IF NOT EXISTS alerts_student_idx ON alerts (student_id, raised_at)`,
+	`CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+		id         BIGSERIAL PRIMARY KEY,
+		url        TEXT NOT NULL,
+		events     TEXT[] NOT NULL,
+		secret     TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id               BIGSERIAL PRIMARY KEY,
+		subscription_id  BIGINT NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+		event            TEXT NOT NULL,
+		payload          JSONB NOT NULL,
+		status           TEXT NOT NULL DEFAULT 'pending',
+		attempts         INTEGER NOT NULL DEFAULT 0,
+		next_attempt_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		last_status_code INTEGER NOT NULL DEFAULT 0,
+		last_error       TEXT NOT NULL DEFAULT '',
+		created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		delivered_at     TIMESTAMPTZ
+	)`,
+	`CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending'`,
+	`CREATE INDEX IF NOT EXISTS webhook_deliveries_subscription_idx ON webhook_deliveries (subscription_id, created_at)`,
+	// Одно событие, замеченное несколькими репликами (NOTIFY приходит
+	// каждой), ставится подписке один раз. Без ключа (NULL) не сравнивается
+	`ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS event_key TEXT`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS webhook_deliveries_event_key_idx ON webhook_deliveries (subscription_id, event_key)`,
+	// Ключ ищется по SHA-256 от него самого; revoked_at в будущем - ключ
+	// после ротации, который еще действует
+	`CREATE TABLE IF NOT EXISTS api_keys (
+		id           BIGSERIAL PRIMARY KEY,
+		name         TEXT NOT NULL,
+		prefix       TEXT NOT NULL,
+		key_hash     BYTEA NOT NULL UNIQUE,
+		scopes       TEXT[] NOT NULL,
+		cohort_ids   BIGINT[] NOT NULL DEFAULT '{}',
+		created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		revoked_at   TIMESTAMPTZ,
+		last_used_at TIMESTAMPTZ,
+		last_used_ip TEXT NOT NULL DEFAULT ''
+	)`,
+	`CREATE TABLE IF NOT EXISTS audit_log (
+		id          BIGSERIAL PRIMARY KEY,
+		occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		actor_type  TEXT NOT NULL,
+		actor       TEXT NOT NULL DEFAULT '',
+		action      TEXT NOT NULL,
+		method      TEXT NOT NULL,
+		endpoint    TEXT NOT NULL,
+		path        TEXT NOT NULL DEFAULT '',
+		student_id  BIGINT,
+		cohort_id   BIGINT,
+		status      INTEGER NOT NULL DEFAULT 0,
+		ip          TEXT NOT NULL DEFAULT ''
+	)`,
+	`CREATE INDEX IF NOT EXISTS audit_log_occurred_idx ON audit_log (occurred_at)`,
+	`CREATE INDEX IF NOT EXISTS audit_log_student_idx ON audit_log (student_id, id) WHERE student_id IS NOT NULL`,
+	`CREATE INDEX IF NOT EXISTS audit_log_actor_idx ON audit_log (actor_type, actor, id)`,
+	// Журнал только дополняется: UPDATE запрещен всегда, DELETE - кроме
+	// очистки по сроку хранения, которая включает audit.retention в своей
+	// транзакции
+	`CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+	BEGIN
+		IF TG_OP = 'DELETE' AND current_setting('audit.retention', true) = 'on' THEN
+			RETURN OLD;
+		END IF;
+		RAISE EXCEPTION 'audit_log is append-only';
+	END
+	$$ LANGUAGE plpgsql`,
+	`DROP TRIGGER IF EXISTS audit_log_append_only_trg ON audit_log`,
+	`CREATE TRIGGER audit_log_append_only_trg
+		BEFORE UPDATE OR DELETE ON audit_log
+		FOR EACH ROW EXECUTE FUNCTION audit_log_append_only()`,
+	`DROP TRIGGER IF EXISTS audit_log_no_truncate_trg ON audit_log`,
+	`CREATE TRIGGER audit_log_no_truncate_trg
+		BEFORE TRUNCATE ON audit_log
+		FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only()`,
+}
+
+// migrateLock - имя advisory-блокировки миграции.
+const migrateLock = "core-service:migrate"
+
+// migrate применяет схему одной транзакцией под advisory-блокировкой:
+// реплики, стартующие одновременно, ждут друг друга, а не пересоздают
+// триггеры одновременно, и между DROP и CREATE TRIGGER запись в таблицу
+// не проходит мимо триггера. Блокировка снимается вместе с транзакцией.
+func (r *PostgresRepository) migrate(ctx context.Context) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, migrateLock); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	for _, stmt := range schema {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to apply schema: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+)
+
+// --- ПОДПИСКИ ---
+
+func (r *PostgresRepository) CreateWebhookSubscription(ctx context.Context, sub *domain.WebhookSubscription) error {
+	query := `
+		INSERT INTO webhook_subscriptions (url, events, secret)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at`
+	return r.db.QueryRowContext(ctx, query, sub.URL, pq.Array(sub.Events), sub.Secret).Scan(&sub.ID, &sub.CreatedAt)
+}
+
+// GetWebhookSubscription и ListWebhookSubscriptions секрет не читают
+func (r *PostgresRepository) GetWebhookSubscription(ctx context.Context, id uint64) (*domain.WebhookSubscription, error) {
+	sub := &domain.WebhookSubscription{}
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, url, events, created_at FROM webhook_subscriptions WHERE id = $1`, id,
+	).Scan(&sub.ID, &sub.URL, pq.Array(&sub.Events), &sub.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return sub, err
+}
+
+func (r *PostgresRepository) ListWebhookSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, url, events, created_at FROM webhook_subscriptions ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []*domain.WebhookSubscription
+	for rows.Next() {
+		sub := &domain.WebhookSubscription{}
+		if err := rows.Scan(&sub.ID, &sub.URL, pq.Array(&sub.Events), &sub.CreatedAt); err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+func (r *PostgresRepository) DeleteWebhookSubscription(ctx context.Context, id uint64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	return err
+}
+
+// --- ДОСТАВКИ ---
+
+const webhookDeliveryColumns = `id, subscription_id, event, payload, status, attempts, next_attempt_at,
+	last_status_code, last_error, created_at, delivered_at`
+
+func scanWebhookDelivery(row interface{ Scan(...any) error }) (*domain.WebhookDelivery, error) {
+	d := &domain.WebhookDelivery{}
+	var payload []byte
+	var deliveredAt sql.NullTime
+	err := row.Scan(&d.ID, &d.SubscriptionID, &d.Event, &payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+		&d.LastStatusCode, &d.LastError, &d.CreatedAt, &deliveredAt)
+	d.Payload = payload
+	if deliveredAt.Valid {
+		d.DeliveredAt = &deliveredAt.Time
+	}
+	return d, err
+}
+
+// EnqueueWebhookDeliveries одним запросом ставит доставку каждой подписке
+// на event и возвращает их число. Подписки, которым событие с тем же key
+// уже поставлено, пропускаются.
+func (r *PostgresRepository) EnqueueWebhookDeliveries(ctx context.Context, event, key string, payload []byte) (int, error) {
+	query := `
+		INSERT INTO webhook_deliveries (subscription_id, event, event_key, payload)
+		SELECT id, $1, NULLIF($2, ''), $3 FROM webhook_subscriptions WHERE $1 = ANY(events)
+		ON CONFLICT (subscription_id, event_key) DO NOTHING`
+	res, err := r.db.ExecContext(ctx, query, event, key, payload)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// ClaimWebhookDeliveries берет в работу до limit доставок, чей срок
+// подошел, и откладывает их на lease: другие реплики их не возьмут, а если
+// реплика упадет, доставка снова станет доступна после lease.
+func (r *PostgresRepository) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*domain.WebhookDeliveryTask, error) {
+	query := `
+		WITH due AS (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d SET next_attempt_at = NOW() + make_interval(secs => $2)
+		FROM due, webhook_subscriptions s
+		WHERE d.id = due.id AND s.id = d.subscription_id
+		RETURNING d.id, d.subscription_id, d.event, d.payload, d.status, d.attempts, d.next_attempt_at,
+			d.last_status_code, d.last_error, d.created_at, d.delivered_at, s.url, s.secret`
+	rows, err := r.db.QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []*domain.WebhookDeliveryTask
+	for rows.Next() {
+		t := &domain.WebhookDeliveryTask{Delivery: &domain.WebhookDelivery{}}
+		d := t.Delivery
+		var payload []byte
+		var deliveredAt sql.NullTime
+		err := rows.Scan(&d.ID, &d.SubscriptionID, &d.Event, &payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+			&d.LastStatusCode, &d.LastError, &d.CreatedAt, &deliveredAt, &t.URL, &t.Secret)
+		if err != nil {
+			return nil, err
+		}
+		d.Payload = payload
+		if deliveredAt.Valid {
+			d.DeliveredAt = &deliveredAt.Time
+		}
+		t.ClaimedUntil = d.NextAttemptAt
+		tasks = append(tasks, t)
+	}
+	return tasks, rows.Err()
+}
+
+// UpdateWebhookDelivery сохраняет результат попытки. Взятие сдвигает
+// next_attempt_at на срок lease, поэтому совпадение с ClaimedUntil
+// значит, что доставку с тех пор никто не брал и не переотправлял.
+func (r *PostgresRepository) UpdateWebhookDelivery(ctx context.Context, t *domain.WebhookDeliveryTask) (bool, error) {
+	d := t.Delivery
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, next_attempt_at = $4, last_status_code = $5, last_error = $6, delivered_at = $7
+		WHERE id = $1 AND status = 'pending' AND next_attempt_at = $8`
+	res, err := r.db.ExecContext(ctx, query,
+		d.ID, d.Status, d.Attempts, d.NextAttemptAt, d.LastStatusCode, d.LastError, d.DeliveredAt, t.ClaimedUntil,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *PostgresRepository) GetWebhookDelivery(ctx context.Context, id uint64) (*domain.WebhookDelivery, error) {
+	d, err := scanWebhookDelivery(r.db.QueryRowContext(ctx, `SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return d, err
+}
+
+func (r *PostgresRepository) ListWebhookDeliveries(ctx context.Context, f domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error) {
+	var where []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.SubscriptionID != 0 {
+		add("subscription_id = $%d", f.SubscriptionID)
+	}
+	if f.Status != "" {
+		add("status = $%d", f.Status)
+	}
+
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	query += ` ORDER BY created_at DESC, id DESC`
+	if f.Limit > 0 {
+		args = append(args, f.Limit)
+		query += fmt.Sprintf(` LIMIT $%d`, len(args))
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*domain.WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// RequeueWebhookDelivery возвращает доставку в очередь с полным набором
+// попыток; результат прошлых попыток остается до следующей.
+func (r *PostgresRepository) RequeueWebhookDelivery(ctx context.Context, id uint64, at time.Time) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = $2, delivered_at = NULL
+		WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id, at)
+	return err
+}
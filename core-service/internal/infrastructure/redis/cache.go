@@ -16,6 +16,10 @@ type RedisCache struct {
 }
 
 func NewRedisCache(addr, password string, db int) interfaces.Cache {
+    return newRedisCache(addr, password, db)
+}
+
+func newRedisCache(addr, password string, db int) *RedisCache {
     client := redis.NewClient(&redis.Options{
         Addr:     addr,
         Password: password,
@@ -35,23 +39,119 @@ func (c *RedisCache) Get(ctx context.Context, key string) (string, error) {
     return val, err
 }
 
+func (c *RedisCache) GetDel(ctx context.Context, key string) (string, error) {
+    val, err := c.client.GetDel(ctx, key).Result()
+    if err == redis.Nil {
+        return "", nil
+    }
+    return val, err
+}
+
 func (c *RedisCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
-    var data string
-    
+    data, err := encodeValue(value)
+    if err != nil {
+        return err
+    }
+    return c.client.Set(ctx, key, data, expiration).Err()
+}
+
+func (c *RedisCache) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+    data, err := encodeValue(value)
+    if err != nil {
+        return false, err
+    }
+    return c.client.SetNX(ctx, key, data, expiration).Result()
+}
+
+// tagKey - множество ключей, привязанных к тегу
+func tagKey(tag string) string {
+    return "tag:" + tag
+}
+
+// setWithTagsScript записывает значение и добавляет ключ в множества
+// тегов. Множество живет не меньше самого долгого своего ключа, чтобы
+// инвалидация не потеряла еще живые записи.
+var setWithTagsScript = redis.NewScript(`
+local ttl = tonumber(ARGV[2])
+if ttl > 0 then
+    redis.call('SET', KEYS[1], ARGV[1], 'PX', ttl)
+else
+    redis.call('SET', KEYS[1], ARGV[1])
+end
+for i = 2, #KEYS do
+    local left = redis.call('PTTL', KEYS[i])
+    redis.call('SADD', KEYS[i], KEYS[1])
+    if ttl > 0 then
+        -- -2: множества еще не было; -1: в нем есть бессрочные записи
+        if left == -2 or (left >= 0 and left < ttl) then
+            redis.call('PEXPIRE', KEYS[i], ttl)
+        end
+    else
+        redis.call('PERSIST', KEYS[i])
+    end
+end
+return 1
+`)
+
+// invalidateTagsScript удаляет ключи тегов вместе с самими множествами и
+// возвращает удаленные ключи.
+var invalidateTagsScript = redis.NewScript(`
+local keys = {}
+for i = 1, #KEYS do
+    for _, key in ipairs(redis.call('SMEMBERS', KEYS[i])) do
+        redis.call('DEL', key)
+        table.insert(keys, key)
+    end
+    redis.call('DEL', KEYS[i])
+end
+return keys
+`)
+
+// SetWithTags: запись и привязка к тегам выполняются одним скриптом,
+// иначе InvalidateTags между ними оставил бы непривязанную запись.
+func (c *RedisCache) SetWithTags(ctx context.Context, key string, value interface{}, expiration time.Duration, tags ...string) error {
+    data, err := encodeValue(value)
+    if err != nil {
+        return err
+    }
+    keys := make([]string, 0, len(tags)+1)
+    keys = append(keys, key)
+    for _, tag := range tags {
+        keys = append(keys, tagKey(tag))
+    }
+    return setWithTagsScript.Run(ctx, c.client, keys, data, expiration.Milliseconds()).Err()
+}
+
+func (c *RedisCache) InvalidateTags(ctx context.Context, tags ...string) error {
+    _, err := c.invalidateTags(ctx, tags)
+    return err
+}
+
+func (c *RedisCache) invalidateTags(ctx context.Context, tags []string) ([]string, error) {
+    if len(tags) == 0 {
+        return nil, nil
+    }
+    keys := make([]string, len(tags))
+    for i, tag := range tags {
+        keys[i] = tagKey(tag)
+    }
+    return invalidateTagsScript.Run(ctx, c.client, keys).StringSlice()
+}
+
+// encodeValue - строки и []byte пишутся как есть, остальное в JSON
+func encodeValue(value interface{}) (string, error) {
     switch v := value.(type) {
     case string:
-        data = v
+        return v, nil
     case []byte:
-        data = string(v)
+        return string(v), nil
     default:
         jsonData, err := json.Marshal(v)
         if err != nil {
-            return fmt.Errorf("failed to marshal value: %w", err)
+            return "", fmt.Errorf("failed to marshal value: %w", err)
         }
-        data = string(jsonData)
+        return string(jsonData), nil
     }
-    
-    return c.client.Set(ctx, key, data, expiration).Err()
 }
 
 func (c *RedisCache) Delete(ctx context.Context, key string) error {
@@ -63,6 +163,10 @@ func (c *RedisCache) Exists(ctx context.Context, key string) (bool, error) {
     return count > 0, err
 }
 
+func (c *RedisCache) Ping(ctx context.Context) error {
+    return c.client.Ping(ctx).Err()
+}
+
 func (c *RedisCache) Close() error {
     return c.client.Close()
 }
\ No newline at end of file
@@ -0,0 +1,71 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+)
+
+// tokenBucketScript пополняет ведро за прошедшее время и расходует
+// маркер. Время берется у Redis, чтобы расхождение часов реплик не
+// влияло на лимит. Пустое ведро хранится, пока не наполнится снова.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+    tokens, ts = burst, now
+end
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+
+local allowed, retry = 0, 0
+if tokens >= 1 then
+    tokens = tokens - 1
+    allowed = 1
+else
+    retry = math.ceil((1 - tokens) * 1000 / rate)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return {allowed, math.floor(tokens), retry}
+`)
+
+// RateLimiter - маркерные ведра в Redis, общие для всех реплик.
+type RateLimiter struct {
+	client *redis.Client
+}
+
+var _ interfaces.RateLimiter = (*RateLimiter)(nil)
+
+func NewRateLimiter(addr, password string, db int) *RateLimiter {
+	return &RateLimiter{client: redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       db,
+	})}
+}
+
+func (l *RateLimiter) Allow(ctx context.Context, key string, limit domain.RateLimit) (domain.RateLimitDecision, error) {
+	res, err := tokenBucketScript.Run(ctx, l.client, []string{key}, limit.Rate, max(limit.Burst, 1)).Int64Slice()
+	if err != nil {
+		return domain.RateLimitDecision{}, err
+	}
+	return domain.RateLimitDecision{
+		Allowed:    res[0] == 1,
+		Remaining:  int(res[1]),
+		RetryAfter: time.Duration(res[2]) * time.Millisecond,
+	}, nil
+}
+
+func (l *RateLimiter) Close() error {
+	return l.client.Close()
+}
@@ -0,0 +1,212 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/infrastructure/memory"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+)
+
+// InvalidationChannel - канал, в который реплики публикуют измененные ключи.
+const InvalidationChannel = "cache:invalidate"
+
+// TieredOptions - LocalTTL ограничивает срок локальной копии: столько
+// реплика может отдавать старое значение, если сообщение об изменении
+// до нее не дошло.
+type TieredOptions struct {
+	LocalMaxEntries int
+	LocalTTL        time.Duration
+}
+
+// TieredCache - локальный LRU перед Redis. Чтения сначала идут в память
+// процесса; запись и удаление меняют оба уровня и публикуют ключ в
+// InvalidationChannel, чтобы остальные реплики выбросили свою копию.
+type TieredCache struct {
+	remote   *RedisCache
+	local    *memory.LRUCache
+	localTTL time.Duration
+	// id отличает свои сообщения об изменениях от чужих
+	id     string
+	pubsub *redis.PubSub
+	done   chan struct{}
+	logger *slog.Logger
+}
+
+var _ interfaces.Cache = (*TieredCache)(nil)
+
+func NewTieredCache(addr, password string, db int, opts TieredOptions, logger *slog.Logger) *TieredCache {
+	if opts.LocalTTL <= 0 {
+		opts.LocalTTL = 30 * time.Second
+	}
+	id := make([]byte, 8)
+	rand.Read(id)
+
+	remote := newRedisCache(addr, password, db)
+	c := &TieredCache{
+		remote:   remote,
+		local:    memory.NewLRUCache(opts.LocalMaxEntries),
+		localTTL: opts.LocalTTL,
+		id:       hex.EncodeToString(id),
+		pubsub:   remote.client.Subscribe(context.Background(), InvalidationChannel),
+		done:     make(chan struct{}),
+		logger:   logger,
+	}
+	go c.listen()
+	return c
+}
+
+// listen выбрасывает локальные копии ключей, измененных другими
+// репликами. После переподключения к Redis сообщения могли потеряться,
+// поэтому локальный уровень очищается целиком.
+func (c *TieredCache) listen() {
+	defer close(c.done)
+	subscribed := false
+	for msg := range c.pubsub.ChannelWithSubscriptions() {
+		switch m := msg.(type) {
+		case *redis.Subscription:
+			if m.Kind != "subscribe" {
+				continue
+			}
+			if subscribed {
+				c.local.Clear()
+				c.logger.Info("cache invalidation channel resubscribed, local cache cleared")
+			}
+			subscribed = true
+		case *redis.Message:
+			sender, key, ok := strings.Cut(m.Payload, "\n")
+			if ok && sender != c.id {
+				c.local.Delete(context.Background(), key)
+			}
+		}
+	}
+}
+
+func (c *TieredCache) Get(ctx context.Context, key string) (string, error) {
+	if val, _ := c.local.Get(ctx, key); val != "" {
+		return val, nil
+	}
+	val, err := c.remote.Get(ctx, key)
+	if err != nil || val == "" {
+		return val, err
+	}
+	// Остаток TTL в Redis не запрашиваем: копия живет не дольше localTTL
+	c.local.Set(ctx, key, val, c.localTTL)
+	return val, nil
+}
+
+// GetDel решает только Redis: локальная копия могла бы отдать значение
+// второй раз.
+func (c *TieredCache) GetDel(ctx context.Context, key string) (string, error) {
+	val, err := c.remote.GetDel(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	c.local.Delete(ctx, key)
+	c.invalidate(ctx, key)
+	return val, nil
+}
+
+func (c *TieredCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	data, err := encodeValue(value)
+	if err != nil {
+		return err
+	}
+	if err := c.remote.Set(ctx, key, data, expiration); err != nil {
+		return err
+	}
+	c.local.Set(ctx, key, data, c.localExpiration(expiration))
+	c.invalidate(ctx, key)
+	return nil
+}
+
+// SetNX решает только Redis: блокировки должны быть общими для реплик.
+func (c *TieredCache) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	ok, err := c.remote.SetNX(ctx, key, value, expiration)
+	if err != nil || !ok {
+		return ok, err
+	}
+	c.local.Delete(ctx, key)
+	c.invalidate(ctx, key)
+	return true, nil
+}
+
+// SetWithTags: теги хранятся только в Redis, локальные копии удаляются
+// по списку ключей, который возвращает InvalidateTags.
+func (c *TieredCache) SetWithTags(ctx context.Context, key string, value interface{}, expiration time.Duration, tags ...string) error {
+	data, err := encodeValue(value)
+	if err != nil {
+		return err
+	}
+	if err := c.remote.SetWithTags(ctx, key, data, expiration, tags...); err != nil {
+		return err
+	}
+	c.local.Set(ctx, key, data, c.localExpiration(expiration))
+	c.invalidate(ctx, key)
+	return nil
+}
+
+func (c *TieredCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	keys, err := c.remote.invalidateTags(ctx, tags)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		c.local.Delete(ctx, key)
+		c.invalidate(ctx, key)
+	}
+	return nil
+}
+
+// Delete удаляет сначала из Redis: если удалить сначала локальную копию,
+// параллельный Get успеет взять из Redis старое значение и снова положить
+// его в память, где оно проживет до localTTL.
+func (c *TieredCache) Delete(ctx context.Context, key string) error {
+	if err := c.remote.Delete(ctx, key); err != nil {
+		return err
+	}
+	c.local.Delete(ctx, key)
+	c.invalidate(ctx, key)
+	return nil
+}
+
+func (c *TieredCache) Exists(ctx context.Context, key string) (bool, error) {
+	if ok, _ := c.local.Exists(ctx, key); ok {
+		return true, nil
+	}
+	return c.remote.Exists(ctx, key)
+}
+
+func (c *TieredCache) Ping(ctx context.Context) error {
+	return c.remote.Ping(ctx)
+}
+
+func (c *TieredCache) Close() error {
+	err := c.pubsub.Close()
+	<-c.done
+	if cerr := c.remote.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (c *TieredCache) localExpiration(expiration time.Duration) time.Duration {
+	if expiration > 0 && expiration < c.localTTL {
+		return expiration
+	}
+	return c.localTTL
+}
+
+// invalidate не возвращает ошибку: значение в Redis уже изменено, а
+// копии других реплик в худшем случае доживут до localTTL.
+func (c *TieredCache) invalidate(ctx context.Context, key string) {
+	if err := c.remote.client.Publish(ctx, InvalidationChannel, c.id+"\n"+key).Err(); err != nil {
+		c.logger.WarnContext(ctx, "failed to publish cache invalidation", slog.String("key", key), slog.String("error", err.Error()))
+	}
+}
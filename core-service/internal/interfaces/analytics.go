@@ -2,6 +2,8 @@ package interfaces
 
 import (
     "context"
+    "encoding/json"
+    "io"
     "time"
     
     "github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
@@ -18,25 +20,258 @@ type AnalyticsService interface {
     GetStudentByID(ctx context.Context, id uint64) (*domain.Student, error)
 }
 
+// ExportService пишет выгрузки в w построчно, по мере чтения из базы.
+type ExportService interface {
+    ExportStudentLogs(ctx context.Context, studentID uint64, opts domain.ExportOptions, w io.Writer) error
+    ExportCohortAnalytics(ctx context.Context, cohortID uint64, opts domain.ExportOptions, w io.Writer) error
+}
+
+// ReportService строит PDF-отчеты по аналитике студентов.
+type ReportService interface {
+    StudentReport(ctx context.Context, studentID uint64, opts domain.ReportOptions, w io.Writer) error
+    // CohortReports пишет в w ZIP с отчетами всех студентов когорты
+    CohortReports(ctx context.Context, cohortID uint64, opts domain.ReportOptions, w io.Writer) error
+}
+
+type ImportService interface {
+    Import(ctx context.Context, r io.Reader, opts domain.ImportOptions) (*domain.ImportReport, error)
+}
+
+// XAPIService - прием xAPI statement'ов. Возвращает id statement'ов в
+// порядке запроса, как того требует спецификация LRS.
+type XAPIService interface {
+    SaveStatements(ctx context.Context, statements []json.RawMessage) ([]string, error)
+}
+
+// LTIService - вход преподавателей из LMS по LTI 1.3.
+type LTIService interface {
+    // InitiateLogin проверяет параметры платформы и готовит перенаправление
+    // на ее OIDC-эндпоинт с новыми state и nonce.
+    InitiateLogin(ctx context.Context, req domain.LTILoginRequest) (*domain.LTIAuthRedirect, error)
+    // Launch проверяет id_token и state и открывает сессию, ограниченную
+    // курсом запуска. cookieState - state из cookie браузера, если он есть.
+    Launch(ctx context.Context, idToken, state, cookieState string) (*domain.Session, error)
+    GetSession(ctx context.Context, token string) (*domain.Session, error)
+    EndSession(ctx context.Context, token string) error
+}
+
 type Repository interface {
     SaveStudent(ctx context.Context, student *domain.Student) error
     GetStudentByID(ctx context.Context, id uint64) (*domain.Student, error)
     GetStudents(ctx context.Context) ([]uint64, error)
     
     SaveLog(ctx context.Context, log *domain.StudentLog) error
+    // SaveLogs вставляет пачку логов, пропуская уже существующие (тот же
+    // студент, тип действия и время), и возвращает число вставленных
+    SaveLogs(ctx context.Context, logs []*domain.StudentLog) (int, error)
+    // SaveXAPIStatement сохраняет statement (повторная отправка с тем же
+    // id ничего не меняет) и сообщает, что его лог еще не отправлен: новый
+    // statement с действием или сохраненный ранее, отправка которого не
+    // удалась. MarkXAPILogSent снимает эту отметку.
+    SaveXAPIStatement(ctx context.Context, st *domain.XAPIStatement) (bool, error)
+    MarkXAPILogSent(ctx context.Context, id string) error
     GetLogsByStudentID(ctx context.Context, studentID uint64, from, to time.Time) ([]*domain.StudentLog, error)
     GetLogsByMaterialID(ctx context.Context, materialID string) ([]*domain.StudentLog, error)
+    // GetStudentsWithNewLogs - студенты, у которых есть логи, принятые
+    // после since и после последнего анализа, включая еще ни разу не
+    // анализированных
+    GetStudentsWithNewLogs(ctx context.Context, since time.Time) ([]uint64, error)
+    // LastJobRun - начало последнего успешного запуска задачи планировщика
+    // (нулевое время, если его не было); RecordJobRun его запоминает
+    LastJobRun(ctx context.Context, job string) (time.Time, error)
+    RecordJobRun(ctx context.Context, job string, startedAt time.Time) error
+    // StreamLogsByStudentID вызывает fn для каждой строки, не собирая результат в память
+    StreamLogsByStudentID(ctx context.Context, studentID uint64, from, to time.Time, fn func(*domain.StudentLog) error) error
     
     SaveAnalytics(ctx context.Context, analytics *domain.StudentAnalytics) error
     GetAnalyticsByStudentID(ctx context.Context, studentID uint64) (*domain.StudentAnalytics, error)
     UpdateAnalytics(ctx context.Context, analytics *domain.StudentAnalytics) error
+    // GetAnalyticsHistory - все результаты анализа студента за период, от старых к новым
+    GetAnalyticsHistory(ctx context.Context, studentID uint64, from, to time.Time) ([]*domain.StudentAnalytics, error)
+
+    GetCohortByID(ctx context.Context, id uint64) (*domain.Cohort, error)
+    // EnsureLTICohort возвращает когорту, привязанную к курсу LMS, и
+    // создает ее при первом запуске из этого курса.
+    EnsureLTICohort(ctx context.Context, issuer, contextID, title string) (*domain.Cohort, error)
+    GetCohortStudentIDs(ctx context.Context, cohortID uint64) ([]uint64, error)
+    IsStudentInCohort(ctx context.Context, cohortID, studentID uint64) (bool, error)
+    StreamCohortAnalytics(ctx context.Context, cohortID uint64, from, to time.Time, fn func(*domain.StudentAnalytics) error) error
+
+    CreateAlertRule(ctx context.Context, rule *domain.AlertRule) error
+    GetAlertRule(ctx context.Context, id uint64) (*domain.AlertRule, error)
+    // ListAlertRules - правила когорты; cohortID = 0 - все правила
+    ListAlertRules(ctx context.Context, cohortID uint64) ([]*domain.AlertRule, error)
+    // GetAlertRulesForStudent - общие правила и правила когорт студента
+    GetAlertRulesForStudent(ctx context.Context, studentID uint64) ([]*domain.AlertRule, error)
+    DeleteAlertRule(ctx context.Context, id uint64) error
+    // GetAnswerStats - число ответов и верных ответов студента начиная с since
+    GetAnswerStats(ctx context.Context, studentID uint64, since time.Time) (answers int, correct int, err error)
+    // GetInactiveStudents - студенты когорты (0 - все), у которых нет логов
+    // начиная с since, в том числе вообще без логов
+    GetInactiveStudents(ctx context.Context, cohortID uint64, since time.Time) ([]uint64, error)
+    // CreateAlert сохраняет оповещение и сообщает, было ли оно новым: пока
+    // есть нерешенное оповещение по тому же правилу и студенту, второе не создается
+    CreateAlert(ctx context.Context, alert *domain.Alert) (bool, error)
+    GetAlert(ctx context.Context, id uint64) (*domain.Alert, error)
+    ListAlerts(ctx context.Context, filter domain.AlertFilter) ([]*domain.Alert, error)
+    UpdateAlertStatus(ctx context.Context, id uint64, from, to domain.AlertStatus, actor string, at time.Time) (bool, error)
 
+    CreateWebhookSubscription(ctx context.Context, sub *domain.WebhookSubscription) error
+    GetWebhookSubscription(ctx context.Context, id uint64) (*domain.WebhookSubscription, error)
+    ListWebhookSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error)
+    DeleteWebhookSubscription(ctx context.Context, id uint64) error
+    // EnqueueWebhookDeliveries ставит доставку события всем подпискам на
+    // него; непустой key ставит событие подписке не больше одного раза
+    EnqueueWebhookDeliveries(ctx context.Context, event, key string, payload []byte) (int, error)
+    // ClaimWebhookDeliveries берет в работу доставки, чей срок подошел, и
+    // откладывает их на lease, чтобы их не взяла другая реплика
+    ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*domain.WebhookDeliveryTask, error)
+    // UpdateWebhookDelivery сохраняет результат попытки, только если
+    // доставка все еще взята этой задачей; false - lease истек и ее взяла
+    // другая реплика или ее переотправили вручную
+    UpdateWebhookDelivery(ctx context.Context, task *domain.WebhookDeliveryTask) (bool, error)
+    GetWebhookDelivery(ctx context.Context, id uint64) (*domain.WebhookDelivery, error)
+    ListWebhookDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error)
+    RequeueWebhookDelivery(ctx context.Context, id uint64, at time.Time) error
+
+    CreateAPIKey(ctx context.Context, key *domain.APIKey, hash []byte) error
+    GetAPIKey(ctx context.Context, id uint64) (*domain.APIKey, error)
+    // GetActiveAPIKeyByHash ищет ключ, не отозванный к моменту now
+    GetActiveAPIKeyByHash(ctx context.Context, hash []byte, now time.Time) (*domain.APIKey, error)
+    ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error)
+    // RotateAPIKey в одной транзакции выпускает next с настройками ключа
+    // id и назначает старому ключу отзыв на revokeAt
+    RotateAPIKey(ctx context.Context, id uint64, next *domain.APIKey, hash []byte, revokeAt time.Time) error
+    RevokeAPIKey(ctx context.Context, id uint64, at time.Time) error
+    // TouchAPIKey запоминает последнее использование, но пишет не чаще
+    // раза в minInterval
+    TouchAPIKey(ctx context.Context, id uint64, ip string, at time.Time, minInterval time.Duration) error
+
+    SaveAuditEntries(ctx context.Context, entries []*domain.AuditEntry) error
+    ListAuditEntries(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, error)
+    // DeleteAuditEntriesBefore - очистка журнала по сроку хранения
+    DeleteAuditEntriesBefore(ctx context.Context, before time.Time) (int64, error)
+
+    // EraseStudent удаляет все данные студента, кроме журнала доступа
+    EraseStudent(ctx context.Context, studentID uint64) (*domain.StudentErasure, error)
+    // AnonymizeStudent переносит логи и аналитику на pseudonymID и удаляет профиль
+    AnonymizeStudent(ctx context.Context, studentID, pseudonymID uint64) (*domain.StudentErasure, error)
+
+    // StreamResearchLogs и StreamResearchAnalytics - данные студентов
+    // когорты (0 - всех) для исследовательской выгрузки
+    StreamResearchLogs(ctx context.Context, cohortID uint64, from, to time.Time, fn func(*domain.StudentLog) error) error
+    StreamResearchAnalytics(ctx context.Context, cohortID uint64, from, to time.Time, fn func(*domain.ResearchAnalytics) error) error
+    GetCohortAggregates(ctx context.Context, cohortID uint64, from, to time.Time) ([]*domain.CohortAggregate, error)
+
+    Ping(ctx context.Context) error
     Close() error
 }
 
+// AlertService - правила оповещений о студентах в зоне риска и сами оповещения.
+type AlertService interface {
+    CreateRule(ctx context.Context, rule *domain.AlertRule) error
+    ListRules(ctx context.Context) ([]*domain.AlertRule, error)
+    DeleteRule(ctx context.Context, id uint64) error
+
+    // EvaluateStudent проверяет правила после нового анализа студента
+    EvaluateStudent(ctx context.Context, studentID uint64) error
+    // EvaluateInactivity проверяет правила неактивности; вызывается по расписанию
+    EvaluateInactivity(ctx context.Context) error
+
+    ListAlerts(ctx context.Context, filter domain.AlertFilter) ([]*domain.Alert, error)
+    Acknowledge(ctx context.Context, id uint64) (*domain.Alert, error)
+    Resolve(ctx context.Context, id uint64) (*domain.Alert, error)
+}
+
+// Notifier доставляет новое оповещение в один канал (webhook, почта).
+type Notifier interface {
+    Name() string
+    Notify(ctx context.Context, alert *domain.Alert) error
+}
+
+// EventPublisher рассылает события сервиса внешним подписчикам.
+type EventPublisher interface {
+    Publish(ctx context.Context, event string, data any) error
+}
+
+// WebhookService - подписки внешних систем (LMS) на события и журнал доставок.
+type WebhookService interface {
+    EventPublisher
+
+    CreateSubscription(ctx context.Context, sub *domain.WebhookSubscription) error
+    ListSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error)
+    DeleteSubscription(ctx context.Context, id uint64) error
+
+    // AnalyticsUpdated публикует analytics.updated с результатом нового анализа
+    AnalyticsUpdated(ctx context.Context, studentID uint64) error
+
+    ListDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error)
+    // Redeliver возвращает доставку в очередь, в том числе уже успешную
+    Redeliver(ctx context.Context, id uint64) (*domain.WebhookDelivery, error)
+}
+
+// APIKeyService выпускает ключи интеграций и проверяет их в запросах.
+type APIKeyService interface {
+    // CreateKey заполняет key.Key - единственный раз, когда ключ виден целиком
+    CreateKey(ctx context.Context, key *domain.APIKey) error
+    ListKeys(ctx context.Context) ([]*domain.APIKey, error)
+    // RotateKey выпускает новый ключ с теми же настройками; старый
+    // действует еще grace
+    RotateKey(ctx context.Context, id uint64, grace time.Duration) (*domain.APIKey, error)
+    RevokeKey(ctx context.Context, id uint64) error
+    // Authenticate возвращает действующий ключ или ошибку KindUnauthorized
+    Authenticate(ctx context.Context, token, ip string) (*domain.APIKey, error)
+}
+
+// AuditService ведет журнал доступа к данным студентов и изменений
+// настроек.
+type AuditService interface {
+    // Record дополняет записи субъектом из контекста (сессия, API-ключ) и
+    // временем и сохраняет их
+    Record(ctx context.Context, entries []*domain.AuditEntry) error
+    List(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, error)
+    // PurgeExpired удаляет записи старше срока хранения; вызывается по расписанию
+    PurgeExpired(ctx context.Context) error
+}
+
+// PrivacyService исполняет запросы субъекта данных: выгрузку всех
+// данных студента и их удаление.
+type PrivacyService interface {
+    // ExportStudentData пишет в w ZIP с профилем, логами и историей аналитики в JSON
+    ExportStudentData(ctx context.Context, studentID uint64, w io.Writer) error
+    // DeleteStudent удаляет (erase) или псевдонимизирует (anonymize) данные
+    // в Postgres и Redis и сообщает об этом аналитическому сервису
+    DeleteStudent(ctx context.Context, studentID uint64, mode string) (*domain.StudentErasure, error)
+}
+
+// ResearchService готовит выгрузки для исследований без персональных
+// данных: вместо ID студентов - псевдонимы, время огрублено.
+type ResearchService interface {
+    // Export пишет в w ZIP с логами, аналитикой и агрегатами по когортам в CSV
+    Export(ctx context.Context, opts domain.ResearchExportOptions, w io.Writer) error
+}
+
+// WebhookSender делает одну попытку доставки и возвращает код ответа.
+type WebhookSender interface {
+    Send(ctx context.Context, task *domain.WebhookDeliveryTask) (int, error)
+}
+
+// Locker - блокировка, общая для всех реплик сервиса.
+type Locker interface {
+    // TryLock выполняет fn, если удалось взять блокировку name, и сообщает,
+    // была ли она взята. Занятую блокировку TryLock не ждет.
+    TryLock(ctx context.Context, name string, fn func(ctx context.Context) error) (bool, error)
+}
+
+// RateLimiter расходует маркер из ведра key; ведра общие для реплик.
+type RateLimiter interface {
+    Allow(ctx context.Context, key string, limit domain.RateLimit) (domain.RateLimitDecision, error)
+}
+
 type MessageProducer interface {
     Send(ctx context.Context, topic string, key []byte, value []byte) error
     SendJSON(ctx context.Context, topic string, data interface{}) error
+    Ping(ctx context.Context) error
     Close() error
 }
 
@@ -45,6 +280,18 @@ type Cache interface {
     Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
     Delete(ctx context.Context, key string) error
     Exists(ctx context.Context, key string) (bool, error)
+    // SetNX записывает значение, только если ключа еще нет, и сообщает,
+    // было ли оно записано. Используется как короткая блокировка.
+    SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error)
+    // GetDel читает значение и удаляет ключ одной операцией: из
+    // одновременных вызовов значение получает только один.
+    GetDel(ctx context.Context, key string) (string, error)
+    // SetWithTags записывает значение и привязывает ключ к тегам
+    // (например student:5, cohort:2); InvalidateTags удаляет все ключи,
+    // привязанные к любому из тегов.
+    SetWithTags(ctx context.Context, key string, value interface{}, expiration time.Duration, tags ...string) error
+    InvalidateTags(ctx context.Context, tags ...string) error
+    Ping(ctx context.Context) error
     Close() error
 }
 
@@ -52,4 +299,9 @@ type AnalyticsClient interface {
     AnalyzeStudent(ctx context.Context, studentID uint64) (*domain.StudentAnalytics, error)
     HealthCheck(ctx context.Context) error
     Close() error
-}
\ No newline at end of file
+}
+
+type HealthChecker interface {
+    Check(ctx context.Context) *domain.HealthReport
+    Last() *domain.HealthReport
+}
@@ -0,0 +1,183 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+type server struct {
+	name     string
+	serve    func() error
+	shutdown func(ctx context.Context) error
+}
+
+type closer struct {
+	name  string
+	close func() error
+}
+
+// Manager управляет жизненным циклом процесса: запускает серверы и фоновые
+// воркеры, а при остановке гасит их в порядке, при котором не теряются
+// запросы и сообщения:
+//  1. серверы перестают принимать соединения и дожидаются текущих запросов;
+//  2. фоновые воркеры получают отмену контекста, и Manager ждет их выхода;
+//  3. закрываются ресурсы (Kafka producer сбрасывает буфер, затем пулы
+//     соединений) в порядке, обратном регистрации.
+//
+// Все три шага вместе ограничены shutdownTimeout.
+type Manager struct {
+	shutdownTimeout time.Duration
+	logger          *slog.Logger
+
+	servers []server
+	closers []closer
+
+	workersCtx    context.Context
+	cancelWorkers context.CancelFunc
+	workers       sync.WaitGroup
+
+	errCh chan error
+}
+
+func NewManager(shutdownTimeout time.Duration, logger *slog.Logger) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		shutdownTimeout: shutdownTimeout,
+		logger:          logger,
+		workersCtx:      ctx,
+		cancelWorkers:   cancel,
+		errCh:           make(chan error, 1),
+	}
+}
+
+// AddServer регистрирует сервер. serve блокируется до остановки сервера,
+// shutdown должен дождаться завершения текущих запросов или отмены ctx.
+func (m *Manager) AddServer(name string, serve func() error, shutdown func(ctx context.Context) error) {
+	m.servers = append(m.servers, server{name: name, serve: serve, shutdown: shutdown})
+}
+
+// AddCloser регистрирует ресурс, который закрывается после остановки серверов и воркеров.
+func (m *Manager) AddCloser(name string, close func() error) {
+	m.closers = append(m.closers, closer{name: name, close: close})
+}
+
+// Go запускает фоновый воркер. ctx отменяется при остановке, и Shutdown
+// ждет возврата fn.
+func (m *Manager) Go(name string, fn func(ctx context.Context)) {
+	m.workers.Add(1)
+	go func() {
+		defer m.workers.Done()
+		fn(m.workersCtx)
+		m.logger.Info("background worker stopped", slog.String("worker", name))
+	}()
+}
+
+// Task запускает короткую фоновую задачу (например, обновление кэша
+// после ответа клиенту). Как и для Go, ctx отменяется при остановке и
+// Shutdown ждет возврата fn, но о завершении задача в лог не пишет.
+func (m *Manager) Task(fn func(ctx context.Context)) {
+	m.workers.Add(1)
+	go func() {
+		defer m.workers.Done()
+		fn(m.workersCtx)
+	}()
+}
+
+// Run запускает серверы и блокируется, пока не отменен ctx (обычно по
+// сигналу) или пока один из серверов не упал. Сам Run ничего не
+// останавливает - для этого нужно вызвать Shutdown.
+func (m *Manager) Run(ctx context.Context) error {
+	for _, s := range m.servers {
+		go func(s server) {
+			m.logger.Info("server started", slog.String("server", s.name))
+			if err := s.serve(); err != nil {
+				select {
+				case m.errCh <- fmt.Errorf("%s server: %w", s.name, err):
+				default:
+				}
+			}
+		}(s)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-m.errCh:
+		return err
+	}
+}
+
+func (m *Manager) Shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.shutdownTimeout)
+	defer cancel()
+
+	var errs []error
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for _, s := range m.servers {
+		wg.Add(1)
+		go func(s server) {
+			defer wg.Done()
+			if err := s.shutdown(ctx); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s server: %w", s.name, err))
+				mu.Unlock()
+			}
+		}(s)
+	}
+	wg.Wait()
+
+	m.cancelWorkers()
+	if err := waitContext(ctx, m.workers.Wait); err != nil {
+		errs = append(errs, fmt.Errorf("background workers: %w", err))
+	}
+
+	for i := len(m.closers) - 1; i >= 0; i-- {
+		c := m.closers[i]
+		var closeErr error
+		err := waitContext(ctx, func() { closeErr = c.close() })
+		if err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// waitContext выполняет fn, но возвращается не позже отмены ctx.
+func waitContext(ctx context.Context, fn func()) error {
+	done := make(chan struct{})
+	go func() {
+		fn()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// GRPCShutdown возвращает shutdown-функцию для gRPC-сервера: GracefulStop
+// дожидается текущих RPC, а по истечении ctx соединения рвутся через Stop.
+func GRPCShutdown(s *grpc.Server) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		err := waitContext(ctx, s.GracefulStop)
+		if err != nil {
+			s.Stop()
+		}
+		return err
+	}
+}
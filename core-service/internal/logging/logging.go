@@ -0,0 +1,120 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// New создает логгер с уровнем level (debug, info, warn, error) и форматом
+// format (json или text). Атрибуты из контекста (см. With) добавляются
+// к каждой записи автоматически, если писать через *Context-методы.
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q", format)
+	}
+
+	return slog.New(contextHandler{handler}), nil
+}
+
+// Nop возвращает логгер, который ничего не пишет. Нужен в тестах.
+func Nop() *slog.Logger {
+	return slog.New(slog.DiscardHandler)
+}
+
+type ctxKey struct{}
+
+type ctxFields struct {
+	requestID string
+	attrs     []slog.Attr
+}
+
+// With возвращает контекст, все записи лога в котором получат attrs.
+// Атрибут с уже существующим ключом заменяет прежнее значение.
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	fields := fieldsFrom(ctx)
+	merged := make([]slog.Attr, 0, len(fields.attrs)+len(attrs))
+	for _, existing := range fields.attrs {
+		if !hasKey(attrs, existing.Key) {
+			merged = append(merged, existing)
+		}
+	}
+	merged = append(merged, attrs...)
+	return context.WithValue(ctx, ctxKey{}, ctxFields{requestID: fields.requestID, attrs: merged})
+}
+
+func hasKey(attrs []slog.Attr, key string) bool {
+	for _, a := range attrs {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	fields := fieldsFrom(ctx)
+	fields.requestID = requestID
+	return context.WithValue(ctx, ctxKey{}, fields)
+}
+
+func WithStudentID(ctx context.Context, studentID uint64) context.Context {
+	return With(ctx, slog.Uint64("student_id", studentID))
+}
+
+// RequestID возвращает ID запроса из контекста или пустую строку.
+func RequestID(ctx context.Context) string {
+	return fieldsFrom(ctx).requestID
+}
+
+// NewRequestID генерирует случайный ID для запросов, пришедших без X-Request-ID.
+func NewRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func fieldsFrom(ctx context.Context) ctxFields {
+	fields, _ := ctx.Value(ctxKey{}).(ctxFields)
+	return fields
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	fields := fieldsFrom(ctx)
+	if fields.requestID != "" {
+		r.AddAttrs(slog.String("request_id", fields.requestID))
+	}
+	r.AddAttrs(fields.attrs...)
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
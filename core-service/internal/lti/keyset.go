@@ -0,0 +1,92 @@
+// Package lti реализует проверку LTI 1.3 запусков: ключи платформы
+// (JWKS) и разбор id_token с LTI-claim'ами.
+package lti
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// KeySet - публичные ключи платформы по kid. Ключи задаются локальным
+// файлом, а не скачиваются с платформы: смена ключа в Moodle требует
+// явного обновления файла, и подменить ключ через сеть нельзя.
+type KeySet struct {
+	keys map[string]*rsa.PublicKey
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// LoadKeySet читает JWKS ({"keys": [...]}) из файла.
+func LoadKeySet(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read platform key set: %w", err)
+	}
+	return ParseKeySet(data)
+}
+
+// ParseKeySet разбирает JWKS. Ключи не RSA и ключи не для подписи
+// пропускаются: LTI 1.3 подписывает id_token только RS256.
+func ParseKeySet(data []byte) (*KeySet, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse platform key set: %w", err)
+	}
+
+	ks := &KeySet{keys: make(map[string]*rsa.PublicKey)}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("platform key %q: %w", k.Kid, err)
+		}
+		ks.keys[k.Kid] = key
+	}
+	if len(ks.keys) == 0 {
+		return nil, errors.New("platform key set contains no RSA signing keys")
+	}
+	return ks, nil
+}
+
+// Key возвращает ключ по kid. Если kid не указан, а ключ один, берется он.
+func (ks *KeySet) Key(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" && len(ks.keys) == 1 {
+		for _, key := range ks.keys {
+			return key, true
+		}
+	}
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+func (k jwk) publicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() < 3 {
+		return nil, errors.New("invalid exponent")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
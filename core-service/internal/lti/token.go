@@ -0,0 +1,148 @@
+package lti
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claim'ы LTI 1.3 Core.
+const (
+	claimMessageType  = "https://purl.imsglobal.org/spec/lti/claim/message_type"
+	claimVersion      = "https://purl.imsglobal.org/spec/lti/claim/version"
+	claimDeploymentID = "https://purl.imsglobal.org/spec/lti/claim/deployment_id"
+	claimRoles        = "https://purl.imsglobal.org/spec/lti/claim/roles"
+	claimContext      = "https://purl.imsglobal.org/spec/lti/claim/context"
+
+	MessageTypeResourceLink = "LtiResourceLinkRequest"
+	Version                 = "1.3.0"
+)
+
+// Роли LIS, которым открыт дашборд. Учащиеся сюда не входят.
+var (
+	instructorRoles = []string{
+		"http://purl.imsglobal.org/vocab/lis/v2/membership#Instructor",
+		"http://purl.imsglobal.org/vocab/lis/v2/membership/Instructor#TeachingAssistant",
+		// Администратор курса управляет только своим курсом и видит его
+		// как преподаватель
+		"http://purl.imsglobal.org/vocab/lis/v2/membership#Administrator",
+		// Короткая форма из LTI 1.1, Moodle присылает ее в части ролей
+		"Instructor",
+	}
+	// Администраторы учреждения и системы: им открыты настройки по всем курсам
+	administratorRoles = []string{
+		"http://purl.imsglobal.org/vocab/lis/v2/institution/person#Administrator",
+		"http://purl.imsglobal.org/vocab/lis/v2/system/person#Administrator",
+	}
+)
+
+// ErrInvalidToken - id_token не прошел проверку. Подробности в тексте
+// ошибки; клиенту они не отдаются.
+var ErrInvalidToken = errors.New("invalid id_token")
+
+type Context struct {
+	ID    string   `json:"id"`
+	Label string   `json:"label,omitempty"`
+	Title string   `json:"title,omitempty"`
+	Type  []string `json:"type,omitempty"`
+}
+
+// LaunchClaims - содержимое id_token, которое нужно для запуска.
+type LaunchClaims struct {
+	jwt.RegisteredClaims
+	AuthorizedParty string   `json:"azp,omitempty"`
+	Nonce           string   `json:"nonce"`
+	Name            string   `json:"name,omitempty"`
+	MessageType     string   `json:"https://purl.imsglobal.org/spec/lti/claim/message_type"`
+	Version         string   `json:"https://purl.imsglobal.org/spec/lti/claim/version"`
+	DeploymentID    string   `json:"https://purl.imsglobal.org/spec/lti/claim/deployment_id"`
+	Roles           []string `json:"https://purl.imsglobal.org/spec/lti/claim/roles"`
+	Context         *Context `json:"https://purl.imsglobal.org/spec/lti/claim/context,omitempty"`
+}
+
+func (c *LaunchClaims) IsInstructor() bool {
+	return containsAny(c.Roles, instructorRoles)
+}
+
+func (c *LaunchClaims) IsAdministrator() bool {
+	return containsAny(c.Roles, administratorRoles)
+}
+
+func containsAny(roles, wanted []string) bool {
+	for _, r := range roles {
+		if slices.Contains(wanted, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Platform - зарегистрированная у нас LMS: кто выпускает токены, под каким
+// client_id зарегистрирован инструмент и какие deployment разрешены
+// (пустой список - любые).
+type Platform struct {
+	Issuer        string
+	ClientID      string
+	DeploymentIDs []string
+	Keys          *KeySet
+}
+
+// leeway покрывает расхождение часов LMS и сервиса.
+const leeway = time.Minute
+
+// Validate проверяет подпись, iss, aud/azp, сроки и обязательные LTI
+// claim'ы. Nonce сверяет вызывающий: он знает, какой nonce выдавал.
+func (p *Platform) Validate(idToken string, now time.Time) (*LaunchClaims, error) {
+	claims := &LaunchClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, p.keyFunc,
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(p.Issuer),
+		jwt.WithAudience(p.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(leeway),
+		jwt.WithTimeFunc(func() time.Time { return now }),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	// При нескольких получателях спецификация требует azp = client_id
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.ClientID {
+		return nil, fmt.Errorf("%w: azp %q does not match client_id", ErrInvalidToken, claims.AuthorizedParty)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: sub is required", ErrInvalidToken)
+	}
+	if claims.Nonce == "" {
+		return nil, fmt.Errorf("%w: nonce is required", ErrInvalidToken)
+	}
+	if claims.MessageType != MessageTypeResourceLink {
+		return nil, fmt.Errorf("%w: unsupported %s %q", ErrInvalidToken, claimMessageType, claims.MessageType)
+	}
+	if claims.Version != Version {
+		return nil, fmt.Errorf("%w: unsupported %s %q", ErrInvalidToken, claimVersion, claims.Version)
+	}
+	if claims.DeploymentID == "" || (len(p.DeploymentIDs) > 0 && !slices.Contains(p.DeploymentIDs, claims.DeploymentID)) {
+		return nil, fmt.Errorf("%w: unknown %s %q", ErrInvalidToken, claimDeploymentID, claims.DeploymentID)
+	}
+	if claims.Roles == nil {
+		return nil, fmt.Errorf("%w: %s is required", ErrInvalidToken, claimRoles)
+	}
+	if claims.Context == nil || claims.Context.ID == "" {
+		return nil, fmt.Errorf("%w: %s is required", ErrInvalidToken, claimContext)
+	}
+	return claims, nil
+}
+
+func (p *Platform) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := p.Keys.Key(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
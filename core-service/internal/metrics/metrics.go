@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "core_service"
+
+var (
+	HTTPRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Количество HTTP-запросов по маршрутам gin.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Время обработки HTTP-запросов.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	GRPCRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_requests_total",
+		Help:      "Количество обработанных gRPC-вызовов.",
+	}, []string{"method", "code"})
+
+	GRPCRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_request_duration_seconds",
+		Help:      "Время обработки gRPC-вызовов.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	KafkaMessagesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_messages_total",
+		Help:      "Сообщения, отправленные в Kafka, по итогу доставки (success/failure).",
+	}, []string{"topic", "result"})
+
+	CacheLookupsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_lookups_total",
+		Help:      "Обращения к кэшу по результату (hit/miss/error; stale - отдана предыдущая версия, пока идет обновление).",
+	}, []string{"cache", "result"})
+
+	LogsIngestedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logs_ingested_total",
+		Help:      "Сохраненные логи активности студентов.",
+	})
+
+	AnalysesTriggeredTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "analyses_triggered_total",
+		Help:      "Отправленные команды analyze_student.",
+	})
+
+	SchedulerJobRunsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "scheduler_job_runs_total",
+		Help:      "Запуски задач планировщика по итогу (success/failure/skipped - задачу выполняет другая реплика).",
+	}, []string{"job", "result"})
+
+	AlertsRaisedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "alerts_raised_total",
+		Help:      "Созданные оповещения по типу правила.",
+	}, []string{"type"})
+
+	AlertNotificationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "alert_notifications_total",
+		Help:      "Доставка оповещений по каналу и итогу (success/failure/dropped).",
+	}, []string{"notifier", "result"})
+
+	WebhookDeliveriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Попытки доставки webhook по событию и итогу (success/retry/failed - попытки кончились).",
+	}, []string{"event", "result"})
+
+	RateLimitRejectionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_rejections_total",
+		Help:      "Запросы, отклоненные ограничением частоты, по группе маршрутов (http: ingest/dashboard, grpc).",
+	}, []string{"group"})
+
+	AuditWriteFailuresTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "audit_write_failures_total",
+		Help:      "Записи журнала доступа, которые не удалось сохранить.",
+	})
+)
+
+// RegisterDBStats публикует статистику пула sql.DB (открытые, занятые,
+// ожидания соединения). Повторная регистрация с тем же именем игнорируется.
+func RegisterDBStats(db *sql.DB, dbName string) error {
+	err := prometheus.Register(collectors.NewDBStatsCollector(db, dbName))
+
+	var already prometheus.AlreadyRegisteredError
+	if errors.As(err, &already) {
+		return nil
+	}
+	return err
+}
@@ -31,6 +31,24 @@ func (_m *MessageProducer) Close() error {
 	return r0
 }
 
+// Ping provides a mock function with given fields: ctx
+func (_m *MessageProducer) Ping(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Ping")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Send provides a mock function with given fields: ctx, topic, key, value
 func (_m *MessageProducer) Send(ctx context.Context, topic string, key []byte, value []byte) error {
 	ret := _m.Called(ctx, topic, key, value)
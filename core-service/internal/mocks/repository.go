@@ -17,6 +17,66 @@ type Repository struct {
 	mock.Mock
 }
 
+// AnonymizeStudent provides a mock function with given fields: ctx, studentID, pseudonymID
+func (_m *Repository) AnonymizeStudent(ctx context.Context, studentID uint64, pseudonymID uint64) (*domain.StudentErasure, error) {
+	ret := _m.Called(ctx, studentID, pseudonymID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AnonymizeStudent")
+	}
+
+	var r0 *domain.StudentErasure
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, uint64) (*domain.StudentErasure, error)); ok {
+		return rf(ctx, studentID, pseudonymID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, uint64) *domain.StudentErasure); ok {
+		r0 = rf(ctx, studentID, pseudonymID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.StudentErasure)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, uint64) error); ok {
+		r1 = rf(ctx, studentID, pseudonymID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ClaimWebhookDeliveries provides a mock function with given fields: ctx, limit, lease
+func (_m *Repository) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*domain.WebhookDeliveryTask, error) {
+	ret := _m.Called(ctx, limit, lease)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimWebhookDeliveries")
+	}
+
+	var r0 []*domain.WebhookDeliveryTask
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Duration) ([]*domain.WebhookDeliveryTask, error)); ok {
+		return rf(ctx, limit, lease)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Duration) []*domain.WebhookDeliveryTask); ok {
+		r0 = rf(ctx, limit, lease)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.WebhookDeliveryTask)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, time.Duration) error); ok {
+		r1 = rf(ctx, limit, lease)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Close provides a mock function with no fields
 func (_m *Repository) Close() error {
 	ret := _m.Called()
@@ -35,29 +95,924 @@ func (_m *Repository) Close() error {
 	return r0
 }
 
-// GetAnalyticsByStudentID provides a mock function with given fields: ctx, studentID
-func (_m *Repository) GetAnalyticsByStudentID(ctx context.Context, studentID uint64) (*domain.StudentAnalytics, error) {
+// CreateAPIKey provides a mock function with given fields: ctx, key, hash
+func (_m *Repository) CreateAPIKey(ctx context.Context, key *domain.APIKey, hash []byte) error {
+	ret := _m.Called(ctx, key, hash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAPIKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.APIKey, []byte) error); ok {
+		r0 = rf(ctx, key, hash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateAlert provides a mock function with given fields: ctx, alert
+func (_m *Repository) CreateAlert(ctx context.Context, alert *domain.Alert) (bool, error) {
+	ret := _m.Called(ctx, alert)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAlert")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Alert) (bool, error)); ok {
+		return rf(ctx, alert)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Alert) bool); ok {
+		r0 = rf(ctx, alert)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.Alert) error); ok {
+		r1 = rf(ctx, alert)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateAlertRule provides a mock function with given fields: ctx, rule
+func (_m *Repository) CreateAlertRule(ctx context.Context, rule *domain.AlertRule) error {
+	ret := _m.Called(ctx, rule)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAlertRule")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AlertRule) error); ok {
+		r0 = rf(ctx, rule)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateWebhookSubscription provides a mock function with given fields: ctx, sub
+func (_m *Repository) CreateWebhookSubscription(ctx context.Context, sub *domain.WebhookSubscription) error {
+	ret := _m.Called(ctx, sub)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWebhookSubscription")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.WebhookSubscription) error); ok {
+		r0 = rf(ctx, sub)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteAlertRule provides a mock function with given fields: ctx, id
+func (_m *Repository) DeleteAlertRule(ctx context.Context, id uint64) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteAlertRule")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteAuditEntriesBefore provides a mock function with given fields: ctx, before
+func (_m *Repository) DeleteAuditEntriesBefore(ctx context.Context, before time.Time) (int64, error) {
+	ret := _m.Called(ctx, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteAuditEntriesBefore")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int64, error)); ok {
+		return rf(ctx, before)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteWebhookSubscription provides a mock function with given fields: ctx, id
+func (_m *Repository) DeleteWebhookSubscription(ctx context.Context, id uint64) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteWebhookSubscription")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EnqueueWebhookDeliveries provides a mock function with given fields: ctx, event, key, payload
+func (_m *Repository) EnqueueWebhookDeliveries(ctx context.Context, event string, key string, payload []byte) (int, error) {
+	ret := _m.Called(ctx, event, key, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnqueueWebhookDeliveries")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []byte) (int, error)); ok {
+		return rf(ctx, event, key, payload)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []byte) int); ok {
+		r0 = rf(ctx, event, key, payload)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, []byte) error); ok {
+		r1 = rf(ctx, event, key, payload)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EnsureLTICohort provides a mock function with given fields: ctx, issuer, contextID, title
+func (_m *Repository) EnsureLTICohort(ctx context.Context, issuer string, contextID string, title string) (*domain.Cohort, error) {
+	ret := _m.Called(ctx, issuer, contextID, title)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnsureLTICohort")
+	}
+
+	var r0 *domain.Cohort
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*domain.Cohort, error)); ok {
+		return rf(ctx, issuer, contextID, title)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *domain.Cohort); ok {
+		r0 = rf(ctx, issuer, contextID, title)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Cohort)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, issuer, contextID, title)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EraseStudent provides a mock function with given fields: ctx, studentID
+func (_m *Repository) EraseStudent(ctx context.Context, studentID uint64) (*domain.StudentErasure, error) {
 	ret := _m.Called(ctx, studentID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetAnalyticsByStudentID")
+		panic("no return value specified for EraseStudent")
 	}
 
-	var r0 *domain.StudentAnalytics
+	var r0 *domain.StudentErasure
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, uint64) (*domain.StudentAnalytics, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) (*domain.StudentErasure, error)); ok {
 		return rf(ctx, studentID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, uint64) *domain.StudentAnalytics); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) *domain.StudentErasure); ok {
 		r0 = rf(ctx, studentID)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*domain.StudentAnalytics)
+			r0 = ret.Get(0).(*domain.StudentErasure)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, studentID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAPIKey provides a mock function with given fields: ctx, id
+func (_m *Repository) GetAPIKey(ctx context.Context, id uint64) (*domain.APIKey, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAPIKey")
+	}
+
+	var r0 *domain.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) (*domain.APIKey, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) *domain.APIKey); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetActiveAPIKeyByHash provides a mock function with given fields: ctx, hash, now
+func (_m *Repository) GetActiveAPIKeyByHash(ctx context.Context, hash []byte, now time.Time) (*domain.APIKey, error) {
+	ret := _m.Called(ctx, hash, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetActiveAPIKeyByHash")
+	}
+
+	var r0 *domain.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []byte, time.Time) (*domain.APIKey, error)); ok {
+		return rf(ctx, hash, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []byte, time.Time) *domain.APIKey); ok {
+		r0 = rf(ctx, hash, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []byte, time.Time) error); ok {
+		r1 = rf(ctx, hash, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAlert provides a mock function with given fields: ctx, id
+func (_m *Repository) GetAlert(ctx context.Context, id uint64) (*domain.Alert, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAlert")
+	}
+
+	var r0 *domain.Alert
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) (*domain.Alert, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) *domain.Alert); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Alert)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAlertRule provides a mock function with given fields: ctx, id
+func (_m *Repository) GetAlertRule(ctx context.Context, id uint64) (*domain.AlertRule, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAlertRule")
+	}
+
+	var r0 *domain.AlertRule
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) (*domain.AlertRule, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) *domain.AlertRule); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.AlertRule)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAlertRulesForStudent provides a mock function with given fields: ctx, studentID
+func (_m *Repository) GetAlertRulesForStudent(ctx context.Context, studentID uint64) ([]*domain.AlertRule, error) {
+	ret := _m.Called(ctx, studentID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAlertRulesForStudent")
+	}
+
+	var r0 []*domain.AlertRule
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) ([]*domain.AlertRule, error)); ok {
+		return rf(ctx, studentID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) []*domain.AlertRule); ok {
+		r0 = rf(ctx, studentID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.AlertRule)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, studentID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAnalyticsByStudentID provides a mock function with given fields: ctx, studentID
+func (_m *Repository) GetAnalyticsByStudentID(ctx context.Context, studentID uint64) (*domain.StudentAnalytics, error) {
+	ret := _m.Called(ctx, studentID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAnalyticsByStudentID")
+	}
+
+	var r0 *domain.StudentAnalytics
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) (*domain.StudentAnalytics, error)); ok {
+		return rf(ctx, studentID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) *domain.StudentAnalytics); ok {
+		r0 = rf(ctx, studentID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.StudentAnalytics)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, studentID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAnalyticsHistory provides a mock function with given fields: ctx, studentID, from, to
+func (_m *Repository) GetAnalyticsHistory(ctx context.Context, studentID uint64, from time.Time, to time.Time) ([]*domain.StudentAnalytics, error) {
+	ret := _m.Called(ctx, studentID, from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAnalyticsHistory")
+	}
+
+	var r0 []*domain.StudentAnalytics
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, time.Time, time.Time) ([]*domain.StudentAnalytics, error)); ok {
+		return rf(ctx, studentID, from, to)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, time.Time, time.Time) []*domain.StudentAnalytics); ok {
+		r0 = rf(ctx, studentID, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.StudentAnalytics)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, time.Time, time.Time) error); ok {
+		r1 = rf(ctx, studentID, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAnswerStats provides a mock function with given fields: ctx, studentID, since
+func (_m *Repository) GetAnswerStats(ctx context.Context, studentID uint64, since time.Time) (int, int, error) {
+	ret := _m.Called(ctx, studentID, since)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAnswerStats")
+	}
+
+	var r0 int
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, time.Time) (int, int, error)); ok {
+		return rf(ctx, studentID, since)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, time.Time) int); ok {
+		r0 = rf(ctx, studentID, since)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, time.Time) int); ok {
+		r1 = rf(ctx, studentID, since)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, uint64, time.Time) error); ok {
+		r2 = rf(ctx, studentID, since)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetCohortAggregates provides a mock function with given fields: ctx, cohortID, from, to
+func (_m *Repository) GetCohortAggregates(ctx context.Context, cohortID uint64, from time.Time, to time.Time) ([]*domain.CohortAggregate, error) {
+	ret := _m.Called(ctx, cohortID, from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCohortAggregates")
+	}
+
+	var r0 []*domain.CohortAggregate
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, time.Time, time.Time) ([]*domain.CohortAggregate, error)); ok {
+		return rf(ctx, cohortID, from, to)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, time.Time, time.Time) []*domain.CohortAggregate); ok {
+		r0 = rf(ctx, cohortID, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.CohortAggregate)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, time.Time, time.Time) error); ok {
+		r1 = rf(ctx, cohortID, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCohortByID provides a mock function with given fields: ctx, id
+func (_m *Repository) GetCohortByID(ctx context.Context, id uint64) (*domain.Cohort, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCohortByID")
+	}
+
+	var r0 *domain.Cohort
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) (*domain.Cohort, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) *domain.Cohort); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Cohort)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCohortStudentIDs provides a mock function with given fields: ctx, cohortID
+func (_m *Repository) GetCohortStudentIDs(ctx context.Context, cohortID uint64) ([]uint64, error) {
+	ret := _m.Called(ctx, cohortID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCohortStudentIDs")
+	}
+
+	var r0 []uint64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) ([]uint64, error)); ok {
+		return rf(ctx, cohortID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) []uint64); ok {
+		r0 = rf(ctx, cohortID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]uint64)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, cohortID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetInactiveStudents provides a mock function with given fields: ctx, cohortID, since
+func (_m *Repository) GetInactiveStudents(ctx context.Context, cohortID uint64, since time.Time) ([]uint64, error) {
+	ret := _m.Called(ctx, cohortID, since)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetInactiveStudents")
+	}
+
+	var r0 []uint64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, time.Time) ([]uint64, error)); ok {
+		return rf(ctx, cohortID, since)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, time.Time) []uint64); ok {
+		r0 = rf(ctx, cohortID, since)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]uint64)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, time.Time) error); ok {
+		r1 = rf(ctx, cohortID, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLogsByMaterialID provides a mock function with given fields: ctx, materialID
+func (_m *Repository) GetLogsByMaterialID(ctx context.Context, materialID string) ([]*domain.StudentLog, error) {
+	ret := _m.Called(ctx, materialID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLogsByMaterialID")
+	}
+
+	var r0 []*domain.StudentLog
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]*domain.StudentLog, error)); ok {
+		return rf(ctx, materialID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*domain.StudentLog); ok {
+		r0 = rf(ctx, materialID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.StudentLog)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, materialID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLogsByStudentID provides a mock function with given fields: ctx, studentID, from, to
+func (_m *Repository) GetLogsByStudentID(ctx context.Context, studentID uint64, from time.Time, to time.Time) ([]*domain.StudentLog, error) {
+	ret := _m.Called(ctx, studentID, from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLogsByStudentID")
+	}
+
+	var r0 []*domain.StudentLog
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, time.Time, time.Time) ([]*domain.StudentLog, error)); ok {
+		return rf(ctx, studentID, from, to)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, time.Time, time.Time) []*domain.StudentLog); ok {
+		r0 = rf(ctx, studentID, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.StudentLog)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, time.Time, time.Time) error); ok {
+		r1 = rf(ctx, studentID, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStudentByID provides a mock function with given fields: ctx, id
+func (_m *Repository) GetStudentByID(ctx context.Context, id uint64) (*domain.Student, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetStudentByID")
+	}
+
+	var r0 *domain.Student
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) (*domain.Student, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) *domain.Student); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Student)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStudents provides a mock function with given fields: ctx
+func (_m *Repository) GetStudents(ctx context.Context) ([]uint64, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetStudents")
+	}
+
+	var r0 []uint64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]uint64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []uint64); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]uint64)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStudentsWithNewLogs provides a mock function with given fields: ctx, since
+func (_m *Repository) GetStudentsWithNewLogs(ctx context.Context, since time.Time) ([]uint64, error) {
+	ret := _m.Called(ctx, since)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetStudentsWithNewLogs")
+	}
+
+	var r0 []uint64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) ([]uint64, error)); ok {
+		return rf(ctx, since)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) []uint64); ok {
+		r0 = rf(ctx, since)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]uint64)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWebhookDelivery provides a mock function with given fields: ctx, id
+func (_m *Repository) GetWebhookDelivery(ctx context.Context, id uint64) (*domain.WebhookDelivery, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWebhookDelivery")
+	}
+
+	var r0 *domain.WebhookDelivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) (*domain.WebhookDelivery, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) *domain.WebhookDelivery); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.WebhookDelivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWebhookSubscription provides a mock function with given fields: ctx, id
+func (_m *Repository) GetWebhookSubscription(ctx context.Context, id uint64) (*domain.WebhookSubscription, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWebhookSubscription")
+	}
+
+	var r0 *domain.WebhookSubscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) (*domain.WebhookSubscription, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) *domain.WebhookSubscription); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.WebhookSubscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IsStudentInCohort provides a mock function with given fields: ctx, cohortID, studentID
+func (_m *Repository) IsStudentInCohort(ctx context.Context, cohortID uint64, studentID uint64) (bool, error) {
+	ret := _m.Called(ctx, cohortID, studentID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsStudentInCohort")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, uint64) (bool, error)); ok {
+		return rf(ctx, cohortID, studentID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, uint64) bool); ok {
+		r0 = rf(ctx, cohortID, studentID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, uint64) error); ok {
+		r1 = rf(ctx, cohortID, studentID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LastJobRun provides a mock function with given fields: ctx, job
+func (_m *Repository) LastJobRun(ctx context.Context, job string) (time.Time, error) {
+	ret := _m.Called(ctx, job)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LastJobRun")
+	}
+
+	var r0 time.Time
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (time.Time, error)); ok {
+		return rf(ctx, job)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) time.Time); ok {
+		r0 = rf(ctx, job)
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, job)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListAPIKeys provides a mock function with given fields: ctx
+func (_m *Repository) ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAPIKeys")
+	}
+
+	var r0 []*domain.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*domain.APIKey, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []*domain.APIKey); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListAlertRules provides a mock function with given fields: ctx, cohortID
+func (_m *Repository) ListAlertRules(ctx context.Context, cohortID uint64) ([]*domain.AlertRule, error) {
+	ret := _m.Called(ctx, cohortID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAlertRules")
+	}
+
+	var r0 []*domain.AlertRule
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) ([]*domain.AlertRule, error)); ok {
+		return rf(ctx, cohortID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) []*domain.AlertRule); ok {
+		r0 = rf(ctx, cohortID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.AlertRule)
 		}
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
-		r1 = rf(ctx, studentID)
+		r1 = rf(ctx, cohortID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -65,29 +1020,29 @@ func (_m *Repository) GetAnalyticsByStudentID(ctx context.Context, studentID uin
 	return r0, r1
 }
 
-// GetLogsByMaterialID provides a mock function with given fields: ctx, materialID
-func (_m *Repository) GetLogsByMaterialID(ctx context.Context, materialID string) ([]*domain.StudentLog, error) {
-	ret := _m.Called(ctx, materialID)
+// ListAlerts provides a mock function with given fields: ctx, filter
+func (_m *Repository) ListAlerts(ctx context.Context, filter domain.AlertFilter) ([]*domain.Alert, error) {
+	ret := _m.Called(ctx, filter)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetLogsByMaterialID")
+		panic("no return value specified for ListAlerts")
 	}
 
-	var r0 []*domain.StudentLog
+	var r0 []*domain.Alert
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) ([]*domain.StudentLog, error)); ok {
-		return rf(ctx, materialID)
+	if rf, ok := ret.Get(0).(func(context.Context, domain.AlertFilter) ([]*domain.Alert, error)); ok {
+		return rf(ctx, filter)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) []*domain.StudentLog); ok {
-		r0 = rf(ctx, materialID)
+	if rf, ok := ret.Get(0).(func(context.Context, domain.AlertFilter) []*domain.Alert); ok {
+		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*domain.StudentLog)
+			r0 = ret.Get(0).([]*domain.Alert)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, materialID)
+	if rf, ok := ret.Get(1).(func(context.Context, domain.AlertFilter) error); ok {
+		r1 = rf(ctx, filter)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -95,29 +1050,29 @@ func (_m *Repository) GetLogsByMaterialID(ctx context.Context, materialID string
 	return r0, r1
 }
 
-// GetLogsByStudentID provides a mock function with given fields: ctx, studentID, from, to
-func (_m *Repository) GetLogsByStudentID(ctx context.Context, studentID uint64, from time.Time, to time.Time) ([]*domain.StudentLog, error) {
-	ret := _m.Called(ctx, studentID, from, to)
+// ListAuditEntries provides a mock function with given fields: ctx, filter
+func (_m *Repository) ListAuditEntries(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	ret := _m.Called(ctx, filter)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetLogsByStudentID")
+		panic("no return value specified for ListAuditEntries")
 	}
 
-	var r0 []*domain.StudentLog
+	var r0 []*domain.AuditEntry
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, uint64, time.Time, time.Time) ([]*domain.StudentLog, error)); ok {
-		return rf(ctx, studentID, from, to)
+	if rf, ok := ret.Get(0).(func(context.Context, domain.AuditFilter) ([]*domain.AuditEntry, error)); ok {
+		return rf(ctx, filter)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, uint64, time.Time, time.Time) []*domain.StudentLog); ok {
-		r0 = rf(ctx, studentID, from, to)
+	if rf, ok := ret.Get(0).(func(context.Context, domain.AuditFilter) []*domain.AuditEntry); ok {
+		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*domain.StudentLog)
+			r0 = ret.Get(0).([]*domain.AuditEntry)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, uint64, time.Time, time.Time) error); ok {
-		r1 = rf(ctx, studentID, from, to)
+	if rf, ok := ret.Get(1).(func(context.Context, domain.AuditFilter) error); ok {
+		r1 = rf(ctx, filter)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -125,29 +1080,29 @@ func (_m *Repository) GetLogsByStudentID(ctx context.Context, studentID uint64,
 	return r0, r1
 }
 
-// GetStudentByID provides a mock function with given fields: ctx, id
-func (_m *Repository) GetStudentByID(ctx context.Context, id uint64) (*domain.Student, error) {
-	ret := _m.Called(ctx, id)
+// ListWebhookDeliveries provides a mock function with given fields: ctx, filter
+func (_m *Repository) ListWebhookDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error) {
+	ret := _m.Called(ctx, filter)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetStudentByID")
+		panic("no return value specified for ListWebhookDeliveries")
 	}
 
-	var r0 *domain.Student
+	var r0 []*domain.WebhookDelivery
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, uint64) (*domain.Student, error)); ok {
-		return rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error)); ok {
+		return rf(ctx, filter)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, uint64) *domain.Student); ok {
-		r0 = rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, domain.WebhookDeliveryFilter) []*domain.WebhookDelivery); ok {
+		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*domain.Student)
+			r0 = ret.Get(0).([]*domain.WebhookDelivery)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
-		r1 = rf(ctx, id)
+	if rf, ok := ret.Get(1).(func(context.Context, domain.WebhookDeliveryFilter) error); ok {
+		r1 = rf(ctx, filter)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -155,24 +1110,24 @@ func (_m *Repository) GetStudentByID(ctx context.Context, id uint64) (*domain.St
 	return r0, r1
 }
 
-// GetStudents provides a mock function with given fields: ctx
-func (_m *Repository) GetStudents(ctx context.Context) ([]uint64, error) {
+// ListWebhookSubscriptions provides a mock function with given fields: ctx
+func (_m *Repository) ListWebhookSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error) {
 	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetStudents")
+		panic("no return value specified for ListWebhookSubscriptions")
 	}
 
-	var r0 []uint64
+	var r0 []*domain.WebhookSubscription
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) ([]uint64, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*domain.WebhookSubscription, error)); ok {
 		return rf(ctx)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) []uint64); ok {
+	if rf, ok := ret.Get(0).(func(context.Context) []*domain.WebhookSubscription); ok {
 		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]uint64)
+			r0 = ret.Get(0).([]*domain.WebhookSubscription)
 		}
 	}
 
@@ -185,6 +1140,114 @@ func (_m *Repository) GetStudents(ctx context.Context) ([]uint64, error) {
 	return r0, r1
 }
 
+// MarkXAPILogSent provides a mock function with given fields: ctx, id
+func (_m *Repository) MarkXAPILogSent(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkXAPILogSent")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Ping provides a mock function with given fields: ctx
+func (_m *Repository) Ping(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Ping")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecordJobRun provides a mock function with given fields: ctx, job, startedAt
+func (_m *Repository) RecordJobRun(ctx context.Context, job string, startedAt time.Time) error {
+	ret := _m.Called(ctx, job, startedAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordJobRun")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) error); ok {
+		r0 = rf(ctx, job, startedAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RequeueWebhookDelivery provides a mock function with given fields: ctx, id, at
+func (_m *Repository) RequeueWebhookDelivery(ctx context.Context, id uint64, at time.Time) error {
+	ret := _m.Called(ctx, id, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RequeueWebhookDelivery")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, time.Time) error); ok {
+		r0 = rf(ctx, id, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeAPIKey provides a mock function with given fields: ctx, id, at
+func (_m *Repository) RevokeAPIKey(ctx context.Context, id uint64, at time.Time) error {
+	ret := _m.Called(ctx, id, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeAPIKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, time.Time) error); ok {
+		r0 = rf(ctx, id, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RotateAPIKey provides a mock function with given fields: ctx, id, next, hash, revokeAt
+func (_m *Repository) RotateAPIKey(ctx context.Context, id uint64, next *domain.APIKey, hash []byte, revokeAt time.Time) error {
+	ret := _m.Called(ctx, id, next, hash, revokeAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RotateAPIKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, *domain.APIKey, []byte, time.Time) error); ok {
+		r0 = rf(ctx, id, next, hash, revokeAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SaveAnalytics provides a mock function with given fields: ctx, analytics
 func (_m *Repository) SaveAnalytics(ctx context.Context, analytics *domain.StudentAnalytics) error {
 	ret := _m.Called(ctx, analytics)
@@ -203,6 +1266,24 @@ func (_m *Repository) SaveAnalytics(ctx context.Context, analytics *domain.Stude
 	return r0
 }
 
+// SaveAuditEntries provides a mock function with given fields: ctx, entries
+func (_m *Repository) SaveAuditEntries(ctx context.Context, entries []*domain.AuditEntry) error {
+	ret := _m.Called(ctx, entries)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveAuditEntries")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*domain.AuditEntry) error); ok {
+		r0 = rf(ctx, entries)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SaveLog provides a mock function with given fields: ctx, log
 func (_m *Repository) SaveLog(ctx context.Context, log *domain.StudentLog) error {
 	ret := _m.Called(ctx, log)
@@ -221,6 +1302,34 @@ func (_m *Repository) SaveLog(ctx context.Context, log *domain.StudentLog) error
 	return r0
 }
 
+// SaveLogs provides a mock function with given fields: ctx, logs
+func (_m *Repository) SaveLogs(ctx context.Context, logs []*domain.StudentLog) (int, error) {
+	ret := _m.Called(ctx, logs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveLogs")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*domain.StudentLog) (int, error)); ok {
+		return rf(ctx, logs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []*domain.StudentLog) int); ok {
+		r0 = rf(ctx, logs)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []*domain.StudentLog) error); ok {
+		r1 = rf(ctx, logs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SaveStudent provides a mock function with given fields: ctx, student
 func (_m *Repository) SaveStudent(ctx context.Context, student *domain.Student) error {
 	ret := _m.Called(ctx, student)
@@ -239,6 +1348,152 @@ func (_m *Repository) SaveStudent(ctx context.Context, student *domain.Student)
 	return r0
 }
 
+// SaveXAPIStatement provides a mock function with given fields: ctx, st
+func (_m *Repository) SaveXAPIStatement(ctx context.Context, st *domain.XAPIStatement) (bool, error) {
+	ret := _m.Called(ctx, st)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveXAPIStatement")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.XAPIStatement) (bool, error)); ok {
+		return rf(ctx, st)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.XAPIStatement) bool); ok {
+		r0 = rf(ctx, st)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.XAPIStatement) error); ok {
+		r1 = rf(ctx, st)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StreamCohortAnalytics provides a mock function with given fields: ctx, cohortID, from, to, fn
+func (_m *Repository) StreamCohortAnalytics(ctx context.Context, cohortID uint64, from time.Time, to time.Time, fn func(*domain.StudentAnalytics) error) error {
+	ret := _m.Called(ctx, cohortID, from, to, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamCohortAnalytics")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, time.Time, time.Time, func(*domain.StudentAnalytics) error) error); ok {
+		r0 = rf(ctx, cohortID, from, to, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StreamLogsByStudentID provides a mock function with given fields: ctx, studentID, from, to, fn
+func (_m *Repository) StreamLogsByStudentID(ctx context.Context, studentID uint64, from time.Time, to time.Time, fn func(*domain.StudentLog) error) error {
+	ret := _m.Called(ctx, studentID, from, to, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamLogsByStudentID")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, time.Time, time.Time, func(*domain.StudentLog) error) error); ok {
+		r0 = rf(ctx, studentID, from, to, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StreamResearchAnalytics provides a mock function with given fields: ctx, cohortID, from, to, fn
+func (_m *Repository) StreamResearchAnalytics(ctx context.Context, cohortID uint64, from time.Time, to time.Time, fn func(*domain.ResearchAnalytics) error) error {
+	ret := _m.Called(ctx, cohortID, from, to, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamResearchAnalytics")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, time.Time, time.Time, func(*domain.ResearchAnalytics) error) error); ok {
+		r0 = rf(ctx, cohortID, from, to, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StreamResearchLogs provides a mock function with given fields: ctx, cohortID, from, to, fn
+func (_m *Repository) StreamResearchLogs(ctx context.Context, cohortID uint64, from time.Time, to time.Time, fn func(*domain.StudentLog) error) error {
+	ret := _m.Called(ctx, cohortID, from, to, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamResearchLogs")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, time.Time, time.Time, func(*domain.StudentLog) error) error); ok {
+		r0 = rf(ctx, cohortID, from, to, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TouchAPIKey provides a mock function with given fields: ctx, id, ip, at, minInterval
+func (_m *Repository) TouchAPIKey(ctx context.Context, id uint64, ip string, at time.Time, minInterval time.Duration) error {
+	ret := _m.Called(ctx, id, ip, at, minInterval)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TouchAPIKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, string, time.Time, time.Duration) error); ok {
+		r0 = rf(ctx, id, ip, at, minInterval)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateAlertStatus provides a mock function with given fields: ctx, id, from, to, actor, at
+func (_m *Repository) UpdateAlertStatus(ctx context.Context, id uint64, from domain.AlertStatus, to domain.AlertStatus, actor string, at time.Time) (bool, error) {
+	ret := _m.Called(ctx, id, from, to, actor, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateAlertStatus")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, domain.AlertStatus, domain.AlertStatus, string, time.Time) (bool, error)); ok {
+		return rf(ctx, id, from, to, actor, at)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, domain.AlertStatus, domain.AlertStatus, string, time.Time) bool); ok {
+		r0 = rf(ctx, id, from, to, actor, at)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, domain.AlertStatus, domain.AlertStatus, string, time.Time) error); ok {
+		r1 = rf(ctx, id, from, to, actor, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpdateAnalytics provides a mock function with given fields: ctx, analytics
 func (_m *Repository) UpdateAnalytics(ctx context.Context, analytics *domain.StudentAnalytics) error {
 	ret := _m.Called(ctx, analytics)
@@ -257,6 +1512,34 @@ func (_m *Repository) UpdateAnalytics(ctx context.Context, analytics *domain.Stu
 	return r0
 }
 
+// UpdateWebhookDelivery provides a mock function with given fields: ctx, task
+func (_m *Repository) UpdateWebhookDelivery(ctx context.Context, task *domain.WebhookDeliveryTask) (bool, error) {
+	ret := _m.Called(ctx, task)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateWebhookDelivery")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.WebhookDeliveryTask) (bool, error)); ok {
+		return rf(ctx, task)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.WebhookDeliveryTask) bool); ok {
+		r0 = rf(ctx, task)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.WebhookDeliveryTask) error); ok {
+		r1 = rf(ctx, task)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewRepository creates a new instance of Repository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewRepository(t interface {
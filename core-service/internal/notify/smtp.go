@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+)
+
+// SMTPOptions - Addr - host:port сервера. Username пустой - без
+// авторизации (локальный тестовый сервер вроде MailHog).
+type SMTPOptions struct {
+	Addr     string
+	From     string
+	To       []string
+	Username string
+	Password string
+	Timeout  time.Duration
+}
+
+type SMTPNotifier struct {
+	opts SMTPOptions
+}
+
+func NewSMTPNotifier(opts SMTPOptions) *SMTPNotifier {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	return &SMTPNotifier{opts: opts}
+}
+
+func (n *SMTPNotifier) Name() string {
+	return "smtp"
+}
+
+// Notify сам открывает соединение: smtp.SendMail не принимает ни ctx, ни
+// таймаут, и зависший сервер остановил бы проверку правил.
+func (n *SMTPNotifier) Notify(ctx context.Context, alert *domain.Alert) error {
+	ctx, cancel := context.WithTimeout(ctx, n.opts.Timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", n.opts.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	host, _, _ := net.SplitHostPort(n.opts.Addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake failed: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return fmt.Errorf("smtp starttls failed: %w", err)
+		}
+	}
+	if n.opts.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", n.opts.Username, n.opts.Password, host)); err != nil {
+			return fmt.Errorf("smtp auth failed: %w", err)
+		}
+	}
+	if err := c.Mail(n.opts.From); err != nil {
+		return err
+	}
+	for _, rcpt := range n.opts.To {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(n.message(alert)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (n *SMTPNotifier) message(alert *domain.Alert) []byte {
+	subject := fmt.Sprintf("Student %d: %s", alert.StudentID, alert.RuleName)
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", n.opts.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(n.opts.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", alert.RaisedAt.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	fmt.Fprintf(&b, "Rule: %s (%s)\r\n", alert.RuleName, alert.Type)
+	fmt.Fprintf(&b, "Student: %d\r\n", alert.StudentID)
+	fmt.Fprintf(&b, "%s\r\n", alert.Message)
+	fmt.Fprintf(&b, "\r\nAlert #%d raised at %s UTC\r\n", alert.ID, alert.RaisedAt.UTC().Format("2006-01-02 15:04"))
+	return []byte(b.String())
+}
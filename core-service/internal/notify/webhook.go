@@ -0,0 +1,58 @@
+// Package notify - каналы доставки оповещений о студентах в зоне риска.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+)
+
+// WebhookNotifier отправляет оповещение POST-запросом с JSON на один
+// настроенный адрес (например, чат преподавателей).
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string, timeout time.Duration) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (n *WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+type webhookPayload struct {
+	Event string        `json:"event"`
+	Alert *domain.Alert `json:"alert"`
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, alert *domain.Alert) error {
+	body, err := json.Marshal(webhookPayload{Event: "alert.raised", Alert: alert})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
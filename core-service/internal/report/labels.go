@@ -0,0 +1,64 @@
+package report
+
+import "github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+
+type labels struct {
+	title           string
+	student         string
+	generated       string
+	summary         string
+	cluster         string
+	successRate     string
+	engagement      string
+	avgTime         string
+	seconds         string
+	analyzedAt      string
+	topics          string
+	topicsMore      string
+	history         string
+	recommendations string
+	noData          string
+}
+
+var labelsRU = labels{
+	title:           "Отчет по студенту",
+	student:         "Студент",
+	generated:       "Сформирован",
+	summary:         "Итоги анализа",
+	cluster:         "Группа",
+	successRate:     "Доля верных ответов",
+	engagement:      "Вовлеченность",
+	avgTime:         "Среднее время на задание",
+	seconds:         "сек",
+	analyzedAt:      "Дата анализа",
+	topics:          "Эффективность по темам",
+	topicsMore:      "Показаны %d самых слабых тем из %d",
+	history:         "Динамика",
+	recommendations: "Рекомендации",
+	noData:          "Недостаточно данных",
+}
+
+var labelsEN = labels{
+	title:           "Student report",
+	student:         "Student",
+	generated:       "Generated",
+	summary:         "Summary",
+	cluster:         "Group",
+	successRate:     "Success rate",
+	engagement:      "Engagement",
+	avgTime:         "Avg time per task",
+	seconds:         "sec",
+	analyzedAt:      "Analyzed at",
+	topics:          "Topic efficiency",
+	topicsMore:      "Showing the %d weakest of %d topics",
+	history:         "Progress",
+	recommendations: "Recommendations",
+	noData:          "Not enough data",
+}
+
+func labelsFor(lang domain.Language) labels {
+	if lang == domain.LangEN {
+		return labelsEN
+	}
+	return labelsRU
+}
@@ -0,0 +1,338 @@
+// Package report рисует PDF-отчеты по аналитике студента. Графики строятся
+// векторными примитивами PDF, без внешних утилит и растровых картинок.
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+)
+
+const (
+	ContentType = "application/pdf"
+
+	fontFamily = "go"
+	dateLayout = "2006-01-02"
+	timeLayout = "2006-01-02 15:04"
+
+	// maxTopics - сколько тем попадает в диаграмму: самые слабые, остальные
+	// преподаватель увидит в дашборде
+	maxTopics = 15
+)
+
+type color struct{ r, g, b int }
+
+var (
+	colorText    = color{33, 37, 41}
+	colorMuted   = color{108, 117, 125}
+	colorGrid    = color{222, 226, 230}
+	colorGood    = color{40, 167, 69}
+	colorMedium  = color{255, 193, 7}
+	colorWeak    = color{220, 53, 69}
+	colorSuccess = color{0, 123, 255}
+	colorEngage  = color{253, 126, 20}
+)
+
+// Render пишет отчет в w. Шрифт Go встроен в бинарник и покрывает
+// кириллицу, поэтому отчет не зависит от шрифтов в контейнере.
+func Render(w io.Writer, r *domain.StudentReport, lang domain.Language) error {
+	t := labelsFor(lang)
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(fontFamily, "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", gobold.TTF)
+	pdf.SetCreationDate(r.GeneratedAt)
+	pdf.SetModificationDate(r.GeneratedAt)
+	pdf.SetTitle(fmt.Sprintf("%s #%d", t.title, r.StudentID), true)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		setFont(pdf, "", 8, colorMuted)
+		pdf.CellFormat(100, 5, fmt.Sprintf("%s %s UTC", t.generated, r.GeneratedAt.UTC().Format(timeLayout)), "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 5, fmt.Sprintf("%d", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+	pdf.AddPage()
+
+	writeHeader(pdf, r, t)
+	writeSummary(pdf, r.Analytics, t)
+	writeTopics(pdf, r.Analytics.TopicEfficiency, t)
+	writeHistory(pdf, r.History, t)
+	writeRecommendations(pdf, r.Analytics.Recommendations, t)
+
+	if err := pdf.Error(); err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+	return pdf.Output(w)
+}
+
+func writeHeader(pdf *fpdf.Fpdf, r *domain.StudentReport, t labels) {
+	setFont(pdf, "B", 18, colorText)
+	pdf.CellFormat(0, 10, t.title, "", 1, "L", false, 0, "")
+
+	name := fmt.Sprintf("%s #%d", t.student, r.StudentID)
+	if r.Student != nil && r.Student.Name != "" {
+		name = fmt.Sprintf("%s (#%d)", r.Student.Name, r.StudentID)
+	}
+	setFont(pdf, "", 12, colorText)
+	pdf.CellFormat(0, 7, name, "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+}
+
+func writeSummary(pdf *fpdf.Fpdf, a *domain.StudentAnalytics, t labels) {
+	sectionTitle(pdf, t.summary)
+
+	rows := [][2]string{
+		{t.cluster, a.ClusterGroup},
+		{t.successRate, percent(a.SuccessRate)},
+		{t.engagement, fmt.Sprintf("%d / 100", a.EngagementScore)},
+		{t.avgTime, fmt.Sprintf("%.1f %s", a.AvgTimePerTask, t.seconds)},
+		{t.analyzedAt, a.AnalyzedAt.UTC().Format(timeLayout) + " UTC"},
+	}
+	for _, row := range rows {
+		setFont(pdf, "", 10, colorMuted)
+		pdf.CellFormat(60, 6, row[0], "", 0, "L", false, 0, "")
+		setFont(pdf, "B", 10, colorText)
+		pdf.CellFormat(0, 6, row[1], "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+}
+
+// writeTopics - горизонтальная диаграмма эффективности по темам, от
+// самых слабых к сильным.
+func writeTopics(pdf *fpdf.Fpdf, topics map[string]float64, t labels) {
+	sectionTitle(pdf, t.topics)
+	if len(topics) == 0 {
+		noData(pdf, t)
+		return
+	}
+
+	names := make([]string, 0, len(topics))
+	for name := range topics {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if topics[names[i]] != topics[names[j]] {
+			return topics[names[i]] < topics[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > maxTopics {
+		names = names[:maxTopics]
+	}
+
+	const (
+		labelW = 50.0
+		valueW = 15.0
+		barH   = 5.0
+		gap    = 2.0
+	)
+	left, _, right, _ := pdf.GetMargins()
+	pageW, _ := pdf.GetPageSize()
+	chartW := pageW - left - right - labelW - valueW
+
+	ensureSpace(pdf, float64(len(names))*(barH+gap))
+	for _, name := range names {
+		value := clamp01(topics[name])
+		y := pdf.GetY()
+
+		setFont(pdf, "", 9, colorText)
+		pdf.CellFormat(labelW, barH, truncate(pdf, name, labelW-2), "", 0, "L", false, 0, "")
+
+		setFill(pdf, colorGrid)
+		pdf.Rect(left+labelW, y+0.5, chartW, barH-1, "F")
+		setFill(pdf, efficiencyColor(value))
+		if value > 0 {
+			pdf.Rect(left+labelW, y+0.5, chartW*value, barH-1, "F")
+		}
+
+		pdf.SetX(left + labelW + chartW)
+		pdf.CellFormat(valueW, barH, percent(value), "", 1, "R", false, 0, "")
+		pdf.Ln(gap)
+	}
+	if len(topics) > maxTopics {
+		setFont(pdf, "", 8, colorMuted)
+		pdf.CellFormat(0, 5, fmt.Sprintf(t.topicsMore, maxTopics, len(topics)), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+}
+
+// writeHistory - линейный график доли верных ответов и вовлеченности по
+// истории анализов. Обе величины в одной шкале 0-100.
+func writeHistory(pdf *fpdf.Fpdf, history []*domain.StudentAnalytics, t labels) {
+	sectionTitle(pdf, t.history)
+	if len(history) < 2 {
+		noData(pdf, t)
+		return
+	}
+
+	const (
+		axisW   = 10.0
+		chartH  = 60.0
+		legendH = 8.0
+	)
+	left, _, right, _ := pdf.GetMargins()
+	pageW, _ := pdf.GetPageSize()
+	ensureSpace(pdf, chartH+legendH+10)
+
+	x0 := left + axisW
+	y0 := pdf.GetY() + 2
+	chartW := pageW - right - x0
+
+	// Сетка и подписи шкалы
+	setFont(pdf, "", 7, colorMuted)
+	pdf.SetLineWidth(0.2)
+	setDraw(pdf, colorGrid)
+	for v := 0; v <= 100; v += 25 {
+		y := y0 + chartH - chartH*float64(v)/100
+		pdf.Line(x0, y, x0+chartW, y)
+		pdf.SetXY(left, y-2)
+		pdf.CellFormat(axisW-1, 4, fmt.Sprintf("%d", v), "", 0, "R", false, 0, "")
+	}
+
+	first, last := history[0].AnalyzedAt, history[len(history)-1].AnalyzedAt
+	span := last.Sub(first)
+	xOf := func(ts time.Time) float64 {
+		if span <= 0 {
+			return x0
+		}
+		return x0 + chartW*float64(ts.Sub(first))/float64(span)
+	}
+	yOf := func(v float64) float64 {
+		return y0 + chartH - chartH*clamp01(v/100)
+	}
+
+	series := []struct {
+		c     color
+		value func(a *domain.StudentAnalytics) float64
+	}{
+		{colorSuccess, func(a *domain.StudentAnalytics) float64 { return a.SuccessRate * 100 }},
+		{colorEngage, func(a *domain.StudentAnalytics) float64 { return float64(a.EngagementScore) }},
+	}
+	pdf.SetLineWidth(0.6)
+	for _, s := range series {
+		setDraw(pdf, s.c)
+		setFill(pdf, s.c)
+		for i, a := range history {
+			x, y := xOf(a.AnalyzedAt), yOf(s.value(a))
+			if i > 0 {
+				prev := history[i-1]
+				pdf.Line(xOf(prev.AnalyzedAt), yOf(s.value(prev)), x, y)
+			}
+			pdf.Circle(x, y, 0.8, "F")
+		}
+	}
+	pdf.SetLineWidth(0.2)
+
+	// Даты по краям оси X
+	pdf.SetXY(x0, y0+chartH+1)
+	setFont(pdf, "", 7, colorMuted)
+	pdf.CellFormat(chartW/2, 4, first.UTC().Format(dateLayout), "", 0, "L", false, 0, "")
+	pdf.CellFormat(chartW/2, 4, last.UTC().Format(dateLayout), "", 1, "R", false, 0, "")
+
+	pdf.Ln(1)
+	pdf.SetX(x0)
+	legendItem(pdf, colorSuccess, t.successRate)
+	legendItem(pdf, colorEngage, t.engagement)
+	pdf.Ln(legendH)
+}
+
+func writeRecommendations(pdf *fpdf.Fpdf, recommendations []string, t labels) {
+	sectionTitle(pdf, t.recommendations)
+	if len(recommendations) == 0 {
+		noData(pdf, t)
+		return
+	}
+	setFont(pdf, "", 10, colorText)
+	for _, rec := range recommendations {
+		pdf.MultiCell(0, 5.5, "• "+rec, "", "L", false)
+		pdf.Ln(1)
+	}
+}
+
+func sectionTitle(pdf *fpdf.Fpdf, title string) {
+	ensureSpace(pdf, 20)
+	setFont(pdf, "B", 13, colorText)
+	pdf.CellFormat(0, 8, title, "", 1, "L", false, 0, "")
+	pdf.Ln(1)
+}
+
+func noData(pdf *fpdf.Fpdf, t labels) {
+	setFont(pdf, "", 10, colorMuted)
+	pdf.CellFormat(0, 6, t.noData, "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+}
+
+func legendItem(pdf *fpdf.Fpdf, c color, label string) {
+	setFill(pdf, c)
+	x, y := pdf.GetXY()
+	pdf.Rect(x, y+1.5, 3, 3, "F")
+	pdf.SetX(x + 4)
+	setFont(pdf, "", 8, colorText)
+	pdf.CellFormat(pdf.GetStringWidth(label)+6, 6, label, "", 0, "L", false, 0, "")
+}
+
+// ensureSpace переносит блок на новую страницу целиком, чтобы график не
+// разрезало автоматическим разрывом.
+func ensureSpace(pdf *fpdf.Fpdf, h float64) {
+	_, pageH := pdf.GetPageSize()
+	_, _, _, bottom := pdf.GetMargins()
+	if pdf.GetY()+h > pageH-bottom-15 {
+		pdf.AddPage()
+	}
+}
+
+func truncate(pdf *fpdf.Fpdf, s string, w float64) string {
+	if pdf.GetStringWidth(s) <= w {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"…") > w {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+func efficiencyColor(v float64) color {
+	switch {
+	case v < 0.5:
+		return colorWeak
+	case v < 0.75:
+		return colorMedium
+	default:
+		return colorGood
+	}
+}
+
+func percent(v float64) string {
+	return fmt.Sprintf("%.0f%%", v*100)
+}
+
+func clamp01(v float64) float64 {
+	switch {
+	case v < 0:
+		return 0
+	case v > 1:
+		return 1
+	}
+	return v
+}
+
+func setFont(pdf *fpdf.Fpdf, style string, size float64, c color) {
+	pdf.SetFont(fontFamily, style, size)
+	pdf.SetTextColor(c.r, c.g, c.b)
+}
+
+func setFill(pdf *fpdf.Fpdf, c color) {
+	pdf.SetFillColor(c.r, c.g, c.b)
+}
+
+func setDraw(pdf *fpdf.Fpdf, c color) {
+	pdf.SetDrawColor(c.r, c.g, c.b)
+}
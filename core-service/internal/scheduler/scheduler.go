@@ -0,0 +1,83 @@
+// Package scheduler запускает фоновые задачи по cron-расписанию. Каждый
+// запуск идет под блокировкой, общей для реплик: задачу выполняет только
+// одна из них, остальные пропускают запуск.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/metrics"
+)
+
+type job struct {
+	name     string
+	schedule cron.Schedule
+	run      func(ctx context.Context) error
+}
+
+type Scheduler struct {
+	locker interfaces.Locker
+	logger *slog.Logger
+	jobs   []job
+}
+
+func New(locker interfaces.Locker, logger *slog.Logger) *Scheduler {
+	return &Scheduler{locker: locker, logger: logger}
+}
+
+// Add регистрирует задачу. Ошибка в расписании возвращается сразу, чтобы
+// сервис не стартовал с задачей, которая никогда не запустится.
+func (s *Scheduler) Add(name, spec string, run func(ctx context.Context) error) error {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return fmt.Errorf("invalid schedule %q for job %s: %w", spec, name, err)
+	}
+	s.jobs = append(s.jobs, job{name: name, schedule: schedule, run: run})
+	return nil
+}
+
+// Run блокируется до отмены ctx. Отмена прерывает идущие задачи через их
+// ctx, и Run дожидается их завершения.
+func (s *Scheduler) Run(ctx context.Context) {
+	c := cron.New()
+	for _, j := range s.jobs {
+		c.Schedule(j.schedule, cron.FuncJob(func() { s.RunJob(ctx, j.name, j.run) }))
+		s.logger.Info("scheduled job registered",
+			slog.String("job", j.name),
+			slog.Time("next_run", j.schedule.Next(time.Now())),
+		)
+	}
+	c.Start()
+	<-ctx.Done()
+	<-c.Stop().Done()
+}
+
+// RunJob выполняет задачу один раз под блокировкой "scheduler:<name>".
+func (s *Scheduler) RunJob(ctx context.Context, name string, run func(ctx context.Context) error) {
+	start := time.Now()
+	acquired, err := s.locker.TryLock(ctx, "scheduler:"+name, run)
+	switch {
+	case err != nil:
+		metrics.SchedulerJobRunsTotal.WithLabelValues(name, "failure").Inc()
+		s.logger.ErrorContext(ctx, "scheduled job failed",
+			slog.String("job", name),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)),
+		)
+	case !acquired:
+		metrics.SchedulerJobRunsTotal.WithLabelValues(name, "skipped").Inc()
+		s.logger.InfoContext(ctx, "scheduled job is running on another replica", slog.String("job", name))
+	default:
+		metrics.SchedulerJobRunsTotal.WithLabelValues(name, "success").Inc()
+		s.logger.InfoContext(ctx, "scheduled job finished",
+			slog.String("job", name),
+			slog.Duration("duration", time.Since(start)),
+		)
+	}
+}
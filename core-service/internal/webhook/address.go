@@ -0,0 +1,89 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"syscall"
+)
+
+// ErrForbiddenDestination - адрес подписчика во внутренней сети сервиса.
+var ErrForbiddenDestination = errors.New("webhook destination is not a public address")
+
+// Диапазоны, которых нет среди проверок netip.Addr: "этот" хост, CGNAT,
+// служебные IETF, тестовые сети, 6to4-ретрансляция и NAT64, через которые
+// можно добраться до внутреннего IPv4.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2002::/16"),
+}
+
+// IsPublicAddr - false для loopback, RFC 1918, ULA, link-local (в том
+// числе 169.254.169.254 облачных метаданных), multicast и служебных
+// диапазонов.
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckHost проверяет хост из URL подписки: IP-литерал - сразу, имя -
+// по всем адресам, в которые оно разрешается. Если имя сейчас не
+// разрешается, ошибки нет: адрес все равно проверит Sender при
+// соединении.
+func CheckHost(ctx context.Context, host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if addr, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil {
+		if !IsPublicAddr(addr) {
+			return ErrForbiddenDestination
+		}
+		return nil
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrForbiddenDestination
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if !IsPublicAddr(addr) {
+			return ErrForbiddenDestination
+		}
+	}
+	return nil
+}
+
+// guardDial вызывается после разрешения имени, с адресом, к которому
+// идет соединение: имя, которое при создании подписки указывало наружу,
+// а теперь разрешается во внутренний адрес (DNS rebinding), не пройдет.
+func guardDial(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("unexpected dial address %q: %w", address, err)
+	}
+	if !IsPublicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenDestination, addrPort.Addr())
+	}
+	return nil
+}
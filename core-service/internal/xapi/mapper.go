@@ -0,0 +1,146 @@
+package xapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+)
+
+// Mapping задает, во что превращаются statement'ы. Verbs сопоставляет IRI
+// глагола с типом действия. Activities уточняет просмотры по типу
+// активности: "experienced" видео - это watch_video, а не view_material.
+// На ответы Activities не влияет.
+type Mapping struct {
+	Verbs      map[string]string `json:"verbs"`
+	Activities map[string]string `json:"activities"`
+}
+
+func DefaultMapping() Mapping {
+	return Mapping{
+		Verbs: map[string]string{
+			"http://adlnet.gov/expapi/verbs/experienced": domain.ActionViewMaterial,
+			"http://adlnet.gov/expapi/verbs/completed":   domain.ActionViewLesson,
+			"http://adlnet.gov/expapi/verbs/answered":    domain.ActionAnswerQuestion,
+			"https://w3id.org/xapi/video/verbs/played":   domain.ActionWatchVideo,
+			"https://w3id.org/xapi/video/verbs/watched":  domain.ActionWatchVideo,
+		},
+		Activities: map[string]string{
+			"http://adlnet.gov/expapi/activities/lesson":      domain.ActionViewLesson,
+			"http://adlnet.gov/expapi/activities/module":      domain.ActionViewLesson,
+			"https://w3id.org/xapi/video/activity-type/video": domain.ActionWatchVideo,
+		},
+	}
+}
+
+// LoadMapping читает JSON-файл вида {"verbs": {...}, "activities": {...}}
+// и накладывает его на DefaultMapping: записи файла добавляются к
+// стандартным или заменяют их, пустое значение отключает стандартную.
+func LoadMapping(path string) (Mapping, error) {
+	mapping := DefaultMapping()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return mapping, fmt.Errorf("failed to read xapi mapping: %w", err)
+	}
+	var custom Mapping
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return mapping, fmt.Errorf("failed to parse xapi mapping: %w", err)
+	}
+	for _, pair := range []struct{ dst, src map[string]string }{
+		{mapping.Verbs, custom.Verbs},
+		{mapping.Activities, custom.Activities},
+	} {
+		for iri, action := range pair.src {
+			if action == "" {
+				delete(pair.dst, iri)
+				continue
+			}
+			if !domain.IsKnownAction(action) {
+				return mapping, fmt.Errorf("xapi mapping: unknown action %q for %s", action, iri)
+			}
+			pair.dst[iri] = action
+		}
+	}
+	return mapping, nil
+}
+
+type Mapper struct {
+	mapping Mapping
+}
+
+func NewMapper(mapping Mapping) *Mapper {
+	return &Mapper{mapping: mapping}
+}
+
+// ToLog переводит statement в лог. Если у statement нет timestamp, поле
+// остается нулевым - что с этим делать, решает вызывающий.
+func (m *Mapper) ToLog(st *Statement) (*domain.StudentLog, error) {
+	studentID, err := studentID(st.Actor)
+	if err != nil {
+		return nil, err
+	}
+
+	action, ok := m.mapping.Verbs[st.Verb.ID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedVerb, st.Verb.ID)
+	}
+	isAnswer := domain.IsAnswerAction(action)
+	if byActivity, ok := m.mapping.Activities[st.ActivityType()]; ok && !isAnswer {
+		action = byActivity
+	}
+
+	log := &domain.StudentLog{
+		StudentID:  studentID,
+		ActionType: action,
+		MaterialID: st.Object.ID,
+	}
+
+	if st.Timestamp != "" {
+		ts, err := time.Parse(time.RFC3339Nano, st.Timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q: %w", st.Timestamp, err)
+		}
+		log.Timestamp = ts
+	}
+
+	if st.Result != nil && st.Result.Duration != "" {
+		d, err := ParseDuration(st.Result.Duration)
+		if err != nil {
+			return nil, err
+		}
+		log.TimeSpentSec = int(d.Round(time.Second) / time.Second)
+	}
+
+	if isAnswer {
+		// В xAPI каждая попытка - отдельный statement
+		log.Attempts = 1
+		log.TimeSpentOnQuestion = log.TimeSpentSec
+		if st.Result != nil && st.Result.Success != nil {
+			log.Correct = *st.Result.Success
+			if !log.Correct {
+				log.SelectedDistractor = st.Result.Response
+			}
+		}
+	} else {
+		log.TimeSpentOnMat = log.TimeSpentSec
+	}
+
+	return log, nil
+}
+
+// studentID берет ID студента из account.name. В LMS это внутренний
+// числовой ID; mbox и имя не подходят - по ним нельзя найти студента.
+func studentID(actor Actor) (uint64, error) {
+	if actor.Account == nil || actor.Account.Name == "" {
+		return 0, fmt.Errorf("actor has no account")
+	}
+	id, err := strconv.ParseUint(strings.TrimSpace(actor.Account.Name), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("actor account name %q is not a student id", actor.Account.Name)
+	}
+	return id, nil
+}
@@ -0,0 +1,133 @@
+// Package xapi описывает подмножество xAPI (Experience API) statement,
+// которое нужно, чтобы превратить активность из LMS в domain.StudentLog.
+package xapi
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+type Statement struct {
+	ID        string  `json:"id,omitempty"`
+	Actor     Actor   `json:"actor"`
+	Verb      Verb    `json:"verb"`
+	Object    Object  `json:"object"`
+	Result    *Result `json:"result,omitempty"`
+	Timestamp string  `json:"timestamp,omitempty"`
+}
+
+type Actor struct {
+	ObjectType string   `json:"objectType,omitempty"`
+	Name       string   `json:"name,omitempty"`
+	Mbox       string   `json:"mbox,omitempty"`
+	Account    *Account `json:"account,omitempty"`
+}
+
+// Account - учетная запись в LMS. Name у нас - ID студента.
+type Account struct {
+	HomePage string `json:"homePage"`
+	Name     string `json:"name"`
+}
+
+type Verb struct {
+	ID      string            `json:"id"`
+	Display map[string]string `json:"display,omitempty"`
+}
+
+type Object struct {
+	ObjectType string              `json:"objectType,omitempty"`
+	ID         string              `json:"id"`
+	Definition *ActivityDefinition `json:"definition,omitempty"`
+}
+
+type ActivityDefinition struct {
+	Type string            `json:"type,omitempty"`
+	Name map[string]string `json:"name,omitempty"`
+}
+
+type Result struct {
+	Success    *bool                      `json:"success,omitempty"`
+	Completion *bool                      `json:"completion,omitempty"`
+	Response   string                     `json:"response,omitempty"`
+	Duration   string                     `json:"duration,omitempty"`
+	Extensions map[string]json.RawMessage `json:"extensions,omitempty"`
+}
+
+// ActivityType возвращает тип активности или пустую строку.
+func (s *Statement) ActivityType() string {
+	if s.Object.Definition == nil {
+		return ""
+	}
+	return s.Object.Definition.Type
+}
+
+var durationPattern = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// ParseDuration разбирает длительность ISO 8601 в том виде, в каком ее
+// пишут LMS: PT1H2M3.5S, P1DT2H. Годы и месяцы не поддерживаются - у
+// длительности одного действия их не бывает.
+func ParseDuration(s string) (time.Duration, error) {
+	m := durationPattern.FindStringSubmatch(s)
+	if m == nil || s == "P" || s == "PT" {
+		return 0, fmt.Errorf("invalid ISO 8601 duration %q", s)
+	}
+
+	var d time.Duration
+	units := []time.Duration{24 * time.Hour, time.Hour, time.Minute}
+	for i, unit := range units {
+		if m[i+1] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(m[i+1])
+		if err != nil {
+			return 0, err
+		}
+		d += time.Duration(n) * unit
+	}
+	if m[4] != "" {
+		sec, err := strconv.ParseFloat(m[4], 64)
+		if err != nil {
+			return 0, err
+		}
+		d += time.Duration(sec * float64(time.Second))
+	}
+	return d, nil
+}
+
+var ErrUnsupportedVerb = errors.New("unsupported xAPI verb")
+
+var idPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ValidID сообщает, что id statement'а - UUID, как требует спецификация.
+func ValidID(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+// NewID возвращает случайный UUID v4 для statement'а, пришедшего без id.
+func NewID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// WithID дописывает id в исходный JSON statement'а, не трогая остальные
+// поля: сохраняется то, что прислал источник, включая расширения.
+func WithID(raw json.RawMessage, id string) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(id)
+	if err != nil {
+		return nil, err
+	}
+	fields["id"] = encoded
+	return json.Marshal(fields)
+}
@@ -0,0 +1,330 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	internal_http "github.com/RusselRustCode/teacher_analytics/core-service/internal/api/http"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/auth"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/mocks"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/notify"
+)
+
+type AlertServiceTestSuite struct {
+	suite.Suite
+	ctx      context.Context
+	repoMock *mocks.Repository
+	notifier *mocks.Notifier
+	notified chan *domain.Alert
+	stop     context.CancelFunc
+	service  interfaces.AlertService
+}
+
+func (s *AlertServiceTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.repoMock = new(mocks.Repository)
+	s.notifier = new(mocks.Notifier)
+	s.notifier.On("Name").Return("test").Maybe()
+	s.notified = make(chan *domain.Alert, 10)
+
+	alertNotifier := application.NewAlertNotifier([]interfaces.Notifier{s.notifier}, 10, logging.Nop())
+	ctx, stop := context.WithCancel(s.ctx)
+	s.stop = stop
+	go alertNotifier.Run(ctx)
+	s.service = application.NewAlertService(s.repoMock, alertNotifier, logging.Nop())
+}
+
+func (s *AlertServiceTestSuite) TearDownTest() {
+	s.stop()
+}
+
+// expectNotify - рассылка идет в фоне, поэтому доставленные оповещения
+// попадают в s.notified.
+func (s *AlertServiceTestSuite) expectNotify(err error) *mock.Call {
+	return s.notifier.On("Notify", mock.Anything, mock.AnythingOfType("*domain.Alert")).Return(err).
+		Run(func(args mock.Arguments) { s.notified <- args.Get(1).(*domain.Alert) })
+}
+
+func (s *AlertServiceTestSuite) waitNotified(n int) []*domain.Alert {
+	var alerts []*domain.Alert
+	for range n {
+		select {
+		case alert := <-s.notified:
+			alerts = append(alerts, alert)
+		case <-time.After(time.Second):
+			s.T().Fatalf("got %d notifications, want %d", len(alerts), n)
+		}
+	}
+	return alerts
+}
+
+func (s *AlertServiceTestSuite) TestCreateRule_Invalid() {
+	err := s.service.CreateRule(s.ctx, &domain.AlertRule{Name: "low", Type: domain.RuleSuccessRateBelow, Threshold: 1.5})
+
+	appErr, ok := application.AsError(err)
+	require.True(s.T(), ok)
+	assert.Equal(s.T(), application.KindValidation, appErr.Kind)
+	var fields []string
+	for _, f := range appErr.Fields {
+		fields = append(fields, f.Field)
+	}
+	assert.Equal(s.T(), []string{"threshold", "window_days"}, fields)
+	s.repoMock.AssertNotCalled(s.T(), "CreateAlertRule", mock.Anything, mock.Anything)
+}
+
+func (s *AlertServiceTestSuite) TestCreateRule_BoundToSessionCourse() {
+	ctx := auth.WithSession(s.ctx, &domain.Session{Subject: "teacher-1", CohortID: 3})
+	s.repoMock.On("GetCohortByID", ctx, uint64(3)).Return(&domain.Cohort{ID: 3}, nil)
+	s.repoMock.On("CreateAlertRule", ctx, mock.MatchedBy(func(r *domain.AlertRule) bool {
+		return r.CohortID == 3 && r.CreatedBy == "teacher-1"
+	})).Return(nil)
+
+	err := s.service.CreateRule(ctx, &domain.AlertRule{Name: "idle", Type: domain.RuleInactivity, WindowDays: 5})
+	require.NoError(s.T(), err)
+
+	err = s.service.CreateRule(ctx, &domain.AlertRule{Name: "idle", Type: domain.RuleInactivity, WindowDays: 5, CohortID: 4})
+	assert.True(s.T(), application.IsKind(err, application.KindForbidden))
+}
+
+func (s *AlertServiceTestSuite) TestEvaluateStudent_SuccessRateBelow() {
+	rule := &domain.AlertRule{ID: 1, Name: "low", Type: domain.RuleSuccessRateBelow, Threshold: 0.4, WindowDays: 7}
+	s.repoMock.On("GetAlertRulesForStudent", s.ctx, uint64(5)).Return([]*domain.AlertRule{rule}, nil)
+	s.repoMock.On("GetAnswerStats", s.ctx, uint64(5), mock.AnythingOfType("time.Time")).Return(10, 3, nil)
+	s.repoMock.On("CreateAlert", s.ctx, mock.MatchedBy(func(a *domain.Alert) bool {
+		return a.RuleID == 1 && a.StudentID == 5 && a.Status == domain.AlertOpen &&
+			a.Message == "success rate 30% over the last 7 days is below 40%"
+	})).Return(true, nil)
+	s.expectNotify(errors.New("smtp down"))
+
+	// Сбой доставки не ломает проверку
+	require.NoError(s.T(), s.service.EvaluateStudent(s.ctx, 5))
+	s.waitNotified(1)
+
+	since := s.repoMock.Calls[1].Arguments.Get(2).(time.Time)
+	assert.WithinDuration(s.T(), time.Now().AddDate(0, 0, -7), since, time.Minute)
+}
+
+func (s *AlertServiceTestSuite) TestEvaluateStudent_AlreadyOpenIsNotRenotified() {
+	rule := &domain.AlertRule{ID: 1, Name: "low", Type: domain.RuleSuccessRateBelow, Threshold: 0.4, WindowDays: 7}
+	s.repoMock.On("GetAlertRulesForStudent", s.ctx, uint64(5)).Return([]*domain.AlertRule{rule}, nil)
+	s.repoMock.On("GetAnswerStats", s.ctx, uint64(5), mock.Anything).Return(10, 1, nil)
+	s.repoMock.On("CreateAlert", s.ctx, mock.Anything).Return(false, nil)
+
+	require.NoError(s.T(), s.service.EvaluateStudent(s.ctx, 5))
+	s.notifier.AssertNotCalled(s.T(), "Notify", mock.Anything, mock.Anything)
+}
+
+func (s *AlertServiceTestSuite) TestEvaluateStudent_ClusterTransitionOnly() {
+	rule := &domain.AlertRule{ID: 2, Name: "struggling", Type: domain.RuleClusterChanged, Cluster: "struggling"}
+	s.repoMock.On("GetAlertRulesForStudent", s.ctx, mock.Anything).Return([]*domain.AlertRule{rule}, nil)
+	s.repoMock.On("GetAnalyticsHistory", s.ctx, uint64(5), time.Time{}, mock.Anything).Return([]*domain.StudentAnalytics{
+		{ClusterGroup: "average"}, {ClusterGroup: "struggling"},
+	}, nil)
+	s.repoMock.On("GetAnalyticsHistory", s.ctx, uint64(6), time.Time{}, mock.Anything).Return([]*domain.StudentAnalytics{
+		{ClusterGroup: "struggling"}, {ClusterGroup: "struggling"},
+	}, nil)
+	s.repoMock.On("CreateAlert", s.ctx, mock.MatchedBy(func(a *domain.Alert) bool {
+		return a.StudentID == 5 && a.Message == `moved to cluster "struggling" from "average"`
+	})).Return(true, nil).Once()
+	s.expectNotify(nil)
+
+	require.NoError(s.T(), s.service.EvaluateStudent(s.ctx, 5))
+	require.NoError(s.T(), s.service.EvaluateStudent(s.ctx, 6))
+	s.repoMock.AssertNumberOfCalls(s.T(), "CreateAlert", 1)
+	s.waitNotified(1)
+}
+
+func (s *AlertServiceTestSuite) TestEvaluateInactivity() {
+	s.repoMock.On("ListAlertRules", s.ctx, uint64(0)).Return([]*domain.AlertRule{
+		{ID: 1, Type: domain.RuleSuccessRateBelow, Threshold: 0.4, WindowDays: 7},
+		{ID: 2, Name: "idle", Type: domain.RuleInactivity, CohortID: 3, WindowDays: 5},
+	}, nil)
+	s.repoMock.On("GetInactiveStudents", s.ctx, uint64(3), mock.AnythingOfType("time.Time")).Return([]uint64{7, 8}, nil)
+	s.repoMock.On("CreateAlert", s.ctx, mock.MatchedBy(func(a *domain.Alert) bool {
+		return a.RuleID == 2 && a.Message == "no activity for at least 5 days"
+	})).Return(true, nil)
+	s.expectNotify(nil)
+
+	require.NoError(s.T(), s.service.EvaluateInactivity(s.ctx))
+	s.repoMock.AssertNumberOfCalls(s.T(), "CreateAlert", 2)
+	s.waitNotified(2)
+}
+
+// Медленный канал (SMTP) не задерживает проверку правил.
+func (s *AlertServiceTestSuite) TestEvaluateInactivity_DoesNotWaitForNotifiers() {
+	s.repoMock.On("ListAlertRules", s.ctx, uint64(0)).Return([]*domain.AlertRule{
+		{ID: 2, Name: "idle", Type: domain.RuleInactivity, CohortID: 3, WindowDays: 5},
+	}, nil)
+	s.repoMock.On("GetInactiveStudents", s.ctx, uint64(3), mock.Anything).Return([]uint64{7, 8}, nil)
+	s.repoMock.On("CreateAlert", s.ctx, mock.Anything).Return(true, nil)
+	release := make(chan struct{})
+	s.expectNotify(nil).Run(func(args mock.Arguments) {
+		<-release
+		s.notified <- args.Get(1).(*domain.Alert)
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- s.service.EvaluateInactivity(s.ctx) }()
+	select {
+	case err := <-done:
+		require.NoError(s.T(), err)
+	case <-time.After(time.Second):
+		s.T().Fatal("EvaluateInactivity waited for notifiers")
+	}
+
+	close(release)
+	alerts := s.waitNotified(2)
+	assert.ElementsMatch(s.T(), []uint64{7, 8}, []uint64{alerts[0].StudentID, alerts[1].StudentID})
+}
+
+func (s *AlertServiceTestSuite) TestAcknowledgeAndResolve() {
+	ctx := auth.WithSession(s.ctx, &domain.Session{Subject: "teacher-1", CohortID: 3})
+	s.repoMock.On("IsStudentInCohort", ctx, uint64(3), uint64(5)).Return(true, nil)
+	s.repoMock.On("GetAlert", ctx, uint64(9)).Return(&domain.Alert{ID: 9, StudentID: 5, Status: domain.AlertOpen}, nil).Once()
+	s.repoMock.On("UpdateAlertStatus", ctx, uint64(9), domain.AlertOpen, domain.AlertAcknowledged, "teacher-1", mock.Anything).Return(true, nil)
+	s.repoMock.On("GetAlert", ctx, uint64(9)).Return(&domain.Alert{ID: 9, StudentID: 5, Status: domain.AlertAcknowledged}, nil).Once()
+
+	alert, err := s.service.Acknowledge(ctx, 9)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), domain.AlertAcknowledged, alert.Status)
+
+	s.repoMock.On("GetAlert", ctx, uint64(10)).Return(&domain.Alert{ID: 10, StudentID: 5, Status: domain.AlertResolved}, nil)
+	_, err = s.service.Resolve(ctx, 10)
+	assert.True(s.T(), application.IsKind(err, application.KindConflict))
+}
+
+func TestAlertService(t *testing.T) {
+	suite.Run(t, new(AlertServiceTestSuite))
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	alert := &domain.Alert{ID: 1, StudentID: 5, RuleName: "low", Status: domain.AlertOpen}
+	require.NoError(t, notify.NewWebhookNotifier(server.URL, time.Second).Notify(context.Background(), alert))
+	assert.Equal(t, "alert.raised", got["event"])
+	assert.Equal(t, float64(5), got["alert"].(map[string]any)["student_id"])
+
+	err := notify.NewWebhookNotifier(server.URL+"/fail", time.Second).Notify(context.Background(), alert)
+	assert.EqualError(t, err, "webhook responded with status 502")
+}
+
+// startSMTPServer - минимальный SMTP-сервер для теста: принимает одно
+// письмо и отдает его текст в канал.
+func startSMTPServer(t *testing.T) (string, <-chan string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		tp.PrintfLine("220 localhost ready")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.Fields(line)[0]); cmd {
+			case "EHLO", "HELO":
+				tp.PrintfLine("250 localhost")
+			case "DATA":
+				tp.PrintfLine("354 go ahead")
+				data, _ := io.ReadAll(tp.DotReader())
+				messages <- string(data)
+				tp.PrintfLine("250 queued")
+			case "QUIT":
+				tp.PrintfLine("221 bye")
+				return
+			default:
+				tp.PrintfLine("250 ok")
+			}
+		}
+	}()
+	return ln.Addr().String(), messages
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	addr, messages := startSMTPServer(t)
+	n := notify.NewSMTPNotifier(notify.SMTPOptions{
+		Addr: addr,
+		From: "alerts@example.edu",
+		To:   []string{"teacher@example.edu"},
+	})
+
+	err := n.Notify(context.Background(), &domain.Alert{
+		ID: 3, StudentID: 5, RuleName: "Низкая успеваемость", Type: domain.RuleSuccessRateBelow,
+		Message: "success rate 30% over the last 7 days is below 40%", RaisedAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	msg := <-messages
+	r := textproto.NewReader(bufio.NewReader(strings.NewReader(msg)))
+	header, err := r.ReadMIMEHeader()
+	require.NoError(t, err)
+	assert.Equal(t, "teacher@example.edu", header.Get("To"))
+	assert.Contains(t, header.Get("Subject"), "=?utf-8?q?")
+	assert.Contains(t, msg, "success rate 30% over the last 7 days is below 40%")
+}
+
+func TestAlertHandler(t *testing.T) {
+	alerts := new(mocks.AlertService)
+	alerts.On("ListAlerts", mock.Anything, domain.AlertFilter{Status: domain.AlertOpen, StudentID: 5}).
+		Return([]*domain.Alert{{ID: 1, StudentID: 5, Status: domain.AlertOpen}}, nil)
+	alerts.On("Resolve", mock.Anything, uint64(1)).
+		Return(nil, application.Conflict("invalid_alert_status", "alert 1 is resolved"))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	internal_http.SetupRoutes(router, internal_http.Handlers{
+		API:    internal_http.NewHTTPHandler(new(mocks.AnalyticsService)),
+		Health: internal_http.NewHealthHandler(new(mocks.HealthChecker)),
+		Alert:  internal_http.NewAlertHandler(alerts),
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/alerts?status=open&student_id=5", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"open"`)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/alerts?status=closed", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/alerts/1/resolve", nil))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
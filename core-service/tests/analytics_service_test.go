@@ -2,15 +2,21 @@ package tests
 
 import (
 	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
     "github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
 	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/lifecycle"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
 	"github.com/RusselRustCode/teacher_analytics/core-service/internal/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -21,6 +27,7 @@ type AnalyticsServiceTestSuite struct {
 	cacheMock    *mocks.Cache
 	producerMock *mocks.MessageProducer
 	clientMock   *mocks.AnalyticsClient
+	eventsMock   *mocks.EventPublisher
 	service      interfaces.AnalyticsService
 }
 
@@ -30,12 +37,16 @@ func (s *AnalyticsServiceTestSuite) SetupTest() {
 	s.cacheMock = new(mocks.Cache)
 	s.producerMock = new(mocks.MessageProducer)
 	s.clientMock = new(mocks.AnalyticsClient)
+	s.eventsMock = new(mocks.EventPublisher)
 
 	s.service = application.NewAnalyticsService(
 		s.repoMock,
 		s.cacheMock,
 		s.producerMock,
 		s.clientMock,
+		s.eventsMock,
+		application.AnalyticsOptions{CacheTTL: time.Minute, StaleTTL: time.Hour, LockTTL: time.Second},
+		logging.Nop(),
 	)
 }
 
@@ -56,12 +67,18 @@ func (s *AnalyticsServiceTestSuite) TestSendLog_Success() {
     ).Return(nil)
 
     s.cacheMock.On("Delete", s.ctx, mock.Anything).Return(nil)
+    // Студент уже встречался - общий список не сбрасывается
+    s.cacheMock.On("SetNX", s.ctx, "students:seen:1", "1", 24*time.Hour).Return(false, nil)
+    s.cacheMock.On("InvalidateTags", s.ctx, "student:1").Return(nil)
+    s.eventsMock.On("Publish", s.ctx, domain.EventLogIngested, log).Return(nil)
 
     err := s.service.SendLog(s.ctx, log)
 
     assert.NoError(s.T(), err)
     s.repoMock.AssertExpectations(s.T())
     s.producerMock.AssertExpectations(s.T())
+    s.eventsMock.AssertExpectations(s.T())
+    s.cacheMock.AssertExpectations(s.T())
 }
 
 func (s *AnalyticsServiceTestSuite) TestGetAnalytics_CacheHit() {
@@ -79,6 +96,149 @@ func (s *AnalyticsServiceTestSuite) TestGetAnalytics_CacheHit() {
 	s.repoMock.AssertNotCalled(s.T(), "GetAnalyticsByStudentID", mock.Anything, mock.Anything)
 }
 
+func (s *AnalyticsServiceTestSuite) TestGetAnalytics_ServesStaleWhileRefreshing() {
+	s.cacheMock.On("Get", s.ctx, "analytics:5").Return("", nil)
+	s.cacheMock.On("Get", s.ctx, "analytics:stale:5").Return(`{"student_id":5,"cluster_group":"average"}`, nil)
+	s.cacheMock.On("SetNX", mock.Anything, "lock:analytics:5", "1", time.Second).Return(true, nil)
+	s.repoMock.On("GetAnalyticsByStudentID", mock.Anything, uint64(5)).
+		Return(&domain.StudentAnalytics{StudentID: 5, ClusterGroup: "advanced"}, nil)
+	refreshed := make(chan struct{})
+	s.cacheMock.On("Set", mock.Anything, "analytics:5", mock.Anything, time.Minute).Return(nil)
+	s.cacheMock.On("Set", mock.Anything, "analytics:stale:5", mock.Anything, time.Hour).Return(nil)
+	s.cacheMock.On("Delete", mock.Anything, "lock:analytics:5").Return(nil).Run(func(mock.Arguments) { close(refreshed) })
+
+	res, err := s.service.GetAnalytics(s.ctx, 5)
+
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), res.Stale)
+	assert.Equal(s.T(), "average", res.ClusterGroup)
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		s.T().Fatal("stale analytics were not refreshed")
+	}
+	s.cacheMock.AssertExpectations(s.T())
+}
+
+// Фоновое обновление идет через lifecycle: остановка отменяет его
+// контекст и ждет, пока оно завершится.
+func (s *AnalyticsServiceTestSuite) TestGetAnalytics_ShutdownWaitsForStaleRefresh() {
+	lc := lifecycle.NewManager(time.Second, logging.Nop())
+	service := application.NewAnalyticsService(s.repoMock, s.cacheMock, s.producerMock, s.clientMock, s.eventsMock,
+		application.AnalyticsOptions{CacheTTL: time.Minute, StaleTTL: time.Hour, LockTTL: time.Second, Background: lc.Task},
+		logging.Nop())
+
+	s.cacheMock.On("Get", s.ctx, "analytics:5").Return("", nil)
+	s.cacheMock.On("Get", s.ctx, "analytics:stale:5").Return(`{"student_id":5,"cluster_group":"average"}`, nil)
+	s.cacheMock.On("SetNX", mock.Anything, "lock:analytics:5", "1", time.Second).Return(true, nil)
+	started := make(chan struct{})
+	var finished atomic.Bool
+	s.repoMock.On("GetAnalyticsByStudentID", mock.Anything, uint64(5)).
+		Return((*domain.StudentAnalytics)(nil), context.Canceled).
+		Run(func(args mock.Arguments) {
+			close(started)
+			<-args.Get(0).(context.Context).Done()
+		})
+	s.cacheMock.On("Delete", mock.Anything, "lock:analytics:5").Return(nil).Run(func(mock.Arguments) { finished.Store(true) })
+
+	res, err := service.GetAnalytics(s.ctx, 5)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), res.Stale)
+	<-started
+
+	require.NoError(s.T(), lc.Shutdown())
+	assert.True(s.T(), finished.Load(), "shutdown must wait for the refresh")
+}
+
+func (s *AnalyticsServiceTestSuite) TestGetAnalytics_CoalescesConcurrentMisses() {
+	const readers = 20
+	var lookups atomic.Int32
+	release := make(chan struct{})
+	s.cacheMock.On("Get", s.ctx, mock.Anything).Return("", nil).Run(func(mock.Arguments) { lookups.Add(1) })
+	s.cacheMock.On("SetNX", mock.Anything, "lock:analytics:5", "1", time.Second).Return(true, nil)
+	s.repoMock.On("GetAnalyticsByStudentID", mock.Anything, uint64(5)).
+		Return(&domain.StudentAnalytics{StudentID: 5}, nil).
+		Run(func(mock.Arguments) { <-release })
+	s.cacheMock.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	s.cacheMock.On("Delete", mock.Anything, "lock:analytics:5").Return(nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := s.service.GetAnalytics(s.ctx, 5)
+			assert.NoError(s.T(), err)
+			assert.Equal(s.T(), uint64(5), res.StudentID)
+		}()
+	}
+	// Каждый читатель проверяет свежую запись и копию, затем ждет загрузку
+	assert.Eventually(s.T(), func() bool { return lookups.Load() == 2*readers }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	s.repoMock.AssertNumberOfCalls(s.T(), "GetAnalyticsByStudentID", 1)
+	s.cacheMock.AssertNumberOfCalls(s.T(), "SetNX", 1)
+}
+
+func (s *AnalyticsServiceTestSuite) TestGetAnalytics_LockedElsewhereWaitsForCache() {
+	s.cacheMock.On("Get", s.ctx, "analytics:5").Return("", nil).Once()
+	s.cacheMock.On("Get", s.ctx, "analytics:stale:5").Return("", nil).Once()
+	s.cacheMock.On("SetNX", mock.Anything, "lock:analytics:5", "1", time.Second).Return(false, nil)
+	s.cacheMock.On("Get", mock.Anything, "analytics:5").Return("", nil).Once()
+	s.cacheMock.On("Get", mock.Anything, "analytics:5").Return(`{"student_id":5,"cluster_group":"advanced"}`, nil).Once()
+
+	res, err := s.service.GetAnalytics(s.ctx, 5)
+
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "advanced", res.ClusterGroup)
+	assert.False(s.T(), res.Stale)
+	s.repoMock.AssertNotCalled(s.T(), "GetAnalyticsByStudentID", mock.Anything, mock.Anything)
+	s.cacheMock.AssertNotCalled(s.T(), "Delete", mock.Anything, mock.Anything)
+}
+
+func (s *AnalyticsServiceTestSuite) TestGetAnalytics_NotAnalyzedTriggersOnlyUnderLock() {
+	s.cacheMock.On("Get", mock.Anything, mock.Anything).Return("", nil)
+	s.cacheMock.On("SetNX", mock.Anything, "lock:analytics:5", "1", time.Second).Return(false, nil)
+	s.repoMock.On("GetAnalyticsByStudentID", mock.Anything, uint64(5)).Return(nil, nil)
+
+	res, err := s.service.GetAnalytics(s.ctx, 5)
+
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "processing", res.ClusterGroup)
+	// Команду анализа отправил держатель блокировки
+	s.producerMock.AssertNotCalled(s.T(), "SendJSON", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *AnalyticsServiceTestSuite) TestSendLog_ValidationError() {
+	err := s.service.SendLog(s.ctx, &domain.StudentLog{ActionType: "view_lesson"})
+
+	assert.True(s.T(), application.IsKind(err, application.KindValidation))
+	s.repoMock.AssertNotCalled(s.T(), "SaveLog", mock.Anything, mock.Anything)
+}
+
+func (s *AnalyticsServiceTestSuite) TestGetStudentByID_NotFound() {
+	s.repoMock.On("GetStudentByID", s.ctx, uint64(7)).Return(nil, nil)
+
+	student, err := s.service.GetStudentByID(s.ctx, 7)
+
+	assert.Nil(s.T(), student)
+	appErr, ok := application.AsError(err)
+	if assert.True(s.T(), ok) {
+		assert.Equal(s.T(), application.KindNotFound, appErr.Kind)
+		assert.Equal(s.T(), "student_not_found", appErr.Code)
+	}
+}
+
+func (s *AnalyticsServiceTestSuite) TestTriggerAnalysis_ProducerDown() {
+	s.producerMock.On("SendJSON", s.ctx, "analysis-commands", mock.Anything).Return(errors.New("broker unreachable"))
+
+	err := s.service.TriggerAnalysis(s.ctx, 5)
+
+	assert.True(s.T(), application.IsKind(err, application.KindUnavailable))
+}
+
 func TestAnalyticsService(t *testing.T) {
 	suite.Run(t, new(AnalyticsServiceTestSuite))
 }
\ No newline at end of file
@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/api/apierror"
+	internal_grpc "github.com/RusselRustCode/teacher_analytics/core-service/internal/api/grpc"
+	internal_http "github.com/RusselRustCode/teacher_analytics/core-service/internal/api/http"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/mocks"
+	"github.com/RusselRustCode/teacher_analytics/core-service/proto"
+)
+
+func TestErrorMapping(t *testing.T) {
+	cases := []struct {
+		err      error
+		httpCode int
+		grpcCode codes.Code
+	}{
+		{application.NotFound("student_not_found", "not found"), http.StatusNotFound, codes.NotFound},
+		{application.Validation("invalid_log", "bad"), http.StatusBadRequest, codes.InvalidArgument},
+		{application.Conflict("duplicate", "exists"), http.StatusConflict, codes.AlreadyExists},
+		{application.Unavailable("analysis_unavailable", "down", errors.New("eof")), http.StatusServiceUnavailable, codes.Unavailable},
+		{fmt.Errorf("wrapped: %w", application.NotFound("student_not_found", "not found")), http.StatusNotFound, codes.NotFound},
+		{errors.New("boom"), http.StatusInternalServerError, codes.Internal},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.httpCode, apierror.HTTPStatus(tc.err), tc.err.Error())
+		assert.Equal(t, tc.grpcCode, apierror.GRPCCode(tc.err), tc.err.Error())
+	}
+}
+
+func TestErrorResponseHidesInternalDetails(t *testing.T) {
+	ctx := logging.WithRequestID(context.Background(), "req-1")
+
+	resp := apierror.NewResponse(ctx, errors.New("pq: password authentication failed"))
+
+	assert.Equal(t, apierror.CodeInternal, resp.Error.Code)
+	assert.NotContains(t, resp.Error.Message, "pq")
+	assert.Equal(t, "req-1", resp.Error.RequestID)
+}
+
+func TestHTTPHandlerErrorSchema(t *testing.T) {
+	serviceMock := new(mocks.AnalyticsService)
+	serviceMock.On("GetAnalytics", mock.Anything, uint64(3)).
+		Return(nil, application.Unavailable("analysis_unavailable", "failed to send analysis command", errors.New("eof")))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	internal_http.SetupRoutes(router, internal_http.Handlers{
+		API:    internal_http.NewHTTPHandler(serviceMock),
+		Health: internal_http.NewHealthHandler(new(mocks.HealthChecker)),
+		Export: internal_http.NewExportHandler(new(mocks.ExportService)),
+	})
+
+	for path, expected := range map[string]struct {
+		status int
+		code   string
+	}{
+		"/api/analytics/3":   {http.StatusServiceUnavailable, "analysis_unavailable"},
+		"/api/analytics/abc": {http.StatusBadRequest, "invalid_student_id"},
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		assert.Equal(t, expected.status, rec.Code, path)
+		var body apierror.Response
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, expected.code, body.Error.Code, path)
+		assert.NotEmpty(t, body.Error.Message, path)
+	}
+}
+
+func TestGRPCHandlerMapsNotFound(t *testing.T) {
+	ctx := context.Background()
+	serviceMock := new(mocks.AnalyticsService)
+	serviceMock.On("GetAnalytics", ctx, uint64(9)).
+		Return(nil, application.NotFound("student_not_found", "student 9 not found"))
+	handler := internal_grpc.NewGRPCHandler(serviceMock, new(mocks.HealthChecker))
+
+	_, err := handler.AnalyzeStudent(ctx, &proto.AnalyzeStudentRequest{StudentId: 9})
+
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, codes.NotFound, st.Code())
+	assert.Equal(t, "student_not_found: student 9 not found", st.Message())
+}
+
+func TestHTTPHandlerReturnsFieldErrors(t *testing.T) {
+	serviceMock := new(mocks.AnalyticsService)
+	serviceMock.On("SendLog", mock.Anything, mock.Anything).
+		Return(application.InvalidFields("invalid_log", "student log is invalid", []application.FieldError{
+			{Field: "attempts", Code: "out_of_range", Message: "attempts must be at least 1 for answers"},
+		}))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	internal_http.SetupRoutes(router, internal_http.Handlers{
+		API:    internal_http.NewHTTPHandler(serviceMock),
+		Health: internal_http.NewHealthHandler(new(mocks.HealthChecker)),
+		Export: internal_http.NewExportHandler(new(mocks.ExportService)),
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/log", strings.NewReader(`{"student_id": 1, "action_type": "exam"}`)))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	var body apierror.Response
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "invalid_log", body.Error.Code)
+	require.Len(t, body.Error.Fields, 1)
+	assert.Equal(t, "attempts", body.Error.Fields[0].Field)
+}
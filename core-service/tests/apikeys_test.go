@@ -0,0 +1,336 @@
+package tests
+
+import (
+	"context"
+	"crypto/sha256"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	google_grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	internal_grpc "github.com/RusselRustCode/teacher_analytics/core-service/internal/api/grpc"
+	internal_http "github.com/RusselRustCode/teacher_analytics/core-service/internal/api/http"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/auth"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/infrastructure/memory"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/mocks"
+	pb "github.com/RusselRustCode/teacher_analytics/core-service/proto"
+)
+
+type APIKeyServiceTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	repo    *mocks.Repository
+	service *application.APIKeyServiceImpl
+}
+
+func (s *APIKeyServiceTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.repo = new(mocks.Repository)
+	s.service = application.NewAPIKeyService(s.repo, nil, application.APIKeyOptions{}, logging.Nop())
+}
+
+func (s *APIKeyServiceTestSuite) TestCreateKey_StoresHashAndReturnsKeyOnce() {
+	s.repo.On("GetCohortByID", s.ctx, uint64(3)).Return(&domain.Cohort{ID: 3}, nil)
+	var storedHash []byte
+	s.repo.On("CreateAPIKey", s.ctx, mock.AnythingOfType("*domain.APIKey"), mock.Anything).
+		Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.APIKey).ID = 7
+			storedHash = args.Get(2).([]byte)
+		}).Return(nil)
+
+	key := &domain.APIKey{Name: " moodle ", Scopes: []string{domain.ScopeReadAnalytics, domain.ScopeIngestLogs, domain.ScopeIngestLogs}, CohortIDs: []uint64{3}}
+	s.Require().NoError(s.service.CreateKey(s.ctx, key))
+
+	s.True(strings.HasPrefix(key.Key, "ta_"))
+	s.Equal(key.Key[:11], key.Prefix)
+	s.Equal("moodle", key.Name)
+	s.Equal([]string{domain.ScopeIngestLogs, domain.ScopeReadAnalytics}, key.Scopes)
+	sum := sha256.Sum256([]byte(key.Key))
+	s.Equal(sum[:], storedHash)
+}
+
+func (s *APIKeyServiceTestSuite) TestCreateKey_ReportsInvalidFields() {
+	s.repo.On("GetCohortByID", s.ctx, uint64(9)).Return(nil, nil)
+
+	err := s.service.CreateKey(s.ctx, &domain.APIKey{Scopes: []string{"write:everything"}, CohortIDs: []uint64{9}})
+
+	var appErr *application.Error
+	s.Require().ErrorAs(err, &appErr)
+	s.Equal(application.KindValidation, appErr.Kind)
+	var got []string
+	for _, f := range appErr.Fields {
+		got = append(got, f.Field+":"+f.Code)
+	}
+	s.Equal([]string{"name:required", "scopes:unknown_value", "cohort_ids:not_found"}, got)
+	s.repo.AssertNotCalled(s.T(), "CreateAPIKey", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *APIKeyServiceTestSuite) TestRotateKey_KeepsOldKeyForGracePeriod() {
+	s.repo.On("GetAPIKey", s.ctx, uint64(7)).
+		Return(&domain.APIKey{ID: 7, Name: "moodle", Scopes: []string{domain.ScopeIngestLogs}, CohortIDs: []uint64{3}}, nil)
+	s.repo.On("RotateAPIKey", s.ctx, uint64(7), mock.AnythingOfType("*domain.APIKey"), mock.Anything, mock.MatchedBy(func(at time.Time) bool {
+		return at.Sub(time.Now()) > 59*time.Minute && at.Sub(time.Now()) <= time.Hour
+	})).Run(func(args mock.Arguments) {
+		args.Get(2).(*domain.APIKey).ID = 8
+	}).Return(nil)
+
+	next, err := s.service.RotateKey(s.ctx, 7, time.Hour)
+	s.Require().NoError(err)
+	s.Equal(uint64(8), next.ID)
+	s.Equal([]string{domain.ScopeIngestLogs}, next.Scopes)
+	s.Equal([]uint64{3}, next.CohortIDs)
+	s.True(strings.HasPrefix(next.Key, next.Prefix))
+
+	_, err = s.service.RotateKey(s.ctx, 7, 30*24*time.Hour)
+	s.True(application.IsKind(err, application.KindValidation))
+}
+
+func (s *APIKeyServiceTestSuite) TestRevokeKey_RejectsRevokedAndMissing() {
+	revoked := time.Now().Add(-time.Minute)
+	s.repo.On("GetAPIKey", s.ctx, uint64(7)).Return(&domain.APIKey{ID: 7, RevokedAt: &revoked}, nil)
+	s.repo.On("GetAPIKey", s.ctx, uint64(9)).Return(nil, nil)
+
+	s.True(application.IsKind(s.service.RevokeKey(s.ctx, 7), application.KindConflict))
+	s.True(application.IsKind(s.service.RevokeKey(s.ctx, 9), application.KindNotFound))
+	s.repo.AssertNotCalled(s.T(), "RevokeAPIKey", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *APIKeyServiceTestSuite) TestAuthenticate_LooksUpHashAndRecordsUse() {
+	token := "ta_0123456789abcdef"
+	sum := sha256.Sum256([]byte(token))
+	s.repo.On("GetActiveAPIKeyByHash", s.ctx, sum[:], mock.Anything).Return(&domain.APIKey{ID: 7}, nil)
+	s.repo.On("TouchAPIKey", s.ctx, uint64(7), "192.0.2.1", mock.Anything, time.Minute).Return(nil)
+
+	key, err := s.service.Authenticate(s.ctx, token, "192.0.2.1")
+	s.Require().NoError(err)
+	s.Equal(uint64(7), key.ID)
+
+	s.repo.On("GetActiveAPIKeyByHash", s.ctx, mock.Anything, mock.Anything).Return(nil, nil)
+	_, err = s.service.Authenticate(s.ctx, "ta_unknown", "192.0.2.1")
+	s.True(application.IsKind(err, application.KindUnauthorized))
+
+	// Чужой формат отклоняется без запроса в базу
+	_, err = s.service.Authenticate(s.ctx, "Bearer xyz", "192.0.2.1")
+	s.True(application.IsKind(err, application.KindUnauthorized))
+	s.repo.AssertNumberOfCalls(s.T(), "GetActiveAPIKeyByHash", 2)
+}
+
+func (s *APIKeyServiceTestSuite) TestAuthenticate_CachesLookups() {
+	service := application.NewAPIKeyService(s.repo, memory.NewLRUCache(100), application.APIKeyOptions{CacheTTL: time.Minute}, logging.Nop())
+	token := "ta_0123456789abcdef"
+	sum := sha256.Sum256([]byte(token))
+	s.repo.On("GetActiveAPIKeyByHash", s.ctx, sum[:], mock.Anything).Return(&domain.APIKey{ID: 7}, nil)
+	s.repo.On("GetActiveAPIKeyByHash", s.ctx, mock.Anything, mock.Anything).Return(nil, nil)
+	s.repo.On("TouchAPIKey", s.ctx, uint64(7), mock.Anything, mock.Anything, time.Minute).Return(nil)
+
+	// И действующий, и неизвестный ключ ищутся в базе один раз
+	for i := 0; i < 3; i++ {
+		key, err := service.Authenticate(s.ctx, token, "192.0.2.1")
+		s.Require().NoError(err)
+		s.Equal(uint64(7), key.ID)
+		_, err = service.Authenticate(s.ctx, "ta_unknown", "192.0.2.1")
+		s.True(application.IsKind(err, application.KindUnauthorized))
+	}
+	s.repo.AssertNumberOfCalls(s.T(), "GetActiveAPIKeyByHash", 2)
+	s.repo.AssertNumberOfCalls(s.T(), "TouchAPIKey", 1)
+
+	// Отзыв на этой реплике сбрасывает кэш: ключ снова ищется в базе
+	s.repo.On("GetAPIKey", s.ctx, uint64(7)).Return(&domain.APIKey{ID: 7}, nil)
+	s.repo.On("RevokeAPIKey", s.ctx, uint64(7), mock.Anything).Return(nil)
+	s.Require().NoError(service.RevokeKey(s.ctx, 7))
+	_, err := service.Authenticate(s.ctx, token, "192.0.2.1")
+	s.Require().NoError(err)
+	s.repo.AssertNumberOfCalls(s.T(), "GetActiveAPIKeyByHash", 3)
+}
+
+func (s *APIKeyServiceTestSuite) TestAuthenticate_CachedRotatedKeyExpires() {
+	service := application.NewAPIKeyService(s.repo, memory.NewLRUCache(100), application.APIKeyOptions{CacheTTL: time.Minute}, logging.Nop())
+	revokedAt := time.Now().Add(50 * time.Millisecond)
+	s.repo.On("GetActiveAPIKeyByHash", s.ctx, mock.Anything, mock.Anything).Return(&domain.APIKey{ID: 7, RevokedAt: &revokedAt}, nil).Once()
+	s.repo.On("GetActiveAPIKeyByHash", s.ctx, mock.Anything, mock.Anything).Return(nil, nil).Once()
+	s.repo.On("TouchAPIKey", s.ctx, uint64(7), mock.Anything, mock.Anything, time.Minute).Return(nil)
+
+	_, err := service.Authenticate(s.ctx, "ta_old", "192.0.2.1")
+	s.Require().NoError(err)
+
+	// Старый ключ после ротации не переживает свой срок в кэше
+	s.Eventually(func() bool {
+		_, err := service.Authenticate(s.ctx, "ta_old", "192.0.2.1")
+		return application.IsKind(err, application.KindUnauthorized)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestAPIKeyService(t *testing.T) {
+	suite.Run(t, new(APIKeyServiceTestSuite))
+}
+
+func TestSendLog_RejectsStudentOutsideAPIKeyCourses(t *testing.T) {
+	repo := new(mocks.Repository)
+	service := newCachedAnalyticsService(repo)
+	ctx := auth.WithAPIKey(context.Background(), &domain.APIKey{ID: 7, Scopes: []string{domain.ScopeIngestLogs}, CohortIDs: []uint64{3}})
+
+	repo.On("IsStudentInCohort", ctx, uint64(3), uint64(9)).Return(false, nil)
+	err := service.SendLog(ctx, &domain.StudentLog{StudentID: 9, ActionType: domain.ActionViewLesson, MaterialID: "math_101", Timestamp: time.Now()})
+	assert.True(t, application.IsKind(err, application.KindForbidden))
+	repo.AssertNotCalled(t, "SaveLog", mock.Anything, mock.Anything)
+}
+
+func newAPIKeyRouter(keys *mocks.APIKeyService, analytics *mocks.AnalyticsService, requiredScopes ...string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	internal_http.SetupRoutes(router, internal_http.Handlers{
+		API:     internal_http.NewHTTPHandler(analytics),
+		Health:  internal_http.NewHealthHandler(new(mocks.HealthChecker)),
+		Webhook: internal_http.NewWebhookHandler(new(mocks.WebhookService)),
+		APIKey:  internal_http.NewAPIKeyHandler(keys, internal_http.APIKeyHandlerOptions{RequiredScopes: requiredScopes}),
+		LTI:     adminLTIHandler(),
+	})
+	return router
+}
+
+// adminLTIHandler знает две сессии: admin - администратор LMS, teacher -
+// преподаватель курса 3.
+func adminLTIHandler() *internal_http.LTIHandler {
+	lti := new(mocks.LTIService)
+	lti.On("GetSession", mock.Anything, "admin").
+		Return(&domain.Session{ID: "admin", Subject: "admin-1", Role: domain.RoleAdministrator}, nil)
+	lti.On("GetSession", mock.Anything, "teacher").
+		Return(&domain.Session{ID: "teacher", Subject: "teacher-42", CohortID: 3, Role: domain.RoleInstructor}, nil)
+	return internal_http.NewLTIHandler(lti, internal_http.LTIHandlerOptions{})
+}
+
+func withSession(req *http.Request, token string) *http.Request {
+	req.AddCookie(&http.Cookie{Name: internal_http.SessionCookie, Value: token})
+	return req
+}
+
+func apiKeyRequest(method, path, token string) *http.Request {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "ApiKey "+token)
+	}
+	return req
+}
+
+func TestAPIKeyMiddleware_Scopes(t *testing.T) {
+	keys := new(mocks.APIKeyService)
+	reader := &domain.APIKey{ID: 7, Scopes: []string{domain.ScopeReadAnalytics}}
+	keys.On("Authenticate", mock.Anything, "ta_reader", "192.0.2.1").Return(reader, nil)
+	keys.On("Authenticate", mock.Anything, "ta_revoked", "192.0.2.1").
+		Return(nil, application.Unauthorized("invalid_api_key", "api key is invalid or revoked"))
+
+	analytics := new(mocks.AnalyticsService)
+	analytics.On("GetStudents", mock.MatchedBy(func(ctx context.Context) bool {
+		return auth.APIKeyFrom(ctx) == reader
+	})).Return([]uint64{5}, nil)
+
+	router := newAPIKeyRouter(keys, analytics, domain.ScopeIngestLogs)
+	cases := []struct {
+		name   string
+		req    *http.Request
+		status int
+	}{
+		{"read scope", apiKeyRequest(http.MethodGet, "/api/students", "ta_reader"), http.StatusOK},
+		{"missing scope", apiKeyRequest(http.MethodPost, "/api/log", "ta_reader"), http.StatusForbidden},
+		{"required without key", apiKeyRequest(http.MethodPost, "/api/log", ""), http.StatusUnauthorized},
+		{"revoked key", apiKeyRequest(http.MethodGet, "/api/students", "ta_revoked"), http.StatusUnauthorized},
+		{"admin route", apiKeyRequest(http.MethodGet, "/api/webhooks", "ta_reader"), http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, tc.req)
+			assert.Equal(t, tc.status, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+func TestAPIKeyHandler_CreateReturnsKey(t *testing.T) {
+	keys := new(mocks.APIKeyService)
+	keys.On("CreateKey", mock.Anything, mock.MatchedBy(func(k *domain.APIKey) bool {
+		return k.Name == "moodle" && len(k.Scopes) == 1 && k.Key == ""
+	})).Run(func(args mock.Arguments) {
+		k := args.Get(1).(*domain.APIKey)
+		k.ID, k.Prefix, k.Key = 7, "ta_01234567", "ta_0123456789"
+	}).Return(nil)
+
+	router := newAPIKeyRouter(keys, new(mocks.AnalyticsService))
+	body := `{"name":"moodle","scopes":["ingest:logs"],"key":"ta_chosen"}`
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, withSession(httptest.NewRequest(http.MethodPost, "/api/api-keys", strings.NewReader(body)), "admin"))
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"key":"ta_0123456789"`)
+}
+
+func TestAPIKeyHandler_RequiresAdministrator(t *testing.T) {
+	keys := new(mocks.APIKeyService)
+	router := newAPIKeyRouter(keys, new(mocks.AnalyticsService))
+	body := `{"name":"moodle","scopes":["read:analytics"]}`
+
+	// Аноним не выпускает ключи, даже если дашборд открыт без сессии
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/api-keys", strings.NewReader(body)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, withSession(httptest.NewRequest(http.MethodPost, "/api/api-keys", strings.NewReader(body)), "teacher"))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/api-keys", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	keys.AssertNotCalled(t, "CreateKey", mock.Anything, mock.Anything)
+	keys.AssertNotCalled(t, "ListKeys", mock.Anything)
+}
+
+func TestAPIKeyUnaryInterceptor(t *testing.T) {
+	keys := new(mocks.APIKeyService)
+	keys.On("Authenticate", mock.Anything, "ta_ingest", "10.0.0.1").
+		Return(&domain.APIKey{ID: 7, Scopes: []string{domain.ScopeIngestLogs}}, nil)
+	keys.On("Authenticate", mock.Anything, "ta_reader", "10.0.0.1").
+		Return(&domain.APIKey{ID: 8, Scopes: []string{domain.ScopeReadAnalytics}}, nil)
+	interceptor := internal_grpc.APIKeyUnaryInterceptor(keys, []string{domain.ScopeReadAnalytics})
+
+	base := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 50000}})
+	withKey := func(token string) context.Context {
+		return metadata.NewIncomingContext(base, metadata.Pairs("authorization", "ApiKey "+token))
+	}
+	analyze := &google_grpc.UnaryServerInfo{FullMethod: pb.AnalyticsService_AnalyzeStudent_FullMethodName}
+	var seen *domain.APIKey
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		seen = auth.APIKeyFrom(ctx)
+		return "ok", nil
+	}
+
+	_, err := interceptor(withKey("ta_reader"), nil, analyze, handler)
+	require.NoError(t, err)
+	require.NotNil(t, seen)
+	assert.Equal(t, uint64(8), seen.ID)
+
+	_, err = interceptor(withKey("ta_ingest"), nil, analyze, handler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = interceptor(base, nil, analyze, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// HealthCheck ключ не требует
+	_, err = interceptor(base, nil, &google_grpc.UnaryServerInfo{FullMethod: pb.AnalyticsService_HealthCheck_FullMethodName}, handler)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,222 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	google_grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	internal_grpc "github.com/RusselRustCode/teacher_analytics/core-service/internal/api/grpc"
+	internal_http "github.com/RusselRustCode/teacher_analytics/core-service/internal/api/http"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/auth"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/mocks"
+	pb "github.com/RusselRustCode/teacher_analytics/core-service/proto"
+)
+
+func TestAuditService_RecordFillsActor(t *testing.T) {
+	repo := new(mocks.Repository)
+	service := application.NewAuditService(repo, application.AuditOptions{}, logging.Nop())
+
+	cases := []struct {
+		ctx       context.Context
+		actorType string
+		actor     string
+	}{
+		{auth.WithSession(context.Background(), &domain.Session{Subject: "teacher-42"}), domain.ActorUser, "teacher-42"},
+		{auth.WithAPIKey(context.Background(), &domain.APIKey{ID: 7}), domain.ActorAPIKey, "7"},
+		{context.Background(), domain.ActorAnonymous, ""},
+	}
+	for _, tc := range cases {
+		entry := &domain.AuditEntry{Action: domain.AuditRead, Endpoint: "/api/students"}
+		repo.On("SaveAuditEntries", tc.ctx, []*domain.AuditEntry{entry}).Return(nil).Once()
+
+		require.NoError(t, service.Record(tc.ctx, []*domain.AuditEntry{entry}))
+		assert.Equal(t, tc.actorType, entry.ActorType)
+		assert.Equal(t, tc.actor, entry.Actor)
+		assert.WithinDuration(t, time.Now(), entry.OccurredAt, time.Second)
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestAuditService_ListAndPurge(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.Repository)
+	service := application.NewAuditService(repo, application.AuditOptions{Retention: 30 * 24 * time.Hour}, logging.Nop())
+
+	repo.On("ListAuditEntries", ctx, domain.AuditFilter{StudentID: 5, Limit: 500}).Return([]*domain.AuditEntry{{ID: 1}}, nil)
+	entries, err := service.List(ctx, domain.AuditFilter{StudentID: 5, Limit: 10000})
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	now := time.Now()
+	_, err = service.List(ctx, domain.AuditFilter{From: now, To: now.Add(-time.Hour)})
+	assert.True(t, application.IsKind(err, application.KindValidation))
+
+	repo.On("DeleteAuditEntriesBefore", ctx, mock.MatchedBy(func(before time.Time) bool {
+		return before.Sub(now.Add(-30*24*time.Hour)).Abs() < time.Second
+	})).Return(int64(12), nil).Once()
+	require.NoError(t, service.PurgeExpired(ctx))
+
+	// Без срока хранения записи не удаляются
+	keep := application.NewAuditService(repo, application.AuditOptions{}, logging.Nop())
+	require.NoError(t, keep.PurgeExpired(ctx))
+	repo.AssertNumberOfCalls(t, "DeleteAuditEntriesBefore", 1)
+}
+
+func newAuditRouter(audit *mocks.AuditService, analytics *mocks.AnalyticsService, webhooks *mocks.WebhookService, keys *mocks.APIKeyService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	internal_http.SetupRoutes(router, internal_http.Handlers{
+		API:     internal_http.NewHTTPHandler(analytics),
+		Health:  internal_http.NewHealthHandler(new(mocks.HealthChecker)),
+		Webhook: internal_http.NewWebhookHandler(webhooks),
+		APIKey:  internal_http.NewAPIKeyHandler(keys, internal_http.APIKeyHandlerOptions{}),
+		Audit:   internal_http.NewAuditHandler(audit, logging.Nop()),
+		LTI:     adminLTIHandler(),
+	})
+	return router
+}
+
+func auditEntryMatching(check func(e *domain.AuditEntry) bool) interface{} {
+	return mock.MatchedBy(func(entries []*domain.AuditEntry) bool {
+		return len(entries) == 1 && check(entries[0])
+	})
+}
+
+func TestAuditMiddleware_RecordsReadsAndMutations(t *testing.T) {
+	audit := new(mocks.AuditService)
+	analytics := new(mocks.AnalyticsService)
+	webhooks := new(mocks.WebhookService)
+	keys := new(mocks.APIKeyService)
+	router := newAuditRouter(audit, analytics, webhooks, keys)
+
+	analytics.On("GetStudentLogs", mock.Anything, uint64(5), mock.Anything, mock.Anything).Return([]*domain.StudentLog{}, nil)
+	audit.On("Record", mock.Anything, auditEntryMatching(func(e *domain.AuditEntry) bool {
+		return e.Action == domain.AuditRead && e.Endpoint == "/api/students/:student_id/logs" &&
+			e.Path == "/api/students/5/logs" && *e.StudentID == 5 && e.Status == http.StatusOK && e.IP == "192.0.2.1"
+	})).Return(nil).Once()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/students/5/logs", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Отказ в доступе ключу без read:analytics тоже попадает в журнал
+	keys.On("Authenticate", mock.Anything, "ta_ingest", "192.0.2.1").
+		Return(&domain.APIKey{ID: 7, Scopes: []string{domain.ScopeIngestLogs}}, nil)
+	audit.On("Record", mock.MatchedBy(func(ctx context.Context) bool {
+		return auth.APIKeyFrom(ctx) != nil
+	}), auditEntryMatching(func(e *domain.AuditEntry) bool {
+		return e.Endpoint == "/api/analytics/:student_id" && *e.StudentID == 9 && e.Status == http.StatusForbidden
+	})).Return(nil).Once()
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, apiKeyRequest(http.MethodGet, "/api/analytics/9", "ta_ingest"))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	webhooks.On("CreateSubscription", mock.Anything, mock.Anything).Return(nil)
+	audit.On("Record", mock.Anything, auditEntryMatching(func(e *domain.AuditEntry) bool {
+		return e.Action == domain.AuditMutation && e.Method == http.MethodPost && e.Endpoint == "/api/webhooks" && e.StudentID == nil
+	})).Return(nil).Once()
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, withSession(httptest.NewRequest(http.MethodPost, "/api/webhooks", strings.NewReader(`{"url":"https://lms.example.com/hook","events":["alert.raised"]}`)), "admin"))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	// Чтение списка подписок - не изменение и не данные студентов
+	webhooks.On("ListSubscriptions", mock.Anything).Return([]*domain.WebhookSubscription{}, nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, withSession(httptest.NewRequest(http.MethodGet, "/api/webhooks", nil), "admin"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	audit.AssertExpectations(t)
+	audit.AssertNumberOfCalls(t, "Record", 3)
+}
+
+func TestAuditMiddleware_FailureDoesNotBreakResponse(t *testing.T) {
+	audit := new(mocks.AuditService)
+	analytics := new(mocks.AnalyticsService)
+	analytics.On("GetStudents", mock.Anything).Return([]uint64{5}, nil)
+	audit.On("Record", mock.Anything, mock.Anything).Return(errors.New("connection refused"))
+
+	rec := httptest.NewRecorder()
+	newAuditRouter(audit, analytics, new(mocks.WebhookService), new(mocks.APIKeyService)).
+		ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/students", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestAuditHandler_ListEntriesFilters(t *testing.T) {
+	audit := new(mocks.AuditService)
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	audit.On("List", mock.Anything, domain.AuditFilter{
+		ActorType: domain.ActorUser,
+		Actor:     "teacher-42",
+		Action:    domain.AuditRead,
+		StudentID: 5,
+		From:      from,
+		BeforeID:  100,
+		Limit:     20,
+	}).Return([]*domain.AuditEntry{{ID: 99}}, nil)
+	router := newAuditRouter(audit, new(mocks.AnalyticsService), new(mocks.WebhookService), new(mocks.APIKeyService))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, withSession(httptest.NewRequest(http.MethodGet,
+		"/api/audit-log?actor_type=user&actor=teacher-42&action=read&student_id=5&from=2026-09-01T00:00:00Z&before_id=100&limit=20", nil), "admin"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"id":99`)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, withSession(httptest.NewRequest(http.MethodGet, "/api/audit-log?action=delete", nil), "admin"))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Журнал показывает действия во всех курсах - только администратору
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/audit-log", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, withSession(httptest.NewRequest(http.MethodGet, "/api/audit-log", nil), "teacher"))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	audit.AssertNumberOfCalls(t, "List", 1)
+}
+
+func TestAuditUnaryInterceptor_BatchAnalyze(t *testing.T) {
+	audit := new(mocks.AuditService)
+	audit.On("Record", mock.Anything, mock.MatchedBy(func(entries []*domain.AuditEntry) bool {
+		return len(entries) == 2 && *entries[0].StudentID == 5 && *entries[1].StudentID == 8 &&
+			entries[0].Endpoint == pb.AnalyticsService_BatchAnalyze_FullMethodName && entries[0].Status == int(codes.OK)
+	})).Return(nil).Once()
+	interceptor := internal_grpc.AuditUnaryInterceptor(audit, logging.Nop())
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	_, err := interceptor(context.Background(), &pb.BatchAnalyzeRequest{StudentIds: []uint64{5, 8}},
+		&google_grpc.UnaryServerInfo{FullMethod: pb.AnalyticsService_BatchAnalyze_FullMethodName}, handler)
+	require.NoError(t, err)
+
+	// Ошибка обработчика возвращается как есть, запись - с ее кодом
+	audit.On("Record", mock.Anything, auditEntryMatching(func(e *domain.AuditEntry) bool {
+		return *e.StudentID == 9 && e.Status == int(codes.PermissionDenied)
+	})).Return(nil).Once()
+	_, err = interceptor(context.Background(), &pb.AnalyzeStudentRequest{StudentId: 9},
+		&google_grpc.UnaryServerInfo{FullMethod: pb.AnalyticsService_AnalyzeStudent_FullMethodName},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, status.Error(codes.PermissionDenied, "forbidden")
+		})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// HealthCheck не пишется
+	_, err = interceptor(context.Background(), &pb.HealthCheckRequest{},
+		&google_grpc.UnaryServerInfo{FullMethod: pb.AnalyticsService_HealthCheck_FullMethodName}, handler)
+	require.NoError(t, err)
+	audit.AssertExpectations(t)
+	audit.AssertNumberOfCalls(t, "Record", 2)
+}
@@ -0,0 +1,225 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/infrastructure/memory"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/infrastructure/redis"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+)
+
+func TestLRUCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	c := memory.NewLRUCache(2)
+
+	require.NoError(t, c.Set(ctx, "a", "1", 0))
+	require.NoError(t, c.Set(ctx, "b", "2", 0))
+	// Обращение к a делает вытесняемым b
+	v, _ := c.Get(ctx, "a")
+	assert.Equal(t, "1", v)
+	require.NoError(t, c.Set(ctx, "c", "3", 0))
+
+	v, _ = c.Get(ctx, "b")
+	assert.Empty(t, v)
+	v, _ = c.Get(ctx, "a")
+	assert.Equal(t, "1", v)
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestLRUCache_TTLAndSetNX(t *testing.T) {
+	ctx := context.Background()
+	c := memory.NewLRUCache(10)
+
+	require.NoError(t, c.Set(ctx, "json", map[string]int{"student_id": 5}, 0))
+	v, _ := c.Get(ctx, "json")
+	assert.JSONEq(t, `{"student_id":5}`, v)
+
+	ok, err := c.SetNX(ctx, "lock", "1", 30*time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, _ = c.SetNX(ctx, "lock", "1", 30*time.Millisecond)
+	assert.False(t, ok)
+
+	time.Sleep(40 * time.Millisecond)
+	exists, _ := c.Exists(ctx, "lock")
+	assert.False(t, exists)
+	ok, _ = c.SetNX(ctx, "lock", "1", time.Second)
+	assert.True(t, ok)
+
+	require.NoError(t, c.Delete(ctx, "lock"))
+	exists, _ = c.Exists(ctx, "lock")
+	assert.False(t, exists)
+}
+
+func newTieredPair(t *testing.T) (*miniredis.Miniredis, *redis.TieredCache, *redis.TieredCache) {
+	srv := miniredis.RunT(t)
+	opts := redis.TieredOptions{LocalMaxEntries: 100, LocalTTL: time.Minute}
+	a := redis.NewTieredCache(srv.Addr(), "", 0, opts, logging.Nop())
+	b := redis.NewTieredCache(srv.Addr(), "", 0, opts, logging.Nop())
+	t.Cleanup(func() {
+		a.Close()
+		b.Close()
+	})
+	// Подписка на канал инвалидации устанавливается асинхронно
+	require.Eventually(t, func() bool {
+		return srv.PubSubNumSub(redis.InvalidationChannel)[redis.InvalidationChannel] == 2
+	}, time.Second, 5*time.Millisecond)
+	return srv, a, b
+}
+
+func TestTieredCache_ServesLocalCopy(t *testing.T) {
+	ctx := context.Background()
+	srv, a, _ := newTieredPair(t)
+
+	require.NoError(t, a.Set(ctx, "analytics:5", `{"student_id":5}`, time.Minute))
+	assert.Equal(t, `{"student_id":5}`, mustGet(t, srv, "analytics:5"))
+
+	// Ключ пропал из Redis в обход кэша - реплика все еще отвечает из памяти
+	srv.Del("analytics:5")
+	v, err := a.Get(ctx, "analytics:5")
+	require.NoError(t, err)
+	assert.Equal(t, `{"student_id":5}`, v)
+}
+
+func TestTieredCache_InvalidatesOtherReplicas(t *testing.T) {
+	ctx := context.Background()
+	_, a, b := newTieredPair(t)
+
+	require.NoError(t, a.Set(ctx, "analytics:5", "v1", time.Minute))
+	v, _ := b.Get(ctx, "analytics:5")
+	require.Equal(t, "v1", v)
+
+	require.NoError(t, a.Set(ctx, "analytics:5", "v2", time.Minute))
+	assert.Eventually(t, func() bool {
+		v, _ := b.Get(ctx, "analytics:5")
+		return v == "v2"
+	}, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, a.Delete(ctx, "analytics:5"))
+	assert.Eventually(t, func() bool {
+		v, _ := b.Get(ctx, "analytics:5")
+		return v == ""
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestTieredCache_SetNXIsShared(t *testing.T) {
+	ctx := context.Background()
+	_, a, b := newTieredPair(t)
+
+	ok, err := a.SetNX(ctx, "lock:analytics:5", "1", time.Second)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = b.SetNX(ctx, "lock:analytics:5", "1", time.Second)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+// Значение, которое реплика успела скопировать в память, GetDel другой
+// реплики второй раз не отдает
+func TestTieredCache_GetDelConsumesOnce(t *testing.T) {
+	ctx := context.Background()
+	srv, a, b := newTieredPair(t)
+
+	require.NoError(t, a.Set(ctx, "lti:state:st", "n1", time.Minute))
+	v, err := b.GetDel(ctx, "lti:state:st")
+	require.NoError(t, err)
+	assert.Equal(t, "n1", v)
+	assert.False(t, srv.Exists("lti:state:st"))
+
+	v, err = a.GetDel(ctx, "lti:state:st")
+	require.NoError(t, err)
+	assert.Empty(t, v)
+	assert.Eventually(t, func() bool {
+		v, _ := a.Get(ctx, "lti:state:st")
+		return v == ""
+	}, time.Second, 5*time.Millisecond)
+}
+
+func mustGet(t *testing.T, srv *miniredis.Miniredis, key string) string {
+	t.Helper()
+	v, err := srv.Get(key)
+	require.NoError(t, err)
+	return v
+}
+
+func TestRedisCache_SetNX(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+	c := redis.NewRedisCache(srv.Addr(), "", 0)
+	defer c.Close()
+
+	ok, err := c.SetNX(ctx, "lock:analytics:5", "1", time.Second)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = c.SetNX(ctx, "lock:analytics:5", "1", time.Second)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	srv.FastForward(2 * time.Second)
+	ok, _ = c.SetNX(ctx, "lock:analytics:5", "1", time.Second)
+	assert.True(t, ok)
+}
+
+func TestRedisCache_InvalidateTags(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+	c := redis.NewRedisCache(srv.Addr(), "", 0)
+	defer c.Close()
+
+	require.NoError(t, c.SetWithTags(ctx, "logs:5:0:now", "[]", time.Minute, "student:5"))
+	require.NoError(t, c.SetWithTags(ctx, "students:cohort:2", "[5,8]", 2*time.Minute, "cohort:2", "student:5"))
+	require.NoError(t, c.SetWithTags(ctx, "logs:8:0:now", "[]", time.Minute, "student:8"))
+
+	members, err := srv.SMembers("tag:student:5")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"logs:5:0:now", "students:cohort:2"}, members)
+	// Множество тега живет не меньше самой долгой записи
+	assert.Equal(t, 2*time.Minute, srv.TTL("tag:student:5"))
+
+	require.NoError(t, c.InvalidateTags(ctx, "student:5"))
+	assert.False(t, srv.Exists("logs:5:0:now"))
+	assert.False(t, srv.Exists("students:cohort:2"))
+	assert.False(t, srv.Exists("tag:student:5"))
+	assert.True(t, srv.Exists("logs:8:0:now"))
+}
+
+func TestLRUCache_InvalidateTags(t *testing.T) {
+	ctx := context.Background()
+	c := memory.NewLRUCache(2)
+
+	require.NoError(t, c.SetWithTags(ctx, "a", "1", 0, "student:5"))
+	require.NoError(t, c.SetWithTags(ctx, "b", "2", 0, "student:5", "cohort:2"))
+	require.NoError(t, c.SetWithTags(ctx, "c", "3", 0, "student:8"))
+
+	// a вытеснена, b осталась
+	require.NoError(t, c.InvalidateTags(ctx, "cohort:2"))
+	v, _ := c.Get(ctx, "b")
+	assert.Empty(t, v)
+	v, _ = c.Get(ctx, "c")
+	assert.Equal(t, "3", v)
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestTieredCache_InvalidateTagsOnAllReplicas(t *testing.T) {
+	ctx := context.Background()
+	srv, a, b := newTieredPair(t)
+
+	require.NoError(t, a.SetWithTags(ctx, "logs:5:0:now", "[]", time.Minute, "student:5"))
+	v, _ := b.Get(ctx, "logs:5:0:now")
+	require.Equal(t, "[]", v)
+
+	require.NoError(t, b.InvalidateTags(ctx, "student:5"))
+	assert.False(t, srv.Exists("logs:5:0:now"))
+	v, _ = b.Get(ctx, "logs:5:0:now")
+	assert.Empty(t, v)
+	assert.Eventually(t, func() bool {
+		v, _ := a.Get(ctx, "logs:5:0:now")
+		return v == ""
+	}, time.Second, 5*time.Millisecond)
+}
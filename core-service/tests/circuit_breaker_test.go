@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/infrastructure/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type CircuitBreakerTestSuite struct {
+	suite.Suite
+	now     time.Time
+	breaker *grpc.CircuitBreaker
+}
+
+func (s *CircuitBreakerTestSuite) SetupTest() {
+	s.now = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	s.breaker = grpc.NewCircuitBreakerWithClock(2, 20*time.Second, func() time.Time { return s.now })
+}
+
+func (s *CircuitBreakerTestSuite) fail() {
+	generation, err := s.breaker.Allow()
+	require.NoError(s.T(), err)
+	s.breaker.Done(generation, false)
+}
+
+func (s *CircuitBreakerTestSuite) TestOpensAfterThreshold() {
+	s.fail()
+	assert.Equal(s.T(), grpc.BreakerClosed, s.breaker.State())
+
+	s.fail()
+	assert.Equal(s.T(), grpc.BreakerOpen, s.breaker.State())
+	_, err := s.breaker.Allow()
+	assert.ErrorIs(s.T(), err, grpc.ErrCircuitOpen)
+}
+
+func (s *CircuitBreakerTestSuite) TestSuccessResetsFailures() {
+	s.fail()
+	generation, err := s.breaker.Allow()
+	require.NoError(s.T(), err)
+	s.breaker.Done(generation, true)
+	s.fail()
+
+	assert.Equal(s.T(), grpc.BreakerClosed, s.breaker.State())
+}
+
+func (s *CircuitBreakerTestSuite) TestHalfOpenAllowsSingleProbe() {
+	s.fail()
+	s.fail()
+	s.now = s.now.Add(19 * time.Second)
+	assert.Equal(s.T(), grpc.BreakerOpen, s.breaker.State())
+	s.now = s.now.Add(time.Second)
+
+	assert.Equal(s.T(), grpc.BreakerHalfOpen, s.breaker.State())
+	probe, err := s.breaker.Allow()
+	require.NoError(s.T(), err)
+	_, err = s.breaker.Allow()
+	assert.ErrorIs(s.T(), err, grpc.ErrCircuitOpen)
+
+	s.breaker.Done(probe, true)
+	assert.Equal(s.T(), grpc.BreakerClosed, s.breaker.State())
+}
+
+func (s *CircuitBreakerTestSuite) TestFailedProbeReopens() {
+	s.fail()
+	s.fail()
+	s.now = s.now.Add(20 * time.Second)
+
+	s.fail()
+	assert.Equal(s.T(), grpc.BreakerOpen, s.breaker.State())
+}
+
+// Медленный вызов, пропущенный до размыкания, завершился успешно уже во
+// время пробы: он не должен снять пробу и замкнуть цепь.
+func (s *CircuitBreakerTestSuite) TestStaleResultIgnored() {
+	slow, err := s.breaker.Allow()
+	require.NoError(s.T(), err)
+	s.fail()
+	s.fail()
+	require.Equal(s.T(), grpc.BreakerOpen, s.breaker.State())
+
+	s.breaker.Done(slow, true)
+	assert.Equal(s.T(), grpc.BreakerOpen, s.breaker.State())
+
+	s.now = s.now.Add(20 * time.Second)
+	probe, err := s.breaker.Allow()
+	require.NoError(s.T(), err)
+	s.breaker.Done(slow, true)
+	_, err = s.breaker.Allow()
+	assert.ErrorIs(s.T(), err, grpc.ErrCircuitOpen, "stale success must not clear the probe")
+
+	s.breaker.Done(probe, false)
+	assert.Equal(s.T(), grpc.BreakerOpen, s.breaker.State())
+}
+
+func TestCircuitBreakerSuite(t *testing.T) {
+	suite.Run(t, new(CircuitBreakerTestSuite))
+}
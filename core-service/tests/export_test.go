@@ -0,0 +1,183 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"github.com/xuri/excelize/v2"
+
+	internal_http "github.com/RusselRustCode/teacher_analytics/core-service/internal/api/http"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/mocks"
+)
+
+type ExportServiceTestSuite struct {
+	suite.Suite
+	ctx      context.Context
+	repoMock *mocks.Repository
+	service  interfaces.ExportService
+	from, to time.Time
+}
+
+func (s *ExportServiceTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.repoMock = new(mocks.Repository)
+	s.service = application.NewExportService(s.repoMock)
+	s.to = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	s.from = s.to.AddDate(0, -1, 0)
+}
+
+func (s *ExportServiceTestSuite) streamLogs(logs ...*domain.StudentLog) {
+	s.repoMock.On("StreamLogsByStudentID", s.ctx, uint64(1), s.from, s.to, mock.Anything).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(4).(func(*domain.StudentLog) error)
+			for _, l := range logs {
+				require.NoError(s.T(), fn(l))
+			}
+		}).
+		Return(nil)
+}
+
+func (s *ExportServiceTestSuite) TestExportStudentLogs_CSV() {
+	s.streamLogs(
+		&domain.StudentLog{StudentID: 1, ActionType: "exam", Correct: true, TimeSpentSec: 30, Timestamp: s.from.Add(time.Hour)},
+		&domain.StudentLog{StudentID: 1, ActionType: "view_lesson", TimeSpentSec: 120, Timestamp: s.from.Add(2 * time.Hour)},
+	)
+
+	var buf bytes.Buffer
+	err := s.service.ExportStudentLogs(s.ctx, 1, domain.ExportOptions{
+		Format: domain.ExportCSV, Lang: domain.LangEN, From: s.from, To: s.to,
+	}, &buf)
+	require.NoError(s.T(), err)
+
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(buf.String(), "\ufeff"))).ReadAll()
+	require.NoError(s.T(), err)
+	require.Len(s.T(), records, 3)
+	assert.Equal(s.T(), []string{"Student ID", "Timestamp", "Action type", "Correct", "Time spent, sec"}, records[0])
+	assert.Equal(s.T(), []string{"1", "2026-02-01 01:00:00", "exam", "yes", "30"}, records[1])
+}
+
+func (s *ExportServiceTestSuite) TestExportStudentLogs_XLSXRussianHeaders() {
+	s.streamLogs(&domain.StudentLog{StudentID: 1, ActionType: "exam", TimeSpentSec: 30, Timestamp: s.from})
+
+	var buf bytes.Buffer
+	err := s.service.ExportStudentLogs(s.ctx, 1, domain.ExportOptions{
+		Format: domain.ExportXLSX, Lang: domain.LangRU, From: s.from, To: s.to,
+	}, &buf)
+	require.NoError(s.T(), err)
+
+	file, err := excelize.OpenReader(&buf)
+	require.NoError(s.T(), err)
+	defer file.Close()
+	rows, err := file.GetRows("Sheet1")
+	require.NoError(s.T(), err)
+	require.Len(s.T(), rows, 2)
+	assert.Equal(s.T(), "Тип действия", rows[0][2])
+	assert.Equal(s.T(), "нет", rows[1][3])
+}
+
+// Выгрузка больше буфера excelize пишется во временный файл; если чтение
+// из базы оборвалось, файл не должен остаться на диске
+func (s *ExportServiceTestSuite) TestExportStudentLogs_XLSXFailureRemovesTempFile() {
+	tmp := s.T().TempDir()
+	s.T().Setenv("TMPDIR", tmp)
+	action := strings.Repeat("x", 1024)
+	s.repoMock.On("StreamLogsByStudentID", s.ctx, uint64(1), s.from, s.to, mock.Anything).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(4).(func(*domain.StudentLog) error)
+			for range 20000 {
+				require.NoError(s.T(), fn(&domain.StudentLog{StudentID: 1, ActionType: action, Timestamp: s.from}))
+			}
+		}).
+		Return(errors.New("connection reset"))
+
+	var buf bytes.Buffer
+	err := s.service.ExportStudentLogs(s.ctx, 1, domain.ExportOptions{
+		Format: domain.ExportXLSX, Lang: domain.LangEN, From: s.from, To: s.to,
+	}, &buf)
+
+	require.Error(s.T(), err)
+	assert.Zero(s.T(), buf.Len())
+	left, err := os.ReadDir(tmp)
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), left)
+}
+
+func (s *ExportServiceTestSuite) TestExportCohortAnalytics_NotFound() {
+	s.repoMock.On("GetCohortByID", s.ctx, uint64(4)).Return(nil, nil)
+
+	var buf bytes.Buffer
+	err := s.service.ExportCohortAnalytics(s.ctx, 4, domain.ExportOptions{Format: domain.ExportCSV}, &buf)
+
+	assert.True(s.T(), application.IsKind(err, application.KindNotFound))
+	assert.Zero(s.T(), buf.Len())
+	s.repoMock.AssertNotCalled(s.T(), "StreamCohortAnalytics", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestExportService(t *testing.T) {
+	suite.Run(t, new(ExportServiceTestSuite))
+}
+
+func newExportRouter(exporter *mocks.ExportService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	internal_http.SetupRoutes(router, internal_http.Handlers{
+		API:    internal_http.NewHTTPHandler(new(mocks.AnalyticsService)),
+		Health: internal_http.NewHealthHandler(new(mocks.HealthChecker)),
+		Export: internal_http.NewExportHandler(exporter),
+	})
+	return router
+}
+
+func TestExportHandlerStreamsFile(t *testing.T) {
+	exporter := new(mocks.ExportService)
+	exporter.On("ExportStudentLogs", mock.Anything, uint64(5), mock.MatchedBy(func(opts domain.ExportOptions) bool {
+		return opts.Format == domain.ExportXLSX && opts.Lang == domain.LangEN
+	}), mock.Anything).
+		Run(func(args mock.Arguments) {
+			io.WriteString(args.Get(3).(io.Writer), "data")
+		}).
+		Return(nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/students/5/logs/export?format=xlsx", nil)
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	rec := httptest.NewRecorder()
+	newExportRouter(exporter).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "data", rec.Body.String())
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), `filename="student_5_logs.xlsx"`)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "spreadsheetml")
+}
+
+func TestExportHandlerErrors(t *testing.T) {
+	exporter := new(mocks.ExportService)
+	exporter.On("ExportCohortAnalytics", mock.Anything, uint64(8), mock.Anything, mock.Anything).
+		Return(application.NotFound("cohort_not_found", "cohort 8 not found"))
+	router := newExportRouter(exporter)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/cohorts/8/analytics/export", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "application/json")
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/students/5/logs/export?format=pdf", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -10,18 +10,21 @@ import (
 	"github.com/RusselRustCode/teacher_analytics/core-service/internal/mocks"
 	"github.com/RusselRustCode/teacher_analytics/core-service/proto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
 type GRPCHandlerTestSuite struct {
 	suite.Suite
 	serviceMock *mocks.AnalyticsService
+	healthMock  *mocks.HealthChecker
 	handler     *grpc.GRPCHandler
 }
 
 func (s *GRPCHandlerTestSuite) SetupTest() {
 	s.serviceMock = new(mocks.AnalyticsService)
-	s.handler = grpc.NewGRPCHandler(s.serviceMock)
+	s.healthMock = new(mocks.HealthChecker)
+	s.handler = grpc.NewGRPCHandler(s.serviceMock, s.healthMock)
 }
 
 func (s *GRPCHandlerTestSuite) TestAnalyzeStudent_Success() {
@@ -53,6 +56,26 @@ func (s *GRPCHandlerTestSuite) TestAnalyzeStudent_Success() {
 	s.serviceMock.AssertExpectations(s.T())
 }
 
+func (s *GRPCHandlerTestSuite) TestHealthCheck_RequiredDependencyDown() {
+	ctx := context.Background()
+
+	s.healthMock.On("Last").Return(&domain.HealthReport{
+		Healthy: false,
+		Dependencies: []domain.DependencyStatus{
+			{Name: "postgres", Required: true, Healthy: false},
+			{Name: "redis", Required: false, Healthy: false},
+		},
+	})
+
+	resp, err := s.handler.HealthCheck(ctx, &proto.HealthCheckRequest{})
+
+	assert.NoError(s.T(), err)
+	assert.False(s.T(), resp.Healthy)
+	assert.Contains(s.T(), resp.Message, "postgres")
+	assert.NotContains(s.T(), resp.Message, "redis")
+	s.serviceMock.AssertNotCalled(s.T(), "GetStudents", mock.Anything)
+}
+
 func TestGRPCHandlerSuite(t *testing.T) {
 	suite.Run(t, new(GRPCHandlerTestSuite))
 }
\ No newline at end of file
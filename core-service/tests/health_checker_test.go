@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type HealthCheckerTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	checker *application.HealthChecker
+}
+
+func (s *HealthCheckerTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.checker = application.NewHealthChecker(50 * time.Millisecond)
+}
+
+func healthyDependency(context.Context) error { return nil }
+
+func (s *HealthCheckerTestSuite) TestOptionalFailureKeepsServiceHealthy() {
+	s.checker.Register("postgres", true, healthyDependency)
+	s.checker.Register("redis", false, func(context.Context) error { return errors.New("connection refused") })
+
+	report := s.checker.Check(s.ctx)
+
+	assert.True(s.T(), report.Healthy)
+	assert.Len(s.T(), report.Dependencies, 2)
+	assert.False(s.T(), report.Dependencies[1].Healthy)
+	assert.Equal(s.T(), "connection refused", report.Dependencies[1].Error)
+}
+
+func (s *HealthCheckerTestSuite) TestRequiredFailureMakesServiceUnhealthy() {
+	s.checker.Register("postgres", true, func(context.Context) error { return errors.New("db unreachable") })
+	s.checker.Register("redis", false, healthyDependency)
+
+	report := s.checker.Check(s.ctx)
+
+	assert.False(s.T(), report.Healthy)
+	assert.Same(s.T(), report, s.checker.Last())
+}
+
+func (s *HealthCheckerTestSuite) TestSlowDependencyTimesOut() {
+	s.checker.Register("kafka", true, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	report := s.checker.Check(s.ctx)
+
+	assert.False(s.T(), report.Healthy)
+	assert.Equal(s.T(), context.DeadlineExceeded.Error(), report.Dependencies[0].Error)
+}
+
+func TestHealthCheckerSuite(t *testing.T) {
+	suite.Run(t, new(HealthCheckerTestSuite))
+}
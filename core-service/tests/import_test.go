@@ -0,0 +1,264 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	internal_http "github.com/RusselRustCode/teacher_analytics/core-service/internal/api/http"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/auth"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/importer"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/mocks"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/xapi"
+)
+
+type ImportServiceTestSuite struct {
+	suite.Suite
+	ctx           context.Context
+	repoMock      *mocks.Repository
+	cacheMock     *mocks.Cache
+	analyticsMock *mocks.AnalyticsService
+	service       interfaces.ImportService
+}
+
+func (s *ImportServiceTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.repoMock = new(mocks.Repository)
+	s.cacheMock = new(mocks.Cache)
+	s.analyticsMock = new(mocks.AnalyticsService)
+	s.service = application.NewImportService(
+		s.repoMock,
+		s.cacheMock,
+		s.analyticsMock,
+		xapi.NewMapper(xapi.DefaultMapping()),
+		2,
+		logging.Nop(),
+	)
+}
+
+// Строка 3 - дубль строки 2, строка 4 без student_id, строка 5 с
+// неизвестным действием.
+const importCSV = "user_id,event,date,correct,attempts\n" +
+	"1,exam,2025-09-01 10:00:00,да,\n" +
+	"1,exam,2025-09-01 10:00:00,да,\n" +
+	",exam,2025-09-01 11:00:00,нет,1\n" +
+	"2,dance,2025-09-01 12:00:00,,\n" +
+	"2,view_lesson,2025-09-02,,\n" +
+	"3,view_lesson,2025-09-03,,\n"
+
+func (s *ImportServiceTestSuite) TestImportCSV_DedupesAndReportsRejectedRows() {
+	s.repoMock.On("SaveLogs", s.ctx, mock.MatchedBy(func(logs []*domain.StudentLog) bool {
+		return len(logs) == 2
+	})).Return(2, nil).Once()
+	// Последняя неполная пачка; одна строка уже была в базе
+	s.repoMock.On("SaveLogs", s.ctx, mock.MatchedBy(func(logs []*domain.StudentLog) bool {
+		return len(logs) == 1 && logs[0].StudentID == 3
+	})).Return(0, nil).Once()
+	s.cacheMock.On("Delete", s.ctx, mock.Anything).Return(nil)
+	s.cacheMock.On("InvalidateTags", s.ctx, "students", "student:1", "student:2").Return(nil).Once()
+
+	report, err := s.service.Import(s.ctx, strings.NewReader(importCSV), domain.ImportOptions{Format: domain.ImportCSV})
+	require.NoError(s.T(), err)
+
+	assert.Equal(s.T(), 6, report.TotalRows)
+	assert.Equal(s.T(), 3, report.Valid)
+	assert.Equal(s.T(), 2, report.Imported)
+	assert.Equal(s.T(), 2, report.Duplicates)
+	assert.Equal(s.T(), 2, report.RejectedCount)
+	require.Len(s.T(), report.Rejected, 2)
+	assert.Equal(s.T(), 4, report.Rejected[0].Row)
+	assert.Equal(s.T(), 5, report.Rejected[1].Row)
+	assert.Contains(s.T(), report.Rejected[1].Reason, "action_type")
+	assert.Equal(s.T(), 2, report.AffectedStudents)
+	s.cacheMock.AssertNumberOfCalls(s.T(), "Delete", 2)
+	s.analyticsMock.AssertNotCalled(s.T(), "TriggerAnalysis", mock.Anything, mock.Anything)
+}
+
+func (s *ImportServiceTestSuite) TestImportDryRun_DoesNotWrite() {
+	report, err := s.service.Import(s.ctx, strings.NewReader(importCSV), domain.ImportOptions{
+		Format: domain.ImportCSV,
+		DryRun: true,
+	})
+	require.NoError(s.T(), err)
+
+	assert.True(s.T(), report.DryRun)
+	assert.Equal(s.T(), 3, report.Valid)
+	assert.Zero(s.T(), report.Imported)
+	s.repoMock.AssertNotCalled(s.T(), "SaveLogs", mock.Anything, mock.Anything)
+	s.cacheMock.AssertNotCalled(s.T(), "Delete", mock.Anything, mock.Anything)
+}
+
+func (s *ImportServiceTestSuite) TestImportWithAPIKey_RejectsOtherCourses() {
+	ctx := auth.WithAPIKey(s.ctx, &domain.APIKey{ID: 7, Scopes: []string{domain.ScopeIngestLogs}, CohortIDs: []uint64{3}})
+	s.repoMock.On("GetCohortStudentIDs", ctx, uint64(3)).Return([]uint64{1, 2}, nil)
+
+	report, err := s.service.Import(ctx, strings.NewReader(importCSV), domain.ImportOptions{Format: domain.ImportCSV, DryRun: true})
+	require.NoError(s.T(), err)
+
+	assert.Equal(s.T(), 2, report.Valid)
+	assert.Equal(s.T(), 3, report.RejectedCount)
+	assert.Equal(s.T(), "student 3 is not in the api key's courses", report.Rejected[2].Reason)
+}
+
+func (s *ImportServiceTestSuite) TestImportXAPI_TriggersAnalysis() {
+	statements := `{"statements": [
+		{"actor": {"account": {"homePage": "https://lms", "name": "7"}},
+		 "verb": {"id": "http://adlnet.gov/expapi/verbs/answered"},
+		 "object": {"id": "https://lms/q/1"},
+		 "result": {"success": false, "response": "b", "duration": "PT45S"},
+		 "timestamp": "2025-09-01T10:00:00Z"},
+		{"actor": {"mbox": "mailto:someone@example.com"},
+		 "verb": {"id": "http://adlnet.gov/expapi/verbs/answered"},
+		 "object": {"id": "https://lms/q/2"},
+		 "timestamp": "2025-09-01T10:00:00Z"}
+	]}`
+	s.repoMock.On("SaveLogs", s.ctx, mock.MatchedBy(func(logs []*domain.StudentLog) bool {
+		l := logs[0]
+		return len(logs) == 1 && l.StudentID == 7 && l.ActionType == domain.ActionAnswerQuestion &&
+			!l.Correct && l.SelectedDistractor == "b" && l.TimeSpentOnQuestion == 45 && l.Attempts == 1
+	})).Return(1, nil)
+	s.cacheMock.On("Delete", s.ctx, mock.Anything).Return(nil)
+	s.cacheMock.On("InvalidateTags", s.ctx, "students", "student:7").Return(nil)
+	s.analyticsMock.On("TriggerAnalysis", s.ctx, uint64(7)).Return(nil)
+
+	report, err := s.service.Import(s.ctx, strings.NewReader(statements), domain.ImportOptions{
+		Format:          domain.ImportXAPI,
+		TriggerAnalysis: true,
+	})
+	require.NoError(s.T(), err)
+
+	assert.Equal(s.T(), 1, report.Imported)
+	assert.Equal(s.T(), 1, report.RejectedCount)
+	assert.Equal(s.T(), 1, report.AnalysisTriggered)
+}
+
+func (s *ImportServiceTestSuite) TestImportInvalidFile() {
+	_, err := s.service.Import(s.ctx, strings.NewReader("foo,bar\n1,2\n"), domain.ImportOptions{Format: domain.ImportCSV})
+
+	assert.True(s.T(), application.IsKind(err, application.KindValidation))
+}
+
+func TestImportService(t *testing.T) {
+	suite.Run(t, new(ImportServiceTestSuite))
+}
+
+func TestParseCSV_ColumnOverrides(t *testing.T) {
+	columns, err := importer.ParseColumnMapping("student_id=learner, timestamp=when")
+	require.NoError(t, err)
+
+	var records []importer.Record
+	err = importer.ParseCSV(strings.NewReader("learner,action,when\n5,view_lesson,2025-09-01T10:00:00Z\n"),
+		importer.CSVOptions{Columns: columns},
+		func(r importer.Record) error {
+			records = append(records, r)
+			return nil
+		})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.NoError(t, records[0].Err)
+	assert.Equal(t, uint64(5), records[0].Log.StudentID)
+	assert.Equal(t, time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC), records[0].Log.Timestamp)
+
+	_, err = importer.ParseColumnMapping("nickname=login")
+	assert.Error(t, err)
+}
+
+func TestXAPIParseDuration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"PT45S":      45 * time.Second,
+		"PT1H2M3.5S": time.Hour + 2*time.Minute + 3500*time.Millisecond,
+		"P1DT2H":     26 * time.Hour,
+	}
+	for in, want := range cases {
+		got, err := xapi.ParseDuration(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	for _, in := range []string{"", "P", "PT", "45S", "P1Y"} {
+		_, err := xapi.ParseDuration(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func newImportRouter(importer *mocks.ImportService, maxUploadBytes int64) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	internal_http.SetupRoutes(router, internal_http.Handlers{
+		API:    internal_http.NewHTTPHandler(new(mocks.AnalyticsService)),
+		Health: internal_http.NewHealthHandler(new(mocks.HealthChecker)),
+		Export: internal_http.NewExportHandler(new(mocks.ExportService)),
+		Import: internal_http.NewImportHandler(importer, maxUploadBytes),
+	})
+	return router
+}
+
+func TestImportHandlerMultipartUpload(t *testing.T) {
+	importer := new(mocks.ImportService)
+	importer.On("Import", mock.Anything, mock.Anything, domain.ImportOptions{
+		Format:     domain.ImportXAPI,
+		DryRun:     true,
+		CSVColumns: nil,
+	}).Return(&domain.ImportReport{DryRun: true, TotalRows: 3, Valid: 3}, nil)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "statements.json")
+	require.NoError(t, err)
+	part.Write([]byte("[]"))
+	require.NoError(t, form.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/import?dry_run=true", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rec := httptest.NewRecorder()
+	newImportRouter(importer, 1<<20).ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var report domain.ImportReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, 3, report.Valid)
+}
+
+func TestImportHandlerErrors(t *testing.T) {
+	importer := new(mocks.ImportService)
+	importer.On("Import", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, errors.New("unexpected call"))
+	router := newImportRouter(importer, 16)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/import?format=xml", strings.NewReader("a")))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/import?columns=nickname", strings.NewReader("a")))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "big.csv")
+	part.Write(bytes.Repeat([]byte("x"), 64))
+	form.Close()
+	req := httptest.NewRequest(http.MethodPost, "/api/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	importer.AssertNotCalled(t, "Import", mock.Anything, mock.Anything, mock.Anything)
+}
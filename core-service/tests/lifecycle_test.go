@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/lifecycle"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+)
+
+type LifecycleTestSuite struct {
+	suite.Suite
+	manager *lifecycle.Manager
+	runDone chan error
+	cancel  context.CancelFunc
+}
+
+func (s *LifecycleTestSuite) SetupTest() {
+	s.manager = lifecycle.NewManager(2 * time.Second, logging.Nop())
+}
+
+func (s *LifecycleTestSuite) run() {
+	var ctx context.Context
+	ctx, s.cancel = context.WithCancel(context.Background())
+	s.runDone = make(chan error, 1)
+	go func() { s.runDone <- s.manager.Run(ctx) }()
+}
+
+// startHTTP регистрирует в менеджере HTTP-сервер, хендлер которого
+// сигналит о начале обработки и ждет release.
+func (s *LifecycleTestSuite) startHTTP(started chan<- struct{}, release <-chan struct{}) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(s.T(), err)
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+	})}
+	s.manager.AddServer("http", func() error {
+		err := srv.Serve(lis)
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	}, srv.Shutdown)
+
+	return "http://" + lis.Addr().String()
+}
+
+func (s *LifecycleTestSuite) TestInFlightRequestCompletes() {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	url := s.startHTTP(started, release)
+	s.run()
+
+	type result struct {
+		status int
+		body   string
+		err    error
+	}
+	resCh := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			resCh <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		resCh <- result{status: resp.StatusCode, body: string(body)}
+	}()
+
+	<-started
+	s.cancel()
+	require.NoError(s.T(), <-s.runDone)
+
+	shutdownDone := make(chan error, 1)
+	go func() { shutdownDone <- s.manager.Shutdown() }()
+
+	// Пока запрос в обработке, Shutdown не должен завершиться
+	select {
+	case <-shutdownDone:
+		s.T().Fatal("shutdown returned before in-flight request finished")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+
+	res := <-resCh
+	require.NoError(s.T(), res.err)
+	assert.Equal(s.T(), http.StatusOK, res.status)
+	assert.Equal(s.T(), "done", res.body)
+	assert.NoError(s.T(), <-shutdownDone)
+
+	_, err := http.Get(url)
+	assert.Error(s.T(), err, "server must not accept new connections after shutdown")
+}
+
+func (s *LifecycleTestSuite) TestWorkersStopBeforeClosersInReverseOrder() {
+	var mu sync.Mutex
+	var events []string
+	record := func(e string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	}
+
+	s.manager.AddCloser("postgres", func() error { record("close postgres"); return nil })
+	s.manager.AddCloser("kafka producer", func() error { record("close kafka"); return nil })
+	s.manager.Go("worker", func(ctx context.Context) {
+		<-ctx.Done()
+		record("worker stopped")
+	})
+
+	s.run()
+	s.cancel()
+	require.NoError(s.T(), <-s.runDone)
+	require.NoError(s.T(), s.manager.Shutdown())
+
+	assert.Equal(s.T(), []string{"worker stopped", "close kafka", "close postgres"}, events)
+}
+
+func (s *LifecycleTestSuite) TestShutdownTimeout() {
+	manager := lifecycle.NewManager(50 * time.Millisecond, logging.Nop())
+	manager.AddCloser("stuck", func() error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	err := manager.Shutdown()
+
+	assert.ErrorIs(s.T(), err, context.DeadlineExceeded)
+}
+
+func (s *LifecycleTestSuite) TestRunReturnsServerError() {
+	s.manager.AddServer("broken", func() error {
+		return errors.New("address already in use")
+	}, func(context.Context) error { return nil })
+
+	err := s.manager.Run(context.Background())
+
+	assert.ErrorContains(s.T(), err, "broken server: address already in use")
+}
+
+func TestLifecycleSuite(t *testing.T) {
+	suite.Run(t, new(LifecycleTestSuite))
+}
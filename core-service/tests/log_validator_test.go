@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+)
+
+func validAnswerLog(now time.Time) *domain.StudentLog {
+	return &domain.StudentLog{
+		StudentID:           1,
+		ActionType:          domain.ActionAnswerQuestion,
+		MaterialID:          "math_001",
+		Correct:             false,
+		TimeSpentSec:        45,
+		TimeSpentOnQuestion: 45,
+		Difficulty:          3,
+		Attempts:            2,
+		SelectedDistractor:  "B",
+		Timestamp:           now.Add(-time.Minute),
+	}
+}
+
+func invalidFields(t *testing.T, err error) []string {
+	appErr, ok := application.AsError(err)
+	require.True(t, ok, "expected application error, got %v", err)
+	require.Equal(t, application.KindValidation, appErr.Kind)
+
+	var fields []string
+	for _, f := range appErr.Fields {
+		fields = append(fields, f.Field)
+	}
+	return fields
+}
+
+func TestValidateLogAcceptsValidLogs(t *testing.T) {
+	now := time.Now()
+	assert.NoError(t, application.ValidateLog(validAnswerLog(now), now))
+
+	view := &domain.StudentLog{
+		StudentID:      1,
+		ActionType:     domain.ActionViewMaterial,
+		TimeSpentSec:   600,
+		TimeSpentOnMat: 600,
+		Timestamp:      now,
+	}
+	assert.NoError(t, application.ValidateLog(view, now))
+}
+
+func TestValidateLogRejectsInvalidFields(t *testing.T) {
+	now := time.Now()
+
+	cases := map[string]struct {
+		mutate func(l *domain.StudentLog)
+		field  string
+	}{
+		"missing student":       {func(l *domain.StudentLog) { l.StudentID = 0 }, "student_id"},
+		"unknown action":        {func(l *domain.StudentLog) { l.ActionType = "hack" }, "action_type"},
+		"negative time":         {func(l *domain.StudentLog) { l.TimeSpentSec = -1 }, "time_spent_sec"},
+		"absurd time":           {func(l *domain.StudentLog) { l.TimeSpentOnQuestion = 24 * 60 * 60 }, "time_spent_on_question"},
+		"difficulty too high":   {func(l *domain.StudentLog) { l.Difficulty = 6 }, "difficulty"},
+		"answer without tries":  {func(l *domain.StudentLog) { l.Attempts = 0 }, "attempts"},
+		"distractor on correct": {func(l *domain.StudentLog) { l.Correct = true }, "selected_distractor"},
+		"future timestamp":      {func(l *domain.StudentLog) { l.Timestamp = now.Add(time.Hour) }, "timestamp"},
+		"ancient timestamp":     {func(l *domain.StudentLog) { l.Timestamp = time.Unix(0, 0) }, "timestamp"},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := validAnswerLog(now)
+			tc.mutate(log)
+
+			assert.Equal(t, []string{tc.field}, invalidFields(t, application.ValidateLog(log, now)))
+		})
+	}
+}
+
+func TestValidateLogReportsAllFieldsAtOnce(t *testing.T) {
+	now := time.Now()
+	log := &domain.StudentLog{
+		ActionType:         domain.ActionWatchVideo,
+		TimeSpentSec:       -5,
+		SelectedDistractor: "C",
+		Timestamp:          now,
+	}
+
+	assert.Equal(t,
+		[]string{"student_id", "time_spent_sec", "selected_distractor"},
+		invalidFields(t, application.ValidateLog(log, now)))
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internal_http "github.com/RusselRustCode/teacher_analytics/core-service/internal/api/http"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+)
+
+func newLoggedRouter(t *testing.T, buf *bytes.Buffer) *gin.Engine {
+	logger, err := logging.New(buf, "debug", "json")
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(internal_http.RequestIDMiddleware())
+	router.Use(internal_http.LoggingMiddleware(logger))
+	router.GET("/api/analytics/:student_id", func(c *gin.Context) {
+		logger.InfoContext(logging.WithStudentID(c.Request.Context(), 42), "handled")
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func decodeLogLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var lines []map[string]interface{}
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var line map[string]interface{}
+		require.NoError(t, dec.Decode(&line))
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestRequestIDIsPropagatedToResponseAndLogs(t *testing.T) {
+	var buf bytes.Buffer
+	router := newLoggedRouter(t, &buf)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/analytics/42", nil)
+	req.Header.Set(logging.RequestIDHeader, "req-123")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, "req-123", rec.Header().Get(logging.RequestIDHeader))
+
+	lines := decodeLogLines(t, &buf)
+	require.Len(t, lines, 2)
+
+	handled := lines[0]
+	assert.Equal(t, "handled", handled["msg"])
+	assert.Equal(t, "req-123", handled["request_id"])
+	assert.Equal(t, "/api/analytics/:student_id", handled["route"])
+	assert.Equal(t, 42.0, handled["student_id"])
+
+	access := lines[1]
+	assert.Equal(t, "http request", access["msg"])
+	assert.Equal(t, "req-123", access["request_id"])
+	assert.Equal(t, 200.0, access["status"])
+}
+
+func TestRequestIDIsGeneratedWhenMissing(t *testing.T) {
+	var buf bytes.Buffer
+	router := newLoggedRouter(t, &buf)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/analytics/42", nil))
+
+	requestID := rec.Header().Get(logging.RequestIDHeader)
+	assert.Len(t, requestID, 32)
+
+	for _, line := range decodeLogLines(t, &buf) {
+		assert.Equal(t, requestID, line["request_id"])
+	}
+}
+
+func TestNewRejectsUnknownLevelAndFormat(t *testing.T) {
+	_, err := logging.New(&bytes.Buffer{}, "verbose", "json")
+	assert.Error(t, err)
+
+	_, err = logging.New(&bytes.Buffer{}, "info", "xml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,352 @@
+package tests
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	internal_http "github.com/RusselRustCode/teacher_analytics/core-service/internal/api/http"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/auth"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/infrastructure/memory"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/lti"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/mocks"
+)
+
+const (
+	ltiIssuer   = "https://moodle.example.edu"
+	ltiClientID = "analytics-tool"
+	instructor  = "http://purl.imsglobal.org/vocab/lis/v2/membership#Instructor"
+	learner     = "http://purl.imsglobal.org/vocab/lis/v2/membership#Learner"
+)
+
+func newPlatformKey(t *testing.T) (*rsa.PrivateKey, *lti.KeySet) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	jwks, _ := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+	keys, err := lti.ParseKeySet(jwks)
+	require.NoError(t, err)
+	return key, keys
+}
+
+func launchClaims(nonce string, roles ...string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":   ltiIssuer,
+		"aud":   ltiClientID,
+		"sub":   "teacher-42",
+		"name":  "Ирина Петровна",
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+		"nonce": nonce,
+		"https://purl.imsglobal.org/spec/lti/claim/message_type":  "LtiResourceLinkRequest",
+		"https://purl.imsglobal.org/spec/lti/claim/version":       "1.3.0",
+		"https://purl.imsglobal.org/spec/lti/claim/deployment_id": "1",
+		"https://purl.imsglobal.org/spec/lti/claim/roles":         roles,
+		"https://purl.imsglobal.org/spec/lti/claim/context": map[string]string{
+			"id": "course-7", "label": "ALG", "title": "Алгебра, 9А",
+		},
+	}
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "k1"
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+type LTIServiceTestSuite struct {
+	suite.Suite
+	ctx       context.Context
+	key       *rsa.PrivateKey
+	repoMock  *mocks.Repository
+	cacheMock *mocks.Cache
+	service   interfaces.LTIService
+}
+
+func (s *LTIServiceTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	var keys *lti.KeySet
+	s.key, keys = newPlatformKey(s.T())
+	s.repoMock = new(mocks.Repository)
+	s.cacheMock = new(mocks.Cache)
+	platform := &lti.Platform{Issuer: ltiIssuer, ClientID: ltiClientID, DeploymentIDs: []string{"1"}, Keys: keys}
+	s.service = application.NewLTIService(s.repoMock, s.cacheMock, platform, application.LTIOptions{
+		AuthLoginURL: "https://moodle.example.edu/mod/lti/auth.php",
+		RedirectURI:  "https://analytics.example.edu/api/lti/launch",
+		SessionTTL:   time.Hour,
+	}, logging.Nop())
+}
+
+func (s *LTIServiceTestSuite) expectState(state, nonce string) {
+	s.cacheMock.On("GetDel", s.ctx, "lti:state:"+state).Return(nonce, nil)
+}
+
+func (s *LTIServiceTestSuite) TestInitiateLogin_RedirectsToPlatform() {
+	var nonce string
+	s.cacheMock.On("Set", s.ctx, mock.MatchedBy(func(key string) bool {
+		return strings.HasPrefix(key, "lti:state:")
+	}), mock.Anything, 10*time.Minute).
+		Run(func(args mock.Arguments) { nonce = args.String(2) }).
+		Return(nil)
+
+	redirect, err := s.service.InitiateLogin(s.ctx, domain.LTILoginRequest{
+		Issuer: ltiIssuer, LoginHint: "42", LTIMessageHint: "hint", ClientID: ltiClientID,
+	})
+	require.NoError(s.T(), err)
+
+	u, err := url.Parse(redirect.URL)
+	require.NoError(s.T(), err)
+	q := u.Query()
+	assert.Equal(s.T(), "moodle.example.edu", u.Host)
+	assert.Equal(s.T(), "id_token", q.Get("response_type"))
+	assert.Equal(s.T(), "form_post", q.Get("response_mode"))
+	assert.Equal(s.T(), "https://analytics.example.edu/api/lti/launch", q.Get("redirect_uri"))
+	assert.Equal(s.T(), "hint", q.Get("lti_message_hint"))
+	assert.Equal(s.T(), redirect.State, q.Get("state"))
+	assert.Equal(s.T(), nonce, q.Get("nonce"))
+}
+
+func (s *LTIServiceTestSuite) TestInitiateLogin_UnknownPlatform() {
+	_, err := s.service.InitiateLogin(s.ctx, domain.LTILoginRequest{Issuer: "https://evil.example.com", LoginHint: "42"})
+
+	assert.True(s.T(), application.IsKind(err, application.KindValidation))
+	s.cacheMock.AssertNotCalled(s.T(), "Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *LTIServiceTestSuite) TestLaunch_CreatesCourseScopedSession() {
+	s.expectState("st", "n1")
+	s.repoMock.On("EnsureLTICohort", s.ctx, ltiIssuer, "course-7", "Алгебра, 9А").
+		Return(&domain.Cohort{ID: 3, Name: "Алгебра, 9А"}, nil)
+	s.cacheMock.On("Set", s.ctx, mock.MatchedBy(func(key string) bool {
+		return strings.HasPrefix(key, "session:")
+	}), mock.Anything, time.Hour).Return(nil)
+
+	session, err := s.service.Launch(s.ctx, signToken(s.T(), s.key, launchClaims("n1", instructor)), "st", "st")
+	require.NoError(s.T(), err)
+
+	assert.Equal(s.T(), uint64(3), session.CohortID)
+	assert.Equal(s.T(), domain.RoleInstructor, session.Role)
+	assert.Equal(s.T(), "teacher-42", session.Subject)
+	assert.NotEmpty(s.T(), session.ID)
+	// В хранилище токен не попадает в ключ как есть
+	s.cacheMock.AssertNotCalled(s.T(), "Set", s.ctx, "session:"+session.ID, mock.Anything, mock.Anything)
+}
+
+// Администратор курса получает сессию преподавателя своего курса, а не
+// доступ к настройкам всего учреждения
+func (s *LTIServiceTestSuite) TestLaunch_CourseAdministratorIsNotInstitutionAdmin() {
+	s.expectState("st", "n1")
+	s.repoMock.On("EnsureLTICohort", s.ctx, ltiIssuer, "course-7", "Алгебра, 9А").
+		Return(&domain.Cohort{ID: 3, Name: "Алгебра, 9А"}, nil)
+	s.cacheMock.On("Set", s.ctx, mock.Anything, mock.Anything, time.Hour).Return(nil)
+
+	courseAdmin := "http://purl.imsglobal.org/vocab/lis/v2/membership#Administrator"
+	session, err := s.service.Launch(s.ctx, signToken(s.T(), s.key, launchClaims("n1", courseAdmin)), "st", "st")
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), domain.RoleInstructor, session.Role)
+	assert.Equal(s.T(), uint64(3), session.CohortID)
+
+	ltiService := new(mocks.LTIService)
+	ltiService.On("GetSession", mock.Anything, session.ID).Return(session, nil)
+	router := newLTIRouter(ltiService, new(mocks.AnalyticsService), true)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, withSession(httptest.NewRequest(http.MethodGet, "/api/webhooks", nil), session.ID))
+	assert.Equal(s.T(), http.StatusForbidden, rec.Code)
+}
+
+func (s *LTIServiceTestSuite) TestLaunch_RejectsLearner() {
+	s.expectState("st", "n1")
+
+	_, err := s.service.Launch(s.ctx, signToken(s.T(), s.key, launchClaims("n1", learner)), "st", "st")
+
+	assert.True(s.T(), application.IsKind(err, application.KindForbidden))
+	s.repoMock.AssertNotCalled(s.T(), "EnsureLTICohort", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *LTIServiceTestSuite) TestLaunch_RejectsInvalidTokens() {
+	otherKey, _ := newPlatformKey(s.T())
+	expired := launchClaims("n1", instructor)
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	wrongAudience := launchClaims("n1", instructor)
+	wrongAudience["aud"] = "other-tool"
+	unknownDeployment := launchClaims("n1", instructor)
+	unknownDeployment["https://purl.imsglobal.org/spec/lti/claim/deployment_id"] = "99"
+
+	cases := map[string]string{
+		"wrong nonce":        signToken(s.T(), s.key, launchClaims("other", instructor)),
+		"foreign signature":  signToken(s.T(), otherKey, launchClaims("n1", instructor)),
+		"expired":            signToken(s.T(), s.key, expired),
+		"wrong audience":     signToken(s.T(), s.key, wrongAudience),
+		"unknown deployment": signToken(s.T(), s.key, unknownDeployment),
+	}
+	s.expectState("st", "n1")
+	for name, token := range cases {
+		s.Run(name, func() {
+			_, err := s.service.Launch(s.ctx, token, "st", "st")
+
+			assert.True(s.T(), application.IsKind(err, application.KindUnauthorized), err)
+		})
+	}
+}
+
+func (s *LTIServiceTestSuite) TestLaunch_StateMustBeKnownAndMatchCookie() {
+	s.cacheMock.On("GetDel", s.ctx, "lti:state:replayed").Return("", nil)
+	token := signToken(s.T(), s.key, launchClaims("n1", instructor))
+
+	_, err := s.service.Launch(s.ctx, token, "replayed", "replayed")
+	assert.True(s.T(), application.IsKind(err, application.KindUnauthorized))
+
+	_, err = s.service.Launch(s.ctx, token, "st", "other-browser")
+	assert.True(s.T(), application.IsKind(err, application.KindUnauthorized))
+
+	// Без cookie браузер не докажет, что вход начинал он
+	_, err = s.service.Launch(s.ctx, token, "st", "")
+	assert.True(s.T(), application.IsKind(err, application.KindUnauthorized))
+	s.cacheMock.AssertNotCalled(s.T(), "GetDel", s.ctx, "lti:state:st")
+}
+
+// Два запуска с одним state: забрать его из хранилища успевает только один
+func (s *LTIServiceTestSuite) TestLaunch_StateIsConsumedOnce() {
+	s.cacheMock.On("GetDel", s.ctx, "lti:state:st").Return("n1", nil).Once()
+	s.cacheMock.On("GetDel", s.ctx, "lti:state:st").Return("", nil).Once()
+	s.repoMock.On("EnsureLTICohort", s.ctx, ltiIssuer, "course-7", "Алгебра, 9А").
+		Return(&domain.Cohort{ID: 3, Name: "Алгебра, 9А"}, nil)
+	s.cacheMock.On("Set", s.ctx, mock.Anything, mock.Anything, time.Hour).Return(nil)
+	token := signToken(s.T(), s.key, launchClaims("n1", instructor))
+
+	_, err := s.service.Launch(s.ctx, token, "st", "st")
+	require.NoError(s.T(), err)
+	_, err = s.service.Launch(s.ctx, token, "st", "st")
+	assert.True(s.T(), application.IsKind(err, application.KindUnauthorized))
+	s.repoMock.AssertNumberOfCalls(s.T(), "EnsureLTICohort", 1)
+}
+
+func TestLTIService(t *testing.T) {
+	suite.Run(t, new(LTIServiceTestSuite))
+}
+
+func TestCourseScopedAnalytics(t *testing.T) {
+	repo := new(mocks.Repository)
+	service := application.NewAnalyticsService(repo, memory.NewLRUCache(100), new(mocks.MessageProducer), new(mocks.AnalyticsClient), nil, application.AnalyticsOptions{}, logging.Nop())
+	ctx := auth.WithSession(context.Background(), &domain.Session{Subject: "teacher-42", CohortID: 3})
+
+	repo.On("GetCohortStudentIDs", ctx, uint64(3)).Return([]uint64{5, 8}, nil)
+	students, err := service.GetStudents(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{5, 8}, students)
+	repo.AssertNotCalled(t, "GetStudents", mock.Anything)
+
+	repo.On("IsStudentInCohort", ctx, uint64(3), uint64(9)).Return(false, nil)
+	_, err = service.GetAnalytics(ctx, 9)
+	assert.True(t, application.IsKind(err, application.KindForbidden))
+	_, err = service.GetStudentLogs(ctx, 9, time.Now().AddDate(0, -1, 0), time.Now())
+	assert.True(t, application.IsKind(err, application.KindForbidden))
+}
+
+func newLTIRouter(ltiService *mocks.LTIService, analytics *mocks.AnalyticsService, requireSession bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	internal_http.SetupRoutes(router, internal_http.Handlers{
+		API:    internal_http.NewHTTPHandler(analytics),
+		Health: internal_http.NewHealthHandler(new(mocks.HealthChecker)),
+		Export: internal_http.NewExportHandler(new(mocks.ExportService)),
+		Import: internal_http.NewImportHandler(new(mocks.ImportService), 0),
+		XAPI:   internal_http.NewXAPIHandler(new(mocks.XAPIService)),
+		LTI: internal_http.NewLTIHandler(ltiService, internal_http.LTIHandlerOptions{
+			DashboardURL:   "/dashboard",
+			CookieSecure:   true,
+			RequireSession: requireSession,
+		}),
+	})
+	return router
+}
+
+func TestLTIHandlerLaunchSetsSessionCookie(t *testing.T) {
+	ltiService := new(mocks.LTIService)
+	ltiService.On("Launch", mock.Anything, "token", "st", "st").
+		Return(&domain.Session{ID: "secret", CohortID: 3, ExpiresAt: time.Now().Add(time.Hour)}, nil)
+
+	form := url.Values{"id_token": {"token"}, "state": {"st"}}
+	req := httptest.NewRequest(http.MethodPost, "/api/lti/launch", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: "lti_state", Value: "st"})
+	rec := httptest.NewRecorder()
+	newLTIRouter(ltiService, new(mocks.AnalyticsService), true).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Equal(t, "/dashboard", rec.Header().Get("Location"))
+	var session *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == internal_http.SessionCookie {
+			session = c
+		}
+	}
+	require.NotNil(t, session)
+	assert.Equal(t, "secret", session.Value)
+	assert.True(t, session.HttpOnly)
+	assert.True(t, session.Secure)
+	assert.Equal(t, http.SameSiteNoneMode, session.SameSite)
+}
+
+func TestLTISessionMiddleware(t *testing.T) {
+	ltiService := new(mocks.LTIService)
+	analytics := new(mocks.AnalyticsService)
+	ltiService.On("GetSession", mock.Anything, "").
+		Return(nil, application.Unauthorized("session_required", "sign in through your LMS"))
+	ltiService.On("GetSession", mock.Anything, "secret").
+		Return(&domain.Session{ID: "secret", Subject: "teacher-42", CohortID: 3}, nil)
+	analytics.On("GetStudents", mock.MatchedBy(func(ctx context.Context) bool {
+		session := auth.SessionFrom(ctx)
+		return session != nil && session.CohortID == 3
+	})).Return([]uint64{5}, nil)
+
+	router := newLTIRouter(ltiService, analytics, true)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/students", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/students", nil)
+	req.AddCookie(&http.Cookie{Name: internal_http.SessionCookie, Value: "secret"})
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"students":[5]`)
+
+	// Прием логов сессией не закрывается
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/log", strings.NewReader("{")))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,34 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	internal_http "github.com/RusselRustCode/teacher_analytics/core-service/internal/api/http"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/metrics"
+)
+
+func TestMetricsMiddlewareUsesRouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(internal_http.MetricsMiddleware())
+	router.GET("/api/analytics/:student_id", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	counter := metrics.HTTPRequestsTotal.WithLabelValues("GET", "/api/analytics/:student_id", "200")
+	before := testutil.ToFloat64(counter)
+
+	for _, path := range []string{"/api/analytics/1", "/api/analytics/2"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	assert.Equal(t, before+2, testutil.ToFloat64(counter))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.HTTPRequestsTotal.WithLabelValues("GET", "unmatched", "404")))
+}
@@ -0,0 +1,210 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	internal_http "github.com/RusselRustCode/teacher_analytics/core-service/internal/api/http"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/auth"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/mocks"
+)
+
+func readZip(t *testing.T, data []byte) map[string][]byte {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		files[f.Name], err = io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+	}
+	return files
+}
+
+func TestPrivacyService_ExportStudentData(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.Repository)
+	service := application.NewPrivacyService(repo, new(mocks.Cache), new(mocks.MessageProducer), logging.Nop())
+
+	repo.On("GetStudentByID", ctx, uint64(5)).Return(&domain.Student{ID: 5, Name: "Anna", Email: "anna@example.com"}, nil)
+	repo.On("GetAnalyticsByStudentID", ctx, uint64(5)).Return(&domain.StudentAnalytics{StudentID: 5, ClusterGroup: "active"}, nil)
+	repo.On("GetAnalyticsHistory", ctx, uint64(5), time.Time{}, mock.Anything).
+		Return([]*domain.StudentAnalytics{{StudentID: 5, EngagementScore: 40}, {StudentID: 5, EngagementScore: 70}}, nil)
+	repo.On("StreamLogsByStudentID", ctx, uint64(5), time.Time{}, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(4).(func(*domain.StudentLog) error)
+			require.NoError(t, fn(&domain.StudentLog{StudentID: 5, ActionType: "quiz"}))
+			require.NoError(t, fn(&domain.StudentLog{StudentID: 5, ActionType: "video"}))
+		}).Return(nil)
+
+	var buf bytes.Buffer
+	require.NoError(t, service.ExportStudentData(ctx, 5, &buf))
+	files := readZip(t, buf.Bytes())
+
+	var student domain.Student
+	require.NoError(t, json.Unmarshal(files["profile.json"], &student))
+	assert.Equal(t, "anna@example.com", student.Email)
+	var logs []domain.StudentLog
+	require.NoError(t, json.Unmarshal(files["logs.json"], &logs))
+	require.Len(t, logs, 2)
+	assert.Equal(t, "video", logs[1].ActionType)
+	var analytics domain.StudentAnalytics
+	require.NoError(t, json.Unmarshal(files["analytics.json"], &analytics))
+	assert.Equal(t, "active", analytics.ClusterGroup)
+	var history []domain.StudentAnalytics
+	require.NoError(t, json.Unmarshal(files["analytics_history.json"], &history))
+	assert.Len(t, history, 2)
+}
+
+func TestPrivacyService_ExportUnknownStudent(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.Repository)
+	service := application.NewPrivacyService(repo, new(mocks.Cache), new(mocks.MessageProducer), logging.Nop())
+
+	repo.On("GetStudentByID", ctx, uint64(9)).Return(nil, nil)
+	repo.On("GetAnalyticsByStudentID", ctx, uint64(9)).Return(nil, nil)
+	repo.On("GetAnalyticsHistory", ctx, uint64(9), time.Time{}, mock.Anything).Return(nil, nil)
+	repo.On("StreamLogsByStudentID", ctx, uint64(9), time.Time{}, mock.Anything, mock.Anything).Return(nil)
+
+	var buf bytes.Buffer
+	err := service.ExportStudentData(ctx, 9, &buf)
+	assert.True(t, application.IsKind(err, application.KindNotFound))
+	assert.Zero(t, buf.Len())
+}
+
+func TestPrivacyService_AnonymizeStudent(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.Repository)
+	cache := new(mocks.Cache)
+	producer := new(mocks.MessageProducer)
+	service := application.NewPrivacyService(repo, cache, producer, logging.Nop())
+
+	var published map[string]interface{}
+	producer.On("SendJSON", ctx, "student-erasure", mock.Anything).
+		Run(func(args mock.Arguments) { published = args.Get(2).(map[string]interface{}) }).
+		Return(nil)
+	isPseudonym := mock.MatchedBy(func(id uint64) bool { return id >= 1<<62 && id < 1<<63 })
+	repo.On("AnonymizeStudent", ctx, uint64(5), isPseudonym).
+		Return(func(_ context.Context, studentID, pseudonymID uint64) (*domain.StudentErasure, error) {
+			return &domain.StudentErasure{
+				StudentID:   studentID,
+				Mode:        domain.ErasureModeAnonymize,
+				PseudonymID: pseudonymID,
+				Rows:        map[string]int64{"student_logs": 12, "students": 1},
+				CohortIDs:   []uint64{2},
+			}, nil
+		})
+	for _, key := range []string{"analytics:5", "analytics:stale:5", "lock:analytics:5", "students:seen:5"} {
+		cache.On("Delete", mock.Anything, key).Return(nil).Once()
+	}
+	cache.On("InvalidateTags", mock.Anything, "student:5", "students", "cohort:2").Return(nil).Once()
+
+	erasure, err := service.DeleteStudent(ctx, 5, domain.ErasureModeAnonymize)
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), erasure.Rows["student_logs"])
+	assert.WithinDuration(t, time.Now(), erasure.ErasedAt, time.Second)
+	assert.Equal(t, "student_erased", published["type"])
+	assert.Equal(t, erasure.PseudonymID, published["pseudonym_id"])
+	repo.AssertExpectations(t)
+	cache.AssertExpectations(t)
+}
+
+func TestPrivacyService_DeleteStudentFailures(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.Repository)
+	producer := new(mocks.MessageProducer)
+	service := application.NewPrivacyService(repo, new(mocks.Cache), producer, logging.Nop())
+
+	_, err := service.DeleteStudent(ctx, 5, "")
+	assert.True(t, application.IsKind(err, application.KindValidation))
+
+	// Шина недоступна - данные не трогаем, запрос можно повторить
+	producer.On("SendJSON", ctx, "student-erasure", mock.Anything).Return(errors.New("broker unreachable"))
+	_, err = service.DeleteStudent(ctx, 5, domain.ErasureModeErase)
+	assert.True(t, application.IsKind(err, application.KindUnavailable))
+	repo.AssertNotCalled(t, "EraseStudent", mock.Anything, mock.Anything)
+
+	// Студент не из курса преподавателя
+	session := auth.WithSession(ctx, &domain.Session{Subject: "teacher-42", CohortID: 2})
+	repo.On("IsStudentInCohort", session, uint64(2), uint64(7)).Return(false, nil)
+	_, err = service.DeleteStudent(session, 7, domain.ErasureModeErase)
+	assert.True(t, application.IsKind(err, application.KindForbidden))
+}
+
+func TestPrivacyHandler_Routes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	privacy := new(mocks.PrivacyService)
+	keys := new(mocks.APIKeyService)
+	audit := new(mocks.AuditService)
+	audit.On("Record", mock.Anything, mock.Anything).Return(nil)
+	router := gin.New()
+	internal_http.SetupRoutes(router, internal_http.Handlers{
+		API:     internal_http.NewHTTPHandler(new(mocks.AnalyticsService)),
+		Health:  internal_http.NewHealthHandler(new(mocks.HealthChecker)),
+		APIKey:  internal_http.NewAPIKeyHandler(keys, internal_http.APIKeyHandlerOptions{}),
+		Privacy: internal_http.NewPrivacyHandler(privacy),
+		Audit:   internal_http.NewAuditHandler(audit, logging.Nop()),
+		LTI:     adminLTIHandler(),
+	})
+
+	privacy.On("ExportStudentData", mock.Anything, uint64(5), mock.Anything).
+		Run(func(args mock.Arguments) {
+			_, err := args.Get(2).(io.Writer).Write([]byte("PK"))
+			require.NoError(t, err)
+		}).Return(nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, withSession(httptest.NewRequest(http.MethodGet, "/api/students/5/data-export", nil), "teacher"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/zip", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), "student_5_data.zip")
+	audit.AssertCalled(t, "Record", mock.Anything, auditEntryMatching(func(e *domain.AuditEntry) bool {
+		return e.Action == domain.AuditRead && e.Endpoint == "/api/students/:student_id/data-export" &&
+			*e.StudentID == 5 && e.Status == http.StatusOK
+	}))
+
+	privacy.On("DeleteStudent", mock.Anything, uint64(5), domain.ErasureModeErase).
+		Return(&domain.StudentErasure{StudentID: 5, Mode: domain.ErasureModeErase, Rows: map[string]int64{"students": 1}}, nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, withSession(httptest.NewRequest(http.MethodDelete, "/api/students/5?mode=erase", nil), "admin"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"students":1`)
+
+	// Без сессии удалить нельзя, даже когда дашборд открыт анонимно
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/students/5?mode=anonymize", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Выгружать и удалять данные ключом интеграции нельзя даже с
+	// read:analytics, анониму - тоже
+	keys.On("Authenticate", mock.Anything, "ta_reader", "192.0.2.1").
+		Return(&domain.APIKey{ID: 7, Scopes: []string{domain.ScopeReadAnalytics}}, nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, apiKeyRequest(http.MethodDelete, "/api/students/5?mode=erase", "ta_reader"))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, apiKeyRequest(http.MethodGet, "/api/students/5/data-export", "ta_reader"))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/students/5/data-export", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	privacy.AssertNumberOfCalls(t, "DeleteStudent", 1)
+	privacy.AssertNumberOfCalls(t, "ExportStudentData", 1)
+}
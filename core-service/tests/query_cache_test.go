@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/infrastructure/memory"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/mocks"
+)
+
+// newCachedAnalyticsService - сервис с настоящим кэшем в памяти, чтобы
+// проверять попадания и сброс по тегам, а не вызовы мока.
+func newCachedAnalyticsService(repo *mocks.Repository) interfaces.AnalyticsService {
+	producer := new(mocks.MessageProducer)
+	producer.On("SendJSON", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	return application.NewAnalyticsService(repo, memory.NewLRUCache(100), producer, new(mocks.AnalyticsClient), nil, application.AnalyticsOptions{}, logging.Nop())
+}
+
+func sendViewLog(t *testing.T, service interfaces.AnalyticsService, repo *mocks.Repository, studentID uint64) {
+	t.Helper()
+	log := &domain.StudentLog{StudentID: studentID, ActionType: domain.ActionViewLesson, MaterialID: "math_101", Timestamp: time.Now()}
+	repo.On("SaveLog", mock.Anything, log).Return(nil).Once()
+	require.NoError(t, service.SendLog(context.Background(), log))
+}
+
+func TestGetStudentLogs_CachesDashboardRange(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.Repository)
+	service := newCachedAnalyticsService(repo)
+
+	now := time.Now()
+	old := &domain.StudentLog{ID: 1, StudentID: 5, Timestamp: now.AddDate(0, -2, 0)}
+	recent := &domain.StudentLog{ID: 2, StudentID: 5, Timestamp: now.Add(-time.Hour)}
+	repo.On("GetLogsByStudentID", ctx, uint64(5), mock.Anything, mock.Anything).Return([]*domain.StudentLog{recent, old}, nil).Twice()
+
+	// Как дашборд: период по умолчанию сдвигается с каждым запросом
+	for i := 0; i < 3; i++ {
+		logs, err := service.GetStudentLogs(ctx, 5, time.Now().AddDate(0, -1, 0), time.Now())
+		require.NoError(t, err)
+		require.Len(t, logs, 1)
+		assert.Equal(t, uint64(2), logs[0].ID)
+	}
+	repo.AssertNumberOfCalls(t, "GetLogsByStudentID", 1)
+
+	// Новый лог студента сбрасывает его выборки
+	sendViewLog(t, service, repo, 5)
+	_, err := service.GetStudentLogs(ctx, 5, time.Now().AddDate(0, -1, 0), time.Now())
+	require.NoError(t, err)
+	repo.AssertNumberOfCalls(t, "GetLogsByStudentID", 2)
+}
+
+func TestGetStudents_ResetOnlyForNewStudent(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.Repository)
+	service := newCachedAnalyticsService(repo)
+
+	repo.On("GetStudents", ctx).Return([]uint64{5}, nil)
+	sendViewLog(t, service, repo, 5)
+
+	for i := 0; i < 2; i++ {
+		students, err := service.GetStudents(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{5}, students)
+	}
+	repo.AssertNumberOfCalls(t, "GetStudents", 1)
+
+	// Студент 5 уже в списке
+	sendViewLog(t, service, repo, 5)
+	_, err := service.GetStudents(ctx)
+	require.NoError(t, err)
+	repo.AssertNumberOfCalls(t, "GetStudents", 1)
+
+	sendViewLog(t, service, repo, 8)
+	_, err = service.GetStudents(ctx)
+	require.NoError(t, err)
+	repo.AssertNumberOfCalls(t, "GetStudents", 2)
+}
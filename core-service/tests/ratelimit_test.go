@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	google_grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	internal_grpc "github.com/RusselRustCode/teacher_analytics/core-service/internal/api/grpc"
+	internal_http "github.com/RusselRustCode/teacher_analytics/core-service/internal/api/http"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/infrastructure/memory"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/infrastructure/redis"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/mocks"
+)
+
+func assertTokenBucket(t *testing.T, limiter interfaces.RateLimiter, advance func(time.Duration)) {
+	t.Helper()
+	ctx := context.Background()
+	limit := domain.RateLimit{Rate: 2, Burst: 3}
+
+	for i := 2; i >= 0; i-- {
+		d, err := limiter.Allow(ctx, "ratelimit:ingest:ip:10.0.0.1", limit)
+		require.NoError(t, err)
+		assert.True(t, d.Allowed)
+		assert.Equal(t, i, d.Remaining)
+	}
+	d, err := limiter.Allow(ctx, "ratelimit:ingest:ip:10.0.0.1", limit)
+	require.NoError(t, err)
+	assert.False(t, d.Allowed)
+	assert.InDelta(t, 500*time.Millisecond, d.RetryAfter, float64(50*time.Millisecond))
+
+	// У другого клиента свое ведро
+	d, _ = limiter.Allow(ctx, "ratelimit:ingest:ip:10.0.0.2", limit)
+	assert.True(t, d.Allowed)
+
+	advance(time.Second)
+	for i := 0; i < 2; i++ {
+		d, _ = limiter.Allow(ctx, "ratelimit:ingest:ip:10.0.0.1", limit)
+		assert.True(t, d.Allowed, "token %d after refill", i+1)
+	}
+	d, _ = limiter.Allow(ctx, "ratelimit:ingest:ip:10.0.0.1", limit)
+	assert.False(t, d.Allowed)
+}
+
+func TestRedisRateLimiter_TokenBucket(t *testing.T) {
+	srv := miniredis.RunT(t)
+	now := time.Now()
+	srv.SetTime(now)
+	limiter := redis.NewRateLimiter(srv.Addr(), "", 0)
+	defer limiter.Close()
+
+	assertTokenBucket(t, limiter, func(d time.Duration) {
+		now = now.Add(d)
+		srv.SetTime(now)
+	})
+	assert.True(t, srv.Exists("ratelimit:ingest:ip:10.0.0.1"))
+}
+
+func TestMemoryRateLimiter_TokenBucket(t *testing.T) {
+	assertTokenBucket(t, memory.NewRateLimiter(), time.Sleep)
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	limiter := new(mocks.RateLimiter)
+	limits := map[string]domain.RateLimit{
+		internal_http.RateLimitIngest: {Rate: 10, Burst: 20},
+	}
+	limiter.On("Allow", mock.Anything, "ratelimit:ingest:ip:192.0.2.1", limits[internal_http.RateLimitIngest]).
+		Return(domain.RateLimitDecision{RetryAfter: 1500 * time.Millisecond}, nil)
+
+	analytics := new(mocks.AnalyticsService)
+	analytics.On("GetStudents", mock.Anything).Return([]uint64{5}, nil)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	internal_http.SetupRoutes(router, internal_http.Handlers{
+		API:       internal_http.NewHTTPHandler(analytics),
+		Health:    internal_http.NewHealthHandler(new(mocks.HealthChecker)),
+		RateLimit: internal_http.NewRateLimitHandler(limiter, limits, logging.Nop()),
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/log", strings.NewReader(`{"student_id":5}`)))
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "0", rec.Header().Get("X-RateLimit-Remaining"))
+	assert.Contains(t, rec.Body.String(), `"code":"rate_limited"`)
+
+	// У дашборда лимит не задан
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/students", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	limiter.AssertNumberOfCalls(t, "Allow", 1)
+}
+
+func TestRateLimitUnaryInterceptor(t *testing.T) {
+	limit := domain.RateLimit{Rate: 1, Burst: 1}
+	interceptor := internal_grpc.RateLimitUnaryInterceptor(memory.NewRateLimiter(), limit, logging.Nop())
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 50000}})
+	info := &google_grpc.UnaryServerInfo{FullMethod: "/analytics.AnalyticsService/AnalyzeStudent"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	resp, err := interceptor(ctx, nil, info, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+
+	_, err = interceptor(ctx, nil, info, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// Пробы health не ограничиваются
+	_, err = interceptor(ctx, nil, &google_grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, handler)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,250 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	internal_http "github.com/RusselRustCode/teacher_analytics/core-service/internal/api/http"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/auth"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/mocks"
+)
+
+type ReportServiceTestSuite struct {
+	suite.Suite
+	ctx          context.Context
+	repoMock     *mocks.Repository
+	analyzerMock *mocks.AnalyticsClient
+	service      interfaces.ReportService
+	opts         domain.ReportOptions
+}
+
+func (s *ReportServiceTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.repoMock = new(mocks.Repository)
+	s.analyzerMock = new(mocks.AnalyticsClient)
+	s.service = application.NewReportService(s.repoMock, s.analyzerMock, logging.Nop())
+	to := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	s.opts = domain.ReportOptions{Lang: domain.LangRU, From: to.AddDate(0, -1, 0), To: to}
+}
+
+// analyzed - итоговые показатели из базы, темы и рекомендации из ответа
+// аналитического сервиса
+func (s *ReportServiceTestSuite) analyzed(ctx context.Context, id uint64) {
+	s.repoMock.On("GetAnalyticsByStudentID", ctx, id).Return(&domain.StudentAnalytics{
+		StudentID:       id,
+		ClusterGroup:    "struggling",
+		EngagementScore: 42,
+		SuccessRate:     0.55,
+		AvgTimePerTask:  31.5,
+		AnalyzedAt:      s.opts.To,
+	}, nil)
+	s.analyzerMock.On("AnalyzeStudent", ctx, id).Return(&domain.StudentAnalytics{
+		StudentID:       id,
+		TopicEfficiency: map[string]float64{"Дроби": 0.3, "Уравнения": 0.8, "geometry_basics": 0.6},
+		Recommendations: []string{"Повторить тему «Дроби»", "Больше практики с уравнениями"},
+	}, nil)
+	s.repoMock.On("GetStudentByID", ctx, id).Return(&domain.Student{ID: id, Name: "Иван Петров"}, nil)
+	s.repoMock.On("GetAnalyticsHistory", ctx, id, s.opts.From, s.opts.To).Return([]*domain.StudentAnalytics{
+		{StudentID: id, EngagementScore: 20, SuccessRate: 0.4, AnalyzedAt: s.opts.From},
+		{StudentID: id, EngagementScore: 35, SuccessRate: 0.5, AnalyzedAt: s.opts.From.AddDate(0, 0, 10)},
+		{StudentID: id, EngagementScore: 42, SuccessRate: 0.55, AnalyzedAt: s.opts.To},
+	}, nil)
+}
+
+func (s *ReportServiceTestSuite) notAnalyzed(ctx context.Context, id uint64) {
+	s.repoMock.On("GetAnalyticsByStudentID", ctx, id).Return(nil, nil)
+}
+
+func (s *ReportServiceTestSuite) TestStudentReport_RendersPDF() {
+	s.analyzed(s.ctx, 1)
+
+	var buf bytes.Buffer
+	require.NoError(s.T(), s.service.StudentReport(s.ctx, 1, s.opts, &buf))
+
+	assert.True(s.T(), bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")))
+	assert.Contains(s.T(), buf.String(), "%%EOF")
+	// Раздел по темам не пустой: в нем названия тем из ответа сервиса
+	text := pdfText(s.T(), buf.Bytes())
+	assert.Contains(s.T(), text, "Уравнения")
+	assert.Contains(s.T(), text, "geometry_basics")
+	assert.Contains(s.T(), text, "Больше практики с уравнениями")
+}
+
+// Аналитический сервис недоступен: отчет по данным из базы, разделы тем
+// и рекомендаций без данных
+func (s *ReportServiceTestSuite) TestStudentReport_AnalyzerUnavailable() {
+	s.repoMock.On("GetAnalyticsByStudentID", s.ctx, uint64(7)).
+		Return(&domain.StudentAnalytics{StudentID: 7, ClusterGroup: "average", SuccessRate: 0.7, AnalyzedAt: s.opts.To}, nil)
+	s.analyzerMock.On("AnalyzeStudent", s.ctx, uint64(7)).Return(nil, errors.New("analytics service unavailable"))
+	s.repoMock.On("GetStudentByID", s.ctx, uint64(7)).Return(nil, nil)
+	s.repoMock.On("GetAnalyticsHistory", s.ctx, uint64(7), s.opts.From, s.opts.To).Return(nil, nil)
+
+	var buf bytes.Buffer
+	s.opts.Lang = domain.LangEN
+	require.NoError(s.T(), s.service.StudentReport(s.ctx, 7, s.opts, &buf))
+	assert.True(s.T(), bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")))
+	assert.Contains(s.T(), pdfText(s.T(), buf.Bytes()), "Topic efficiency\nNot enough data")
+}
+
+func (s *ReportServiceTestSuite) TestStudentReport_NotAnalyzed() {
+	s.notAnalyzed(s.ctx, 2)
+
+	var buf bytes.Buffer
+	err := s.service.StudentReport(s.ctx, 2, s.opts, &buf)
+
+	assert.True(s.T(), application.IsKind(err, application.KindNotFound))
+	assert.Zero(s.T(), buf.Len())
+}
+
+func (s *ReportServiceTestSuite) TestStudentReport_OutsideCourse() {
+	ctx := auth.WithSession(s.ctx, &domain.Session{CohortID: 3})
+	s.repoMock.On("IsStudentInCohort", ctx, uint64(3), uint64(9)).Return(false, nil)
+
+	err := s.service.StudentReport(ctx, 9, s.opts, io.Discard)
+
+	assert.True(s.T(), application.IsKind(err, application.KindForbidden))
+	s.repoMock.AssertNotCalled(s.T(), "GetAnalyticsByStudentID", mock.Anything, mock.Anything)
+}
+
+func (s *ReportServiceTestSuite) TestCohortReports_Zip() {
+	s.repoMock.On("GetCohortByID", s.ctx, uint64(3)).Return(&domain.Cohort{ID: 3, Name: "Алгебра"}, nil)
+	s.repoMock.On("GetCohortStudentIDs", s.ctx, uint64(3)).Return([]uint64{1, 2}, nil)
+	s.analyzed(s.ctx, 1)
+	s.notAnalyzed(s.ctx, 2)
+
+	var buf bytes.Buffer
+	require.NoError(s.T(), s.service.CohortReports(s.ctx, 3, s.opts, &buf))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(s.T(), err)
+	require.Len(s.T(), zr.File, 2)
+	assert.Equal(s.T(), "student_1.pdf", zr.File[0].Name)
+	assert.Equal(s.T(), "skipped.txt", zr.File[1].Name)
+
+	f, err := zr.File[1].Open()
+	require.NoError(s.T(), err)
+	skipped, _ := io.ReadAll(f)
+	assert.Equal(s.T(), "2\tnot analyzed yet\n", string(skipped))
+}
+
+func (s *ReportServiceTestSuite) TestCohortReports_NothingAnalyzed() {
+	s.repoMock.On("GetCohortByID", s.ctx, uint64(3)).Return(&domain.Cohort{ID: 3}, nil)
+	s.repoMock.On("GetCohortStudentIDs", s.ctx, uint64(3)).Return([]uint64{2}, nil)
+	s.notAnalyzed(s.ctx, 2)
+
+	var buf bytes.Buffer
+	err := s.service.CohortReports(s.ctx, 3, s.opts, &buf)
+
+	assert.True(s.T(), application.IsKind(err, application.KindNotFound))
+	assert.Zero(s.T(), buf.Len())
+}
+
+func (s *ReportServiceTestSuite) TestCohortReports_OtherCourse() {
+	ctx := auth.WithSession(s.ctx, &domain.Session{CohortID: 4})
+
+	err := s.service.CohortReports(ctx, 3, s.opts, io.Discard)
+
+	assert.True(s.T(), application.IsKind(err, application.KindForbidden))
+	s.repoMock.AssertNotCalled(s.T(), "GetCohortByID", mock.Anything, mock.Anything)
+}
+
+// pdfText распаковывает потоки PDF и переводит строки шрифта UTF-8
+// (fpdf пишет их в UTF-16BE) обратно в текст, чтобы по нему можно было
+// искать.
+func pdfText(t *testing.T, data []byte) string {
+	t.Helper()
+	var text strings.Builder
+	for _, m := range pdfStream.FindAllSubmatch(data, -1) {
+		zr, err := zlib.NewReader(bytes.NewReader(m[1]))
+		if err != nil {
+			continue
+		}
+		content, err := io.ReadAll(zr)
+		if err != nil {
+			continue
+		}
+		for _, s := range pdfString.FindAllSubmatch(content, -1) {
+			raw := pdfEscape.ReplaceAllFunc(s[1], func(e []byte) []byte {
+				if e[1] == 'r' {
+					return []byte{'\r'}
+				}
+				return e[1:]
+			})
+			units := make([]uint16, len(raw)/2)
+			for i := range units {
+				units[i] = binary.BigEndian.Uint16(raw[2*i:])
+			}
+			text.WriteString(string(utf16.Decode(units)))
+			text.WriteByte('\n')
+		}
+	}
+	return text.String()
+}
+
+var (
+	pdfStream = regexp.MustCompile(`(?s)stream\r?\n(.*?)\r?\nendstream`)
+	pdfString = regexp.MustCompile(`(?s)\(((?:\\.|[^\\)])*)\)\s*Tj`)
+	pdfEscape = regexp.MustCompile(`(?s)\\.`)
+)
+
+func TestReportService(t *testing.T) {
+	suite.Run(t, new(ReportServiceTestSuite))
+}
+
+func TestReportHandler(t *testing.T) {
+	reports := new(mocks.ReportService)
+	reports.On("StudentReport", mock.Anything, uint64(5), mock.MatchedBy(func(opts domain.ReportOptions) bool {
+		return opts.Lang == domain.LangEN
+	}), mock.Anything).
+		Run(func(args mock.Arguments) {
+			io.WriteString(args.Get(3).(io.Writer), "%PDF-1.3")
+		}).
+		Return(nil)
+	reports.On("CohortReports", mock.Anything, uint64(8), mock.Anything, mock.Anything).
+		Return(application.NotFound("cohort_not_found", "cohort 8 not found"))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	internal_http.SetupRoutes(router, internal_http.Handlers{
+		API:    internal_http.NewHTTPHandler(new(mocks.AnalyticsService)),
+		Health: internal_http.NewHealthHandler(new(mocks.HealthChecker)),
+		Report: internal_http.NewReportHandler(reports),
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/students/5/report.pdf?lang=en", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/pdf", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), `filename="student_5_report.pdf"`)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/cohorts/8/reports.zip", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), "cohort_not_found")
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/cohorts/x/reports.zip", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
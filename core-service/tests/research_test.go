@@ -0,0 +1,221 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	internal_http "github.com/RusselRustCode/teacher_analytics/core-service/internal/api/http"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/auth"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/mocks"
+)
+
+func researchPseudonym(key string, studentID string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(studentID))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+func readCSV(t *testing.T, data []byte) [][]string {
+	records, err := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff")))).ReadAll()
+	require.NoError(t, err)
+	return records
+}
+
+func TestResearchService_Export(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.Repository)
+	service := application.NewResearchService(repo, application.ResearchOptions{PseudonymKey: []byte("secret"), MinGroupSize: 5}, logging.Nop())
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	// Среда 16 сентября
+	at := time.Date(2026, 9, 16, 14, 35, 0, 0, time.UTC)
+
+	repo.On("GetCohortByID", ctx, uint64(2)).Return(&domain.Cohort{ID: 2, Name: "Группа 101"}, nil)
+	repo.On("GetCohortAggregates", ctx, uint64(2), from, to).Return([]*domain.CohortAggregate{
+		{CohortID: 2, ClusterGroup: "active", Students: 6, AvgEngagement: 72.5, AvgSuccessRate: 0.8, AvgTimePerTask: 40},
+		{CohortID: 2, ClusterGroup: "struggling", Students: 2, AvgEngagement: 20, AvgSuccessRate: 0.3, AvgTimePerTask: 95},
+	}, nil)
+	repo.On("StreamResearchLogs", ctx, uint64(2), from, to, mock.Anything).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(4).(func(*domain.StudentLog) error)
+			require.NoError(t, fn(&domain.StudentLog{StudentID: 5, ActionType: "quiz", Correct: true, TimeSpentSec: 30, Timestamp: at}))
+			require.NoError(t, fn(&domain.StudentLog{StudentID: 5, ActionType: "video", TimeSpentSec: 300, Timestamp: at}))
+			require.NoError(t, fn(&domain.StudentLog{StudentID: 8, ActionType: "quiz", Timestamp: at}))
+		}).Return(nil)
+	repo.On("StreamResearchAnalytics", ctx, uint64(2), from, to, mock.Anything).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(4).(func(*domain.ResearchAnalytics) error)
+			require.NoError(t, fn(&domain.ResearchAnalytics{
+				StudentAnalytics: domain.StudentAnalytics{StudentID: 5, ClusterGroup: "active", EngagementScore: 70, AnalyzedAt: at},
+				GroupSize:        6,
+			}))
+		}).Return(nil)
+
+	var buf bytes.Buffer
+	require.NoError(t, service.Export(ctx, domain.ResearchExportOptions{CohortID: 2, From: from, To: to}, &buf))
+	files := readZip(t, buf.Bytes())
+
+	logs := readCSV(t, files["logs.csv"])
+	require.Len(t, logs, 4)
+	assert.Equal(t, []string{"student", "period", "action_type", "correct", "time_spent_sec"}, logs[0])
+	assert.Equal(t, []string{researchPseudonym("secret", "5"), "2026-09-14", "quiz", "yes", "30"}, logs[1])
+	assert.Equal(t, logs[1][0], logs[2][0])
+	assert.NotEqual(t, logs[1][0], logs[3][0])
+
+	// Псевдоним один и тот же в логах и аналитике - таблицы можно связать
+	analytics := readCSV(t, files["analytics.csv"])
+	require.Len(t, analytics, 2)
+	assert.Equal(t, logs[1][0], analytics[1][0])
+
+	aggregates := readCSV(t, files["cohort_aggregates.csv"])
+	require.Len(t, aggregates, 2)
+	assert.Equal(t, []string{"2", "active", "6", "72.5", "0.8", "40"}, aggregates[1])
+
+	var manifest map[string]interface{}
+	require.NoError(t, json.Unmarshal(files["manifest.json"], &manifest))
+	assert.Equal(t, "week", manifest["granularity"])
+	assert.Equal(t, float64(1), manifest["suppressed_groups"])
+	assert.Equal(t, float64(2), manifest["suppressed_students"])
+	assert.Equal(t, float64(0), manifest["suppressed_analytics_rows"])
+
+	for name, data := range files {
+		assert.NotContains(t, string(data), "Группа 101", name)
+	}
+}
+
+// Кластер меньше k скрыт в агрегатах, и строки его студентов не должны
+// выдать его в analytics.csv.
+func TestResearchService_ExportSuppressesSmallGroupMicrodata(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.Repository)
+	service := application.NewResearchService(repo, application.ResearchOptions{PseudonymKey: []byte("secret"), MinGroupSize: 5}, logging.Nop())
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	at := time.Date(2026, 9, 16, 14, 35, 0, 0, time.UTC)
+
+	repo.On("GetCohortByID", ctx, uint64(2)).Return(&domain.Cohort{ID: 2}, nil)
+	repo.On("GetCohortAggregates", ctx, uint64(2), from, to).Return([]*domain.CohortAggregate{
+		{CohortID: 2, ClusterGroup: "active", Students: 5, AvgEngagement: 70},
+		{CohortID: 2, ClusterGroup: "struggling", Students: 1, AvgEngagement: 12},
+	}, nil)
+	repo.On("StreamResearchLogs", ctx, uint64(2), from, to, mock.Anything).Return(nil)
+	repo.On("StreamResearchAnalytics", ctx, uint64(2), from, to, mock.Anything).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(4).(func(*domain.ResearchAnalytics) error)
+			for id := uint64(1); id <= 5; id++ {
+				require.NoError(t, fn(&domain.ResearchAnalytics{
+					StudentAnalytics: domain.StudentAnalytics{StudentID: id, ClusterGroup: "active", EngagementScore: 70, AnalyzedAt: at},
+					GroupSize:        5,
+				}))
+			}
+			require.NoError(t, fn(&domain.ResearchAnalytics{
+				StudentAnalytics: domain.StudentAnalytics{StudentID: 6, ClusterGroup: "struggling", EngagementScore: 12, AnalyzedAt: at},
+				GroupSize:        1,
+			}))
+		}).Return(nil)
+
+	var buf bytes.Buffer
+	require.NoError(t, service.Export(ctx, domain.ResearchExportOptions{CohortID: 2, From: from, To: to}, &buf))
+	files := readZip(t, buf.Bytes())
+
+	analytics := readCSV(t, files["analytics.csv"])
+	require.Len(t, analytics, 6)
+	for _, row := range analytics[1:] {
+		assert.Equal(t, "active", row[2])
+		assert.NotEqual(t, researchPseudonym("secret", "6"), row[0])
+	}
+	aggregates := readCSV(t, files["cohort_aggregates.csv"])
+	require.Len(t, aggregates, 2)
+	assert.Equal(t, "active", aggregates[1][1])
+
+	var manifest map[string]interface{}
+	require.NoError(t, json.Unmarshal(files["manifest.json"], &manifest))
+	assert.Equal(t, float64(1), manifest["suppressed_analytics_rows"])
+}
+
+func TestResearchService_ExportGranularityAndAccess(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.Repository)
+	service := application.NewResearchService(repo, application.ResearchOptions{PseudonymKey: []byte("secret")}, logging.Nop())
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	err := service.Export(ctx, domain.ResearchExportOptions{From: from, To: to, Granularity: "hour"}, &bytes.Buffer{})
+	assert.True(t, application.IsKind(err, application.KindValidation))
+
+	// Преподавателю с сессией курса выгрузка по всем когортам закрыта
+	session := auth.WithSession(ctx, &domain.Session{Subject: "teacher-42", CohortID: 2})
+	err = service.Export(session, domain.ResearchExportOptions{From: from, To: to}, &bytes.Buffer{})
+	assert.True(t, application.IsKind(err, application.KindForbidden))
+
+	repo.On("GetCohortAggregates", ctx, uint64(0), from, to).Return(nil, nil)
+	repo.On("StreamResearchLogs", ctx, uint64(0), from, to, mock.Anything).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(4).(func(*domain.StudentLog) error)
+			require.NoError(t, fn(&domain.StudentLog{StudentID: 5, ActionType: "quiz", Timestamp: time.Date(2026, 9, 16, 23, 59, 0, 0, time.UTC)}))
+		}).Return(nil)
+	repo.On("StreamResearchAnalytics", ctx, uint64(0), from, to, mock.Anything).Return(nil)
+
+	var buf bytes.Buffer
+	require.NoError(t, service.Export(ctx, domain.ResearchExportOptions{From: from, To: to, Granularity: domain.GranularityMonth}, &buf))
+	logs := readCSV(t, readZip(t, buf.Bytes())["logs.csv"])
+	require.Len(t, logs, 2)
+	assert.Equal(t, "2026-09-01", logs[1][1])
+	repo.AssertNotCalled(t, "GetCohortByID", mock.Anything, mock.Anything)
+}
+
+func TestResearchHandler_Export(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	research := new(mocks.ResearchService)
+	audit := new(mocks.AuditService)
+	audit.On("Record", mock.Anything, mock.Anything).Return(nil)
+	router := gin.New()
+	internal_http.SetupRoutes(router, internal_http.Handlers{
+		API:      internal_http.NewHTTPHandler(new(mocks.AnalyticsService)),
+		Health:   internal_http.NewHealthHandler(new(mocks.HealthChecker)),
+		Research: internal_http.NewResearchHandler(research),
+		Audit:    internal_http.NewAuditHandler(audit, logging.Nop()),
+		LTI:      adminLTIHandler(),
+	})
+
+	research.On("Export", mock.Anything, mock.MatchedBy(func(opts domain.ResearchExportOptions) bool {
+		return opts.CohortID == 2 && opts.Granularity == "day" && opts.From.Equal(time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC))
+	}), mock.Anything).Return(nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, withSession(httptest.NewRequest(http.MethodGet, "/api/research/export?cohort_id=2&granularity=day&from=2026-09-01T00:00:00Z", nil), "admin"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	audit.AssertCalled(t, "Record", mock.Anything, auditEntryMatching(func(e *domain.AuditEntry) bool {
+		return e.Action == domain.AuditRead && e.Endpoint == "/api/research/export" && e.Status == http.StatusOK
+	}))
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, withSession(httptest.NewRequest(http.MethodGet, "/api/research/export?cohort_id=abc", nil), "admin"))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "invalid_cohort_id")
+
+	// Выгрузка по всему учреждению - не для преподавателя курса и не для анонима
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, withSession(httptest.NewRequest(http.MethodGet, "/api/research/export?cohort_id=3", nil), "teacher"))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/research/export", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	research.AssertNumberOfCalls(t, "Export", 1)
+}
@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/mocks"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/scheduler"
+)
+
+func TestReanalysisJob_TriggersAtBoundedRate(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.Repository)
+	analytics := new(mocks.AnalyticsService)
+	repo.On("LastJobRun", ctx, "reanalysis").Return(time.Time{}, nil)
+	repo.On("GetStudentsWithNewLogs", ctx, time.Time{}).Return([]uint64{1, 2, 3}, nil)
+	repo.On("RecordJobRun", ctx, "reanalysis", mock.AnythingOfType("time.Time")).Return(nil)
+	analytics.On("TriggerAnalysis", ctx, mock.AnythingOfType("uint64")).Return(nil)
+
+	job := application.NewReanalysisJob(repo, analytics, 50, logging.Nop())
+	start := time.Now()
+	require.NoError(t, job.Run(ctx))
+
+	// 3 команды при 50/с: между ними два интервала по 20ms
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+	analytics.AssertNumberOfCalls(t, "TriggerAnalysis", 3)
+}
+
+func TestReanalysisJob_ContinuesAfterFailure(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.Repository)
+	analytics := new(mocks.AnalyticsService)
+	repo.On("LastJobRun", ctx, "reanalysis").Return(time.Time{}, nil)
+	repo.On("GetStudentsWithNewLogs", ctx, time.Time{}).Return([]uint64{1, 2}, nil)
+	analytics.On("TriggerAnalysis", ctx, uint64(1)).Return(application.Unavailable("analysis_unavailable", "kafka down", errors.New("eof")))
+	analytics.On("TriggerAnalysis", ctx, uint64(2)).Return(nil)
+
+	err := application.NewReanalysisJob(repo, analytics, 0, logging.Nop()).Run(ctx)
+
+	assert.EqualError(t, err, "failed to trigger analysis for 1 of 2 students")
+	analytics.AssertCalled(t, "TriggerAnalysis", ctx, uint64(2))
+	// Неудачный запуск не сдвигает отметку: студент 1 попадет в следующий
+	repo.AssertNotCalled(t, "RecordJobRun", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReanalysisJob_StartsFromLastRun(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.Repository)
+	analytics := new(mocks.AnalyticsService)
+	lastRun := time.Date(2026, 10, 18, 2, 0, 0, 0, time.UTC)
+	repo.On("LastJobRun", ctx, "reanalysis").Return(lastRun, nil)
+	// Другая реплика только что отправила всех: новых логов с ее запуска нет
+	repo.On("GetStudentsWithNewLogs", ctx, lastRun.Add(-time.Minute)).Return(nil, nil)
+	repo.On("RecordJobRun", ctx, "reanalysis", mock.MatchedBy(func(started time.Time) bool {
+		return time.Since(started) < time.Second
+	})).Return(nil)
+
+	require.NoError(t, application.NewReanalysisJob(repo, analytics, 0, logging.Nop()).Run(ctx))
+	analytics.AssertNotCalled(t, "TriggerAnalysis", mock.Anything, mock.Anything)
+	repo.AssertExpectations(t)
+}
+
+func TestReanalysisJob_StopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	repo := new(mocks.Repository)
+	analytics := new(mocks.AnalyticsService)
+	repo.On("LastJobRun", ctx, "reanalysis").Return(time.Time{}, nil)
+	repo.On("GetStudentsWithNewLogs", ctx, time.Time{}).Return([]uint64{1, 2, 3}, nil)
+	analytics.On("TriggerAnalysis", ctx, uint64(1)).Run(func(mock.Arguments) { cancel() }).Return(nil)
+
+	err := application.NewReanalysisJob(repo, analytics, 1, logging.Nop()).Run(ctx)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	analytics.AssertNumberOfCalls(t, "TriggerAnalysis", 1)
+}
+
+func TestSchedulerRunJob_UsesLock(t *testing.T) {
+	ctx := context.Background()
+	locker := new(mocks.Locker)
+	sched := scheduler.New(locker, logging.Nop())
+
+	ran := false
+	locker.On("TryLock", ctx, "scheduler:reanalysis", mock.Anything).
+		Return(func(ctx context.Context, _ string, fn func(context.Context) error) (bool, error) {
+			return true, fn(ctx)
+		}).Once()
+	sched.RunJob(ctx, "reanalysis", func(context.Context) error { ran = true; return nil })
+	assert.True(t, ran)
+
+	// Другая реплика держит блокировку: задача не выполняется
+	ran = false
+	locker.On("TryLock", ctx, "scheduler:reanalysis", mock.Anything).Return(false, nil).Once()
+	sched.RunJob(ctx, "reanalysis", func(context.Context) error { ran = true; return nil })
+	assert.False(t, ran)
+	locker.AssertExpectations(t)
+}
+
+func TestSchedulerAdd_RejectsInvalidSpec(t *testing.T) {
+	sched := scheduler.New(new(mocks.Locker), logging.Nop())
+
+	assert.NoError(t, sched.Add("reanalysis", "CRON_TZ=Europe/Moscow 0 2 * * *", func(context.Context) error { return nil }))
+	assert.Error(t, sched.Add("reanalysis", "every night", func(context.Context) error { return nil }))
+}
@@ -0,0 +1,206 @@
+package tests
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	google_grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	internal_grpc "github.com/RusselRustCode/teacher_analytics/core-service/internal/api/grpc"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/infrastructure/certs"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/infrastructure/grpc"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/mocks"
+	"github.com/RusselRustCode/teacher_analytics/core-service/proto"
+)
+
+// testCA выпускает сертификаты для тестов на лету.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// issue выпускает листовой сертификат и возвращает PEM сертификата и ключа.
+func (ca *testCA) issue(t *testing.T, serial int64, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+type TLSTestSuite struct {
+	suite.Suite
+	dir string
+	ca  *testCA
+}
+
+func (s *TLSTestSuite) SetupTest() {
+	s.dir = s.T().TempDir()
+	s.ca = newTestCA(s.T())
+	s.write("ca.pem", s.ca.pem)
+}
+
+func (s *TLSTestSuite) write(name string, data []byte) string {
+	path := filepath.Join(s.dir, name)
+	require.NoError(s.T(), os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func (s *TLSTestSuite) writePair(prefix string, serial int64, usage x509.ExtKeyUsage) (string, string) {
+	certPEM, keyPEM := s.ca.issue(s.T(), serial, usage)
+	return s.write(prefix+".pem", certPEM), s.write(prefix+"-key.pem", keyPEM)
+}
+
+func (s *TLSTestSuite) startGRPCServer(reloader *certs.Reloader) string {
+	healthMock := new(mocks.HealthChecker)
+	healthMock.On("Last").Return(&domain.HealthReport{Healthy: true})
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(s.T(), err)
+
+	server := google_grpc.NewServer(google_grpc.Creds(credentials.NewTLS(reloader.ServerConfig(tls.RequireAndVerifyClientCert))))
+	proto.RegisterAnalyticsServiceServer(server, internal_grpc.NewGRPCHandler(new(mocks.AnalyticsService), healthMock))
+	go server.Serve(lis)
+	s.T().Cleanup(server.Stop)
+
+	return lis.Addr().String()
+}
+
+func (s *TLSTestSuite) TestMutualTLSHealthCheck() {
+	certFile, keyFile := s.writePair("server", 10, x509.ExtKeyUsageServerAuth)
+	serverCerts, err := certs.NewReloader(certFile, keyFile, filepath.Join(s.dir, "ca.pem"), time.Second, logging.Nop())
+	require.NoError(s.T(), err)
+	addr := s.startGRPCServer(serverCerts)
+
+	certFile, keyFile = s.writePair("client", 20, x509.ExtKeyUsageClientAuth)
+	clientCerts, err := certs.NewReloader(certFile, keyFile, filepath.Join(s.dir, "ca.pem"), time.Second, logging.Nop())
+	require.NoError(s.T(), err)
+
+	client, err := grpc.NewGRPCAnalyticsClient(addr, grpc.ClientOptions{
+		CallTimeout: 5 * time.Second,
+		TLS:         clientCerts.ClientConfig("localhost"),
+	})
+	require.NoError(s.T(), err)
+	defer client.Close()
+
+	assert.NoError(s.T(), client.HealthCheck(context.Background()))
+}
+
+func (s *TLSTestSuite) TestServerRejectsClientWithoutCertificate() {
+	certFile, keyFile := s.writePair("server", 10, x509.ExtKeyUsageServerAuth)
+	serverCerts, err := certs.NewReloader(certFile, keyFile, filepath.Join(s.dir, "ca.pem"), time.Second, logging.Nop())
+	require.NoError(s.T(), err)
+	addr := s.startGRPCServer(serverCerts)
+
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(s.ca.pem)
+
+	client, err := grpc.NewGRPCAnalyticsClient(addr, grpc.ClientOptions{
+		CallTimeout: 5 * time.Second,
+		TLS:         &tls.Config{RootCAs: pool, ServerName: "localhost"},
+	})
+	require.NoError(s.T(), err)
+	defer client.Close()
+
+	assert.Error(s.T(), client.HealthCheck(context.Background()))
+}
+
+func (s *TLSTestSuite) TestServerCertificateHotReload() {
+	certFile, keyFile := s.writePair("server", 10, x509.ExtKeyUsageServerAuth)
+	serverCerts, err := certs.NewReloader(certFile, keyFile, "", 0, logging.Nop())
+	require.NoError(s.T(), err)
+
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", serverCerts.ServerConfig(tls.NoClientCert))
+	require.NoError(s.T(), err)
+	defer lis.Close()
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(s.ca.pem)
+	servedSerial := func() int64 {
+		conn, err := tls.Dial("tcp", lis.Addr().String(), &tls.Config{RootCAs: pool, ServerName: "localhost"})
+		require.NoError(s.T(), err)
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+	}
+
+	assert.Equal(s.T(), int64(10), servedSerial())
+
+	s.writePair("server", 11, x509.ExtKeyUsageServerAuth)
+	future := time.Now().Add(time.Minute)
+	require.NoError(s.T(), os.Chtimes(certFile, future, future))
+
+	assert.Equal(s.T(), int64(11), servedSerial())
+}
+
+func TestTLSSuite(t *testing.T) {
+	suite.Run(t, new(TLSTestSuite))
+}
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/infrastructure/kafka"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/tracing"
+)
+
+// capturingWriter запоминает сообщения вместо отправки в брокер.
+type capturingWriter struct {
+	messages []kafkago.Message
+}
+
+func (w *capturingWriter) WriteMessages(_ context.Context, msgs ...kafkago.Message) error {
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func (w *capturingWriter) Close() error { return nil }
+
+func TestKafkaHeadersCarryTraceContext(t *testing.T) {
+	_, err := tracing.Setup(context.Background(), tracing.Options{Exporter: tracing.ExporterNone})
+	require.NoError(t, err)
+
+	provider := sdktrace.NewTracerProvider()
+	defer provider.Shutdown(context.Background())
+
+	ctx, span := provider.Tracer("test").Start(context.Background(), "POST /api/log")
+	defer span.End()
+
+	var headers []kafkago.Header
+	otel.GetTextMapPropagator().Inject(ctx, kafka.HeaderCarrier{Headers: &headers})
+
+	require.NotEmpty(t, headers)
+	assert.Equal(t, "traceparent", headers[0].Key)
+
+	extracted := otel.GetTextMapPropagator().Extract(context.Background(), kafka.HeaderCarrier{Headers: &headers})
+	remote := trace.SpanContextFromContext(extracted)
+	assert.True(t, remote.IsRemote())
+	assert.Equal(t, span.SpanContext().TraceID(), remote.TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), remote.SpanID())
+}
+
+func TestKafkaProducerSendPropagatesTraceContext(t *testing.T) {
+	_, err := tracing.Setup(context.Background(), tracing.Options{Exporter: tracing.ExporterNone})
+	require.NoError(t, err)
+
+	provider := sdktrace.NewTracerProvider()
+	defer provider.Shutdown(context.Background())
+
+	ctx, span := provider.Tracer("test").Start(context.Background(), "POST /api/log")
+	defer span.End()
+	ctx = logging.WithRequestID(ctx, "req-1")
+
+	writer := &capturingWriter{}
+	producer := kafka.NewKafkaProducerWithWriter(writer, nil)
+	require.NoError(t, producer.Send(ctx, "student_logs", []byte("1"), []byte(`{}`)))
+
+	require.Len(t, writer.messages, 1)
+	message := writer.messages[0]
+	assert.Equal(t, "student_logs", message.Topic)
+	carrier := kafka.HeaderCarrier{Headers: &message.Headers}
+	require.NotEmpty(t, carrier.Get("traceparent"))
+	assert.Equal(t, "req-1", carrier.Get(logging.RequestIDHeader))
+
+	extracted := otel.GetTextMapPropagator().Extract(context.Background(), carrier)
+	remote := trace.SpanContextFromContext(extracted)
+	assert.True(t, remote.IsRemote())
+	assert.Equal(t, span.SpanContext().TraceID(), remote.TraceID())
+}
@@ -0,0 +1,343 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	internal_http "github.com/RusselRustCode/teacher_analytics/core-service/internal/api/http"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/mocks"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/webhook"
+)
+
+const testWebhookSecret = "0123456789abcdef-secret"
+
+type WebhookTestSuite struct {
+	suite.Suite
+	ctx      context.Context
+	repoMock *mocks.Repository
+	service  *application.WebhookServiceImpl
+
+	server   *httptest.Server
+	status   atomic.Int32
+	received chan *http.Request
+	bodies   chan []byte
+}
+
+func (s *WebhookTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.repoMock = new(mocks.Repository)
+	s.service = application.NewWebhookService(s.repoMock, application.WebhookOptions{}, logging.Nop())
+
+	s.status.Store(http.StatusOK)
+	s.received = make(chan *http.Request, 10)
+	s.bodies = make(chan []byte, 10)
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.received <- r
+		s.bodies <- body
+		w.WriteHeader(int(s.status.Load()))
+	}))
+}
+
+func (s *WebhookTestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+// dispatcher доставляет на тестовый сервер, а он слушает loopback.
+func (s *WebhookTestSuite) dispatcher() *application.WebhookDispatcher {
+	return application.NewWebhookDispatcher(s.repoMock, webhook.NewSender(time.Second, true), application.WebhookDispatcherOptions{
+		MaxAttempts:    3,
+		InitialBackoff: 10 * time.Second,
+		MaxBackoff:     15 * time.Second,
+		PollInterval:   time.Second,
+		Timeout:        time.Second,
+	}, logging.Nop())
+}
+
+func (s *WebhookTestSuite) task(attempts int) *domain.WebhookDeliveryTask {
+	return &domain.WebhookDeliveryTask{
+		Delivery: &domain.WebhookDelivery{
+			ID:             7,
+			SubscriptionID: 2,
+			Event:          domain.EventAnalyticsUpdated,
+			Payload:        json.RawMessage(`{"event":"analytics.updated","data":{"student_id":5}}`),
+			Status:         domain.DeliveryPending,
+			Attempts:       attempts,
+		},
+		URL:    s.server.URL,
+		Secret: testWebhookSecret,
+	}
+}
+
+func (s *WebhookTestSuite) TestCreateSubscription_Invalid() {
+	err := s.service.CreateSubscription(s.ctx, &domain.WebhookSubscription{
+		URL:    "ftp://lms.example.edu/hook",
+		Events: []string{domain.EventAlertRaised, "student.deleted"},
+		Secret: "short",
+	})
+
+	appErr, ok := application.AsError(err)
+	require.True(s.T(), ok)
+	var fields []string
+	for _, f := range appErr.Fields {
+		fields = append(fields, f.Field+":"+f.Code)
+	}
+	assert.Equal(s.T(), []string{"url:invalid_format", "events:unknown_value", "secret:too_short"}, fields)
+}
+
+func (s *WebhookTestSuite) TestCreateSubscription_RejectsInternalDestinations() {
+	for _, u := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://10.0.0.5/hook",
+		"https://172.16.4.1/hook",
+		"http://192.168.1.10/hook",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://[::1]/hook",
+		"http://[fd00:ec2::254]/hook",
+		"http://[::ffff:10.0.0.1]/hook",
+	} {
+		err := s.service.CreateSubscription(s.ctx, &domain.WebhookSubscription{URL: u, Events: []string{domain.EventAlertRaised}})
+		appErr, ok := application.AsError(err)
+		require.True(s.T(), ok, u)
+		require.Len(s.T(), appErr.Fields, 1, u)
+		assert.Equal(s.T(), "forbidden_destination", appErr.Fields[0].Code, u)
+	}
+	s.repoMock.AssertNotCalled(s.T(), "CreateWebhookSubscription", mock.Anything, mock.Anything)
+
+	// Для разработки внутреннюю сеть можно разрешить
+	s.repoMock.On("CreateWebhookSubscription", s.ctx, mock.Anything).Return(nil)
+	service := application.NewWebhookService(s.repoMock, application.WebhookOptions{AllowPrivateNetworks: true}, logging.Nop())
+	require.NoError(s.T(), service.CreateSubscription(s.ctx, &domain.WebhookSubscription{
+		URL: "http://10.0.0.5/hook", Events: []string{domain.EventAlertRaised},
+	}))
+}
+
+// Адрес проверяется и при соединении: имя, которое после создания подписки
+// стало указывать во внутреннюю сеть, не пройдет.
+func (s *WebhookTestSuite) TestSender_RefusesInternalAddressAtDial() {
+	task := s.task(0)
+	task.URL = strings.Replace(s.server.URL, "127.0.0.1", "localhost", 1)
+
+	code, err := webhook.NewSender(time.Second, false).Send(s.ctx, task)
+	require.ErrorIs(s.T(), err, webhook.ErrForbiddenDestination)
+	assert.Zero(s.T(), code)
+	assert.Empty(s.T(), s.received)
+}
+
+func (s *WebhookTestSuite) TestCreateSubscription_GeneratesSecret() {
+	s.repoMock.On("CreateWebhookSubscription", s.ctx, mock.AnythingOfType("*domain.WebhookSubscription")).Return(nil)
+
+	sub := &domain.WebhookSubscription{
+		URL:    "https://lms.example.edu/hook",
+		Events: []string{domain.EventLogIngested, domain.EventAlertRaised, domain.EventLogIngested},
+	}
+	require.NoError(s.T(), s.service.CreateSubscription(s.ctx, sub))
+	assert.Len(s.T(), sub.Secret, 64)
+	assert.Equal(s.T(), []string{domain.EventAlertRaised, domain.EventLogIngested}, sub.Events)
+}
+
+func (s *WebhookTestSuite) TestPublish_EnqueuesEnvelope() {
+	var payload []byte
+	s.repoMock.On("EnqueueWebhookDeliveries", s.ctx, domain.EventAlertRaised, "", mock.Anything).
+		Run(func(args mock.Arguments) { payload = args.Get(3).([]byte) }).
+		Return(2, nil)
+
+	// Оповещение уходит подписчикам как канал доставки
+	require.NoError(s.T(), s.service.Notify(s.ctx, &domain.Alert{ID: 3, StudentID: 5}))
+
+	var got struct {
+		Event      string       `json:"event"`
+		OccurredAt time.Time    `json:"occurred_at"`
+		Data       domain.Alert `json:"data"`
+	}
+	require.NoError(s.T(), json.Unmarshal(payload, &got))
+	assert.Equal(s.T(), domain.EventAlertRaised, got.Event)
+	assert.Equal(s.T(), uint64(5), got.Data.StudentID)
+	assert.WithinDuration(s.T(), time.Now(), got.OccurredAt, time.Minute)
+}
+
+// NOTIFY о сохраненном анализе приходит всем репликам: каждая ставит
+// доставку с одним и тем же ключом, и в очереди остается одна
+func (s *WebhookTestSuite) TestAnalyticsUpdated_SameKeyOnEveryReplica() {
+	analyzedAt := time.Date(2026, 10, 19, 9, 30, 0, 123000, time.UTC)
+	s.repoMock.On("GetAnalyticsByStudentID", s.ctx, uint64(5)).
+		Return(&domain.StudentAnalytics{StudentID: 5, AnalyzedAt: analyzedAt}, nil)
+	s.repoMock.On("EnqueueWebhookDeliveries", s.ctx, domain.EventAnalyticsUpdated, "5@2026-10-19T09:30:00.000123Z", mock.Anything).
+		Return(1, nil).Once()
+	s.repoMock.On("EnqueueWebhookDeliveries", s.ctx, domain.EventAnalyticsUpdated, "5@2026-10-19T09:30:00.000123Z", mock.Anything).
+		Return(0, nil).Once()
+
+	require.NoError(s.T(), s.service.AnalyticsUpdated(s.ctx, 5))
+	require.NoError(s.T(), s.service.AnalyticsUpdated(s.ctx, 5))
+	s.repoMock.AssertExpectations(s.T())
+}
+
+func (s *WebhookTestSuite) TestAnalyticsUpdated_SkipsUnanalyzed() {
+	s.repoMock.On("GetAnalyticsByStudentID", s.ctx, uint64(5)).Return(nil, nil)
+
+	require.NoError(s.T(), s.service.AnalyticsUpdated(s.ctx, 5))
+	s.repoMock.AssertNotCalled(s.T(), "EnqueueWebhookDeliveries", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *WebhookTestSuite) TestDispatcher_DeliversSignedPayload() {
+	task := s.task(0)
+	s.repoMock.On("ClaimWebhookDeliveries", s.ctx, 50, 50*time.Second+time.Minute).Return([]*domain.WebhookDeliveryTask{task}, nil)
+	s.repoMock.On("UpdateWebhookDelivery", s.ctx, task).Return(true, nil)
+
+	n, err := s.dispatcher().DeliverDue(s.ctx)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, n)
+
+	r, body := <-s.received, <-s.bodies
+	assert.Equal(s.T(), domain.EventAnalyticsUpdated, r.Header.Get(webhook.EventHeader))
+	assert.Equal(s.T(), "7", r.Header.Get(webhook.DeliveryHeader))
+	assert.JSONEq(s.T(), string(task.Delivery.Payload), string(body))
+	ts, err := strconv.ParseInt(r.Header.Get(webhook.TimestampHeader), 10, 64)
+	require.NoError(s.T(), err)
+	signature := r.Header.Get(webhook.SignatureHeader)
+	assert.True(s.T(), strings.HasPrefix(signature, "sha256="))
+	assert.True(s.T(), webhook.Verify(testWebhookSecret, signature, ts, body, time.Now(), 5*time.Minute))
+	assert.False(s.T(), webhook.Verify("another-secret-value", signature, ts, body, time.Now(), 5*time.Minute))
+
+	d := task.Delivery
+	assert.Equal(s.T(), domain.DeliverySucceeded, d.Status)
+	assert.Equal(s.T(), 1, d.Attempts)
+	assert.Equal(s.T(), http.StatusOK, d.LastStatusCode)
+	assert.NotNil(s.T(), d.DeliveredAt)
+}
+
+func (s *WebhookTestSuite) TestDispatcher_RetriesWithBackoffThenFails() {
+	s.status.Store(http.StatusServiceUnavailable)
+
+	cases := []struct {
+		attempts int
+		status   domain.WebhookDeliveryStatus
+		wait     time.Duration
+	}{
+		{attempts: 0, status: domain.DeliveryPending, wait: 10 * time.Second},
+		// 20s урезается до MaxBackoff
+		{attempts: 1, status: domain.DeliveryPending, wait: 15 * time.Second},
+		{attempts: 2, status: domain.DeliveryFailed},
+	}
+	for _, tc := range cases {
+		task := s.task(tc.attempts)
+		s.repoMock = new(mocks.Repository)
+		s.repoMock.On("ClaimWebhookDeliveries", s.ctx, mock.Anything, mock.Anything).Return([]*domain.WebhookDeliveryTask{task}, nil)
+		s.repoMock.On("UpdateWebhookDelivery", s.ctx, task).Return(true, nil)
+
+		_, err := s.dispatcher().DeliverDue(s.ctx)
+		require.NoError(s.T(), err)
+		<-s.received
+		<-s.bodies
+
+		d := task.Delivery
+		assert.Equal(s.T(), tc.status, d.Status, "attempt %d", tc.attempts+1)
+		assert.Equal(s.T(), tc.attempts+1, d.Attempts)
+		assert.Equal(s.T(), http.StatusServiceUnavailable, d.LastStatusCode)
+		assert.Equal(s.T(), "webhook responded with status 503", d.LastError)
+		if tc.wait > 0 {
+			assert.WithinDuration(s.T(), time.Now().Add(tc.wait), d.NextAttemptAt, 2*time.Second)
+		}
+		assert.Nil(s.T(), d.DeliveredAt)
+	}
+}
+
+// Пока запрос шел, lease истек и доставку взяла другая реплика: результат
+// не записывается, а пачка продолжается
+func (s *WebhookTestSuite) TestDispatcher_LostClaimDoesNotFailBatch() {
+	lost, next := s.task(0), s.task(0)
+	next.Delivery.ID = 8
+	s.repoMock.On("ClaimWebhookDeliveries", s.ctx, 50, mock.Anything).Return([]*domain.WebhookDeliveryTask{lost, next}, nil)
+	s.repoMock.On("UpdateWebhookDelivery", s.ctx, lost).Return(false, nil)
+	s.repoMock.On("UpdateWebhookDelivery", s.ctx, next).Return(true, nil)
+
+	n, err := s.dispatcher().DeliverDue(s.ctx)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 2, n)
+	for range 2 {
+		<-s.received
+		<-s.bodies
+	}
+	s.repoMock.AssertExpectations(s.T())
+}
+
+func (s *WebhookTestSuite) TestRedeliver() {
+	s.repoMock.On("GetWebhookDelivery", s.ctx, uint64(8)).Return(nil, nil)
+	_, err := s.service.Redeliver(s.ctx, 8)
+	assert.True(s.T(), application.IsKind(err, application.KindNotFound))
+
+	s.repoMock.On("GetWebhookDelivery", s.ctx, uint64(7)).Return(&domain.WebhookDelivery{ID: 7, Status: domain.DeliveryFailed}, nil).Once()
+	s.repoMock.On("RequeueWebhookDelivery", s.ctx, uint64(7), mock.AnythingOfType("time.Time")).Return(nil)
+	s.repoMock.On("GetWebhookDelivery", s.ctx, uint64(7)).Return(&domain.WebhookDelivery{ID: 7, Status: domain.DeliveryPending}, nil).Once()
+
+	d, err := s.service.Redeliver(s.ctx, 7)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), domain.DeliveryPending, d.Status)
+}
+
+func TestWebhooks(t *testing.T) {
+	suite.Run(t, new(WebhookTestSuite))
+}
+
+func TestWebhookHandler(t *testing.T) {
+	webhooks := new(mocks.WebhookService)
+	webhooks.On("CreateSubscription", mock.Anything, mock.MatchedBy(func(sub *domain.WebhookSubscription) bool {
+		return sub.URL == "https://lms.example.edu/hook"
+	})).Run(func(args mock.Arguments) {
+		sub := args.Get(1).(*domain.WebhookSubscription)
+		sub.ID, sub.Secret = 1, "generated-secret-value"
+	}).Return(nil)
+	webhooks.On("ListDeliveries", mock.Anything, domain.WebhookDeliveryFilter{SubscriptionID: 1, Status: domain.DeliveryFailed}).
+		Return(nil, nil)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	internal_http.SetupRoutes(router, internal_http.Handlers{
+		API:     internal_http.NewHTTPHandler(new(mocks.AnalyticsService)),
+		Health:  internal_http.NewHealthHandler(new(mocks.HealthChecker)),
+		Webhook: internal_http.NewWebhookHandler(webhooks),
+		LTI:     adminLTIHandler(),
+	})
+	body := `{"url":"https://lms.example.edu/hook","events":["analytics.updated"]}`
+
+	// Подписки видят события всех курсов: анониму и преподавателю закрыто
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/webhooks", strings.NewReader(body)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, withSession(httptest.NewRequest(http.MethodPost, "/api/webhooks", strings.NewReader(body)), "teacher"))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	webhooks.AssertNotCalled(t, "CreateSubscription", mock.Anything, mock.Anything)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, withSession(httptest.NewRequest(http.MethodPost, "/api/webhooks", strings.NewReader(body)), "admin"))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"secret":"generated-secret-value"`)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, withSession(httptest.NewRequest(http.MethodGet, "/api/webhooks/1/deliveries?status=failed", nil), "admin"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[]`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, withSession(httptest.NewRequest(http.MethodPost, "/api/webhook-deliveries/x/redeliver", nil), "admin"))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,201 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	internal_http "github.com/RusselRustCode/teacher_analytics/core-service/internal/api/http"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/application"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/domain"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/interfaces"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/logging"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/mocks"
+	"github.com/RusselRustCode/teacher_analytics/core-service/internal/xapi"
+)
+
+const (
+	answeredStatement = `{
+		"id": "6fbd600f-d17c-4c74-801a-2ec2e53231c9",
+		"actor": {"account": {"homePage": "https://lms", "name": "12"}},
+		"verb": {"id": "http://adlnet.gov/expapi/verbs/answered"},
+		"object": {"id": "https://lms/q/7"},
+		"result": {"success": true, "duration": "PT1M30S"},
+		"timestamp": "2026-01-10T09:00:00Z"
+	}`
+	// Глагол не сопоставлен с действием: statement хранится, лога нет
+	likedStatement = `{
+		"actor": {"account": {"homePage": "https://lms", "name": "12"}},
+		"verb": {"id": "https://example.com/verbs/liked"},
+		"object": {"id": "https://lms/lesson/1"}
+	}`
+)
+
+type XAPIServiceTestSuite struct {
+	suite.Suite
+	ctx           context.Context
+	repoMock      *mocks.Repository
+	analyticsMock *mocks.AnalyticsService
+	service       interfaces.XAPIService
+}
+
+func (s *XAPIServiceTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.repoMock = new(mocks.Repository)
+	s.analyticsMock = new(mocks.AnalyticsService)
+	s.service = application.NewXAPIService(s.repoMock, s.analyticsMock, xapi.NewMapper(xapi.DefaultMapping()), logging.Nop())
+}
+
+func (s *XAPIServiceTestSuite) TestSaveStatements_StoresRawAndSendsLog() {
+	s.repoMock.On("SaveXAPIStatement", s.ctx, mock.MatchedBy(func(st *domain.XAPIStatement) bool {
+		return st.ID == "6fbd600f-d17c-4c74-801a-2ec2e53231c9" && st.StudentID == 12 &&
+			st.ActionType == domain.ActionAnswerQuestion && strings.Contains(string(st.Raw), "PT1M30S")
+	})).Return(true, nil)
+	s.repoMock.On("SaveXAPIStatement", s.ctx, mock.MatchedBy(func(st *domain.XAPIStatement) bool {
+		return st.VerbID == "https://example.com/verbs/liked" && st.ActionType == "" && xapi.ValidID(st.ID) &&
+			strings.Contains(string(st.Raw), st.ID)
+	})).Return(false, nil)
+	s.analyticsMock.On("SendLog", s.ctx, mock.MatchedBy(func(l *domain.StudentLog) bool {
+		return l.StudentID == 12 && l.Correct && l.Attempts == 1 && l.TimeSpentOnQuestion == 90
+	})).Return(nil).Once()
+	s.repoMock.On("MarkXAPILogSent", s.ctx, "6fbd600f-d17c-4c74-801a-2ec2e53231c9").Return(nil).Once()
+
+	ids, err := s.service.SaveStatements(s.ctx, []json.RawMessage{
+		json.RawMessage(answeredStatement),
+		json.RawMessage(likedStatement),
+	})
+	require.NoError(s.T(), err)
+
+	require.Len(s.T(), ids, 2)
+	assert.Equal(s.T(), "6fbd600f-d17c-4c74-801a-2ec2e53231c9", ids[0])
+	s.analyticsMock.AssertExpectations(s.T())
+	s.repoMock.AssertExpectations(s.T())
+}
+
+func (s *XAPIServiceTestSuite) TestSaveStatements_RepeatedStatementIsNotResent() {
+	s.repoMock.On("SaveXAPIStatement", s.ctx, mock.Anything).Return(false, nil)
+
+	ids, err := s.service.SaveStatements(s.ctx, []json.RawMessage{json.RawMessage(answeredStatement)})
+	require.NoError(s.T(), err)
+
+	assert.Len(s.T(), ids, 1)
+	s.analyticsMock.AssertNotCalled(s.T(), "SendLog", mock.Anything, mock.Anything)
+}
+
+// Statement сохранился, а лог не отправился: повтор пакета досылает лог,
+// хотя сам statement уже лежит в базе
+func (s *XAPIServiceTestSuite) TestSaveStatements_RetrySendsPendingLog() {
+	s.repoMock.On("SaveXAPIStatement", s.ctx, mock.Anything).Return(true, nil)
+	s.analyticsMock.On("SendLog", s.ctx, mock.Anything).
+		Return(application.Unavailable("event_bus_unavailable", "failed to publish student log", nil)).Once()
+
+	_, err := s.service.SaveStatements(s.ctx, []json.RawMessage{json.RawMessage(answeredStatement)})
+	assert.True(s.T(), application.IsKind(err, application.KindUnavailable))
+	s.repoMock.AssertNotCalled(s.T(), "MarkXAPILogSent", mock.Anything, mock.Anything)
+
+	s.analyticsMock.On("SendLog", s.ctx, mock.Anything).Return(nil).Once()
+	s.repoMock.On("MarkXAPILogSent", s.ctx, "6fbd600f-d17c-4c74-801a-2ec2e53231c9").Return(nil).Once()
+	ids, err := s.service.SaveStatements(s.ctx, []json.RawMessage{json.RawMessage(answeredStatement)})
+	require.NoError(s.T(), err)
+
+	assert.Len(s.T(), ids, 1)
+	s.analyticsMock.AssertNumberOfCalls(s.T(), "SendLog", 2)
+	s.repoMock.AssertExpectations(s.T())
+}
+
+func (s *XAPIServiceTestSuite) TestSaveStatements_InvalidBatchStoresNothing() {
+	noStudent := `{"actor": {"mbox": "mailto:a@example.com"}, "verb": {"id": "http://adlnet.gov/expapi/verbs/answered"}, "object": {"id": "q"}}`
+	longAnswer := `{"actor": {"account": {"name": "3"}}, "verb": {"id": "http://adlnet.gov/expapi/verbs/answered"},
+		"object": {"id": "q"}, "result": {"duration": "PT5H"}}`
+
+	_, err := s.service.SaveStatements(s.ctx, []json.RawMessage{
+		json.RawMessage(answeredStatement),
+		json.RawMessage(noStudent),
+		json.RawMessage(longAnswer),
+		json.RawMessage(answeredStatement),
+	})
+
+	appErr, ok := application.AsError(err)
+	require.True(s.T(), ok)
+	fields := make([]string, len(appErr.Fields))
+	for i, f := range appErr.Fields {
+		fields[i] = f.Field
+	}
+	assert.Contains(s.T(), fields, "statements[1].statement")
+	assert.Contains(s.T(), fields, "statements[2].result.duration")
+	assert.Contains(s.T(), fields, "statements[3].id")
+	assert.Len(s.T(), fields, 3)
+	s.repoMock.AssertNotCalled(s.T(), "SaveXAPIStatement", mock.Anything, mock.Anything)
+}
+
+func TestXAPIService(t *testing.T) {
+	suite.Run(t, new(XAPIServiceTestSuite))
+}
+
+func TestXAPILoadMapping(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapping.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"verbs": {
+			"https://example.com/verbs/liked": "view_material",
+			"http://adlnet.gov/expapi/verbs/completed": ""
+		}
+	}`), 0o600))
+
+	mapping, err := xapi.LoadMapping(path)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ActionViewMaterial, mapping.Verbs["https://example.com/verbs/liked"])
+	assert.NotContains(t, mapping.Verbs, "http://adlnet.gov/expapi/verbs/completed")
+	assert.Equal(t, domain.ActionAnswerQuestion, mapping.Verbs["http://adlnet.gov/expapi/verbs/answered"])
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"verbs": {"https://example.com/verbs/liked": "like"}}`), 0o600))
+	_, err = xapi.LoadMapping(path)
+	assert.Error(t, err)
+}
+
+func newXAPIRouter(service *mocks.XAPIService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	internal_http.SetupRoutes(router, internal_http.Handlers{
+		API:    internal_http.NewHTTPHandler(new(mocks.AnalyticsService)),
+		Health: internal_http.NewHealthHandler(new(mocks.HealthChecker)),
+		Export: internal_http.NewExportHandler(new(mocks.ExportService)),
+		Import: internal_http.NewImportHandler(new(mocks.ImportService), 0),
+		XAPI:   internal_http.NewXAPIHandler(service),
+	})
+	return router
+}
+
+func TestXAPIHandlerAcceptsSingleStatement(t *testing.T) {
+	service := new(mocks.XAPIService)
+	service.On("SaveStatements", mock.Anything, mock.MatchedBy(func(statements []json.RawMessage) bool {
+		return len(statements) == 1
+	})).Return([]string{"6fbd600f-d17c-4c74-801a-2ec2e53231c9"}, nil)
+
+	rec := httptest.NewRecorder()
+	newXAPIRouter(service).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/xapi/statements", strings.NewReader(answeredStatement)))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `["6fbd600f-d17c-4c74-801a-2ec2e53231c9"]`, rec.Body.String())
+	assert.Equal(t, internal_http.XAPIVersion, rec.Header().Get("X-Experience-API-Version"))
+}
+
+func TestXAPIHandlerRejectsBrokenJSON(t *testing.T) {
+	service := new(mocks.XAPIService)
+
+	rec := httptest.NewRecorder()
+	newXAPIRouter(service).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/xapi/statements", strings.NewReader(`[{"actor":`)))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	service.AssertNotCalled(t, "SaveStatements", mock.Anything, mock.Anything)
+}